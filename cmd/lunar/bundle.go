@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"lunar/internal/ast"
+	"lunar/internal/lexer"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// bundler collects an entry file and every module it transitively imports
+// into a single Lua file. Imported modules are registered in package.preload
+// under the path the generated require calls use, so they load without
+// touching the file system
+type bundler struct {
+	opts compileOptions
+
+	// modules holds the preload name and generated Lua of each imported module,
+	// in the order they were first reached
+	modules []bundledModule
+
+	// names maps each compiled file to the preload name it was registered under
+	names map[string]string
+
+	// files maps each require path to the file it resolved to, so the same
+	// path can't silently mean two different modules
+	files map[string]string
+
+	// aliases maps extra require paths for an already bundled file to the
+	// name it was first registered under
+	aliases map[string]string
+
+	// used maps each imported file to the names modules import from it.
+	// Files imported with import * or re-exported with export * are in
+	// usedWhole instead, as any of their exports may be used
+	used      map[string]map[string]bool
+	usedWhole map[string]bool
+}
+
+// bundledModule is a module registered in package.preload. It is parsed when
+// first reached and compiled once every module has been, when it's known
+// which of its exports are used
+type bundledModule struct {
+	name       string
+	file       string
+	statements []ast.Statement
+	lua        string
+}
+
+// bundle compiles inputFile and the modules it imports into outputFile. The
+// entry module runs last, after every module it imports has been registered
+func bundle(inputFile, outputFile string, opts compileOptions) error {
+	b := &bundler{
+		opts:      opts,
+		names:     map[string]string{},
+		files:     map[string]string{},
+		aliases:   map[string]string{},
+		used:      map[string]map[string]bool{},
+		usedWhole: map[string]bool{},
+	}
+
+	// The entry file is marked before compiling so an import cycle back to it
+	// doesn't compile it twice
+	b.names[filepath.Clean(inputFile)] = ""
+	entry, err := compileModule(inputFile, opts)
+	if err != nil {
+		return err
+	}
+	if err := b.addImports(inputFile, entry.statements); err != nil {
+		return err
+	}
+	for i := range b.modules {
+		if err := b.compile(&b.modules[i]); err != nil {
+			return err
+		}
+	}
+
+	luaCode := b.generate(entry.lua)
+	if err := ioutil.WriteFile(outputFile, []byte(luaCode), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	opts.logf("Wrote %s (%d bytes, %d bundled modules)", outputFile, len(luaCode), len(b.modules))
+
+	return nil
+}
+
+// addImports bundles every module required by the statements of file,
+// noting the names they import
+func (b *bundler) addImports(file string, statements []ast.Statement) error {
+	for _, stmt := range statements {
+		switch node := stmt.(type) {
+		case *ast.ImportStatement:
+			if node.IsTypeOnly {
+				continue
+			}
+			imported := modulePath(file, node.Module)
+			if node.IsWildcard {
+				b.usedWhole[imported] = true
+				continue
+			}
+			if b.used[imported] == nil {
+				b.used[imported] = map[string]bool{}
+			}
+			for _, name := range node.Names {
+				b.used[imported][name.Value] = true
+			}
+		case *ast.ExportStatement:
+			if node.IsWildcard {
+				b.usedWhole[modulePath(file, node.Module)] = true
+			}
+		}
+	}
+
+	for _, path := range requiredModules(statements) {
+		if err := b.add(file, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add bundles the module that path refers to when imported from file,
+// along with everything it imports in turn. Modules are compiled once, which
+// also stops import cycles from recursing forever
+func (b *bundler) add(from, path string) error {
+	file := modulePath(from, path)
+	if other, ok := b.files[path]; ok {
+		if other != file {
+			return fmt.Errorf("%s: import \"%s\" refers to %s, but it already refers to %s in the bundle", from, path, file, other)
+		}
+		return nil
+	}
+	b.files[path] = file
+
+	if name, ok := b.names[file]; ok {
+		b.aliases[path] = name
+		return nil
+	}
+	b.names[file] = path
+
+	b.opts.logf("Bundling %s as \"%s\"", file, path)
+	statements, err := parseSourceFile(file)
+	if err != nil {
+		return err
+	}
+	b.modules = append(b.modules, bundledModule{name: path, file: file, statements: statements})
+
+	return b.addImports(file, statements)
+}
+
+// compile generates the Lua of a bundled module, leaving out the exported
+// functions and classes that neither the modules importing it nor the
+// module itself use
+func (b *bundler) compile(module *bundledModule) error {
+	opts := b.opts
+	unused, err := b.unusedExports(module)
+	if err != nil {
+		return err
+	}
+	for _, name := range unused {
+		opts.logf("Dropping unused export %s from %s", name, module.file)
+		if opts.dropExports == nil {
+			opts.dropExports = map[string]bool{}
+		}
+		opts.dropExports[name] = true
+	}
+
+	compiled, err := compileModule(module.file, opts)
+	if err != nil {
+		return err
+	}
+	module.lua = compiled.lua
+	return nil
+}
+
+// unusedExports lists the exported functions and classes of a bundled module
+// that no module imports. A name that appears anywhere in the module besides
+// its declaration counts as used, since the module may call it itself;
+// other statements, which may have side effects, are always kept
+func (b *bundler) unusedExports(module *bundledModule) ([]string, error) {
+	if b.usedWhole[module.file] {
+		return nil, nil
+	}
+
+	var candidates []string
+	for _, stmt := range module.statements {
+		export, ok := stmt.(*ast.ExportStatement)
+		if !ok || export.IsDefault || export.IsWildcard {
+			continue
+		}
+		var name string
+		switch decl := export.Statement.(type) {
+		case *ast.FunctionDeclaration:
+			name = decl.Name.Value
+		case *ast.ClassDeclaration:
+			name = decl.Name.Value
+		default:
+			continue
+		}
+		if !b.used[module.file][name] {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	source, err := ioutil.ReadFile(module.file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	mentions := identifierCounts(string(source))
+	var unused []string
+	for _, name := range candidates {
+		if mentions[name] == 1 {
+			unused = append(unused, name)
+		}
+	}
+	return unused, nil
+}
+
+// identifierCounts counts how many times each identifier appears in source
+func identifierCounts(source string) map[string]int {
+	counts := map[string]int{}
+	l := lexer.New(source)
+	for tok := l.NextToken(); tok.Type != lexer.EOF; tok = l.NextToken() {
+		if tok.Type == lexer.IDENT {
+			counts[tok.Literal]++
+		}
+	}
+	return counts
+}
+
+// generate assembles the bundle: a preload registration for each imported
+// module followed by the entry module's code
+func (b *bundler) generate(entryLua string) string {
+	var output strings.Builder
+
+	for _, module := range b.modules {
+		output.WriteString(fmt.Sprintf("package.preload[\"%s\"] = function(...)\n", module.name))
+		output.WriteString(module.lua)
+		if !strings.HasSuffix(module.lua, "\n") {
+			output.WriteString("\n")
+		}
+		output.WriteString("end\n\n")
+	}
+
+	// A module reached through more than one path loads through its first
+	// name, so it still only runs once
+	aliases := make([]string, 0, len(b.aliases))
+	for path := range b.aliases {
+		aliases = append(aliases, path)
+	}
+	sort.Strings(aliases)
+	for _, path := range aliases {
+		// The entry module isn't preloaded; requiring it back is an import
+		// cycle Lua can't load anyway
+		name := b.aliases[path]
+		if name == "" {
+			continue
+		}
+		output.WriteString(fmt.Sprintf("package.preload[\"%s\"] = function(...)\n", path))
+		output.WriteString(fmt.Sprintf("    return require(\"%s\")\n", name))
+		output.WriteString("end\n\n")
+	}
+
+	output.WriteString(entryLua)
+	return output.String()
+}
+
+// requiredModules lists the module paths that the generated Lua for
+// statements will require. Type-only imports are erased, so they're skipped
+func requiredModules(statements []ast.Statement) []string {
+	var paths []string
+	for _, stmt := range statements {
+		switch node := stmt.(type) {
+		case *ast.ImportStatement:
+			if !node.IsTypeOnly {
+				paths = append(paths, node.Module)
+			}
+		case *ast.ExportStatement:
+			if node.IsWildcard {
+				paths = append(paths, node.Module)
+			}
+		}
+	}
+	return paths
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"lunar/internal/types"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// diagnosticsFormats are the values accepted by --diagnostics-format
+var diagnosticsFormats = []string{"text", "github"}
+
+// diagnostic is an error or warning located in a source file
+type diagnostic struct {
+	severity string // "error" or "warning"
+	file     string
+	line     int // 0 when the position isn't known
+	column   int
+	message  string
+}
+
+// positionSuffix matches the position the lexer and parser append to their
+// messages
+var positionSuffix = regexp.MustCompile(` at line (\d+), column (\d+)$`)
+
+// parserDiagnostics turns parser errors into diagnostics, taking the position
+// from the end of each message when it has one
+func parserDiagnostics(file string, errors []string) []diagnostic {
+	diagnostics := make([]diagnostic, len(errors))
+	for i, msg := range errors {
+		d := diagnostic{severity: "error", file: file, message: msg}
+		if match := positionSuffix.FindStringSubmatch(msg); match != nil {
+			d.line, _ = strconv.Atoi(match[1])
+			d.column, _ = strconv.Atoi(match[2])
+			d.message = strings.TrimSuffix(msg, match[0])
+		}
+		diagnostics[i] = d
+	}
+	return diagnostics
+}
+
+// typeDiagnostics turns type checker errors or warnings into diagnostics
+func typeDiagnostics(file, severity string, errors []*types.TypeError) []diagnostic {
+	diagnostics := make([]diagnostic, len(errors))
+	for i, err := range errors {
+		diagnostics[i] = diagnostic{
+			severity: severity,
+			file:     file,
+			line:     err.Line,
+			column:   err.Column,
+			message:  err.Message,
+		}
+	}
+	return diagnostics
+}
+
+// writeGitHubDiagnostics writes each diagnostic as a GitHub Actions workflow
+// command, ::error file=main.lunar,line=3,col=7::message, which annotates the
+// line in pull requests
+func writeGitHubDiagnostics(w io.Writer, diagnostics []diagnostic) {
+	for _, d := range diagnostics {
+		properties := "file=" + escapeGitHubProperty(d.file)
+		if d.line > 0 {
+			properties += fmt.Sprintf(",line=%d,col=%d", d.line, d.column)
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", d.severity, properties, escapeGitHubData(d.message))
+	}
+}
+
+// escapeGitHubData escapes the characters a workflow command message can't
+// contain as is
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeGitHubProperty escapes a workflow command property value, which
+// additionally can't contain the ':' and ',' that delimit properties
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// globList is a flag that may be given more than once, collecting a glob
+// pattern each time
+type globList []string
+
+func (g *globList) String() string {
+	return strings.Join(*g, ",")
+}
+
+func (g *globList) Set(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid glob pattern %q", pattern)
+	}
+	*g = append(*g, pattern)
+	return nil
+}
+
+// matchesGlob reports whether a slash-separated path relative to the
+// compiled directory matches a pattern. A pattern without a slash matches
+// any single element of the path, so "*_test.lunar" matches test files and
+// "vendor" whole directories at any depth. A pattern with a slash matches
+// the path from the start, or any directory it is in, so "lib/vendor"
+// matches everything under lib/vendor
+func matchesGlob(pattern, rel string) bool {
+	elements := strings.Split(rel, "/")
+	if !strings.Contains(pattern, "/") {
+		for _, element := range elements {
+			if matched, _ := path.Match(pattern, element); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := range elements {
+		if matched, _ := path.Match(pattern, strings.Join(elements[:i+1], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyGlob reports whether rel matches one of the patterns
+func matchesAnyGlob(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchesGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverSourceFiles walks dir for the .lunar files to compile, skipping
+// .d.lunar declaration files. With include patterns only the files matching
+// one are kept; files and directories matching an exclude pattern are
+// skipped
+func discoverSourceFiles(dir string, include, exclude []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if matchesAnyGlob(exclude, rel) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		if !strings.HasSuffix(rel, ".lunar") || strings.HasSuffix(rel, ".d.lunar") {
+			return nil
+		}
+		if len(include) > 0 && !matchesAnyGlob(include, rel) {
+			return nil
+		}
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
+
+// compileDirectory compiles every source file discovered in dir to a .lua
+// file next to it. Every file is attempted; the errors of those that fail
+// are reported together. It returns the number of files compiled
+func compileDirectory(dir string, include, exclude []string, opts compileOptions) (int, error) {
+	files, err := discoverSourceFiles(dir, include, exclude)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	compiled := 0
+	var failures []string
+	for _, file := range files {
+		output := strings.TrimSuffix(file, ".lunar") + ".lua"
+		if err := compile(file, output, opts); err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		opts.logf("Compiled %s -> %s", file, output)
+		compiled++
+	}
+
+	if len(failures) > 0 {
+		return compiled, fmt.Errorf("%s", strings.Join(failures, "\n"))
+	}
+	return compiled, nil
+}
@@ -3,11 +3,14 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"lunar/internal/ast"
 	"lunar/internal/codegen"
 	"lunar/internal/lexer"
 	"lunar/internal/parser"
+	"lunar/internal/sourcemap"
+	"lunar/internal/target"
 	"lunar/internal/types"
 	"os"
 	"path/filepath"
@@ -17,46 +20,140 @@ import (
 const version = "1.0.0"
 
 func main() {
-	// Define command-line flags
-	outputFile := flag.String("o", "", "Output file (default: replaces .lunar with .lua)")
-	noTypeCheck := flag.Bool("no-typecheck", false, "Skip type checking")
-	showVersion := flag.Bool("version", false, "Show version information")
-	showHelp := flag.Bool("help", false, "Show help message")
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	flag.Parse()
+// run executes the compiler with the given command-line arguments and
+// returns the process exit code
+func run(arguments []string, stdout, stderr io.Writer) int {
+	// Define command-line flags
+	flags := flag.NewFlagSet("lunar", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	outputFile := flags.String("o", "", "Output file (default: replaces .lunar with .lua)")
+	noTypeCheck := flags.Bool("no-typecheck", false, "Skip type checking")
+	luaTarget := flags.String("target", "", "Lua version to target (5.1, 5.2, 5.3, 5.4)")
+	profile := flags.Bool("profile", false, "Report compiler phase timings to stderr")
+	maxLineLength := flags.Int("max-line-length", 0, "Wrap long table literals and call arguments in the output (0 = no limit)")
+	bundleModules := flags.Bool("bundle", false, "Inline every imported module into a single output file")
+	emitLuaLS := flags.Bool("emit-luals", false, "Annotate the output with LuaLS type comments derived from the type annotations")
+	checkedIndex := flags.Bool("checked-index", false, "Check at runtime that every number index read is within the bounds of its array")
+	sourceMap := flags.Bool("source-map", false, "Write a source map next to the output and reference it from the generated Lua")
+	inlineSourceMap := flags.Bool("inline-source-map", false, "Embed the source map in the generated Lua as a base64 data URL")
+	luaCoercion := flags.Bool("lua-coercion", false, "Allow string operands in arithmetic, as Lua coerces them to numbers")
+	strictClassInit := flags.Bool("strict-class-init", false, "Require constructors to assign every non-optional property on all paths")
+	noImplicitAny := flags.Bool("no-implicit-any", false, "Report parameters and variables that are any because an annotation is missing")
+	traceResolution := flags.Bool("trace-resolution", false, "Trace how type annotations, generic type arguments and imports resolve, to stderr")
+	noStdlibGlobals := flags.Bool("no-stdlib-globals", false, "Don't auto-load .d.lunar declarations; globals must be declared or imported explicitly")
+	diagnosticsFormat := flags.String("diagnostics-format", "text", "How to report errors and warnings: text, or github for GitHub Actions annotations on stdout")
+	quiet := flags.Bool("quiet", false, "Only print errors")
+	verbose := flags.Bool("verbose", false, "Print declaration files, phase progress, and output sizes")
+	showVersion := flags.Bool("version", false, "Show version information")
+	showHelp := flags.Bool("help", false, "Show help message")
+	var include, exclude globList
+	flags.Var(&include, "include", "When compiling a directory, only compile files matching this glob (repeatable)")
+	flags.Var(&exclude, "exclude", "When compiling a directory, skip files and directories matching this glob (repeatable)")
+
+	if err := flags.Parse(arguments); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
 
 	// Handle version flag
 	if *showVersion {
-		fmt.Printf("Lunar compiler version %s\n", version)
-		os.Exit(0)
+		fmt.Fprintf(stdout, "Lunar compiler version %s\n", version)
+		return 0
 	}
 
 	// Handle help flag
 	if *showHelp {
-		printHelp()
-		os.Exit(0)
+		printHelp(stdout)
+		return 0
 	}
 
 	// Get input file
-	args := flag.Args()
+	args := flags.Args()
 	if len(args) < 1 {
-		fmt.Fprintln(os.Stderr, "Error: No input file specified")
-		fmt.Fprintln(os.Stderr, "Usage: lunar [options] <input.lunar>")
-		fmt.Fprintln(os.Stderr, "Run 'lunar --help' for more information")
-		os.Exit(1)
+		fmt.Fprintln(stderr, "Error: No input file specified")
+		fmt.Fprintln(stderr, "Usage: lunar [options] <input.lunar | directory>")
+		fmt.Fprintln(stderr, "Run 'lunar --help' for more information")
+		return 1
+	}
+
+	// Flags after the input aren't parsed, so they would be silently ignored
+	if len(args) > 1 {
+		fmt.Fprintf(stderr, "Error: Unexpected argument '%s' after the input; options go before it\n", args[1])
+		fmt.Fprintln(stderr, "Usage: lunar [options] <input.lunar | directory>")
+		return 1
 	}
 
 	inputFile := args[0]
 
+	// Validate verbosity
+	if *quiet && *verbose {
+		fmt.Fprintln(stderr, "Error: --quiet and --verbose cannot be used together")
+		return 1
+	}
+
+	// Validate target version
+	if *luaTarget != "" && !target.IsValid(*luaTarget) {
+		fmt.Fprintf(stderr, "Error: Unknown target '%s' (expected one of %s)\n", *luaTarget, strings.Join(target.Versions, ", "))
+		return 1
+	}
+
+	// Validate source map options
+	if *sourceMap && *inlineSourceMap {
+		fmt.Fprintln(stderr, "Error: --source-map and --inline-source-map cannot be used together")
+		return 1
+	}
+	// Source maps cover a single source file
+	if *sourceMap && *bundleModules {
+		fmt.Fprintln(stderr, "Error: --source-map and --bundle cannot be used together")
+		return 1
+	}
+	if *inlineSourceMap && *bundleModules {
+		fmt.Fprintln(stderr, "Error: --inline-source-map and --bundle cannot be used together")
+		return 1
+	}
+
+	// Validate diagnostics format
+	if *diagnosticsFormat != "text" && *diagnosticsFormat != "github" {
+		fmt.Fprintf(stderr, "Error: Unknown diagnostics format '%s' (expected one of %s)\n", *diagnosticsFormat, strings.Join(diagnosticsFormats, ", "))
+		return 1
+	}
+
+	// Validate line length
+	if *maxLineLength < 0 {
+		fmt.Fprintf(stderr, "Error: --max-line-length must not be negative, got %d\n", *maxLineLength)
+		return 1
+	}
+
 	// Validate input file exists
-	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error: Input file '%s' does not exist\n", inputFile)
-		os.Exit(1)
+	info, err := os.Stat(inputFile)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(stderr, "Error: Input file '%s' does not exist\n", inputFile)
+		return 1
+	}
+	isDirectory := err == nil && info.IsDir()
+
+	// Validate directory options
+	if isDirectory && *outputFile != "" {
+		fmt.Fprintln(stderr, "Error: -o cannot be used when compiling a directory")
+		return 1
+	}
+	if isDirectory && *bundleModules {
+		fmt.Fprintln(stderr, "Error: --bundle cannot be used when compiling a directory")
+		return 1
+	}
+	if !isDirectory && (len(include) > 0 || len(exclude) > 0) {
+		fmt.Fprintln(stderr, "Error: --include and --exclude only apply when compiling a directory")
+		return 1
 	}
 
 	// Validate input file extension
-	if !strings.HasSuffix(inputFile, ".lunar") {
-		fmt.Fprintf(os.Stderr, "Warning: Input file '%s' does not have .lunar extension\n", inputFile)
+	if !isDirectory && !strings.HasSuffix(inputFile, ".lunar") && !*quiet {
+		fmt.Fprintf(stderr, "Warning: Input file '%s' does not have .lunar extension\n", inputFile)
 	}
 
 	// Determine output file
@@ -66,28 +163,213 @@ func main() {
 	}
 
 	// Compile the file
-	if err := compile(inputFile, output, !*noTypeCheck); err != nil {
-		fmt.Fprintf(os.Stderr, "Compilation failed:\n%v\n", err)
-		os.Exit(1)
+	opts := compileOptions{
+		typeCheck:       !*noTypeCheck,
+		target:          *luaTarget,
+		noStdlibGlobals: *noStdlibGlobals,
+		maxLineLength:   *maxLineLength,
+		emitLuaLS:       *emitLuaLS,
+		checkedIndex:    *checkedIndex,
+		sourceMap:       *sourceMap,
+		inlineSourceMap: *inlineSourceMap,
+		luaCoercion:     *luaCoercion,
+		strictClassInit: *strictClassInit,
+		noImplicitAny:   *noImplicitAny,
+	}
+	if *profile {
+		opts.profile = stderr
+	}
+	if *traceResolution {
+		opts.traceResolution = stderr
+	}
+	if *verbose {
+		opts.verbose = stdout
+	}
+	if !*quiet {
+		opts.warnings = stderr
+	}
+	if *diagnosticsFormat == "github" {
+		opts.githubDiagnostics = stdout
+	}
+
+	if isDirectory {
+		compiled, err := compileDirectory(inputFile, include, exclude, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "Compilation failed:\n%v\n", err)
+			return 1
+		}
+		if !*quiet {
+			fmt.Fprintf(stdout, "Successfully compiled %d files in %s\n", compiled, inputFile)
+		}
+		return 0
+	}
+
+	build := compile
+	if *bundleModules {
+		build = bundle
+	}
+	if err := build(inputFile, output, opts); err != nil {
+		fmt.Fprintf(stderr, "Compilation failed:\n%v\n", err)
+		return 1
 	}
 
-	fmt.Printf("Successfully compiled %s -> %s\n", inputFile, output)
+	if !*quiet {
+		fmt.Fprintf(stdout, "Successfully compiled %s -> %s\n", inputFile, output)
+	}
+	return 0
+}
+
+// compileOptions controls how a file is compiled
+type compileOptions struct {
+	typeCheck bool
+	target    string
+
+	// noStdlibGlobals skips auto-loading declaration files, so Lua globals
+	// like print must be declared or imported by the source itself
+	noStdlibGlobals bool
+
+	// profile receives phase timings when set
+	profile io.Writer
+
+	// traceResolution receives a trace of type and import resolution when
+	// set
+	traceResolution io.Writer
+
+	// maxLineLength wraps long lines in the generated Lua; 0 means no limit
+	maxLineLength int
+
+	// emitLuaLS annotates the generated Lua with LuaLS type comments
+	emitLuaLS bool
+
+	// checkedIndex bounds-checks index reads in the generated Lua
+	checkedIndex bool
+
+	// sourceMap writes a source map for the output to a .map file
+	sourceMap bool
+
+	// inlineSourceMap embeds the source map in the output instead
+	inlineSourceMap bool
+
+	// verbose receives progress details when set
+	verbose io.Writer
+
+	// luaCoercion accepts string operands in arithmetic
+	luaCoercion bool
+
+	// strictClassInit requires constructors to assign every property
+	// that isn't optional
+	strictClassInit bool
+
+	// noImplicitAny reports types that are any only for lack of an annotation
+	noImplicitAny bool
+
+	// warnings receives type checker warnings when set
+	warnings io.Writer
+
+	// githubDiagnostics receives every error, and every warning that would
+	// be printed, as a GitHub Actions workflow command when set
+	githubDiagnostics io.Writer
+
+	// dropExports names the exported functions and classes to leave out of
+	// the generated Lua, because no module in the bundle uses them
+	dropExports map[string]bool
+}
+
+// logf writes a progress message when verbose output is enabled
+func (opts compileOptions) logf(format string, args ...interface{}) {
+	if opts.verbose != nil {
+		fmt.Fprintf(opts.verbose, format+"\n", args...)
+	}
 }
 
 // compile compiles a Lunar source file to Lua
-func compile(inputFile, outputFile string, typeCheck bool) error {
+func compile(inputFile, outputFile string, opts compileOptions) error {
+	module, err := compileModule(inputFile, opts)
+	if err != nil {
+		return err
+	}
+
+	luaCode := module.lua
+	if module.sourceMap != nil {
+		var comment string
+		if opts.inlineSourceMap {
+			locateSourceMap(module.sourceMap, inputFile, outputFile)
+			comment = module.sourceMap.GenerateComment("")
+		} else {
+			comment, err = writeSourceMap(module.sourceMap, inputFile, outputFile)
+			if err != nil {
+				return err
+			}
+			opts.logf("Wrote %s.map", outputFile)
+		}
+		if !strings.HasSuffix(luaCode, "\n") {
+			luaCode += "\n"
+		}
+		luaCode += comment + "\n"
+	}
+
+	// Write output file
+	if err := ioutil.WriteFile(outputFile, []byte(luaCode), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	opts.logf("Wrote %s (%d bytes from %d bytes of source)", outputFile, len(luaCode), module.sourceSize)
+
+	return nil
+}
+
+// locateSourceMap points a source map at the output file and at the source
+// relative to the output's directory, where the map itself is kept
+func locateSourceMap(sourceMap *sourcemap.SourceMap, inputFile, outputFile string) {
+	source, err := filepath.Rel(filepath.Dir(outputFile), inputFile)
+	if err != nil {
+		source = inputFile
+	}
+	sourceMap.File = filepath.Base(outputFile)
+	sourceMap.Sources = []string{filepath.ToSlash(source)}
+}
+
+// writeSourceMap writes sourceMap to outputFile with a .map extension added
+// and returns the comment that links the generated Lua to it
+func writeSourceMap(sourceMap *sourcemap.SourceMap, inputFile, outputFile string) (string, error) {
+	mapFile := outputFile + ".map"
+	locateSourceMap(sourceMap, inputFile, outputFile)
+
+	data, err := sourceMap.ToJSON()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode source map: %w", err)
+	}
+	if err := ioutil.WriteFile(mapFile, []byte(data+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write source map: %w", err)
+	}
+	return sourceMap.GenerateComment(filepath.Base(mapFile)), nil
+}
+
+// compiledModule is the result of compiling a single source file
+type compiledModule struct {
+	statements []ast.Statement
+	lua        string
+	sourceSize int
+
+	// sourceMap maps the generated Lua back to the source when requested
+	sourceMap *sourcemap.SourceMap
+}
+
+// compileModule runs a Lunar source file through every compiler phase and
+// returns the generated Lua without writing it
+func compileModule(inputFile string, opts compileOptions) (*compiledModule, error) {
 	// Auto-load declaration files from the same directory
 	declarationStatements := []ast.Statement{}
-	if typeCheck {
+	if opts.typeCheck && !opts.noStdlibGlobals {
 		declFiles, err := discoverDeclarationFiles(inputFile)
 		if err != nil {
-			return fmt.Errorf("failed to discover declaration files: %w", err)
+			return nil, fmt.Errorf("failed to discover declaration files: %w", err)
 		}
 
 		for _, declFile := range declFiles {
-			declStatements, err := parseDeclarationFile(declFile)
+			opts.logf("Loading declaration file %s", declFile)
+			declStatements, err := parseSourceFile(declFile)
 			if err != nil {
-				return fmt.Errorf("failed to parse declaration file %s: %w", declFile, err)
+				return nil, fmt.Errorf("failed to parse declaration file %s: %w", declFile, err)
 			}
 			declarationStatements = append(declarationStatements, declStatements...)
 		}
@@ -96,41 +378,109 @@ func compile(inputFile, outputFile string, typeCheck bool) error {
 	// Read source file
 	source, err := ioutil.ReadFile(inputFile)
 	if err != nil {
-		return fmt.Errorf("failed to read input file: %w", err)
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	var prof *profiler
+	if opts.profile != nil {
+		prof = newProfiler(inputFile)
+		defer prof.report(opts.profile)
+
+		// The parser pulls tokens lazily, so tokenize in a separate pass to
+		// measure lexing on its own. Parsing lexes again, so the pass is
+		// left out of the total
+		prof.timeRepeated("lex", func() {
+			l := lexer.NewWithOptions(string(source), lexer.Options{Target: opts.target})
+			for l.NextToken().Type != lexer.EOF {
+			}
+		})
 	}
 
 	// Lexer: Tokenize the source
-	l := lexer.New(string(source))
+	l := lexer.NewWithOptions(string(source), lexer.Options{Target: opts.target})
 
 	// Parser: Build AST
+	opts.logf("Parsing %s", inputFile)
 	p := parser.New(l)
-	statements := p.Parse()
+	var statements []ast.Statement
+	prof.time("parse", func() {
+		statements = p.Parse()
+	})
 
 	// Check for parser errors
 	if len(p.Errors()) > 0 {
-		return formatParserErrors(inputFile, p.Errors())
+		if opts.githubDiagnostics != nil {
+			writeGitHubDiagnostics(opts.githubDiagnostics, parserDiagnostics(inputFile, p.Errors()))
+		}
+		return nil, formatParserErrors(inputFile, p.Errors())
 	}
 
 	// Type Checker: Validate types (if enabled)
-	if typeCheck {
+	if opts.typeCheck {
 		// Combine declaration statements with main file statements
 		// Declarations first so they're registered before main code
 		allStatements := append(declarationStatements, statements...)
-		typeErrors := types.Check(allStatements)
+		opts.logf("Type checking %s", inputFile)
+		checker := types.NewCheckerWithOptions(types.Options{
+			Target:          opts.target,
+			File:            inputFile,
+			ResolveModule:   resolveModule,
+			LuaCoercion:     opts.luaCoercion,
+			StrictClassInit: opts.strictClassInit,
+			NoImplicitAny:   opts.noImplicitAny,
+			Trace:           opts.traceResolution,
+		})
+		var typeErrors []*types.TypeError
+		prof.time("type-check", func() {
+			typeErrors = checker.Check(allStatements)
+		})
 		if len(typeErrors) > 0 {
-			return formatTypeErrors(inputFile, string(source), typeErrors)
+			if opts.githubDiagnostics != nil {
+				writeGitHubDiagnostics(opts.githubDiagnostics, typeDiagnostics(inputFile, "error", typeErrors))
+			}
+			return nil, formatTypeErrors(inputFile, string(source), typeErrors)
+		}
+		if opts.warnings != nil && opts.githubDiagnostics != nil {
+			writeGitHubDiagnostics(opts.githubDiagnostics, typeDiagnostics(inputFile, "warning", checker.Warnings()))
+		} else if opts.warnings != nil {
+			for _, warning := range checker.Warnings() {
+				fmt.Fprintf(opts.warnings, "%s:%d:%d: warning: %s\n", inputFile, warning.Line, warning.Column, warning.Message)
+			}
 		}
 	}
 
-	// Code Generator: Transpile to Lua (only main file, not declarations)
-	luaCode := codegen.Generate(statements)
-
-	// Write output file
-	if err := ioutil.WriteFile(outputFile, []byte(luaCode), 0644); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
+	// Tree-shaking a bundle leaves out exports nothing uses
+	if len(opts.dropExports) > 0 {
+		statements = withoutExports(statements, opts.dropExports)
 	}
 
-	return nil
+	// Optimizer: the CLI does not enable optimizations yet, but the pass is
+	// still run so profiling covers every phase
+	optimizer := codegen.NewOptimizer(false)
+	prof.time("optimize", func() {
+		statements = optimizer.OptimizeStatements(statements)
+	})
+
+	// Code Generator: Transpile to Lua (only main file, not declarations)
+	opts.logf("Generating Lua for %s", inputFile)
+	var luaCode string
+	var sourceMap *sourcemap.SourceMap
+	generator := codegen.NewWithOptions(codegen.Options{
+		MaxLineLength: opts.maxLineLength,
+		EmitLuaLS:     opts.emitLuaLS,
+		Target:        opts.target,
+		CheckedIndex:  opts.checkedIndex,
+		ConstEnums:    importedConstEnums(inputFile, statements),
+	})
+	prof.time("codegen", func() {
+		if opts.sourceMap || opts.inlineSourceMap {
+			luaCode, sourceMap = generator.GenerateWithSourceMap(statements, inputFile, string(source), "")
+		} else {
+			luaCode = generator.Generate(statements)
+		}
+	})
+
+	return &compiledModule{statements: statements, lua: luaCode, sourceSize: len(source), sourceMap: sourceMap}, nil
 }
 
 // discoverDeclarationFiles finds all .d.lunar files in the same directory as the input file
@@ -147,8 +497,88 @@ func discoverDeclarationFiles(inputFile string) ([]string, error) {
 	return matches, nil
 }
 
-// parseDeclarationFile parses a declaration file and returns its statements
-func parseDeclarationFile(filename string) ([]ast.Statement, error) {
+// resolveModule locates a module imported from another file. Paths are
+// relative to the importing file, with the .lunar extension optional. A
+// module that doesn't parse is reported by its first parse error, which
+// the importing file's error message holds on one line
+func resolveModule(from, path string) (string, []ast.Statement, error) {
+	file := modulePath(from, path)
+	statements, err := parseSourceFile(file)
+	if parseErr, ok := err.(*parseErrors); ok {
+		return "", nil, fmt.Errorf("%s", parseErr.first())
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return file, statements, nil
+}
+
+// importedConstEnums finds the const enums that statements import from
+// other modules, so their members can be inlined. A module that can't be
+// read is skipped; the type checker reports it
+func importedConstEnums(inputFile string, statements []ast.Statement) []*ast.EnumDeclaration {
+	var enums []*ast.EnumDeclaration
+	for _, stmt := range statements {
+		node, ok := stmt.(*ast.ImportStatement)
+		if !ok || node.IsTypeOnly || node.IsWildcard {
+			continue
+		}
+		imported, err := parseSourceFile(modulePath(inputFile, node.Module))
+		if err != nil {
+			continue
+		}
+		for _, stmt := range imported {
+			export, ok := stmt.(*ast.ExportStatement)
+			if !ok || export.IsDefault {
+				continue
+			}
+			enum, ok := export.Statement.(*ast.EnumDeclaration)
+			if !ok || !enum.IsConst {
+				continue
+			}
+			for _, name := range node.Names {
+				if name.Value == enum.Name.Value {
+					enums = append(enums, enum)
+				}
+			}
+		}
+	}
+	return enums
+}
+
+// withoutExports removes the exported functions and classes named in drop
+func withoutExports(statements []ast.Statement, drop map[string]bool) []ast.Statement {
+	kept := make([]ast.Statement, 0, len(statements))
+	for _, stmt := range statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok && !export.IsDefault {
+			switch decl := export.Statement.(type) {
+			case *ast.FunctionDeclaration:
+				if drop[decl.Name.Value] {
+					continue
+				}
+			case *ast.ClassDeclaration:
+				if drop[decl.Name.Value] {
+					continue
+				}
+			}
+		}
+		kept = append(kept, stmt)
+	}
+	return kept
+}
+
+// modulePath returns the file an import path refers to, relative to the
+// importing file
+func modulePath(from, path string) string {
+	file := filepath.Join(filepath.Dir(from), path)
+	if !strings.HasSuffix(file, ".lunar") {
+		file += ".lunar"
+	}
+	return file
+}
+
+// parseSourceFile parses a source or declaration file and returns its statements
+func parseSourceFile(filename string) ([]ast.Statement, error) {
 	source, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
@@ -165,14 +595,35 @@ func parseDeclarationFile(filename string) ([]ast.Statement, error) {
 	return statements, nil
 }
 
-// formatParserErrors formats parser errors for display
-func formatParserErrors(filename string, errors []string) error {
+// parseErrors is the error for a file with parse errors
+type parseErrors struct {
+	file   string
+	errors []string
+}
+
+// Error lists every parse error for display
+func (e *parseErrors) Error() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("\n%s: Parse errors:\n", filename))
-	for _, msg := range errors {
+	sb.WriteString(fmt.Sprintf("\n%s: Parse errors:\n", e.file))
+	for _, msg := range e.errors {
 		sb.WriteString(fmt.Sprintf("  %s\n", msg))
 	}
-	return fmt.Errorf("%s", sb.String())
+	return sb.String()
+}
+
+// first returns the first parse error on one line, as file:line:column:
+// message, or file: message when it has no position
+func (e *parseErrors) first() string {
+	d := parserDiagnostics(e.file, e.errors[:1])[0]
+	if d.line == 0 {
+		return fmt.Sprintf("%s: %s", d.file, d.message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.file, d.line, d.column, d.message)
+}
+
+// formatParserErrors formats parser errors for display
+func formatParserErrors(filename string, errors []string) error {
+	return &parseErrors{file: filename, errors: errors}
 }
 
 // formatTypeErrors formats type errors for display with source context
@@ -223,23 +674,73 @@ func formatTypeErrors(filename string, source string, errors []*types.TypeError)
 }
 
 // printHelp prints help information
-func printHelp() {
-	fmt.Println("Lunar - A statically-typed superset of Lua")
-	fmt.Printf("Version: %s\n\n", version)
-	fmt.Println("Usage:")
-	fmt.Println("  lunar [options] <input.lunar>")
-	fmt.Println()
-	fmt.Println("Options:")
-	fmt.Println("  -o <file>        Output file (default: replaces .lunar with .lua)")
-	fmt.Println("  --no-typecheck   Skip type checking")
-	fmt.Println("  --version        Show version information")
-	fmt.Println("  --help           Show this help message")
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  lunar main.lunar")
-	fmt.Println("  lunar main.lunar -o output.lua")
-	fmt.Println("  lunar main.lunar --no-typecheck")
-	fmt.Println()
-	fmt.Println("For more information about the Lunar language:")
-	fmt.Println("  See README.md in the repository")
+func printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Lunar - A statically-typed superset of Lua")
+	fmt.Fprintf(w, "Version: %s\n\n", version)
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  lunar [options] <input.lunar>")
+	fmt.Fprintln(w, "  lunar [options] <directory>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  -o <file>        Output file (default: replaces .lunar with .lua)")
+	fmt.Fprintln(w, "  --no-typecheck   Skip type checking")
+	fmt.Fprintln(w, "  --target <ver>   Lua version to target: 5.1, 5.2, 5.3, 5.4")
+	fmt.Fprintln(w, "                   (5.3+ enables the int and float number types)")
+	fmt.Fprintln(w, "                   (5.4 emits const declarations as <const> locals)")
+	fmt.Fprintln(w, "  --profile        Report compiler phase timings to stderr")
+	fmt.Fprintln(w, "  --trace-resolution")
+	fmt.Fprintln(w, "                   Trace how type annotations, generic type arguments and")
+	fmt.Fprintln(w, "                   imports resolve, to stderr, showing where they become any")
+	fmt.Fprintln(w, "  --max-line-length <n>")
+	fmt.Fprintln(w, "                   Wrap table literals and call arguments that would run")
+	fmt.Fprintln(w, "                   past column n in the generated Lua")
+	fmt.Fprintln(w, "  --bundle         Compile the imported modules too and inline them into")
+	fmt.Fprintln(w, "                   one output file that runs without the sources,")
+	fmt.Fprintln(w, "                   leaving out exported functions and classes nothing uses")
+	fmt.Fprintln(w, "  --emit-luals     Add LuaLS annotations (---@param, ---@return, ---@type)")
+	fmt.Fprintln(w, "                   to the output, for editor support in plain Lua")
+	fmt.Fprintln(w, "  --checked-index  Raise an error at runtime when a number index read t[i]")
+	fmt.Fprintln(w, "                   is outside 1..#t, instead of returning nil")
+	fmt.Fprintln(w, "  --source-map     Write a source map to <output>.map and reference it with")
+	fmt.Fprintln(w, "                   a sourceMappingURL comment at the end of the output")
+	fmt.Fprintln(w, "  --inline-source-map")
+	fmt.Fprintln(w, "                   Embed the source map in that comment as a base64 data")
+	fmt.Fprintln(w, "                   URL instead of writing a separate .map file")
+	fmt.Fprintln(w, "  --lua-coercion   Allow string operands in arithmetic (\"10\" + 5), as Lua")
+	fmt.Fprintln(w, "                   converts them to numbers at runtime")
+	fmt.Fprintln(w, "  --strict-class-init")
+	fmt.Fprintln(w, "                   Require constructors to assign every property that")
+	fmt.Fprintln(w, "                   isn't optional, on every path through them")
+	fmt.Fprintln(w, "  --no-implicit-any")
+	fmt.Fprintln(w, "                   Report parameters and variables that are any because")
+	fmt.Fprintln(w, "                   their annotation is missing; an explicit any is allowed")
+	fmt.Fprintln(w, "  --no-stdlib-globals")
+	fmt.Fprintln(w, "                   Don't auto-load .d.lunar declarations; Lua globals")
+	fmt.Fprintln(w, "                   such as print must be declared or imported explicitly")
+	fmt.Fprintln(w, "  --diagnostics-format <format>")
+	fmt.Fprintln(w, "                   How to report errors and warnings: text (default), or")
+	fmt.Fprintln(w, "                   github to print them to stdout as GitHub Actions")
+	fmt.Fprintln(w, "                   workflow commands that annotate pull requests")
+	fmt.Fprintln(w, "  --include <glob> When compiling a directory, only compile the files that")
+	fmt.Fprintln(w, "                   match; may be repeated")
+	fmt.Fprintln(w, "  --exclude <glob> When compiling a directory, skip the files and")
+	fmt.Fprintln(w, "                   directories that match; may be repeated")
+	fmt.Fprintln(w, "                   (a glob without '/' matches any part of the path,")
+	fmt.Fprintln(w, "                   such as *_test.lunar or vendor)")
+	fmt.Fprintln(w, "  --quiet          Only print errors")
+	fmt.Fprintln(w, "  --verbose        Print declaration files, phase progress, and output sizes")
+	fmt.Fprintln(w, "  --version        Show version information")
+	fmt.Fprintln(w, "  --help           Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  lunar main.lunar")
+	fmt.Fprintln(w, "  lunar -o output.lua main.lunar")
+	fmt.Fprintln(w, "  lunar --no-typecheck main.lunar")
+	fmt.Fprintln(w, "  lunar --target 5.4 main.lunar")
+	fmt.Fprintln(w, "  lunar --bundle -o app.lua main.lunar")
+	fmt.Fprintln(w, "  lunar --diagnostics-format github main.lunar")
+	fmt.Fprintln(w, "  lunar --exclude vendor --exclude '*_test.lunar' src")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "For more information about the Lunar language:")
+	fmt.Fprintln(w, "  See README.md in the repository")
 }
@@ -0,0 +1,858 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeSource writes a Lunar source file into dir and returns its path
+func writeSource(t *testing.T, dir, name, source string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestCompileProfile(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", `
+function add(a: number, b: number): number
+	return a + b
+end
+
+local total: number = add(1, 2)
+`)
+	output := strings.TrimSuffix(input, ".lunar") + ".lua"
+
+	var profile bytes.Buffer
+	opts := compileOptions{typeCheck: true, profile: &profile}
+	if err := compile(input, output, opts); err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+
+	durations := map[string]float64{}
+	for _, line := range strings.Split(profile.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasSuffix(fields[1], "ms") {
+			continue
+		}
+		ms, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "ms"), 64)
+		if err != nil {
+			t.Fatalf("unparseable duration in line %q: %v", line, err)
+		}
+		durations[fields[0]] = ms
+	}
+
+	for _, phase := range []string{"lex", "parse", "type-check", "optimize", "codegen", "total"} {
+		ms, ok := durations[phase]
+		if !ok {
+			t.Errorf("profile output missing phase %q:\n%s", phase, profile.String())
+			continue
+		}
+		if ms < 0 {
+			t.Errorf("phase %q has negative duration %f", phase, ms)
+		}
+	}
+
+	// Lexing is repeated within parsing, so it isn't counted again
+	sum := 0.0
+	for _, phase := range []string{"parse", "type-check", "optimize", "codegen"} {
+		sum += durations[phase]
+	}
+	if diff := durations["total"] - sum; diff < -0.005 || diff > 0.005 {
+		t.Errorf("expected the total to leave out lexing, got %f for phases adding up to %f", durations["total"], sum)
+	}
+}
+
+func TestCompileWithoutProfile(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", "local x: number = 1\n")
+	output := strings.TrimSuffix(input, ".lunar") + ".lua"
+
+	if err := compile(input, output, compileOptions{typeCheck: true}); err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Errorf("expected output file: %v", err)
+	}
+}
+
+func TestCompileNoStdlibGlobals(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "lua.d.lunar", "declare function print(message: any): void end\n")
+	input := writeSource(t, dir, "main.lunar", "print(\"x\")\n")
+	output := strings.TrimSuffix(input, ".lunar") + ".lua"
+
+	// Declarations next to the source are auto-loaded by default
+	if err := compile(input, output, compileOptions{typeCheck: true}); err != nil {
+		t.Fatalf("expected print to resolve from lua.d.lunar, got: %v", err)
+	}
+
+	opts := compileOptions{typeCheck: true, noStdlibGlobals: true}
+	err := compile(input, output, opts)
+	if err == nil {
+		t.Fatal("expected an error for undeclared print with noStdlibGlobals")
+	}
+	if !strings.Contains(err.Error(), "Undefined variable 'print'") {
+		t.Errorf("expected undefined variable error, got: %v", err)
+	}
+
+	// An explicit declaration in the source still works
+	explicit := writeSource(t, dir, "explicit.lunar", "declare function print(message: any): void end\nprint(\"x\")\n")
+	if err := compile(explicit, output, opts); err != nil {
+		t.Errorf("expected explicit declaration to satisfy noStdlibGlobals, got: %v", err)
+	}
+}
+
+func TestCompileEmptyPrograms(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"empty", ""},
+		{"whitespace", "  \n\t\n\n   "},
+		{"comments", "-- nothing to see here\n--[[ or\n     here ]]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := writeSource(t, t.TempDir(), "main.lunar", tt.source)
+			output := strings.TrimSuffix(input, ".lunar") + ".lua"
+
+			if err := compile(input, output, compileOptions{typeCheck: true}); err != nil {
+				t.Fatalf("compile failed: %v", err)
+			}
+
+			lua, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatalf("expected output file: %v", err)
+			}
+			if len(lua) != 0 {
+				t.Errorf("expected empty output, got %q", lua)
+			}
+		})
+	}
+}
+
+func TestCompileSubclassCallsInheritedMethod(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", `
+class Animal
+	public name: string
+	constructor(name: string)
+		self.name = name
+	end
+	public speak(): string
+		return self.name
+	end
+end
+
+class Dog extends Animal
+	constructor(name: string)
+		super(name)
+	end
+end
+
+function greet(dog: Dog): string
+	return dog:speak()
+end
+`)
+	output := strings.TrimSuffix(input, ".lunar") + ".lua"
+
+	if err := compile(input, output, compileOptions{typeCheck: true}); err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+
+	lua, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected output file: %v", err)
+	}
+	for _, want := range []string{
+		"setmetatable(Dog, {__index = Animal})",
+		"for key, value in pairs(Animal.new(name)) do",
+		"return dog:speak()",
+	} {
+		if !strings.Contains(string(lua), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, lua)
+		}
+	}
+}
+
+func TestCompileWithSourceMap(t *testing.T) {
+	dir := t.TempDir()
+	source := `local x: number = 1
+
+function double(n: number): number
+	return n * 2
+end
+
+x = double(x)
+`
+	input := writeSource(t, dir, "main.lunar", source)
+	output := filepath.Join(dir, "main.lua")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-source-map", "-o", output, input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	lua, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected output file: %v", err)
+	}
+	if !strings.HasSuffix(string(lua), "\n--# sourceMappingURL=main.lua.map\n") {
+		t.Errorf("expected output to end with a sourceMappingURL comment, got:\n%s", lua)
+	}
+	if !strings.Contains(string(lua), "x = double(x)") {
+		t.Errorf("expected the generated Lua before the comment, got:\n%s", lua)
+	}
+
+	data, err := os.ReadFile(output + ".map")
+	if err != nil {
+		t.Fatalf("expected source map file: %v", err)
+	}
+	var sourceMap struct {
+		Version        int      `json:"version"`
+		File           string   `json:"file"`
+		Sources        []string `json:"sources"`
+		SourcesContent []string `json:"sourcesContent"`
+		Mappings       string   `json:"mappings"`
+	}
+	if err := json.Unmarshal(data, &sourceMap); err != nil {
+		t.Fatalf("expected valid JSON in the source map: %v\n%s", err, data)
+	}
+	if sourceMap.Version != 3 || sourceMap.File != "main.lua" {
+		t.Errorf("expected version 3 for main.lua, got %d for %q", sourceMap.Version, sourceMap.File)
+	}
+	if len(sourceMap.Sources) != 1 || sourceMap.Sources[0] != "main.lunar" {
+		t.Errorf("expected sources [main.lunar], got %q", sourceMap.Sources)
+	}
+	if len(sourceMap.SourcesContent) != 1 || sourceMap.SourcesContent[0] != source {
+		t.Errorf("expected sourcesContent to hold the original source, got %q", sourceMap.SourcesContent)
+	}
+	// One mapping per top-level statement, on the line its Lua starts at
+	if want := "0:0:0:0;;0:0:2:0;;;;0:0:6:0"; sourceMap.Mappings != want {
+		t.Errorf("expected mappings %q, got %q", want, sourceMap.Mappings)
+	}
+}
+
+func TestCompileWithInlineSourceMap(t *testing.T) {
+	dir := t.TempDir()
+	input := writeSource(t, dir, "main.lunar", "local x: number = 1\n")
+	output := filepath.Join(dir, "main.lua")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-inline-source-map", "-o", output, input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	lua, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected output file: %v", err)
+	}
+	if !strings.Contains(string(lua), "--# sourceMappingURL=data:application/json;base64,") {
+		t.Errorf("expected an inline source map comment, got:\n%s", lua)
+	}
+	if _, err := os.Stat(output + ".map"); !os.IsNotExist(err) {
+		t.Errorf("expected no separate source map file, got %v", err)
+	}
+}
+
+func TestRunBothSourceMapFlags(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", "local x: number = 1\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--source-map", "--inline-source-map", input}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "--source-map and --inline-source-map cannot be used together") {
+		t.Errorf("expected a mutual exclusion error, got %q", stderr.String())
+	}
+}
+
+func TestRunSourceMapWithBundle(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", "local x: number = 1\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--source-map", "--bundle", input}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "cannot be used together") {
+		t.Errorf("expected a mutual exclusion error, got %q", stderr.String())
+	}
+}
+
+func TestRunArgumentsAfterInput(t *testing.T) {
+	dir := t.TempDir()
+	input := writeSource(t, dir, "main.lunar", "local x: number = 1\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{input, "-o", filepath.Join(dir, "app.lua")}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Unexpected argument '-o' after the input") {
+		t.Errorf("expected an unexpected argument error, got %q", stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.lua")); !os.IsNotExist(err) {
+		t.Error("expected nothing to be compiled")
+	}
+}
+
+func TestRunQuiet(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", "local x: number = 1\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--quiet", input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no stdout with --quiet, got %q", stdout.String())
+	}
+}
+
+func TestRunVerbose(t *testing.T) {
+	dir := t.TempDir()
+	decl := writeSource(t, dir, "lua.d.lunar", "declare function print(message: any): void end\n")
+	input := writeSource(t, dir, "main.lunar", "print(\"x\")\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--verbose", input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, want := range []string{"Loading declaration file " + decl, "Parsing", "Type checking", "Wrote", "Successfully compiled"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected verbose output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunQuietAndVerbose(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", "local x: number = 1\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--quiet", "--verbose", input}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "cannot be used together") {
+		t.Errorf("expected a mutual exclusion error, got %q", stderr.String())
+	}
+}
+
+func TestRunPrintsWarnings(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", `
+local x: number = 0
+if true then
+	x = 1
+else
+	x = 2
+end
+`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected warnings not to fail compilation, got exit code %d: %s", code, stderr.String())
+	}
+	want := input + ":5:1: warning: Unreachable else branch"
+	if !strings.Contains(stderr.String(), want) {
+		t.Errorf("expected stderr to contain %q, got %q", want, stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--quiet", input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("expected no warnings with --quiet, got %q", stderr.String())
+	}
+}
+
+func TestRunTraceResolution(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", "local name: Strng = \"lunar\"\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--no-stdlib-globals", input}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if strings.Contains(stderr.String(), "lookup of") {
+		t.Errorf("expected no trace without --trace-resolution, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--no-stdlib-globals", "--trace-resolution", input}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	want := "lookup of 'Strng' at line 1, column 13 failed; using any\n"
+	if !strings.Contains(stderr.String(), want) {
+		t.Errorf("expected stderr to contain %q, got %q", want, stderr.String())
+	}
+}
+
+func TestRunGitHubDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	input := writeSource(t, dir, "main.lunar", `
+local count: number = "many"
+`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--diagnostics-format", "github", input}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	want := "::error file=" + input + ",line=2,col=1::Cannot assign type '\"many\"' to variable of type 'number'\n"
+	if stdout.String() != want {
+		t.Errorf("expected stdout %q, got %q", want, stdout.String())
+	}
+
+	parseError := writeSource(t, dir, "broken.lunar", "local x: readonly number\n")
+	stdout.Reset()
+	if code := run([]string{"--diagnostics-format=github", parseError}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	want = "::error file=" + parseError + ",line=1,col=10::'readonly' can only be applied to array types\n"
+	if !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("expected stdout to start with %q, got %q", want, stdout.String())
+	}
+}
+
+func TestRunGitHubWarnings(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", `
+local x: number = 0
+if true then
+	x = 1
+else
+	x = 2
+end
+`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--diagnostics-format", "github", input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected warnings not to fail compilation, got exit code %d: %s", code, stderr.String())
+	}
+	want := "::warning file=" + input + ",line=5,col=1::Unreachable else branch"
+	if !strings.Contains(stdout.String(), want) {
+		t.Errorf("expected stdout to contain %q, got %q", want, stdout.String())
+	}
+}
+
+func TestRunUnknownDiagnosticsFormat(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", "local x: number = 1\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--diagnostics-format", "json", input}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Unknown diagnostics format 'json'") {
+		t.Errorf("expected an unknown format error, got %q", stderr.String())
+	}
+}
+
+func TestEscapeGitHubDiagnostics(t *testing.T) {
+	var out bytes.Buffer
+	writeGitHubDiagnostics(&out, []diagnostic{
+		{severity: "error", file: "src/a,b:c.lunar", line: 3, column: 7, message: "100% wrong\nsecond line"},
+		{severity: "error", file: "main.lunar", message: "no position"},
+	})
+	want := "::error file=src/a%2Cb%3Ac.lunar,line=3,col=7::100%25 wrong%0Asecond line\n" +
+		"::error file=main.lunar::no position\n"
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestRunExportedFunctionsImported(t *testing.T) {
+	dir := t.TempDir()
+	util := writeSource(t, dir, "util.lunar", `
+export function add(a: number, b: number): number
+	return a + b
+end
+
+export function scale(a: number, factor: number): number
+	return a * factor
+end
+`)
+	input := writeSource(t, dir, "main.lunar", `
+import { add, scale } from "./util"
+
+local total = scale(add(1, 2), 10)
+`)
+
+	for _, file := range []string{util, input} {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{file}, &stdout, &stderr); code != 0 {
+			t.Fatalf("expected exit code 0 for %s, got %d: %s", file, code, stderr.String())
+		}
+	}
+
+	utilLua, err := os.ReadFile(filepath.Join(dir, "util.lua"))
+	if err != nil {
+		t.Fatalf("expected util.lua: %v", err)
+	}
+	returned := "\nreturn {\n    add = add,\n    scale = scale,\n}\n"
+	if !strings.HasSuffix(string(utilLua), returned) {
+		t.Errorf("expected util.lua to return its exports, got:\n%s", utilLua)
+	}
+
+	mainLua, err := os.ReadFile(filepath.Join(dir, "main.lua"))
+	if err != nil {
+		t.Fatalf("expected main.lua: %v", err)
+	}
+	imported := `local ___util = require("./util")
+local add = ___util.add
+local scale = ___util.scale
+`
+	if !strings.Contains(string(mainLua), imported) {
+		t.Errorf("expected main.lua to take the exports from the required module, got:\n%s", mainLua)
+	}
+}
+
+func TestRunImportedFunctionMisused(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "util.lunar", `
+export function add(a: number, b: number): number
+	return a + b
+end
+`)
+	input := writeSource(t, dir, "main.lunar", `
+import { add } from "./util"
+
+local total: string = add(1, 2)
+`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{input}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Cannot assign type 'number' to variable of type 'string'") {
+		t.Errorf("expected the imported function's return type to be checked, got:\n%s", stderr.String())
+	}
+}
+
+func TestRunImportedModuleWithParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	util := writeSource(t, dir, "util.lunar", `
+export function add(a: number, b: number): number
+	return a + b
+end
+
+local x = repeat
+`)
+	input := writeSource(t, dir, "main.lunar", `
+import { add } from "./util"
+`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{input}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	expected := "Cannot resolve module './util': " + util + ":6:11: "
+	if !strings.Contains(stderr.String(), expected) {
+		t.Errorf("expected %q, got:\n%s", expected, stderr.String())
+	}
+	if strings.Contains(stderr.String(), "Parse errors") {
+		t.Errorf("expected the module's first parse error on one line, got:\n%s", stderr.String())
+	}
+}
+
+func TestRunBundle(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "util.lunar", `
+export function add(a: number, b: number): number
+	return a + b
+end
+`)
+	input := writeSource(t, dir, "main.lunar", `
+import { add } from "./util"
+
+local total = add(1, 2)
+`)
+	output := filepath.Join(dir, "app.lua")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--bundle", "-o", output, input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	// The bundle must not depend on the sources or on any other output file
+	for _, name := range []string{"util.lunar", "main.lunar"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("failed to remove %s: %v", name, err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read output directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the bundle in the output directory, got %d files", len(entries))
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected bundle file: %v", err)
+	}
+	lua := string(data)
+
+	expected := `package.preload["./util"] = function(...)
+function add(a, b)
+    return a + b
+end
+
+return {
+    add = add,
+}
+end
+
+local ___util = require("./util")
+local add = ___util.add
+`
+	if !strings.HasPrefix(lua, expected) {
+		t.Errorf("unexpected bundle.\nexpected prefix:\n%s\ngot:\n%s", expected, lua)
+	}
+
+	// The entry module runs last, after its imports are registered
+	if !strings.HasSuffix(lua, "local total = add(1, 2)\n") {
+		t.Errorf("expected the entry module at the end of the bundle, got:\n%s", lua)
+	}
+}
+
+func TestBundleDeduplicatesModules(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "a.lunar", `
+import { b } from "./b"
+import { shared } from "./shared.lunar"
+
+export function a(): number
+	return shared()
+end
+`)
+	writeSource(t, dir, "b.lunar", `
+import { a } from "./a"
+import { shared } from "./shared"
+
+export function b(): number
+	return shared()
+end
+`)
+	writeSource(t, dir, "shared.lunar", `
+export function shared(): number
+	return 1
+end
+`)
+	input := writeSource(t, dir, "main.lunar", `
+import { a } from "./a"
+import { b } from "./b"
+`)
+	output := filepath.Join(dir, "app.lua")
+
+	// a and b import each other, and shared is reached through two paths
+	if err := bundle(input, output, compileOptions{typeCheck: true}); err != nil {
+		t.Fatalf("bundle failed: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected bundle file: %v", err)
+	}
+	lua := string(data)
+
+	for _, name := range []string{"./a", "./b", "./shared"} {
+		registration := fmt.Sprintf("package.preload[\"%s\"] = function(...)\n", name)
+		if count := strings.Count(lua, registration); count != 1 {
+			t.Errorf("expected %s to be registered once, got %d:\n%s", name, count, lua)
+		}
+	}
+	if count := strings.Count(lua, "function shared()"); count != 1 {
+		t.Errorf("expected shared to be compiled once, got %d:\n%s", count, lua)
+	}
+
+	alias := "package.preload[\"./shared.lunar\"] = function(...)\n    return require(\"./shared\")\nend\n"
+	if !strings.Contains(lua, alias) {
+		t.Errorf("expected ./shared.lunar to load through ./shared, got:\n%s", lua)
+	}
+}
+
+func TestBundleDropsUnusedExports(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "util.lunar", `
+declare function print(message: any): void end
+
+export function used(): number
+	return helper()
+end
+
+export function helper(): number
+	return 1
+end
+
+export function unused(): number
+	return 2
+end
+
+export class Unused
+	value: number
+end
+
+print("util loaded")
+`)
+	input := writeSource(t, dir, "main.lunar", `
+import { used } from "./util"
+
+local total = used()
+`)
+	output := filepath.Join(dir, "app.lua")
+
+	if err := bundle(input, output, compileOptions{typeCheck: true}); err != nil {
+		t.Fatalf("bundle failed: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected bundle file: %v", err)
+	}
+	lua := string(data)
+
+	// helper isn't imported, but used calls it
+	for _, kept := range []string{"function used()", "function helper()", "print(\"util loaded\")"} {
+		if !strings.Contains(lua, kept) {
+			t.Errorf("expected the bundle to keep %q, got:\n%s", kept, lua)
+		}
+	}
+	for _, dropped := range []string{"unused", "Unused"} {
+		if strings.Contains(lua, dropped) {
+			t.Errorf("expected %s to be left out of the bundle, got:\n%s", dropped, lua)
+		}
+	}
+}
+
+func TestRunImportedConstEnumIsInlined(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "colors.lunar", `
+export const enum Color
+	Red = 1
+	Green = 2
+end
+`)
+	input := writeSource(t, dir, "main.lunar", `
+import { Color } from "./colors"
+
+local c = Color.Green
+`)
+	output := filepath.Join(dir, "main.lua")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--no-stdlib-globals", "-o", output, input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected output file: %v", err)
+	}
+	expected := `local ___colors = require("./colors")
+
+local c = 2
+`
+	if string(data) != expected {
+		t.Errorf("expected the const enum to be inlined.\nexpected:\n%s\ngot:\n%s", expected, string(data))
+	}
+}
+
+func TestRunDirectoryWithFilters(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"src", "src/util", "vendor/lib"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", sub, err)
+		}
+	}
+	writeSource(t, dir, "src/main.lunar", "local x: number = 1\n")
+	writeSource(t, dir, "src/util/strings.lunar", "local s: string = \"s\"\n")
+	writeSource(t, dir, "src/main_test.lunar", "local t: number = 1\n")
+	writeSource(t, dir, "src/lua.d.lunar", "declare function print(message: any): void end\n")
+	// Would fail to compile if it weren't excluded
+	writeSource(t, dir, "vendor/lib/broken.lunar", "local x: number = \"oops\"\n")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--exclude", "vendor", "--exclude", "*_test.lunar", dir}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Successfully compiled 2 files") {
+		t.Errorf("expected a summary of 2 compiled files, got %q", stdout.String())
+	}
+
+	for file, compiled := range map[string]bool{
+		"src/main.lua":          true,
+		"src/util/strings.lua":  true,
+		"src/main_test.lua":     false,
+		"src/lua.d.lua":         false,
+		"vendor/lib/broken.lua": false,
+	} {
+		_, err := os.Stat(filepath.Join(dir, file))
+		if exists := err == nil; exists != compiled {
+			t.Errorf("%s: expected compiled=%v, got %v", file, compiled, exists)
+		}
+	}
+}
+
+func TestRunDirectoryWithInclude(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "lib"), 0755); err != nil {
+		t.Fatalf("failed to create lib: %v", err)
+	}
+	writeSource(t, dir, "main.lunar", "local x: number = 1\n")
+	writeSource(t, dir, "lib/util.lunar", "local y: number = 2\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--include", "lib/*", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "lib", "util.lua")); err != nil {
+		t.Errorf("expected lib/util.lua to be compiled: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.lua")); err == nil {
+		t.Errorf("expected main.lua not to be compiled")
+	}
+}
+
+func TestRunFiltersWithoutDirectory(t *testing.T) {
+	input := writeSource(t, t.TempDir(), "main.lunar", "local x: number = 1\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--exclude", "vendor", input}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "only apply when compiling a directory") {
+		t.Errorf("expected a directory-only error, got %q", stderr.String())
+	}
+}
+
+func TestMatchesGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"vendor", "vendor/lib/a.lunar", true},
+		{"vendor", "src/vendor/a.lunar", true},
+		{"vendor", "src/vendored.lunar", false},
+		{"*_test.lunar", "src/util/strings_test.lunar", true},
+		{"*_test.lunar", "src/util/strings.lunar", false},
+		{"src/util", "src/util/strings.lunar", true},
+		{"src/*.lunar", "src/main.lunar", true},
+		{"src/*.lunar", "src/util/strings.lunar", false},
+		{"src/util", "lib/src/util/a.lunar", false},
+	}
+
+	for _, tt := range tests {
+		if result := matchesGlob(tt.pattern, tt.path); result != tt.expected {
+			t.Errorf("matchesGlob(%q, %q): expected %v, got %v", tt.pattern, tt.path, tt.expected, result)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// phaseTiming records how long a single compiler phase took. A phase that
+// repeats work another phase also does is left out of the total
+type phaseTiming struct {
+	name     string
+	duration time.Duration
+	repeated bool
+}
+
+// profiler collects phase timings for one compiled file. A nil profiler
+// runs phases without timing them
+type profiler struct {
+	file   string
+	phases []phaseTiming
+}
+
+// newProfiler creates a profiler for the given file
+func newProfiler(file string) *profiler {
+	return &profiler{file: file}
+}
+
+// time runs fn and records its duration under name
+func (p *profiler) time(name string, fn func()) {
+	if p == nil {
+		fn()
+		return
+	}
+
+	start := time.Now()
+	fn()
+	p.phases = append(p.phases, phaseTiming{name: name, duration: time.Since(start)})
+}
+
+// timeRepeated runs fn, which repeats work done within another phase, and
+// records its duration under name without counting it towards the total
+func (p *profiler) timeRepeated(name string, fn func()) {
+	if p == nil {
+		fn()
+		return
+	}
+
+	start := time.Now()
+	fn()
+	p.phases = append(p.phases, phaseTiming{name: name, duration: time.Since(start), repeated: true})
+}
+
+// report writes the collected timings as a summary table
+func (p *profiler) report(w io.Writer) {
+	if p == nil {
+		return
+	}
+
+	var total time.Duration
+	fmt.Fprintf(w, "Profile for %s:\n", p.file)
+	for _, phase := range p.phases {
+		fmt.Fprintf(w, "  %-12s %10.3fms\n", phase.name, milliseconds(phase.duration))
+		if !phase.repeated {
+			total += phase.duration
+		}
+	}
+	fmt.Fprintf(w, "  %-12s %10.3fms\n", "total", milliseconds(total))
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -27,8 +27,9 @@ func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 func (i *Identifier) String() string       { return i.Value }
 
 type NumberLiteral struct {
-	Token lexer.Token
-	Value float64
+	Token   lexer.Token
+	Value   float64
+	IsFloat bool // written with a decimal point or an exponent, as in 1.0 or 1e3
 }
 
 func (i *NumberLiteral) expressionNode()      {}
@@ -128,6 +129,37 @@ func (de *DotExpression) String() string {
 	return fmt.Sprintf("%s.%s", de.Left.String(), de.Right.String())
 }
 
+// MethodExpression is a method reference bound to its receiver (obj:method)
+type MethodExpression struct {
+	Token lexer.Token // ':' token
+	Left  Expression  // the receiver
+	Right Expression  // the method name
+}
+
+func (me *MethodExpression) expressionNode()      {}
+func (me *MethodExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MethodExpression) String() string {
+	return fmt.Sprintf("%s:%s", me.Left.String(), me.Right.String())
+}
+
+// VarargExpression is '...', the extra arguments of a variadic function
+type VarargExpression struct {
+	Token lexer.Token // '...' token
+}
+
+func (ve *VarargExpression) expressionNode()      {}
+func (ve *VarargExpression) TokenLiteral() string { return ve.Token.Literal }
+func (ve *VarargExpression) String() string       { return "..." }
+
+// SuperExpression refers to the parent class inside a subclass (super.method())
+type SuperExpression struct {
+	Token lexer.Token // 'super' token
+}
+
+func (se *SuperExpression) expressionNode()      {}
+func (se *SuperExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SuperExpression) String() string       { return "super" }
+
 type IndexExpression struct {
 	Token lexer.Token // '[' token
 	Left  Expression  // the object being indexed
@@ -216,6 +248,38 @@ func (vd *VariableDeclaration) String() string {
 	return out.String()
 }
 
+// DestructuringDeclaration declares a local for each field of a record,
+// such as local { timeout = 30, retries } = options
+type DestructuringDeclaration struct {
+	Token  lexer.Token // 'local' token
+	Fields []*DestructuringField
+	Value  Expression
+}
+
+// DestructuringField is a field of a destructuring pattern. Default, when
+// set, is used in place of a nil field
+type DestructuringField struct {
+	Name    *Identifier
+	Default Expression
+}
+
+func (dd *DestructuringDeclaration) statementNode()       {}
+func (dd *DestructuringDeclaration) TokenLiteral() string { return dd.Token.Literal }
+func (dd *DestructuringDeclaration) String() string {
+	fields := make([]string, len(dd.Fields))
+	for i, field := range dd.Fields {
+		fields[i] = field.Name.String()
+		if field.Default != nil {
+			fields[i] += " = " + field.Default.String()
+		}
+	}
+	out := "local { " + strings.Join(fields, ", ") + " }"
+	if dd.Value != nil {
+		out += " = " + dd.Value.String()
+	}
+	return out
+}
+
 type OptionalType struct {
 	Token lexer.Token
 	Type  Expression
@@ -228,11 +292,24 @@ func (ot *OptionalType) String() string       { return ot.Type.String() + "?" }
 type ArrayType struct {
 	Token       lexer.Token // the element type token
 	ElementType Expression
+	Readonly    bool // readonly T[]: elements may be read but not assigned
 }
 
 func (at *ArrayType) expressionNode()      {}
 func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
-func (at *ArrayType) String() string       { return at.ElementType.String() + "[]" }
+func (at *ArrayType) String() string {
+	prefix := ""
+	if at.Readonly {
+		prefix = "readonly "
+	}
+	// Function, union and intersection element types need parentheses to
+	// keep the [] from binding to their last part
+	switch at.ElementType.(type) {
+	case *FunctionType, *UnionType, *IntersectionType:
+		return prefix + "(" + at.ElementType.String() + ")[]"
+	}
+	return prefix + at.ElementType.String() + "[]"
+}
 
 type TableType struct {
 	Token     lexer.Token // 'table' token
@@ -263,6 +340,24 @@ func (ut *UnionType) String() string {
 	return strings.Join(typeStrs, " | ")
 }
 
+// IntersectionType is a type that is all of its members at once (A & B)
+type IntersectionType struct {
+	Token lexer.Token // '&' token
+	Types []Expression
+}
+
+func (it *IntersectionType) expressionNode()      {}
+func (it *IntersectionType) TokenLiteral() string { return it.Token.Literal }
+func (it *IntersectionType) String() string {
+	typeStrs := []string{}
+	for _, t := range it.Types {
+		if t != nil {
+			typeStrs = append(typeStrs, t.String())
+		}
+	}
+	return strings.Join(typeStrs, " & ")
+}
+
 type TupleType struct {
 	Token lexer.Token // '(' token
 	Types []Expression
@@ -296,6 +391,19 @@ func (ft *FunctionType) String() string {
 	return fmt.Sprintf("(%s) => %s", strings.Join(paramStrs, ", "), ft.ReturnType.String())
 }
 
+// TypePredicate is a return type that narrows an argument: x is Dog
+type TypePredicate struct {
+	Token     lexer.Token // the parameter name token
+	Parameter *Identifier
+	Type      Expression
+}
+
+func (tp *TypePredicate) expressionNode()      {}
+func (tp *TypePredicate) TokenLiteral() string { return tp.Token.Literal }
+func (tp *TypePredicate) String() string {
+	return fmt.Sprintf("%s is %s", tp.Parameter.String(), tp.Type.String())
+}
+
 type GenericType struct {
 	Token         lexer.Token // the base type token
 	BaseType      Expression
@@ -312,16 +420,70 @@ func (gt *GenericType) String() string {
 	return fmt.Sprintf("%s<%s>", gt.BaseType.String(), strings.Join(argStrs, ", "))
 }
 
+// GenericParameter is a type parameter of a generic declaration, optionally
+// with a constraint and a default type: <T extends Comparable = Version>
+type GenericParameter struct {
+	Token      lexer.Token // the parameter name token
+	Name       *Identifier
+	Constraint Expression // nil when the parameter is unconstrained
+	Default    Expression // nil when the parameter has no default
+}
+
+func (gp *GenericParameter) String() string {
+	result := gp.Name.String()
+	if gp.Constraint != nil {
+		result += " extends " + gp.Constraint.String()
+	}
+	if gp.Default != nil {
+		result += " = " + gp.Default.String()
+	}
+	return result
+}
+
+// genericParamsString prints a type parameter list, <T, U>, or nothing when
+// there are no parameters
+func genericParamsString(params []*GenericParameter) string {
+	if len(params) == 0 {
+		return ""
+	}
+	strs := []string{}
+	for _, gp := range params {
+		strs = append(strs, gp.String())
+	}
+	return "<" + strings.Join(strs, ", ") + ">"
+}
+
 type Parameter struct {
 	Token lexer.Token
 	Name  *Identifier
 	Type  Expression
+
+	// Constructor parameter properties: constructor(private balance: number)
+	Visibility string // "public", "private", "protected", or "" for none
+	Readonly   bool
+
+	// IsVariadic marks a trailing ... parameter, whose Name is "..." and
+	// whose Type, if any, is the type of each extra argument
+	IsVariadic bool
+}
+
+// IsProperty reports whether a constructor parameter also declares a
+// property of the class
+func (p *Parameter) IsProperty() bool {
+	return p.Visibility != "" || p.Readonly
 }
 
 func (p *Parameter) expressionNode()      {}
 func (p *Parameter) TokenLiteral() string { return p.Token.Literal }
 func (p *Parameter) String() string {
 	var out strings.Builder
+	if p.Visibility != "" {
+		out.WriteString(p.Visibility)
+		out.WriteString(" ")
+	}
+	if p.Readonly {
+		out.WriteString("readonly ")
+	}
 	out.WriteString(p.Name.String())
 	if p.Type != nil {
 		out.WriteString(": ")
@@ -352,10 +514,11 @@ func (bs *BlockStatement) String() string {
 type FunctionDeclaration struct {
 	Token         lexer.Token
 	Name          *Identifier
-	GenericParams []*Identifier // generic type parameters like <T, U>
+	GenericParams []*GenericParameter // generic type parameters like <T, U>
 	Parameters    []*Parameter
 	ReturnType    Expression
 	Body          *BlockStatement
+	Visibility    string // class methods only: "public", "private", "protected", or "" for public
 }
 
 func (fd *FunctionDeclaration) statementNode()       {}
@@ -370,6 +533,7 @@ func (fd *FunctionDeclaration) String() string {
 
 	out.WriteString("function ")
 	out.WriteString(fd.Name.String())
+	out.WriteString(genericParamsString(fd.GenericParams))
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")")
@@ -386,9 +550,47 @@ func (fd *FunctionDeclaration) String() string {
 	return out.String()
 }
 
+// FunctionLiteral is an anonymous function expression, function(x) ... end
+type FunctionLiteral struct {
+	Token      lexer.Token // 'function' token
+	Parameters []*Parameter
+	ReturnType Expression
+	Body       *BlockStatement
+}
+
+func (fl *FunctionLiteral) expressionNode()      {}
+func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) String() string {
+	var out strings.Builder
+
+	params := []string{}
+	for _, p := range fl.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("function(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+
+	if fl.ReturnType != nil {
+		out.WriteString(": ")
+		out.WriteString(fl.ReturnType.String())
+	}
+
+	out.WriteString("\n")
+	out.WriteString(fl.Body.String())
+	out.WriteString("\nend")
+
+	return out.String()
+}
+
 type ReturnStatement struct {
 	Token       lexer.Token
 	ReturnValue Expression
+
+	// ReturnValues holds every value of return a, b, which leaves
+	// ReturnValue nil
+	ReturnValues []Expression
 }
 
 func (rs *ReturnStatement) statementNode()       {}
@@ -399,6 +601,12 @@ func (rs *ReturnStatement) String() string {
 	if rs.ReturnValue != nil {
 		out.WriteString(rs.ReturnValue.String())
 	}
+	for i, value := range rs.ReturnValues {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(value.String())
+	}
 	return out.String()
 }
 
@@ -420,6 +628,7 @@ type IfStatement struct {
 	Token       lexer.Token // 'if' token
 	Condition   Expression
 	Consequence *BlockStatement
+	ElseIfs     []*ElseIfClause // elseif branches, in order
 	Alternative *BlockStatement // can be nil
 }
 
@@ -433,6 +642,11 @@ func (is *IfStatement) String() string {
 	out.WriteString(" then\n")
 	out.WriteString(is.Consequence.String())
 
+	for _, elseIf := range is.ElseIfs {
+		out.WriteString("\n")
+		out.WriteString(elseIf.String())
+	}
+
 	if is.Alternative != nil {
 		out.WriteString("\nelse\n")
 		out.WriteString(is.Alternative.String())
@@ -442,6 +656,17 @@ func (is *IfStatement) String() string {
 	return out.String()
 }
 
+// ElseIfClause is an elseif branch of an if statement
+type ElseIfClause struct {
+	Token       lexer.Token // 'elseif' token
+	Condition   Expression
+	Consequence *BlockStatement
+}
+
+func (ec *ElseIfClause) String() string {
+	return "elseif " + ec.Condition.String() + " then\n" + ec.Consequence.String()
+}
+
 type WhileStatement struct {
 	Token     lexer.Token // 'while' token
 	Condition Expression
@@ -462,6 +687,26 @@ func (ws *WhileStatement) String() string {
 	return out.String()
 }
 
+// RepeatStatement is a repeat-until loop, whose body runs at least once
+type RepeatStatement struct {
+	Token     lexer.Token // 'repeat' token
+	Body      *BlockStatement
+	Condition Expression
+}
+
+func (rs *RepeatStatement) statementNode()       {}
+func (rs *RepeatStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *RepeatStatement) String() string {
+	var out strings.Builder
+
+	out.WriteString("repeat\n")
+	out.WriteString(rs.Body.String())
+	out.WriteString("\nuntil ")
+	out.WriteString(rs.Condition.String())
+
+	return out.String()
+}
+
 type ForStatement struct {
 	Token    lexer.Token // 'for' token
 	Variable *Identifier
@@ -519,6 +764,26 @@ func (ds *DoStatement) String() string {
 	return out.String()
 }
 
+// DoExpression is a do ... end block used as a value, such as
+// local x = do ... end. Its value is that of the block's final return or
+// expression statement
+type DoExpression struct {
+	Token lexer.Token // 'do' token
+	Body  *BlockStatement
+}
+
+func (de *DoExpression) expressionNode()      {}
+func (de *DoExpression) TokenLiteral() string { return de.Token.Literal }
+func (de *DoExpression) String() string {
+	var out strings.Builder
+
+	out.WriteString("do\n")
+	out.WriteString(de.Body.String())
+	out.WriteString("\nend")
+
+	return out.String()
+}
+
 type BreakStatement struct {
 	Token lexer.Token // 'break' token
 }
@@ -531,25 +796,54 @@ type AssignmentStatement struct {
 	Token lexer.Token // '=' token
 	Name  Expression  // left side (can be identifier, dot expression, index expression)
 	Value Expression  // right side
+
+	// Names and Values hold the targets and values of a multiple assignment
+	// such as a, b = b, a, which leaves Name and Value nil
+	Names  []Expression
+	Values []Expression
 }
 
 func (as *AssignmentStatement) statementNode()       {}
 func (as *AssignmentStatement) TokenLiteral() string { return as.Token.Literal }
 func (as *AssignmentStatement) String() string {
 	var out strings.Builder
-	out.WriteString(as.Name.String())
+	if as.Names == nil {
+		out.WriteString(as.Name.String())
+		out.WriteString(" = ")
+		out.WriteString(as.Value.String())
+		return out.String()
+	}
+
+	names := make([]string, len(as.Names))
+	for i, name := range as.Names {
+		names[i] = name.String()
+	}
+	values := make([]string, len(as.Values))
+	for i, value := range as.Values {
+		values[i] = value.String()
+	}
+	out.WriteString(strings.Join(names, ", "))
 	out.WriteString(" = ")
-	out.WriteString(as.Value.String())
+	out.WriteString(strings.Join(values, ", "))
 	return out.String()
 }
 
+// Targets returns every expression the statement assigns to
+func (as *AssignmentStatement) Targets() []Expression {
+	if as.Names == nil {
+		return []Expression{as.Name}
+	}
+	return as.Names
+}
+
 type ClassDeclaration struct {
 	Token         lexer.Token // 'class' token
 	Name          *Identifier
-	GenericParams []*Identifier           // generic type parameters like <T, U>
+	GenericParams []*GenericParameter     // generic type parameters like <T, U>
 	Properties    []*PropertyDeclaration
 	Methods       []*FunctionDeclaration
 	Constructor   *ConstructorDeclaration
+	Extends       Expression   // parent class name, nil when there is none
 	Implements    []Expression // interface names
 }
 
@@ -560,6 +854,12 @@ func (cd *ClassDeclaration) String() string {
 
 	out.WriteString("class ")
 	out.WriteString(cd.Name.String())
+	out.WriteString(genericParamsString(cd.GenericParams))
+
+	if cd.Extends != nil {
+		out.WriteString(" extends ")
+		out.WriteString(cd.Extends.String())
+	}
 
 	if len(cd.Implements) > 0 {
 		out.WriteString(" implements ")
@@ -609,6 +909,8 @@ type PropertyDeclaration struct {
 	Visibility string      // "public", "private", "protected"
 	Name       *Identifier
 	Type       Expression
+	Readonly   bool // readonly name: Type
+	Optional   bool // name?: Type
 }
 
 func (pd *PropertyDeclaration) statementNode()       {}
@@ -619,7 +921,13 @@ func (pd *PropertyDeclaration) String() string {
 		out.WriteString(pd.Visibility)
 		out.WriteString(" ")
 	}
+	if pd.Readonly {
+		out.WriteString("readonly ")
+	}
 	out.WriteString(pd.Name.String())
+	if pd.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString(": ")
 	out.WriteString(pd.Type.String())
 	return out.String()
@@ -627,6 +935,7 @@ func (pd *PropertyDeclaration) String() string {
 
 type ConstructorDeclaration struct {
 	Token      lexer.Token // 'constructor' token
+	Visibility string      // "public", "private", "protected", or "" for public
 	Parameters []*Parameter
 	Body       *BlockStatement
 }
@@ -641,6 +950,9 @@ func (cd *ConstructorDeclaration) String() string {
 		params = append(params, p.String())
 	}
 
+	if cd.Visibility != "" {
+		out.WriteString(cd.Visibility + " ")
+	}
 	out.WriteString("constructor(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")\n")
@@ -656,6 +968,8 @@ type InterfaceDeclaration struct {
 	Methods    []*InterfaceMethod
 	Properties []*PropertyDeclaration
 	Extends    []Expression // parent interface names
+
+	IndexSignatures []*IndexSignature // [index: number]: T and [key: string]: T
 }
 
 func (id *InterfaceDeclaration) statementNode()       {}
@@ -677,6 +991,12 @@ func (id *InterfaceDeclaration) String() string {
 
 	out.WriteString("\n")
 
+	for _, sig := range id.IndexSignatures {
+		out.WriteString("    ")
+		out.WriteString(sig.String())
+		out.WriteString("\n")
+	}
+
 	// Properties
 	for _, prop := range id.Properties {
 		out.WriteString("    ")
@@ -695,6 +1015,19 @@ func (id *InterfaceDeclaration) String() string {
 	return out.String()
 }
 
+// IndexSignature types the values stored under keys of a given type, as in
+// [index: number]: T or [key: string]: T
+type IndexSignature struct {
+	Token     lexer.Token // '[' token
+	KeyName   *Identifier
+	KeyType   Expression
+	ValueType Expression
+}
+
+func (is *IndexSignature) String() string {
+	return fmt.Sprintf("[%s: %s]: %s", is.KeyName.String(), is.KeyType.String(), is.ValueType.String())
+}
+
 type InterfaceMethod struct {
 	Token      lexer.Token
 	Name       *Identifier
@@ -728,6 +1061,7 @@ type EnumDeclaration struct {
 	Token   lexer.Token // 'enum' token
 	Name    *Identifier
 	Members []*EnumMember
+	IsConst bool // const enum: members are inlined, no runtime table
 }
 
 func (ed *EnumDeclaration) statementNode()       {}
@@ -735,6 +1069,9 @@ func (ed *EnumDeclaration) TokenLiteral() string { return ed.Token.Literal }
 func (ed *EnumDeclaration) String() string {
 	var out strings.Builder
 
+	if ed.IsConst {
+		out.WriteString("const ")
+	}
 	out.WriteString("enum ")
 	out.WriteString(ed.Name.String())
 	out.WriteString("\n")
@@ -767,42 +1104,69 @@ func (em *EnumMember) String() string {
 type TypeDeclaration struct {
 	Token         lexer.Token // 'type' token
 	Name          *Identifier
-	GenericParams []*Identifier            // generic type parameters (e.g., T, U)
+	GenericParams []*GenericParameter      // generic type parameters (e.g., T, U)
 	Type          Expression               // the type being aliased (for type Name = Type)
 	Properties    []*PropertyDeclaration // for object shape (type Name ... end)
+
+	IndexSignatures []*IndexSignature // for object shape, like an interface's
 }
 
 func (td *TypeDeclaration) statementNode()       {}
 func (td *TypeDeclaration) TokenLiteral() string { return td.Token.Literal }
 func (td *TypeDeclaration) String() string {
 	if td.Type != nil {
-		return fmt.Sprintf("type %s = %s", td.Name.String(), td.Type.String())
+		return fmt.Sprintf("type %s%s = %s", td.Name.String(), genericParamsString(td.GenericParams), td.Type.String())
 	}
 	// Object shape type
-	return fmt.Sprintf("type %s { ... }", td.Name.String())
+	return fmt.Sprintf("type %s%s { ... }", td.Name.String(), genericParamsString(td.GenericParams))
 }
 
 // ObjectShapeType represents an inline object shape for type declarations
 type ObjectShapeType struct {
-	Token      lexer.Token
-	Properties []*PropertyDeclaration
+	Token           lexer.Token
+	Properties      []*PropertyDeclaration
+	IndexSignatures []*IndexSignature
 }
 
 func (ost *ObjectShapeType) expressionNode()      {}
 func (ost *ObjectShapeType) TokenLiteral() string { return ost.Token.Literal }
 func (ost *ObjectShapeType) String() string {
-	return "{ object shape }"
+	props := []string{}
+	for _, sig := range ost.IndexSignatures {
+		props = append(props, sig.String())
+	}
+	for _, prop := range ost.Properties {
+		props = append(props, prop.String())
+	}
+	if len(props) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(props, ", ") + " }"
 }
 
-// ExportStatement wraps another statement to mark it as exported
+// ExportStatement wraps another statement to mark it as exported, or
+// re-exports everything from another module (export * from "module")
 type ExportStatement struct {
-	Token     lexer.Token // 'export' token
-	Statement Statement   // the statement being exported
+	Token      lexer.Token // 'export' token
+	Statement  Statement   // the statement being exported
+	Module     string      // source module path for re-exports
+	IsWildcard bool        // true if using export * from
+	IsDefault  bool        // true for export default, which exports the statement as default
 }
 
+// DefaultExportName is the internal name given to an anonymous function or
+// class declared by export default
+const DefaultExportName = "_default"
+
 func (es *ExportStatement) statementNode()       {}
 func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
 func (es *ExportStatement) String() string {
+	if es.IsWildcard {
+		return fmt.Sprintf("export * from \"%s\"", es.Module)
+	}
+	if es.IsDefault {
+		return fmt.Sprintf("export default %s", es.Statement.String())
+	}
 	return fmt.Sprintf("export %s", es.Statement.String())
 }
 
@@ -812,6 +1176,7 @@ type ImportStatement struct {
 	Names   []*Identifier // names being imported
 	Module  string        // module path (string literal)
 	IsWildcard bool       // true if using * import
+	IsTypeOnly bool       // true for import type { ... }, which is erased
 }
 
 func (is *ImportStatement) statementNode()       {}
@@ -824,6 +1189,9 @@ func (is *ImportStatement) String() string {
 	for _, name := range is.Names {
 		names = append(names, name.String())
 	}
+	if is.IsTypeOnly {
+		return fmt.Sprintf("import type { %s } from \"%s\"", strings.Join(names, ", "), is.Module)
+	}
 	return fmt.Sprintf("import { %s } from \"%s\"", strings.Join(names, ", "), is.Module)
 }
 
@@ -842,3 +1210,17 @@ func (ds *DeclareStatement) String() string {
 	}
 	return "declare"
 }
+
+// Trivia is the source text around a statement that its node doesn't keep,
+// recorded when the lexer preserves trivia. Leading, Text and Trailing
+// together reproduce the statement and its surroundings exactly as written.
+// Printed is the node's String() when it was parsed, with the statements of
+// its blocks left out, to tell whether the statement itself has been edited
+// since
+type Trivia struct {
+	Leading  string // blank lines and comments before the statement
+	Text     string // the statement itself, as written
+	Offset   int    // where Text starts in the source
+	Trailing string // spaces and a comment after it, on its last line
+	Printed  string // the statement as its node printed it when parsed
+}
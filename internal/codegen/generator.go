@@ -3,36 +3,172 @@ package codegen
 import (
 	"fmt"
 	"lunar/internal/ast"
+	"lunar/internal/sourcemap"
+	"lunar/internal/target"
+	"strconv"
 	"strings"
 )
 
 // Generator generates Lua code from an AST
 type Generator struct {
 	indent int
+
+	// Module variables whose contents are re-exported (export * from)
+	reExports []string
+
+	// Names declared by export statements, in the order they're declared
+	exports []string
+
+	// Name bound by the module's default export, if it has one
+	defaultExport string
+
+	// Inlined member values of the const enums in scope, by enum and member
+	// name. A local of the same name hides an enum to the end of its block
+	constEnums map[string]map[string]string
+
+	// Parent of the class being generated, for lowering super calls
+	superClass string
+
+	// Column the expression being generated starts at, for deciding
+	// whether a table literal or argument list fits on its line
+	column int
+
+	options Options
 }
 
+// Options configures a Generator
+type Options struct {
+	// MaxLineLength wraps table literals and call arguments across lines
+	// when they would run past this column. Zero means no limit
+	MaxLineLength int
+
+	// EmitLuaLS adds LuaLS annotation comments (---@param, ---@return,
+	// ---@type, ---@class) derived from the type annotations, for editor
+	// support in the generated Lua
+	EmitLuaLS bool
+
+	// Target is the Lua version being generated for ("5.1" to "5.4"). Const
+	// declarations get the <const> attribute from 5.4 and a "-- const"
+	// comment before it. Empty means no specific target
+	Target string
+
+	// CheckedIndex turns every index read t[i] into a call to a helper,
+	// defined at the top of the module, that raises an error when a number
+	// index is outside 1..#t. Assignment targets are left as they are
+	CheckedIndex bool
+
+	// ConstEnums are the const enums the module imports. A const enum has
+	// no runtime value to import, so its members are inlined as those of the
+	// module's own const enums are, and the import doesn't bind it
+	ConstEnums []*ast.EnumDeclaration
+}
+
+// checkedIndexHelper is the helper indexed reads call with CheckedIndex set.
+// Only number indices are checked, so tables keyed by strings still work
+const checkedIndexHelper = `local function __lunar_index(t, i)
+    if type(i) == "number" and (i < 1 or i > #t) then
+        error("index " .. i .. " out of bounds (length " .. #t .. ")", 2)
+    end
+    return t[i]
+end
+`
+
 // New creates a new code generator
 func New() *Generator {
+	return NewWithOptions(Options{})
+}
+
+// NewWithOptions creates a new code generator configured by opts
+func NewWithOptions(opts Options) *Generator {
 	return &Generator{
-		indent: 0,
+		indent:     0,
+		constEnums: make(map[string]map[string]string),
+		options:    opts,
 	}
 }
 
 // Generate generates Lua code from a list of statements
 func (g *Generator) Generate(statements []ast.Statement) string {
+	return g.generate(statements, nil)
+}
+
+// generate generates the Lua for a program, adding a mapping for each
+// top-level statement to sourceMap when it isn't nil
+func (g *Generator) generate(statements []ast.Statement, sourceMap *sourcemap.Builder) string {
 	var output strings.Builder
+	line := 1
+
+	if g.options.CheckedIndex {
+		output.WriteString(checkedIndexHelper)
+		output.WriteString("\n")
+		line += strings.Count(checkedIndexHelper, "\n") + 1
+	}
+
+	// Const enums may be referenced before their declaration
+	for _, enum := range g.options.ConstEnums {
+		g.registerConstEnum(enum)
+	}
+	for _, stmt := range statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			stmt = export.Statement
+		}
+		if enum, ok := stmt.(*ast.EnumDeclaration); ok && enum.IsConst {
+			g.registerConstEnum(enum)
+		}
+	}
 
 	for i, stmt := range statements {
 		code := g.generateStatement(stmt)
 		if code != "" {
+			if start := statementStart(stmt); sourceMap != nil && start.Line > 0 {
+				sourceMap.AddMapping(line, 0, start.Line, start.Column-1, "")
+			}
 			output.WriteString(code)
+			line += strings.Count(code, "\n")
 			// Add blank line between top-level declarations
 			if i < len(statements)-1 {
 				output.WriteString("\n")
+				line++
 			}
 		}
 	}
 
+	if len(g.exports) > 0 || len(g.reExports) > 0 || g.defaultExport != "" {
+		output.WriteString("\n")
+		output.WriteString(g.generateModuleExports())
+	}
+
+	return output.String()
+}
+
+// generateModuleExports generates the module's trailing return table of the
+// exported names, merging in the contents of every re-exported module and
+// the default export. A name the module exports itself wins over one a
+// re-exported module has
+func (g *Generator) generateModuleExports() string {
+	var output strings.Builder
+	if len(g.reExports) == 0 && g.defaultExport == "" {
+		output.WriteString("return {\n")
+		for _, name := range g.exports {
+			output.WriteString(fmt.Sprintf("    %s = %s,\n", name, name))
+		}
+		output.WriteString("}\n")
+		return output.String()
+	}
+
+	output.WriteString("local _exports = {}\n")
+	for _, moduleVar := range g.reExports {
+		output.WriteString(fmt.Sprintf("for key, value in pairs(%s) do\n", moduleVar))
+		output.WriteString("    _exports[key] = value\n")
+		output.WriteString("end\n")
+	}
+	for _, name := range g.exports {
+		output.WriteString(fmt.Sprintf("_exports.%s = %s\n", name, name))
+	}
+	if g.defaultExport != "" {
+		output.WriteString(fmt.Sprintf("_exports.default = %s\n", g.defaultExport))
+	}
+	output.WriteString("return _exports\n")
 	return output.String()
 }
 
@@ -42,12 +178,22 @@ func (g *Generator) generateStatement(stmt ast.Statement) string {
 		return ""
 	}
 
+	// Statements start on a line of their own
+	prevColumn := g.column
+	g.column = len(g.generateIndent())
+	defer func() { g.column = prevColumn }()
+
 	switch node := stmt.(type) {
 	case *ast.VariableDeclaration:
 		return g.generateVariableDeclaration(node)
+	case *ast.DestructuringDeclaration:
+		return g.generateDestructuringDeclaration(node)
 	case *ast.FunctionDeclaration:
 		return g.generateFunctionDeclaration(node)
 	case *ast.ExpressionStatement:
+		if call := superConstructorCall(node); call != nil {
+			return g.generateSuperConstructorCall(call)
+		}
 		return g.generateIndent() + g.generateExpression(node.Expression) + "\n"
 	case *ast.ReturnStatement:
 		return g.generateReturnStatement(node)
@@ -55,6 +201,8 @@ func (g *Generator) generateStatement(stmt ast.Statement) string {
 		return g.generateIfStatement(node)
 	case *ast.WhileStatement:
 		return g.generateWhileStatement(node)
+	case *ast.RepeatStatement:
+		return g.generateRepeatStatement(node)
 	case *ast.ForStatement:
 		return g.generateForStatement(node)
 	case *ast.DoStatement:
@@ -87,16 +235,108 @@ func (g *Generator) generateStatement(stmt ast.Statement) string {
 // generateVariableDeclaration generates code for a variable declaration
 func (g *Generator) generateVariableDeclaration(node *ast.VariableDeclaration) string {
 	var output strings.Builder
+	if g.options.EmitLuaLS && node.Type != nil {
+		output.WriteString(g.generateIndent())
+		output.WriteString("---@type " + luaLSType(node.Type) + "\n")
+	}
 	output.WriteString(g.generateIndent())
 	output.WriteString("local ")
 	output.WriteString(node.Name.Value)
 
+	constAttribute := node.IsConstant && target.AtLeast(g.options.Target, "5.4")
+	if constAttribute {
+		output.WriteString(" <const>")
+	}
+
 	if node.Value != nil {
 		output.WriteString(" = ")
-		output.WriteString(g.generateExpression(node.Value))
+		output.WriteString(g.generateExpressionAt(node.Value, lineColumn(0, output.String())))
+	}
+
+	// Older Lua versions can't enforce a const, so it's only marked
+	if node.IsConstant && !constAttribute && target.IsValid(g.options.Target) {
+		output.WriteString(" -- const")
 	}
 
 	output.WriteString("\n")
+	g.shadowConstEnums(node.Name.Value)
+	return output.String()
+}
+
+// generateDestructuringDeclaration generates a local per destructured field,
+// replacing a nil field with its default:
+//
+//	local timeout = options.timeout
+//	if timeout == nil then
+//	    timeout = 30
+//	end
+//
+// A value other than a variable is evaluated once, in a do block that
+// assigns locals declared before it
+func (g *Generator) generateDestructuringDeclaration(node *ast.DestructuringDeclaration) string {
+	var output strings.Builder
+
+	source, isVariable := node.Value.(*ast.Identifier)
+	if isVariable {
+		// The variable must not be shadowed by one of the fields before
+		// the last field is read from it
+		for _, field := range node.Fields {
+			if field.Name.Value == source.Value {
+				isVariable = false
+			}
+		}
+	}
+
+	if isVariable {
+		for _, field := range node.Fields {
+			output.WriteString(g.generateIndent())
+			output.WriteString(fmt.Sprintf("local %s = %s.%s\n", field.Name.Value, source.Value, field.Name.Value))
+			g.shadowConstEnums(field.Name.Value)
+			output.WriteString(g.generateFieldDefault(field))
+		}
+		return output.String()
+	}
+
+	names := make([]string, len(node.Fields))
+	for i, field := range node.Fields {
+		names[i] = field.Name.Value
+	}
+	output.WriteString(g.generateIndent())
+	output.WriteString("local " + strings.Join(names, ", ") + "\n")
+	g.shadowConstEnums(names...)
+	output.WriteString(g.generateIndent())
+	output.WriteString("do\n")
+	g.indent++
+	output.WriteString(g.generateIndent())
+	output.WriteString("local _value = ")
+	output.WriteString(g.generateExpressionAt(node.Value, len(g.generateIndent())+len("local _value = ")) + "\n")
+	for _, field := range node.Fields {
+		output.WriteString(g.generateIndent())
+		output.WriteString(fmt.Sprintf("%s = _value.%s\n", field.Name.Value, field.Name.Value))
+		output.WriteString(g.generateFieldDefault(field))
+	}
+	g.indent--
+	output.WriteString(g.generateIndent())
+	output.WriteString("end\n")
+	return output.String()
+}
+
+// generateFieldDefault generates the check that replaces a destructured
+// field with its default when it's nil, or nothing when it has no default
+func (g *Generator) generateFieldDefault(field *ast.DestructuringField) string {
+	if field.Default == nil {
+		return ""
+	}
+
+	var output strings.Builder
+	output.WriteString(g.generateIndent())
+	output.WriteString(fmt.Sprintf("if %s == nil then\n", field.Name.Value))
+	g.indent++
+	output.WriteString(g.generateIndent())
+	output.WriteString(fmt.Sprintf("%s = %s\n", field.Name.Value, g.generateExpression(field.Default)))
+	g.indent--
+	output.WriteString(g.generateIndent())
+	output.WriteString("end\n")
 	return output.String()
 }
 
@@ -104,7 +344,12 @@ func (g *Generator) generateVariableDeclaration(node *ast.VariableDeclaration) s
 func (g *Generator) generateFunctionDeclaration(node *ast.FunctionDeclaration) string {
 	var output strings.Builder
 
+	output.WriteString(g.generateFunctionAnnotations(node.GenericParams, node.Parameters, node.ReturnType))
 	output.WriteString(g.generateIndent())
+	// An anonymous default export's internal name stays local to the module
+	if node.Name.Value == ast.DefaultExportName {
+		output.WriteString("local ")
+	}
 	output.WriteString("function ")
 	output.WriteString(node.Name.Value)
 	output.WriteString("(")
@@ -119,13 +364,60 @@ func (g *Generator) generateFunctionDeclaration(node *ast.FunctionDeclaration) s
 
 	// Body
 	g.indent++
-	for _, stmt := range node.Body.Statements {
+	output.WriteString(g.generateBody(node.Body.Statements, params...))
+	g.indent--
+
+	output.WriteString(g.generateIndent())
+	output.WriteString("end\n")
+
+	return output.String()
+}
+
+// generateFunctionLiteral generates code for an anonymous function. Its body
+// is indented one level deeper than the line the function starts on
+func (g *Generator) generateFunctionLiteral(node *ast.FunctionLiteral) string {
+	var output strings.Builder
+
+	params := make([]string, len(node.Parameters))
+	for i, param := range node.Parameters {
+		params[i] = param.Name.Value
+	}
+	output.WriteString("function(")
+	output.WriteString(strings.Join(params, ", "))
+	output.WriteString(")\n")
+
+	g.indent++
+	output.WriteString(g.generateBody(node.Body.Statements, params...))
+	g.indent--
+
+	output.WriteString(g.generateIndent())
+	output.WriteString("end")
+
+	return output.String()
+}
+
+// generateDoExpression generates a do ... end expression as a function that
+// is called right away. A final expression statement becomes its return value
+func (g *Generator) generateDoExpression(node *ast.DoExpression) string {
+	var output strings.Builder
+	output.WriteString("(function()\n")
+
+	constEnums := g.constEnums
+	g.indent++
+	statements := node.Body.Statements
+	for i, stmt := range statements {
+		if exprStmt, ok := stmt.(*ast.ExpressionStatement); ok && i == len(statements)-1 {
+			output.WriteString(g.generateIndent())
+			output.WriteString("return " + g.generateExpression(exprStmt.Expression) + "\n")
+			continue
+		}
 		output.WriteString(g.generateStatement(stmt))
 	}
 	g.indent--
+	g.constEnums = constEnums
 
 	output.WriteString(g.generateIndent())
-	output.WriteString("end\n")
+	output.WriteString("end)()")
 
 	return output.String()
 }
@@ -138,7 +430,10 @@ func (g *Generator) generateReturnStatement(node *ast.ReturnStatement) string {
 
 	if node.ReturnValue != nil {
 		output.WriteString(" ")
-		output.WriteString(g.generateExpression(node.ReturnValue))
+		output.WriteString(g.generateExpressionAt(node.ReturnValue, output.Len()))
+	} else if node.ReturnValues != nil {
+		output.WriteString(" ")
+		output.WriteString(g.generateExpressions(node.ReturnValues, output.Len()))
 	}
 
 	output.WriteString("\n")
@@ -149,6 +444,29 @@ func (g *Generator) generateReturnStatement(node *ast.ReturnStatement) string {
 func (g *Generator) generateIfStatement(node *ast.IfStatement) string {
 	var output strings.Builder
 
+	// A literal true/false condition always takes the same branch, so emit
+	// that branch without the if wrapper
+	if boolLit, ok := node.Condition.(*ast.BooleanLiteral); ok {
+		branch := node.Consequence
+		if !boolLit.Value {
+			// The first elseif takes over as the if
+			if elseIf := promoteElseIf(node); elseIf != nil {
+				return g.generateIfStatement(elseIf)
+			}
+			branch = node.Alternative
+		}
+		if branch == nil {
+			return ""
+		}
+		if needsOwnBlock(branch.Statements) {
+			return g.generateDoStatement(&ast.DoStatement{Body: branch})
+		}
+		for _, stmt := range branch.Statements {
+			output.WriteString(g.generateStatement(stmt))
+		}
+		return output.String()
+	}
+
 	output.WriteString(g.generateIndent())
 	output.WriteString("if ")
 	output.WriteString(g.generateExpression(node.Condition))
@@ -156,20 +474,28 @@ func (g *Generator) generateIfStatement(node *ast.IfStatement) string {
 
 	// Consequence
 	g.indent++
-	for _, stmt := range node.Consequence.Statements {
-		output.WriteString(g.generateStatement(stmt))
-	}
+	output.WriteString(g.generateBody(node.Consequence.Statements))
 	g.indent--
 
+	// Elseif branches
+	for _, elseIf := range node.ElseIfs {
+		output.WriteString(g.generateIndent())
+		output.WriteString("elseif ")
+		output.WriteString(g.generateExpression(elseIf.Condition))
+		output.WriteString(" then\n")
+
+		g.indent++
+		output.WriteString(g.generateBody(elseIf.Consequence.Statements))
+		g.indent--
+	}
+
 	// Alternative (else)
 	if node.Alternative != nil {
 		output.WriteString(g.generateIndent())
 		output.WriteString("else\n")
 
 		g.indent++
-		for _, stmt := range node.Alternative.Statements {
-			output.WriteString(g.generateStatement(stmt))
-		}
+		output.WriteString(g.generateBody(node.Alternative.Statements))
 		g.indent--
 	}
 
@@ -179,6 +505,46 @@ func (g *Generator) generateIfStatement(node *ast.IfStatement) string {
 	return output.String()
 }
 
+// promoteElseIf returns the if statement left when the condition of node is
+// dropped: its first elseif becomes the if. It returns nil when node has no
+// elseif branches
+func promoteElseIf(node *ast.IfStatement) *ast.IfStatement {
+	if len(node.ElseIfs) == 0 {
+		return nil
+	}
+	first := node.ElseIfs[0]
+	return &ast.IfStatement{
+		Token:       first.Token,
+		Condition:   first.Condition,
+		Consequence: first.Consequence,
+		ElseIfs:     node.ElseIfs[1:],
+		Alternative: node.Alternative,
+	}
+}
+
+// needsOwnBlock reports whether statements must keep a block of their own
+// when an if wrapper is dropped: either they declare locals that would leak
+// into the enclosing scope, or they end in a return or break, which Lua only
+// allows as the last statement of a block
+func needsOwnBlock(statements []ast.Statement) bool {
+	if len(statements) == 0 {
+		return false
+	}
+	switch statements[len(statements)-1].(type) {
+	case *ast.ReturnStatement, *ast.BreakStatement:
+		return true
+	}
+	for _, stmt := range statements {
+		switch stmt.(type) {
+		case *ast.VariableDeclaration, *ast.DestructuringDeclaration,
+			*ast.ClassDeclaration, *ast.EnumDeclaration,
+			*ast.ImportStatement, *ast.ExportStatement:
+			return true
+		}
+	}
+	return false
+}
+
 // generateWhileStatement generates code for a while statement
 func (g *Generator) generateWhileStatement(node *ast.WhileStatement) string {
 	var output strings.Builder
@@ -188,6 +554,25 @@ func (g *Generator) generateWhileStatement(node *ast.WhileStatement) string {
 	output.WriteString(g.generateExpression(node.Condition))
 	output.WriteString(" do\n")
 
+	g.indent++
+	output.WriteString(g.generateBody(node.Body.Statements))
+	g.indent--
+
+	output.WriteString(g.generateIndent())
+	output.WriteString("end\n")
+
+	return output.String()
+}
+
+// generateRepeatStatement generates code for a repeat-until loop
+func (g *Generator) generateRepeatStatement(node *ast.RepeatStatement) string {
+	var output strings.Builder
+
+	output.WriteString(g.generateIndent())
+	output.WriteString("repeat\n")
+
+	// The condition is within the body's scope, so sees its locals
+	constEnums := g.constEnums
 	g.indent++
 	for _, stmt := range node.Body.Statements {
 		output.WriteString(g.generateStatement(stmt))
@@ -195,7 +580,10 @@ func (g *Generator) generateWhileStatement(node *ast.WhileStatement) string {
 	g.indent--
 
 	output.WriteString(g.generateIndent())
-	output.WriteString("end\n")
+	output.WriteString("until ")
+	output.WriteString(g.generateExpression(node.Condition))
+	output.WriteString("\n")
+	g.constEnums = constEnums
 
 	return output.String()
 }
@@ -227,10 +615,11 @@ func (g *Generator) generateForStatement(node *ast.ForStatement) string {
 
 	output.WriteString(" do\n")
 
+	// Closures in the body capture the loop variable as is: every Lua
+	// version from 5.1 on makes it a fresh local for each iteration, so
+	// each closure already sees its own value without a copy
 	g.indent++
-	for _, stmt := range node.Body.Statements {
-		output.WriteString(g.generateStatement(stmt))
-	}
+	output.WriteString(g.generateBody(node.Body.Statements, node.Variable.Value))
 	g.indent--
 
 	output.WriteString(g.generateIndent())
@@ -247,9 +636,7 @@ func (g *Generator) generateDoStatement(node *ast.DoStatement) string {
 	output.WriteString("do\n")
 
 	g.indent++
-	for _, stmt := range node.Body.Statements {
-		output.WriteString(g.generateStatement(stmt))
-	}
+	output.WriteString(g.generateBody(node.Body.Statements))
 	g.indent--
 
 	output.WriteString(g.generateIndent())
@@ -258,6 +645,39 @@ func (g *Generator) generateDoStatement(node *ast.DoStatement) string {
 	return output.String()
 }
 
+// generateBody generates the statements of a block. The locals it binds
+// before them, such as parameters, and those its statements declare hide
+// the const enums of the same name until the block ends
+func (g *Generator) generateBody(statements []ast.Statement, locals ...string) string {
+	constEnums := g.constEnums
+	defer func() { g.constEnums = constEnums }()
+	g.shadowConstEnums(locals...)
+
+	var output strings.Builder
+	for _, stmt := range statements {
+		output.WriteString(g.generateStatement(stmt))
+	}
+	return output.String()
+}
+
+// shadowConstEnums stops inlining the const enums that locals named names
+// now hide. The map is copied rather than changed, so the enclosing block
+// still inlines them once generateBody restores it
+func (g *Generator) shadowConstEnums(names ...string) {
+	for _, name := range names {
+		if _, ok := g.constEnums[name]; !ok {
+			continue
+		}
+		visible := make(map[string]map[string]string, len(g.constEnums))
+		for enum, members := range g.constEnums {
+			if enum != name {
+				visible[enum] = members
+			}
+		}
+		g.constEnums = visible
+	}
+}
+
 // generateBlockStatement generates code for a block statement
 func (g *Generator) generateBlockStatement(node *ast.BlockStatement) string {
 	var output strings.Builder
@@ -274,28 +694,92 @@ func (g *Generator) generateAssignmentStatement(node *ast.AssignmentStatement) s
 	var output strings.Builder
 
 	output.WriteString(g.generateIndent())
-	output.WriteString(g.generateExpression(node.Name))
+	if node.Names != nil {
+		targets := make([]string, len(node.Names))
+		for i, name := range node.Names {
+			targets[i] = g.generateAssignmentTarget(name)
+		}
+		output.WriteString(strings.Join(targets, ", "))
+		output.WriteString(" = ")
+		output.WriteString(g.generateExpressions(node.Values, lineColumn(0, output.String())))
+		output.WriteString("\n")
+		return output.String()
+	}
+	output.WriteString(g.generateAssignmentTarget(node.Name))
 	output.WriteString(" = ")
-	output.WriteString(g.generateExpression(node.Value))
+	output.WriteString(g.generateExpressionAt(node.Value, lineColumn(0, output.String())))
 	output.WriteString("\n")
 
 	return output.String()
 }
 
+// generateAssignmentTarget generates the left side of an assignment. An
+// indexed target stays t[i] with CheckedIndex set, since a call can't be
+// assigned to
+func (g *Generator) generateAssignmentTarget(target ast.Expression) string {
+	if index, ok := target.(*ast.IndexExpression); ok {
+		return g.generateIndex(index)
+	}
+	return g.generateExpression(target)
+}
+
+// generateExpressions generates a comma-separated list of expressions
+// starting at column
+func (g *Generator) generateExpressions(exprs []ast.Expression, column int) string {
+	var output strings.Builder
+	for i, expr := range exprs {
+		if i > 0 {
+			output.WriteString(", ")
+		}
+		output.WriteString(g.generateExpressionAt(expr, lineColumn(column, output.String())))
+	}
+	return output.String()
+}
+
 // generateClassDeclaration generates code for a class (transpiled to Lua table with metatable)
 func (g *Generator) generateClassDeclaration(node *ast.ClassDeclaration) string {
 	var output strings.Builder
 	className := node.Name.Value
 
+	// Track the parent class so super.method() calls inside the class
+	// body can be resolved
+	outerSuperClass := g.superClass
+	g.superClass = ""
+	if node.Extends != nil {
+		g.superClass = g.generateExpression(node.Extends)
+	}
+	defer func() { g.superClass = outerSuperClass }()
+
 	// Create class table
+	output.WriteString(g.generateClassAnnotations(node))
 	output.WriteString(g.generateIndent())
 	output.WriteString(fmt.Sprintf("local %s = {}\n", className))
+	g.shadowConstEnums(className)
 	output.WriteString(g.generateIndent())
 	output.WriteString(fmt.Sprintf("%s.__index = %s\n", className, className))
+	if g.superClass != "" {
+		// Methods the class doesn't define are looked up on the parent
+		output.WriteString(g.generateIndent())
+		output.WriteString(fmt.Sprintf("setmetatable(%s, {__index = %s})\n", className, g.superClass))
+	}
 	output.WriteString("\n")
 
+	// A subclass without a constructor is built by its parent's constructor
+	if node.Constructor == nil && g.superClass != "" {
+		output.WriteString(g.generateIndent())
+		output.WriteString(fmt.Sprintf("function %s.new(...)\n", className))
+		g.indent++
+		output.WriteString(g.generateIndent())
+		output.WriteString(fmt.Sprintf("return setmetatable(%s.new(...), %s)\n", g.superClass, className))
+		g.indent--
+		output.WriteString(g.generateIndent())
+		output.WriteString("end\n")
+		output.WriteString("\n")
+	}
+
 	// Generate constructor as new() function
 	if node.Constructor != nil {
+		output.WriteString(g.generateFunctionAnnotations(node.GenericParams, node.Constructor.Parameters, node.Name))
 		output.WriteString(g.generateIndent())
 		output.WriteString(fmt.Sprintf("function %s.new(", className))
 
@@ -310,11 +794,17 @@ func (g *Generator) generateClassDeclaration(node *ast.ClassDeclaration) string
 		output.WriteString(g.generateIndent())
 		output.WriteString("local self = setmetatable({}, " + className + ")\n")
 
-		// Initialize properties from constructor body
-		for _, stmt := range node.Constructor.Body.Statements {
-			output.WriteString(g.generateStatement(stmt))
+		// Parameter properties are assigned before the constructor body
+		for _, param := range node.Constructor.Parameters {
+			if param.IsProperty() {
+				output.WriteString(g.generateIndent())
+				output.WriteString(fmt.Sprintf("self.%s = %s\n", param.Name.Value, param.Name.Value))
+			}
 		}
 
+		// Initialize properties from constructor body
+		output.WriteString(g.generateBody(node.Constructor.Body.Statements, params...))
+
 		output.WriteString(g.generateIndent())
 		output.WriteString("return self\n")
 		g.indent--
@@ -326,6 +816,7 @@ func (g *Generator) generateClassDeclaration(node *ast.ClassDeclaration) string
 
 	// Generate methods
 	for _, method := range node.Methods {
+		output.WriteString(g.generateFunctionAnnotations(method.GenericParams, method.Parameters, method.ReturnType))
 		output.WriteString(g.generateIndent())
 		output.WriteString(fmt.Sprintf("function %s:%s(", className, method.Name.Value))
 
@@ -337,9 +828,7 @@ func (g *Generator) generateClassDeclaration(node *ast.ClassDeclaration) string
 		output.WriteString(")\n")
 
 		g.indent++
-		for _, stmt := range method.Body.Statements {
-			output.WriteString(g.generateStatement(stmt))
-		}
+		output.WriteString(g.generateBody(method.Body.Statements, params...))
 		g.indent--
 
 		output.WriteString(g.generateIndent())
@@ -350,8 +839,49 @@ func (g *Generator) generateClassDeclaration(node *ast.ClassDeclaration) string
 	return output.String()
 }
 
+// superConstructorCall returns the super(...) call a statement consists of,
+// or nil for any other statement
+func superConstructorCall(stmt ast.Statement) *ast.CallExpression {
+	exprStmt, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return nil
+	}
+	call, ok := exprStmt.Expression.(*ast.CallExpression)
+	if !ok {
+		return nil
+	}
+	if _, isSuper := call.Function.(*ast.SuperExpression); !isSuper {
+		return nil
+	}
+	return call
+}
+
+// generateSuperConstructorCall generates super(...) in a constructor. The
+// parent's constructor builds an instance of its own, whose fields are copied
+// onto self
+func (g *Generator) generateSuperConstructorCall(call *ast.CallExpression) string {
+	var output strings.Builder
+
+	output.WriteString(g.generateIndent())
+	output.WriteString(fmt.Sprintf("for key, value in pairs(%s) do\n", g.generateExpression(call)))
+	g.indent++
+	output.WriteString(g.generateIndent())
+	output.WriteString("self[key] = value\n")
+	g.indent--
+	output.WriteString(g.generateIndent())
+	output.WriteString("end\n")
+
+	return output.String()
+}
+
 // generateEnumDeclaration generates code for an enum (transpiled to Lua table)
 func (g *Generator) generateEnumDeclaration(node *ast.EnumDeclaration) string {
+	// Const enums have no runtime table; their members are inlined
+	if node.IsConst {
+		g.registerConstEnum(node)
+		return ""
+	}
+
 	var output strings.Builder
 	enumName := node.Name.Value
 
@@ -359,28 +889,60 @@ func (g *Generator) generateEnumDeclaration(node *ast.EnumDeclaration) string {
 	output.WriteString(fmt.Sprintf("local %s = {\n", enumName))
 
 	g.indent++
+	values := g.enumMemberValues(node)
 	for i, member := range node.Members {
 		output.WriteString(g.generateIndent())
 		output.WriteString(member.Name.Value)
 		output.WriteString(" = ")
-
-		if member.Value != nil {
-			output.WriteString(g.generateExpression(member.Value))
-		} else {
-			// Auto-increment starting from 0
-			output.WriteString(fmt.Sprintf("%d", i))
-		}
-
+		output.WriteString(values[i])
 		output.WriteString(",\n")
 	}
 	g.indent--
 
 	output.WriteString(g.generateIndent())
 	output.WriteString("}\n")
+	g.shadowConstEnums(enumName)
 
 	return output.String()
 }
 
+// registerConstEnum records the values of a const enum's members for inlining
+func (g *Generator) registerConstEnum(node *ast.EnumDeclaration) {
+	members := make(map[string]string)
+	values := g.enumMemberValues(node)
+	for i, member := range node.Members {
+		members[member.Name.Value] = values[i]
+	}
+	// Copied, as the map may be an enclosing block's
+	visible := make(map[string]map[string]string, len(g.constEnums)+1)
+	for enum, enumMembers := range g.constEnums {
+		visible[enum] = enumMembers
+	}
+	visible[node.Name.Value] = members
+	g.constEnums = visible
+}
+
+// enumMemberValues returns the Lua value of each member of an enum. Members
+// without a value are numbered by their position from 0, except after a
+// member with a string value, where they take their own name as a string
+// (the checker reports them, as a number there is usually a mistake)
+func (g *Generator) enumMemberValues(node *ast.EnumDeclaration) []string {
+	values := make([]string, len(node.Members))
+	afterString := false
+	for i, member := range node.Members {
+		switch {
+		case member.Value != nil:
+			values[i] = g.generateExpression(member.Value)
+			_, afterString = member.Value.(*ast.StringLiteral)
+		case afterString:
+			values[i] = fmt.Sprintf("%q", member.Name.Value)
+		default:
+			values[i] = fmt.Sprintf("%d", i)
+		}
+	}
+	return values
+}
+
 // generateExpression generates code for an expression
 func (g *Generator) generateExpression(expr ast.Expression) string {
 	if expr == nil {
@@ -391,9 +953,9 @@ func (g *Generator) generateExpression(expr ast.Expression) string {
 	case *ast.Identifier:
 		return node.Value
 	case *ast.NumberLiteral:
-		return node.Token.Literal
+		return generateNumberLiteral(node)
 	case *ast.StringLiteral:
-		return fmt.Sprintf("\"%s\"", node.Value)
+		return generateStringLiteral(node)
 	case *ast.BooleanLiteral:
 		if node.Value {
 			return "true"
@@ -411,6 +973,16 @@ func (g *Generator) generateExpression(expr ast.Expression) string {
 		return g.generateCallExpression(node)
 	case *ast.DotExpression:
 		return g.generateDotExpression(node)
+	case *ast.MethodExpression:
+		return g.generateMethodExpression(node)
+	case *ast.SuperExpression:
+		return g.superClass
+	case *ast.VarargExpression:
+		return "..."
+	case *ast.FunctionLiteral:
+		return g.generateFunctionLiteral(node)
+	case *ast.DoExpression:
+		return g.generateDoExpression(node)
 	case *ast.IndexExpression:
 		return g.generateIndexExpression(node)
 	default:
@@ -418,39 +990,248 @@ func (g *Generator) generateExpression(expr ast.Expression) string {
 	}
 }
 
+// generateNumberLiteral keeps a number as written, except binary literals,
+// which Lua doesn't support and are emitted in hexadecimal. They're parsed
+// from their digits rather than Value, which loses precision past 53 bits.
+// Digit separators are dropped, since Lua doesn't accept them either
+func generateNumberLiteral(node *ast.NumberLiteral) string {
+	literal := strings.ReplaceAll(node.Token.Literal, "_", "")
+	if len(literal) > 1 && literal[0] == '0' && (literal[1] == 'b' || literal[1] == 'B') {
+		value, _ := strconv.ParseUint(literal[2:], 2, 64)
+		return "0x" + strconv.FormatUint(value, 16)
+	}
+	return literal
+}
+
+// generateStringLiteral quotes a string for Lua. Strings holding quotes,
+// backslashes or newlines, such as those written as long bracket strings,
+// are emitted in long bracket form so their contents survive unchanged
+func generateStringLiteral(node *ast.StringLiteral) string {
+	if hasControlCharacter(node.Value) {
+		return escapeString(node.Value)
+	}
+	if !strings.ContainsAny(node.Value, "\"\\\n") {
+		return fmt.Sprintf("\"%s\"", node.Value)
+	}
+
+	// Use the fewest = signs whose closing bracket doesn't occur in the
+	// string, including right after a trailing ]
+	equals := ""
+	for strings.Contains(node.Value+"]", "]"+equals+"]") {
+		equals += "="
+	}
+
+	// Lua drops a newline directly after the opening bracket, so a leading
+	// newline needs another in front of it
+	value := node.Value
+	if strings.HasPrefix(value, "\n") {
+		value = "\n" + value
+	}
+	return fmt.Sprintf("[%s[%s]%s]", equals, value, equals)
+}
+
+// hasControlCharacter reports whether a string holds a control character
+// other than a newline or tab. Neither quoted nor long bracket strings can
+// hold those as written; long brackets turn a carriage return into a newline
+func hasControlCharacter(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < ' ' && value[i] != '\n' && value[i] != '\t' {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeString quotes a string with every special byte escaped. Decimal
+// escapes are padded to three digits so a following digit can't extend them
+func escapeString(value string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch ch := value[i]; {
+		case ch == '"' || ch == '\\':
+			out.WriteByte('\\')
+			out.WriteByte(ch)
+		case ch == '\n':
+			out.WriteString("\\n")
+		case ch < ' ':
+			out.WriteString(fmt.Sprintf("\\%03d", ch))
+		default:
+			out.WriteByte(ch)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
 // generateTableLiteral generates code for a table literal
 func (g *Generator) generateTableLiteral(node *ast.TableLiteral) string {
-	var output strings.Builder
-	output.WriteString("{")
+	open, close, items := g.tableListItems(node)
+	return g.generateList(open, close, items)
+}
 
-	// Generate array-style values
-	if len(node.Values) > 0 {
-		values := make([]string, len(node.Values))
-		for i, val := range node.Values {
-			values[i] = g.generateExpression(val)
-		}
-		output.WriteString(strings.Join(values, ", "))
+// tableListItems returns the brackets and items of a table literal: its
+// array-style values followed by its key-value pairs
+func (g *Generator) tableListItems(node *ast.TableLiteral) (string, string, []listItem) {
+	items := make([]listItem, 0, len(node.Values)+len(node.Pairs))
+	for _, val := range node.Values {
+		items = append(items, listItem{value: val})
+	}
+	for key, val := range node.Pairs {
+		items = append(items, listItem{prefix: bracketed(g.generateExpression(key)) + " = ", value: val})
+	}
+	return "{", "}", items
+}
+
+// listItem is an entry of a table literal or argument list: an optional
+// prefix, such as the "[key] = " of a key-value pair, and a value
+type listItem struct {
+	prefix string
+	value  ast.Expression // nil for an item that is only its prefix
+}
+
+// generateList places items between open and close, which starts at the
+// current column. Each item is generated once: inline when the first line
+// of the list fits within MaxLineLength, otherwise on lines of their own
+// one indentation level deeper
+func (g *Generator) generateList(open, close string, items []listItem) string {
+	column := g.column
+	if !g.listFits(open, close, items, column) {
+		return g.generateWrappedList(open, close, items)
 	}
 
-	// Generate key-value pairs
-	if len(node.Pairs) > 0 {
-		if len(node.Values) > 0 {
+	var output strings.Builder
+	output.WriteString(open)
+	for i, item := range items {
+		if i > 0 {
 			output.WriteString(", ")
 		}
+		output.WriteString(item.prefix)
+		if item.value != nil {
+			output.WriteString(g.generateExpressionAt(item.value, lineColumn(column, output.String())))
+		}
+	}
+	output.WriteString(close)
+	return output.String()
+}
+
+// generateWrappedList places each item on a line of its own
+func (g *Generator) generateWrappedList(open, close string, items []listItem) string {
+	var output strings.Builder
+	output.WriteString(open)
+	output.WriteString("\n")
 
-		pairs := []string{}
-		for key, val := range node.Pairs {
-			keyStr := g.generateExpression(key)
-			valStr := g.generateExpression(val)
-			pairs = append(pairs, fmt.Sprintf("[%s] = %s", keyStr, valStr))
+	g.indent++
+	itemIndent := g.generateIndent()
+	for i, item := range items {
+		last := i == len(items)-1
+		output.WriteString(itemIndent)
+		output.WriteString(item.prefix)
+		if item.value != nil {
+			// Leave room for the comma that follows the item
+			maxLineLength := g.options.MaxLineLength
+			if !last && maxLineLength > 0 {
+				g.options.MaxLineLength--
+			}
+			output.WriteString(g.generateExpressionAt(item.value, len(itemIndent)+len(item.prefix)))
+			g.options.MaxLineLength = maxLineLength
+		}
+		if !last {
+			output.WriteString(",")
 		}
-		output.WriteString(strings.Join(pairs, ", "))
+		output.WriteString("\n")
 	}
+	g.indent--
 
-	output.WriteString("}")
+	output.WriteString(g.generateIndent())
+	output.WriteString(close)
 	return output.String()
 }
 
+// listFits reports whether a list starting at column keeps the lines it
+// places inline within MaxLineLength: its first line, and the rest of the
+// line after any multi-line item. Other lines of a multi-line item (such as
+// a function body) are laid out on their own
+func (g *Generator) listFits(open, close string, items []listItem, column int) bool {
+	if g.options.MaxLineLength <= 0 || len(items) == 0 {
+		return true
+	}
+	widest, _, _ := g.measureInlineList(open, close, items, column)
+	return widest <= g.options.MaxLineLength
+}
+
+// measureInlineList measures a list placed inline at column. It returns the
+// column reached by the widest line the list places, the column its last
+// line ends at, and whether it spans several lines. Nested lists are
+// measured, not generated, so each item is only generated once it is placed
+func (g *Generator) measureInlineList(open, close string, items []listItem, column int) (int, int, bool) {
+	widest := 0
+	end := column + len(open)
+	multiline := false
+	for i, item := range items {
+		if i > 0 {
+			end += len(", ")
+		}
+		end += len(item.prefix)
+		if item.value == nil {
+			continue
+		}
+		itemWidest, itemEnd, itemMultiline := g.measureExpression(item.value, end)
+		if itemMultiline {
+			// Later items continue on the item's last line
+			widest = max(widest, itemWidest)
+			multiline = true
+		}
+		end = itemEnd
+	}
+	end += len(close)
+	return max(widest, end), end, multiline
+}
+
+// measureExpression measures expr generated at column, like
+// measureInlineList
+func (g *Generator) measureExpression(expr ast.Expression, column int) (int, int, bool) {
+	var open, close string
+	var items []listItem
+	switch node := expr.(type) {
+	case *ast.TableLiteral:
+		open, close, items = g.tableListItems(node)
+	case *ast.CallExpression:
+		open, close, items = g.callListItems(node)
+	default:
+		// Other expressions don't wrap, though lists nested in them are
+		// measured unwrapped
+		maxLineLength := g.options.MaxLineLength
+		g.options.MaxLineLength = 0
+		code := g.generateExpression(expr)
+		g.options.MaxLineLength = maxLineLength
+		firstLine, _, multiline := strings.Cut(code, "\n")
+		return column + len(firstLine), lineColumn(column, code), multiline
+	}
+	widest, end, multiline := g.measureInlineList(open, close, items, column)
+	if g.options.MaxLineLength > 0 && len(items) > 0 && widest > g.options.MaxLineLength {
+		// Wrapped, the list's last line is its closing bracket
+		return column + len(open), len(g.generateIndent()) + len(close), true
+	}
+	return widest, end, multiline
+}
+
+// generateExpressionAt generates expr starting at column
+func (g *Generator) generateExpressionAt(expr ast.Expression, column int) string {
+	prevColumn := g.column
+	g.column = column
+	defer func() { g.column = prevColumn }()
+	return g.generateExpression(expr)
+}
+
+// lineColumn returns the column that follows code written from column start
+func lineColumn(start int, code string) int {
+	if newline := strings.LastIndex(code, "\n"); newline >= 0 {
+		return len(code) - newline - 1
+	}
+	return start + len(code)
+}
+
 // generatePrefixExpression generates code for a prefix expression
 func (g *Generator) generatePrefixExpression(node *ast.PrefixExpression) string {
 	operator := node.Operator
@@ -500,30 +1281,120 @@ func (g *Generator) generateInfixExpression(node *ast.InfixExpression) string {
 
 // generateCallExpression generates code for a function call
 func (g *Generator) generateCallExpression(node *ast.CallExpression) string {
+	open, close, items := g.callListItems(node)
+	return g.generateList(open, close, items)
+}
+
+// callListItems returns the opening "f(", closing parenthesis and arguments
+// of a call
+func (g *Generator) callListItems(node *ast.CallExpression) (string, string, []listItem) {
 	function := g.generateExpression(node.Function)
 
-	args := make([]string, len(node.Arguments))
-	for i, arg := range node.Arguments {
-		args[i] = g.generateExpression(arg)
+	if method, ok := node.Function.(*ast.MethodExpression); ok {
+		// obj:method(args) is native Lua method call syntax
+		function = fmt.Sprintf("%s:%s", g.generateExpression(method.Left), g.generateExpression(method.Right))
+	}
+
+	// super.method(args) calls the parent's implementation on the current
+	// instance. Dispatching through self would find the override again
+	superMethod := superMethodName(node.Function)
+	if superMethod != "" {
+		function = fmt.Sprintf("%s.%s", g.superClass, superMethod)
+	}
+
+	// super(args) used as a value builds a parent instance; as a statement
+	// its fields are copied onto self by generateSuperConstructorCall
+	if _, isSuper := node.Function.(*ast.SuperExpression); isSuper {
+		function = g.superClass + ".new"
 	}
 
-	return fmt.Sprintf("%s(%s)", function, strings.Join(args, ", "))
+	items := make([]listItem, 0, len(node.Arguments)+1)
+	if superMethod != "" {
+		items = append(items, listItem{prefix: "self"})
+	}
+	for _, arg := range node.Arguments {
+		items = append(items, listItem{value: arg})
+	}
+	return function + "(", ")", items
 }
 
 // generateDotExpression generates code for a dot expression
 func (g *Generator) generateDotExpression(node *ast.DotExpression) string {
+	// Const enum members are replaced with their values
+	if enumIdent, ok := node.Left.(*ast.Identifier); ok {
+		if members, isConstEnum := g.constEnums[enumIdent.Value]; isConstEnum {
+			if memberIdent, ok := node.Right.(*ast.Identifier); ok {
+				if value, ok := members[memberIdent.Value]; ok {
+					return value
+				}
+			}
+		}
+	}
+
 	left := g.generateExpression(node.Left)
 	right := g.generateExpression(node.Right)
 
 	return fmt.Sprintf("%s.%s", left, right)
 }
 
+// superMethodName returns the method named by a super.method or
+// super:method callee, or "" for any other callee
+func superMethodName(function ast.Expression) string {
+	var left, right ast.Expression
+	switch callee := function.(type) {
+	case *ast.DotExpression:
+		left, right = callee.Left, callee.Right
+	case *ast.MethodExpression:
+		left, right = callee.Left, callee.Right
+	default:
+		return ""
+	}
+
+	if _, ok := left.(*ast.SuperExpression); !ok {
+		return ""
+	}
+	if ident, ok := right.(*ast.Identifier); ok {
+		return ident.Value
+	}
+	return ""
+}
+
+// generateMethodExpression generates code for a method reference that isn't
+// called directly. Lua has no bound-method values, so wrap the call in a
+// closure that forwards its arguments
+func (g *Generator) generateMethodExpression(node *ast.MethodExpression) string {
+	left := g.generateExpression(node.Left)
+	right := g.generateExpression(node.Right)
+
+	return fmt.Sprintf("function(...) return %s:%s(...) end", left, right)
+}
+
 // generateIndexExpression generates code for an index expression
 func (g *Generator) generateIndexExpression(node *ast.IndexExpression) string {
+	if g.options.CheckedIndex {
+		left := g.generateExpression(node.Left)
+		index := g.generateExpression(node.Index)
+		return fmt.Sprintf("__lunar_index(%s, %s)", left, index)
+	}
+	return g.generateIndex(node)
+}
+
+// generateIndex generates t[i], unchecked
+func (g *Generator) generateIndex(node *ast.IndexExpression) string {
 	left := g.generateExpression(node.Left)
 	index := g.generateExpression(node.Index)
 
-	return fmt.Sprintf("%s[%s]", left, index)
+	return left + bracketed(index)
+}
+
+// bracketed wraps an index or table key in square brackets. A key that is a
+// long bracket string gets a space on each side, since Lua would read [[[
+// as the start of a long string
+func bracketed(key string) string {
+	if strings.HasPrefix(key, "[") {
+		return "[ " + key + " ]"
+	}
+	return "[" + key + "]"
 }
 
 // generateIndent generates the current indentation
@@ -533,14 +1404,64 @@ func (g *Generator) generateIndent() string {
 
 // generateExportStatement generates code for an export statement
 func (g *Generator) generateExportStatement(node *ast.ExportStatement) string {
-	// In Lua, exports are handled via return tables at the end of modules
-	// For now, just generate the underlying statement without special export handling
-	// The exported names should be collected and returned at module end
+	if node.IsWildcard {
+		// export * from "module" -> require it now and merge it into the
+		// module's return table at the end
+		moduleVar := moduleVarName(node.Module)
+		g.reExports = append(g.reExports, moduleVar)
+		return g.generateIndent() + fmt.Sprintf("local %s = require(\"%s\")\n", moduleVar, node.Module)
+	}
+
+	// export default binds the function or class under its own name, or the
+	// internal one the parser gave it, and exports that as default
+	if node.IsDefault {
+		switch decl := node.Statement.(type) {
+		case *ast.FunctionDeclaration:
+			g.defaultExport = decl.Name.Value
+		case *ast.ClassDeclaration:
+			g.defaultExport = decl.Name.Value
+		}
+	}
+
+	// Other exports are collected into the module's return table
+	if !node.IsDefault {
+		g.exports = append(g.exports, exportedNames(node.Statement)...)
+	}
 	return g.generateStatement(node.Statement)
 }
 
+// exportedNames returns the runtime names an exported statement declares.
+// Interfaces, type aliases, ambient declarations and const enums leave
+// nothing to export at runtime
+func exportedNames(stmt ast.Statement) []string {
+	switch decl := stmt.(type) {
+	case *ast.FunctionDeclaration:
+		return []string{decl.Name.Value}
+	case *ast.VariableDeclaration:
+		return []string{decl.Name.Value}
+	case *ast.DestructuringDeclaration:
+		names := make([]string, len(decl.Fields))
+		for i, field := range decl.Fields {
+			names[i] = field.Name.Value
+		}
+		return names
+	case *ast.ClassDeclaration:
+		return []string{decl.Name.Value}
+	case *ast.EnumDeclaration:
+		if !decl.IsConst {
+			return []string{decl.Name.Value}
+		}
+	}
+	return nil
+}
+
 // generateImportStatement generates code for an import statement
 func (g *Generator) generateImportStatement(node *ast.ImportStatement) string {
+	// Type-only imports are erased; they never require the module
+	if node.IsTypeOnly {
+		return ""
+	}
+
 	var output strings.Builder
 	output.WriteString(g.generateIndent())
 
@@ -552,25 +1473,46 @@ func (g *Generator) generateImportStatement(node *ast.ImportStatement) string {
 		parts := strings.Split(moduleName, "/")
 		varName := strings.TrimSuffix(parts[len(parts)-1], ".lunar")
 		output.WriteString(fmt.Sprintf("local %s = require(\"%s\")\n", varName, moduleName))
+		g.shadowConstEnums(varName)
 	} else {
 		// import { name1, name2 } from "module"
 		// -> local _module = require("module")
 		// -> local name1 = _module.name1
 		// -> local name2 = _module.name2
-		tempVar := "_" + strings.ReplaceAll(node.Module, "/", "_")
-		tempVar = strings.ReplaceAll(tempVar, ".", "_")
+		tempVar := moduleVarName(node.Module)
 
 		output.WriteString(fmt.Sprintf("local %s = require(\"%s\")\n", tempVar, node.Module))
 
 		for _, name := range node.Names {
+			if g.isImportedConstEnum(name.Value) {
+				continue
+			}
 			output.WriteString(g.generateIndent())
 			output.WriteString(fmt.Sprintf("local %s = %s.%s\n", name.Value, tempVar, name.Value))
+			g.shadowConstEnums(name.Value)
 		}
 	}
 
 	return output.String()
 }
 
+// isImportedConstEnum reports whether name is one of the const enums the
+// module imports
+func (g *Generator) isImportedConstEnum(name string) bool {
+	for _, enum := range g.options.ConstEnums {
+		if enum.Name.Value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleVarName derives the local variable name holding a required module
+func moduleVarName(module string) string {
+	name := "_" + strings.ReplaceAll(module, "/", "_")
+	return strings.ReplaceAll(name, ".", "_")
+}
+
 // Generate is the main entry point for code generation
 // Note: Optimizations disabled by default in v1.0 (enabled in future versions)
 func Generate(statements []ast.Statement) string {
@@ -636,16 +1578,24 @@ func getOperatorPrecedence(op string) int {
 		return 2
 	case "<", ">", "<=", ">=", "~=", "!=", "==":
 		return 3
-	case "..":
+	case "|":
 		return 4
-	case "+", "-":
+	case "~":
 		return 5
-	case "*", "/", "%":
+	case "&":
 		return 6
-	case "not", "!", "unary-":
+	case "<<", ">>":
 		return 7
-	case "^":
+	case "..":
 		return 8
+	case "+", "-":
+		return 9
+	case "*", "/", "//", "%":
+		return 10
+	case "not", "!", "unary-":
+		return 11
+	case "^":
+		return 12
 	default:
 		return 0
 	}
@@ -1,6 +1,7 @@
 package codegen
 
 import (
+	"fmt"
 	"lunar/internal/ast"
 	"lunar/internal/lexer"
 	"strings"
@@ -27,6 +28,42 @@ func TestGenerateVariableDeclaration(t *testing.T) {
 	}
 }
 
+func TestGenerateConstDeclaration(t *testing.T) {
+	// const MAX = 100
+	stmt := &ast.VariableDeclaration{
+		Token:      lexer.Token{Type: lexer.CONST, Literal: "const"},
+		Name:       &ast.Identifier{Value: "MAX"},
+		Value:      &ast.NumberLiteral{Token: lexer.Token{Literal: "100"}, Value: 100},
+		IsConstant: true,
+	}
+
+	tests := []struct {
+		target   string
+		expected string
+	}{
+		{"", "local MAX = 100\n"},
+		{"5.1", "local MAX = 100 -- const\n"},
+		{"5.3", "local MAX = 100 -- const\n"},
+		{"5.4", "local MAX <const> = 100\n"},
+	}
+
+	for _, tt := range tests {
+		g := NewWithOptions(Options{Target: tt.target})
+		result := g.generateStatement(stmt)
+
+		if result != tt.expected {
+			t.Errorf("target %q: expected:\n%s\nGot:\n%s", tt.target, tt.expected, result)
+		}
+	}
+
+	// Variables that aren't const are unaffected by the target
+	stmt.IsConstant = false
+	g := NewWithOptions(Options{Target: "5.4"})
+	if result := g.generateStatement(stmt); result != "local MAX = 100\n" {
+		t.Errorf("Expected a plain local, got:\n%s", result)
+	}
+}
+
 func TestGenerateNumberExpression(t *testing.T) {
 	expr := &ast.NumberLiteral{
 		Token: lexer.Token{Literal: "42"},
@@ -42,6 +79,35 @@ func TestGenerateNumberExpression(t *testing.T) {
 	}
 }
 
+func TestGenerateBitwiseOperators(t *testing.T) {
+	ident := func(name string) ast.Expression { return &ast.Identifier{Value: name} }
+	infix := func(left ast.Expression, op string, right ast.Expression) ast.Expression {
+		return &ast.InfixExpression{Left: left, Operator: op, Right: right}
+	}
+
+	tests := []struct {
+		expr     ast.Expression
+		expected string
+	}{
+		{infix(infix(ident("a"), "&", ident("m")), "|", infix(ident("b"), "<<", ident("n"))), "a & m | b << n"},
+		{infix(infix(ident("a"), "|", ident("b")), "&", ident("c")), "(a | b) & c"},
+		{infix(ident("a"), "~", infix(ident("b"), "&", ident("c"))), "a ~ b & c"},
+		{infix(infix(ident("a"), "~", ident("b")), "&", ident("c")), "(a ~ b) & c"},
+		{infix(infix(ident("a"), "+", ident("b")), "<<", ident("n")), "a + b << n"},
+		{infix(infix(ident("a"), "<<", ident("n")), "..", ident("s")), "(a << n) .. s"},
+		{&ast.PrefixExpression{Operator: "~", Right: ident("a")}, "~ a"},
+	}
+
+	for _, tt := range tests {
+		g := New()
+		result := g.generateExpression(tt.expr)
+
+		if result != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, result)
+		}
+	}
+}
+
 func TestGenerateStringExpression(t *testing.T) {
 	expr := &ast.StringLiteral{
 		Token: lexer.Token{Literal: "hello"},
@@ -57,6 +123,55 @@ func TestGenerateStringExpression(t *testing.T) {
 	}
 }
 
+func TestGenerateLongBracketStrings(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"say \"hi\"", "[[say \"hi\"]]"},
+		{"line one\nline two", "[[line one\nline two]]"},
+		{`C:\lua`, `[[C:\lua]]`},
+		{"a]]b\"", "[=[a]]b\"]=]"},
+		{"ends with ]\"", "[[ends with ]\"]]"},
+		{"\"ends with\"]", "[=[\"ends with\"]]=]"},
+		{"\nleading newline", "[[\n\nleading newline]]"},
+	}
+
+	for _, tt := range tests {
+		expr := &ast.StringLiteral{Token: lexer.Token{Type: lexer.STRING, Literal: tt.value}, Value: tt.value}
+
+		g := New()
+		result := g.generateExpression(expr)
+
+		if result != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.value, tt.expected, result)
+		}
+	}
+}
+
+func TestGenerateEscapedStrings(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"carriage\rreturn", `"carriage\013return"`},
+		{"nul\x001", `"nul\0001"`},
+		{"bell\a \"quoted\"\n\\", `"bell\007 \"quoted\"\n\\"`},
+		{"caf\u00e9", "\"caf\u00e9\""},
+	}
+
+	for _, tt := range tests {
+		expr := &ast.StringLiteral{Token: lexer.Token{Type: lexer.STRING, Literal: tt.value}, Value: tt.value}
+
+		g := New()
+		result := g.generateExpression(expr)
+
+		if result != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.value, tt.expected, result)
+		}
+	}
+}
+
 func TestGenerateBooleanExpression(t *testing.T) {
 	tests := []struct {
 		value    bool
@@ -103,6 +218,31 @@ func TestGenerateInfixExpression(t *testing.T) {
 	}
 }
 
+func TestGenerateCStyleLogicalOperators(t *testing.T) {
+	tests := []struct {
+		operator string
+		expected string
+	}{
+		{"&&", "a and b"},
+		{"||", "a or b"},
+	}
+
+	for _, tt := range tests {
+		expr := &ast.InfixExpression{
+			Left:     &ast.Identifier{Value: "a"},
+			Operator: tt.operator,
+			Right:    &ast.Identifier{Value: "b"},
+		}
+
+		g := New()
+		result := g.generateExpression(expr)
+
+		if result != tt.expected {
+			t.Errorf("Expected: %s, Got: %s", tt.expected, result)
+		}
+	}
+}
+
 func TestGenerateCallExpression(t *testing.T) {
 	// print("hello")
 	expr := &ast.CallExpression{
@@ -140,11 +280,166 @@ func TestGenerateReturnStatement(t *testing.T) {
 	}
 }
 
+func TestGenerateMultipleAssignment(t *testing.T) {
+	// a, t.b = b, a
+	stmt := &ast.AssignmentStatement{
+		Token: lexer.Token{Type: lexer.ASSIGN, Literal: "="},
+		Names: []ast.Expression{
+			&ast.Identifier{Value: "a"},
+			&ast.DotExpression{Left: &ast.Identifier{Value: "t"}, Right: &ast.Identifier{Value: "b"}},
+		},
+		Values: []ast.Expression{
+			&ast.Identifier{Value: "b"},
+			&ast.Identifier{Value: "a"},
+		},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+	expected := "a, t.b = b, a\n"
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateMultipleReturnValues(t *testing.T) {
+	// return true, "ok"
+	stmt := &ast.ReturnStatement{
+		Token: lexer.Token{Type: lexer.RETURN, Literal: "return"},
+		ReturnValues: []ast.Expression{
+			&ast.BooleanLiteral{Value: true},
+			&ast.StringLiteral{Value: "ok"},
+		},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+	expected := "return true, \"ok\"\n"
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateVariadicFunction(t *testing.T) {
+	// function log(level: string, ...: any) print(level, ...) end
+	stmt := &ast.FunctionDeclaration{
+		Token: lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+		Name:  &ast.Identifier{Value: "log"},
+		Parameters: []*ast.Parameter{
+			{Name: &ast.Identifier{Value: "level"}, Type: &ast.Identifier{Value: "string"}},
+			{Name: &ast.Identifier{Value: "..."}, Type: &ast.Identifier{Value: "any"}, IsVariadic: true},
+		},
+		Body: &ast.BlockStatement{
+			Statements: []ast.Statement{
+				&ast.ExpressionStatement{
+					Expression: &ast.CallExpression{
+						Function: &ast.Identifier{Value: "print"},
+						Arguments: []ast.Expression{
+							&ast.Identifier{Value: "level"},
+							&ast.VarargExpression{Token: lexer.Token{Type: lexer.ELLIPSIS, Literal: "..."}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+	expected := "function log(level, ...)\n    print(level, ...)\nend\n"
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateFunctionLiteralArgument(t *testing.T) {
+	// map(items, function(x) return x * 2 end)
+	call := &ast.ExpressionStatement{
+		Expression: &ast.CallExpression{
+			Function: &ast.Identifier{Value: "map"},
+			Arguments: []ast.Expression{
+				&ast.Identifier{Value: "items"},
+				&ast.FunctionLiteral{
+					Token:      lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+					Parameters: []*ast.Parameter{{Name: &ast.Identifier{Value: "x"}}},
+					Body: &ast.BlockStatement{
+						Statements: []ast.Statement{
+							&ast.ReturnStatement{
+								Token: lexer.Token{Type: lexer.RETURN, Literal: "return"},
+								ReturnValue: &ast.InfixExpression{
+									Left:     &ast.Identifier{Value: "x"},
+									Operator: "*",
+									Right:    &ast.NumberLiteral{Token: lexer.Token{Literal: "2"}, Value: 2},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	g := New()
+	result := g.generateStatement(call)
+	expected := "map(items, function(x)\n    return x * 2\nend)\n"
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+
+	// Inside a block the body is indented relative to the enclosing line
+	g = New()
+	result = g.generateStatement(&ast.DoStatement{Body: &ast.BlockStatement{Statements: []ast.Statement{call}}})
+	expected = "do\n    map(items, function(x)\n        return x * 2\n    end)\nend\n"
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateDoExpression(t *testing.T) {
+	// local x = do local t = compute(); t + 1 end
+	stmt := &ast.VariableDeclaration{
+		Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+		Name:  &ast.Identifier{Value: "x"},
+		Value: &ast.DoExpression{
+			Token: lexer.Token{Type: lexer.DO, Literal: "do"},
+			Body: &ast.BlockStatement{
+				Statements: []ast.Statement{
+					&ast.VariableDeclaration{
+						Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+						Name:  &ast.Identifier{Value: "t"},
+						Value: &ast.CallExpression{Function: &ast.Identifier{Value: "compute"}},
+					},
+					&ast.ExpressionStatement{
+						Expression: &ast.InfixExpression{
+							Left:     &ast.Identifier{Value: "t"},
+							Operator: "+",
+							Right:    &ast.NumberLiteral{Token: lexer.Token{Literal: "1"}, Value: 1},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+	expected := "local x = (function()\n    local t = compute()\n    return t + 1\nend)()\n"
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
 func TestGenerateIfStatement(t *testing.T) {
-	// if true then return 1 end
+	// if ready then return 1 end
 	stmt := &ast.IfStatement{
 		Token: lexer.Token{Type: lexer.IF, Literal: "if"},
-		Condition: &ast.BooleanLiteral{Value: true},
+		Condition: &ast.Identifier{Value: "ready"},
 		Consequence: &ast.BlockStatement{
 			Statements: []ast.Statement{
 				&ast.ReturnStatement{
@@ -160,13 +455,158 @@ func TestGenerateIfStatement(t *testing.T) {
 
 	g := New()
 	result := g.generateStatement(stmt)
-	expected := "if true then\n    return 1\nend\n"
+	expected := "if ready then\n    return 1\nend\n"
 
 	if result != expected {
 		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
 	}
 }
 
+func TestGenerateElseIfChain(t *testing.T) {
+	assign := func(value string) *ast.BlockStatement {
+		return &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.AssignmentStatement{
+				Name:  &ast.Identifier{Value: "x"},
+				Value: &ast.NumberLiteral{Token: lexer.Token{Literal: value}},
+			},
+		}}
+	}
+	chain := func(condition ast.Expression) *ast.IfStatement {
+		// if <condition> then x = 1 elseif b then x = 2 elseif c then x = 3 else x = 4 end
+		return &ast.IfStatement{
+			Token:       lexer.Token{Type: lexer.IF, Literal: "if"},
+			Condition:   condition,
+			Consequence: assign("1"),
+			ElseIfs: []*ast.ElseIfClause{
+				{Condition: &ast.Identifier{Value: "b"}, Consequence: assign("2")},
+				{Condition: &ast.Identifier{Value: "c"}, Consequence: assign("3")},
+			},
+			Alternative: assign("4"),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		stmt     *ast.IfStatement
+		expected string
+	}{
+		{
+			"three branches",
+			chain(&ast.Identifier{Value: "a"}),
+			"if a then\n    x = 1\nelseif b then\n    x = 2\nelseif c then\n    x = 3\nelse\n    x = 4\nend\n",
+		},
+		{
+			// A false condition hands the if over to the first elseif
+			"false condition",
+			chain(&ast.BooleanLiteral{Value: false}),
+			"if b then\n    x = 2\nelseif c then\n    x = 3\nelse\n    x = 4\nend\n",
+		},
+	}
+
+	for _, tt := range tests {
+		g := New()
+		result := g.generateStatement(tt.stmt)
+
+		if result != tt.expected {
+			t.Errorf("%s: expected:\n%q\nGot:\n%q", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestGenerateConstantIfStatement(t *testing.T) {
+	returnStmt := func(lit string, value float64) ast.Statement {
+		return &ast.ReturnStatement{
+			Token: lexer.Token{Type: lexer.RETURN, Literal: "return"},
+			ReturnValue: &ast.NumberLiteral{
+				Token: lexer.Token{Literal: lit},
+				Value: value,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		stmt     *ast.IfStatement
+		expected string
+	}{
+		{
+			// if true then return 1 end
+			"true condition",
+			&ast.IfStatement{
+				Token:       lexer.Token{Type: lexer.IF, Literal: "if"},
+				Condition:   &ast.BooleanLiteral{Value: true},
+				Consequence: &ast.BlockStatement{Statements: []ast.Statement{returnStmt("1", 1)}},
+			},
+			"do\n    return 1\nend\n",
+		},
+		{
+			// if false then return 1 else return 2 end
+			"false condition with else",
+			&ast.IfStatement{
+				Token:       lexer.Token{Type: lexer.IF, Literal: "if"},
+				Condition:   &ast.BooleanLiteral{Value: false},
+				Consequence: &ast.BlockStatement{Statements: []ast.Statement{returnStmt("1", 1)}},
+				Alternative: &ast.BlockStatement{Statements: []ast.Statement{returnStmt("2", 2)}},
+			},
+			"do\n    return 2\nend\n",
+		},
+		{
+			// if false then return 1 end
+			"false condition without else",
+			&ast.IfStatement{
+				Token:       lexer.Token{Type: lexer.IF, Literal: "if"},
+				Condition:   &ast.BooleanLiteral{Value: false},
+				Consequence: &ast.BlockStatement{Statements: []ast.Statement{returnStmt("1", 1)}},
+			},
+			"",
+		},
+		{
+			// if true then local x = 2 return x end
+			"branch local and trailing return",
+			&ast.IfStatement{
+				Token:     lexer.Token{Type: lexer.IF, Literal: "if"},
+				Condition: &ast.BooleanLiteral{Value: true},
+				Consequence: &ast.BlockStatement{Statements: []ast.Statement{
+					&ast.VariableDeclaration{
+						Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+						Name:  &ast.Identifier{Value: "x"},
+						Value: &ast.NumberLiteral{Token: lexer.Token{Literal: "2"}, Value: 2},
+					},
+					&ast.ReturnStatement{
+						Token:       lexer.Token{Type: lexer.RETURN, Literal: "return"},
+						ReturnValue: &ast.Identifier{Value: "x"},
+					},
+				}},
+			},
+			"do\n    local x = 2\n    return x\nend\n",
+		},
+		{
+			// if true then x = 1 end
+			"plain statements stay inline",
+			&ast.IfStatement{
+				Token:     lexer.Token{Type: lexer.IF, Literal: "if"},
+				Condition: &ast.BooleanLiteral{Value: true},
+				Consequence: &ast.BlockStatement{Statements: []ast.Statement{
+					&ast.AssignmentStatement{
+						Name:  &ast.Identifier{Value: "x"},
+						Value: &ast.NumberLiteral{Token: lexer.Token{Literal: "1"}, Value: 1},
+					},
+				}},
+			},
+			"x = 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		g := New()
+		result := g.generateStatement(tt.stmt)
+
+		if result != tt.expected {
+			t.Errorf("%s: expected:\n%q\nGot:\n%q", tt.name, tt.expected, result)
+		}
+	}
+}
+
 func TestGenerateWhileStatement(t *testing.T) {
 	// while true do break end
 	stmt := &ast.WhileStatement{
@@ -190,6 +630,31 @@ func TestGenerateWhileStatement(t *testing.T) {
 	}
 }
 
+func TestGenerateRepeatStatement(t *testing.T) {
+	// repeat local done = true until done
+	stmt := &ast.RepeatStatement{
+		Token: lexer.Token{Type: lexer.REPEAT, Literal: "repeat"},
+		Body: &ast.BlockStatement{
+			Statements: []ast.Statement{
+				&ast.VariableDeclaration{
+					Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+					Name:  &ast.Identifier{Value: "done"},
+					Value: &ast.BooleanLiteral{Value: true},
+				},
+			},
+		},
+		Condition: &ast.Identifier{Value: "done"},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+	expected := "repeat\n    local done = true\nuntil done\n"
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
 func TestGenerateForStatement(t *testing.T) {
 	// for i = 1, 10 do break end
 	stmt := &ast.ForStatement{
@@ -216,37 +681,175 @@ func TestGenerateForStatement(t *testing.T) {
 	}
 }
 
-func TestGenerateClass(t *testing.T) {
-	// Simple class with constructor
-	stmt := &ast.ClassDeclaration{
-		Token: lexer.Token{Type: lexer.CLASS, Literal: "class"},
-		Name:  &ast.Identifier{Value: "Car"},
-		Constructor: &ast.ConstructorDeclaration{
-			Token: lexer.Token{Type: lexer.CONSTRUCTOR, Literal: "constructor"},
-			Parameters: []*ast.Parameter{
-				{Name: &ast.Identifier{Value: "brand"}},
-			},
+func TestGenerateGlobalFunctionsCallingEachOther(t *testing.T) {
+	// function isEven(n) return isOdd(n) end, and isOdd calling isEven
+	recursive := func(name, calls string) *ast.FunctionDeclaration {
+		return &ast.FunctionDeclaration{
+			Token:      lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+			Name:       &ast.Identifier{Value: name},
+			Parameters: []*ast.Parameter{{Name: &ast.Identifier{Value: "n"}}},
 			Body: &ast.BlockStatement{
 				Statements: []ast.Statement{
-					&ast.AssignmentStatement{
-						Token: lexer.Token{Type: lexer.ASSIGN, Literal: "="},
-						Name: &ast.DotExpression{
-							Left:  &ast.Identifier{Value: "self"},
-							Right: &ast.Identifier{Value: "brand"},
+					&ast.ReturnStatement{
+						Token: lexer.Token{Type: lexer.RETURN, Literal: "return"},
+						ReturnValue: &ast.CallExpression{
+							Function:  &ast.Identifier{Value: calls},
+							Arguments: []ast.Expression{&ast.Identifier{Value: "n"}},
 						},
-						Value: &ast.Identifier{Value: "brand"},
 					},
 				},
 			},
-		},
-		Methods: []*ast.FunctionDeclaration{},
+		}
 	}
+	statements := []ast.Statement{recursive("isEven", "isOdd"), recursive("isOdd", "isEven")}
 
-	g := New()
-	result := g.generateStatement(stmt)
+	// Function declarations are emitted as global functions, not local
+	// ones. Both globals are assigned when the chunk runs, before either
+	// body is called, so each finds the other and there's nothing to
+	// declare ahead of them
+	expected := "function isEven(n)\n    return isOdd(n)\nend\n\nfunction isOdd(n)\n    return isEven(n)\nend\n"
+	if result := New().Generate(statements); result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
 
-	// Check expected parts
-	expectedParts := []string{
+func TestGenerateDestructuringDeclaration(t *testing.T) {
+	// local { name, timeout = 30 } = <value>
+	fields := []*ast.DestructuringField{
+		{Name: &ast.Identifier{Value: "name"}},
+		{
+			Name:    &ast.Identifier{Value: "timeout"},
+			Default: &ast.NumberLiteral{Token: lexer.Token{Literal: "30"}, Value: 30},
+		},
+	}
+
+	tests := []struct {
+		value    ast.Expression
+		expected string
+	}{
+		{
+			// A field that is present is used; a nil one gets its default
+			&ast.Identifier{Value: "options"},
+			"local name = options.name\n" +
+				"local timeout = options.timeout\n" +
+				"if timeout == nil then\n" +
+				"    timeout = 30\n" +
+				"end\n",
+		},
+		{
+			// Any other value is evaluated only once
+			&ast.CallExpression{Function: &ast.Identifier{Value: "load"}},
+			"local name, timeout\n" +
+				"do\n" +
+				"    local _value = load()\n" +
+				"    name = _value.name\n" +
+				"    timeout = _value.timeout\n" +
+				"    if timeout == nil then\n" +
+				"        timeout = 30\n" +
+				"    end\n" +
+				"end\n",
+		},
+		{
+			// So is a variable that a field would shadow
+			&ast.Identifier{Value: "name"},
+			"local name, timeout\n" +
+				"do\n" +
+				"    local _value = name\n" +
+				"    name = _value.name\n" +
+				"    timeout = _value.timeout\n" +
+				"    if timeout == nil then\n" +
+				"        timeout = 30\n" +
+				"    end\n" +
+				"end\n",
+		},
+	}
+
+	for _, tt := range tests {
+		stmt := &ast.DestructuringDeclaration{
+			Token:  lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+			Fields: fields,
+			Value:  tt.value,
+		}
+		if result := New().generateStatement(stmt); result != tt.expected {
+			t.Errorf("Expected:\n%s\nGot:\n%s", tt.expected, result)
+		}
+	}
+}
+
+func TestGenerateForLoopClosureCapture(t *testing.T) {
+	// for i = 1, 3 do callbacks[i] = function() return i end end
+	i := &ast.Identifier{Value: "i"}
+	stmt := &ast.ForStatement{
+		Token:    lexer.Token{Type: lexer.FOR, Literal: "for"},
+		Variable: i,
+		Start:    &ast.NumberLiteral{Token: lexer.Token{Literal: "1"}, Value: 1},
+		End:      &ast.NumberLiteral{Token: lexer.Token{Literal: "3"}, Value: 3},
+		Body: &ast.BlockStatement{
+			Statements: []ast.Statement{
+				&ast.AssignmentStatement{
+					Token: lexer.Token{Type: lexer.ASSIGN, Literal: "="},
+					Name: &ast.IndexExpression{
+						Left:  &ast.Identifier{Value: "callbacks"},
+						Index: i,
+					},
+					Value: &ast.FunctionLiteral{
+						Token: lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+						Body: &ast.BlockStatement{
+							Statements: []ast.Statement{
+								&ast.ReturnStatement{
+									Token:       lexer.Token{Type: lexer.RETURN, Literal: "return"},
+									ReturnValue: i,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// Lua gives each iteration its own i on every target, so the closure
+	// captures it directly rather than through a per-iteration copy
+	expected := "for i = 1, 3 do\n    callbacks[i] = function()\n        return i\n    end\nend\n"
+	for _, target := range []string{"", "5.1", "5.4"} {
+		g := NewWithOptions(Options{Target: target})
+		if result := g.generateStatement(stmt); result != expected {
+			t.Errorf("target %q: Expected:\n%s\nGot:\n%s", target, expected, result)
+		}
+	}
+}
+
+func TestGenerateClass(t *testing.T) {
+	// Simple class with constructor
+	stmt := &ast.ClassDeclaration{
+		Token: lexer.Token{Type: lexer.CLASS, Literal: "class"},
+		Name:  &ast.Identifier{Value: "Car"},
+		Constructor: &ast.ConstructorDeclaration{
+			Token: lexer.Token{Type: lexer.CONSTRUCTOR, Literal: "constructor"},
+			Parameters: []*ast.Parameter{
+				{Name: &ast.Identifier{Value: "brand"}},
+			},
+			Body: &ast.BlockStatement{
+				Statements: []ast.Statement{
+					&ast.AssignmentStatement{
+						Token: lexer.Token{Type: lexer.ASSIGN, Literal: "="},
+						Name: &ast.DotExpression{
+							Left:  &ast.Identifier{Value: "self"},
+							Right: &ast.Identifier{Value: "brand"},
+						},
+						Value: &ast.Identifier{Value: "brand"},
+					},
+				},
+			},
+		},
+		Methods: []*ast.FunctionDeclaration{},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+
+	// Check expected parts
+	expectedParts := []string{
 		"local Car = {}",
 		"Car.__index = Car",
 		"function Car.new(brand)",
@@ -262,6 +865,77 @@ func TestGenerateClass(t *testing.T) {
 	}
 }
 
+func TestGenerateSubclass(t *testing.T) {
+	// class Dog extends Animal with constructor(name) calling super(name)
+	stmt := &ast.ClassDeclaration{
+		Token:   lexer.Token{Type: lexer.CLASS, Literal: "class"},
+		Name:    &ast.Identifier{Value: "Dog"},
+		Extends: &ast.Identifier{Value: "Animal"},
+		Constructor: &ast.ConstructorDeclaration{
+			Token: lexer.Token{Type: lexer.CONSTRUCTOR, Literal: "constructor"},
+			Parameters: []*ast.Parameter{
+				{Name: &ast.Identifier{Value: "name"}},
+			},
+			Body: &ast.BlockStatement{
+				Statements: []ast.Statement{
+					&ast.ExpressionStatement{
+						Expression: &ast.CallExpression{
+							Function:  &ast.SuperExpression{Token: lexer.Token{Type: lexer.IDENT, Literal: "super"}},
+							Arguments: []ast.Expression{&ast.Identifier{Value: "name"}},
+						},
+					},
+				},
+			},
+		},
+		Methods: []*ast.FunctionDeclaration{},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+
+	expected := `local Dog = {}
+Dog.__index = Dog
+setmetatable(Dog, {__index = Animal})
+
+function Dog.new(name)
+    local self = setmetatable({}, Dog)
+    for key, value in pairs(Animal.new(name)) do
+        self[key] = value
+    end
+    return self
+end
+
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateSubclassWithoutConstructor(t *testing.T) {
+	stmt := &ast.ClassDeclaration{
+		Token:   lexer.Token{Type: lexer.CLASS, Literal: "class"},
+		Name:    &ast.Identifier{Value: "Dog"},
+		Extends: &ast.Identifier{Value: "Animal"},
+		Methods: []*ast.FunctionDeclaration{},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+
+	expected := `local Dog = {}
+Dog.__index = Dog
+setmetatable(Dog, {__index = Animal})
+
+function Dog.new(...)
+    return setmetatable(Animal.new(...), Dog)
+end
+
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
 func TestGenerateEnum(t *testing.T) {
 	// enum Color { Red = 1, Green = 2 }
 	stmt := &ast.EnumDeclaration{
@@ -324,6 +998,149 @@ func TestGenerateEnumAutoIncrement(t *testing.T) {
 	}
 }
 
+func TestGenerateStringEnum(t *testing.T) {
+	// enum Color { Red = "red", Green = "green", Blue }
+	str := func(s string) ast.Expression {
+		return &ast.StringLiteral{Token: lexer.Token{Type: lexer.STRING, Literal: s}, Value: s}
+	}
+	stmt := &ast.EnumDeclaration{
+		Token: lexer.Token{Type: lexer.ENUM, Literal: "enum"},
+		Name:  &ast.Identifier{Value: "Color"},
+		Members: []*ast.EnumMember{
+			{Name: &ast.Identifier{Value: "Red"}, Value: str("red")},
+			{Name: &ast.Identifier{Value: "Green"}, Value: str("green")},
+			{Name: &ast.Identifier{Value: "Blue"}, Value: nil},
+		},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+
+	expected := `local Color = {
+    Red = "red",
+    Green = "green",
+    Blue = "Blue",
+}
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateConstEnum(t *testing.T) {
+	// const enum Color { Red, Green = 5 }
+	statements := []ast.Statement{
+		&ast.VariableDeclaration{
+			Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+			Name:  &ast.Identifier{Value: "c"},
+			Value: &ast.DotExpression{
+				Left:  &ast.Identifier{Value: "Color"},
+				Right: &ast.Identifier{Value: "Green"},
+			},
+		},
+		&ast.EnumDeclaration{
+			Token: lexer.Token{Type: lexer.ENUM, Literal: "enum"},
+			Name:  &ast.Identifier{Value: "Color"},
+			Members: []*ast.EnumMember{
+				{Name: &ast.Identifier{Value: "Red"}, Value: nil},
+				{Name: &ast.Identifier{Value: "Green"}, Value: &ast.NumberLiteral{Token: lexer.Token{Literal: "5"}, Value: 5}},
+			},
+			IsConst: true,
+		},
+		&ast.ExpressionStatement{
+			Expression: &ast.CallExpression{
+				Function: &ast.Identifier{Value: "print"},
+				Arguments: []ast.Expression{
+					&ast.DotExpression{
+						Left:  &ast.Identifier{Value: "Color"},
+						Right: &ast.Identifier{Value: "Red"},
+					},
+				},
+			},
+		},
+	}
+
+	g := New()
+	result := g.Generate(statements)
+
+	if strings.Contains(result, "Color") {
+		t.Errorf("Expected no runtime table or references for const enum, got:\n%s", result)
+	}
+
+	expectedParts := []string{
+		"local c = 5",
+		"print(0)",
+	}
+
+	for _, part := range expectedParts {
+		if !strings.Contains(result, part) {
+			t.Errorf("Expected output to contain:\n%s\nGot:\n%s", part, result)
+		}
+	}
+}
+
+func TestGenerateConstEnumShadowedByLocal(t *testing.T) {
+	// const enum Color { Red }
+	// function paint(Color) return Color.Red end
+	// do local Color = palette; print(Color.Red) end
+	// print(Color.Red)
+	colorRed := func() ast.Expression {
+		return &ast.DotExpression{
+			Left:  &ast.Identifier{Value: "Color"},
+			Right: &ast.Identifier{Value: "Red"},
+		}
+	}
+	printRed := func() ast.Statement {
+		return &ast.ExpressionStatement{
+			Expression: &ast.CallExpression{
+				Function:  &ast.Identifier{Value: "print"},
+				Arguments: []ast.Expression{colorRed()},
+			},
+		}
+	}
+	statements := []ast.Statement{
+		&ast.EnumDeclaration{
+			Token:   lexer.Token{Type: lexer.ENUM, Literal: "enum"},
+			Name:    &ast.Identifier{Value: "Color"},
+			Members: []*ast.EnumMember{{Name: &ast.Identifier{Value: "Red"}}},
+			IsConst: true,
+		},
+		&ast.FunctionDeclaration{
+			Name:       &ast.Identifier{Value: "paint"},
+			Parameters: []*ast.Parameter{{Name: &ast.Identifier{Value: "Color"}}},
+			Body: &ast.BlockStatement{Statements: []ast.Statement{
+				&ast.ReturnStatement{ReturnValue: colorRed()},
+			}},
+		},
+		&ast.DoStatement{Body: &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.VariableDeclaration{
+				Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+				Name:  &ast.Identifier{Value: "Color"},
+				Value: &ast.Identifier{Value: "palette"},
+			},
+			printRed(),
+		}}},
+		printRed(),
+	}
+
+	result := New().Generate(statements)
+
+	expected := `function paint(Color)
+    return Color.Red
+end
+
+do
+    local Color = palette
+    print(Color.Red)
+end
+
+print(0)
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
 func TestGenerateDotExpression(t *testing.T) {
 	// math.max
 	expr := &ast.DotExpression{
@@ -356,6 +1173,24 @@ func TestGenerateIndexExpression(t *testing.T) {
 	}
 }
 
+func TestGenerateLongBracketIndexKeys(t *testing.T) {
+	key := &ast.StringLiteral{Token: lexer.Token{Type: lexer.STRING, Literal: "say \"hi\""}, Value: "say \"hi\""}
+
+	// t["say \"hi\""]
+	index := &ast.IndexExpression{Left: &ast.Identifier{Value: "t"}, Index: key}
+	if result, expected := New().generateExpression(index), "t[ [[say \"hi\"]] ]"; result != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, result)
+	}
+
+	// { ["say \"hi\""] = 1 }
+	table := &ast.TableLiteral{Pairs: map[ast.Expression]ast.Expression{
+		key: &ast.NumberLiteral{Token: lexer.Token{Literal: "1"}, Value: 1},
+	}}
+	if result, expected := New().generateExpression(table), "{[ [[say \"hi\"]] ] = 1}"; result != expected {
+		t.Errorf("Expected: %s, Got: %s", expected, result)
+	}
+}
+
 func TestInterfaceGeneratesNoCode(t *testing.T) {
 	stmt := &ast.InterfaceDeclaration{
 		Token: lexer.Token{Type: lexer.INTERFACE, Literal: "interface"},
@@ -413,3 +1248,617 @@ func TestGenerateMultipleStatements(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateExportedFunctions(t *testing.T) {
+	// export function add() end
+	// export function sub() end
+	exported := func(name string) ast.Statement {
+		return &ast.ExportStatement{
+			Token: lexer.Token{Type: lexer.EXPORT, Literal: "export"},
+			Statement: &ast.FunctionDeclaration{
+				Token: lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+				Name:  &ast.Identifier{Value: name},
+				Body:  &ast.BlockStatement{},
+			},
+		}
+	}
+	statements := []ast.Statement{exported("add"), exported("sub")}
+
+	g := New()
+	result := g.Generate(statements)
+
+	expected := `function add()
+end
+
+function sub()
+end
+
+return {
+    add = add,
+    sub = sub,
+}
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateExportWithReExport(t *testing.T) {
+	statements := []ast.Statement{
+		&ast.ExportStatement{
+			Token:      lexer.Token{Type: lexer.EXPORT, Literal: "export"},
+			Module:     "./util",
+			IsWildcard: true,
+		},
+		&ast.ExportStatement{
+			Token: lexer.Token{Type: lexer.EXPORT, Literal: "export"},
+			Statement: &ast.VariableDeclaration{
+				Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+				Name:  &ast.Identifier{Value: "version"},
+				Value: &ast.NumberLiteral{Token: lexer.Token{Literal: "2"}, Value: 2},
+			},
+		},
+	}
+
+	g := New()
+	result := g.Generate(statements)
+
+	// The module's own export is assigned after the merge, so it wins
+	expected := `for key, value in pairs(___util) do
+    _exports[key] = value
+end
+_exports.version = version
+return _exports
+`
+	if !strings.HasSuffix(result, expected) {
+		t.Errorf("Expected output to end with:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateReExport(t *testing.T) {
+	statements := []ast.Statement{
+		&ast.ExportStatement{
+			Token:      lexer.Token{Type: lexer.EXPORT, Literal: "export"},
+			Module:     "./util",
+			IsWildcard: true,
+		},
+	}
+
+	g := New()
+	result := g.Generate(statements)
+
+	expected := `local ___util = require("./util")
+
+local _exports = {}
+for key, value in pairs(___util) do
+    _exports[key] = value
+end
+return _exports
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateDefaultExport(t *testing.T) {
+	name := &ast.Identifier{Token: lexer.Token{Type: lexer.IDENT, Literal: ast.DefaultExportName}, Value: ast.DefaultExportName}
+	statements := []ast.Statement{
+		&ast.ExportStatement{
+			Token:     lexer.Token{Type: lexer.EXPORT, Literal: "export"},
+			IsDefault: true,
+			Statement: &ast.FunctionDeclaration{
+				Token: lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+				Name:  name,
+				Parameters: []*ast.Parameter{
+					{Name: &ast.Identifier{Token: lexer.Token{Type: lexer.IDENT, Literal: "a"}, Value: "a"}},
+				},
+				Body: &ast.BlockStatement{
+					Statements: []ast.Statement{
+						&ast.ReturnStatement{
+							Token:       lexer.Token{Type: lexer.RETURN, Literal: "return"},
+							ReturnValue: &ast.Identifier{Token: lexer.Token{Type: lexer.IDENT, Literal: "a"}, Value: "a"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	g := New()
+	result := g.Generate(statements)
+
+	expected := `local function _default(a)
+    return a
+end
+
+local _exports = {}
+_exports.default = _default
+return _exports
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateDefaultExportClass(t *testing.T) {
+	statements := []ast.Statement{
+		&ast.ExportStatement{
+			Token:     lexer.Token{Type: lexer.EXPORT, Literal: "export"},
+			IsDefault: true,
+			Statement: &ast.ClassDeclaration{
+				Token: lexer.Token{Type: lexer.CLASS, Literal: "class"},
+				Name:  &ast.Identifier{Token: lexer.Token{Type: lexer.IDENT, Literal: ast.DefaultExportName}, Value: ast.DefaultExportName},
+			},
+		},
+	}
+
+	g := New()
+	result := g.Generate(statements)
+
+	for _, part := range []string{
+		"local _default = {}",
+		"_default.__index = _default",
+		"_exports.default = _default\nreturn _exports\n",
+	} {
+		if !strings.Contains(result, part) {
+			t.Errorf("Expected output to contain:\n%s\nGot:\n%s", part, result)
+		}
+	}
+}
+
+func TestGenerateMethodExpression(t *testing.T) {
+	method := &ast.MethodExpression{
+		Token: lexer.Token{Type: lexer.COLON, Literal: ":"},
+		Left:  &ast.Identifier{Token: lexer.Token{Type: lexer.IDENT, Literal: "counter"}, Value: "counter"},
+		Right: &ast.Identifier{Token: lexer.Token{Type: lexer.IDENT, Literal: "add"}, Value: "add"},
+	}
+
+	statements := []ast.Statement{
+		&ast.ExpressionStatement{
+			Expression: &ast.CallExpression{
+				Token:     lexer.Token{Type: lexer.LPAREN, Literal: "("},
+				Function:  method,
+				Arguments: []ast.Expression{&ast.NumberLiteral{Token: lexer.Token{Type: lexer.NUMBER, Literal: "1"}, Value: 1}},
+			},
+		},
+		&ast.VariableDeclaration{
+			Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+			Name:  &ast.Identifier{Token: lexer.Token{Type: lexer.IDENT, Literal: "add"}, Value: "add"},
+			Value: method,
+		},
+	}
+
+	g := New()
+	result := g.Generate(statements)
+
+	expected := `counter:add(1)
+
+local add = function(...) return counter:add(...) end
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateSuperMethodCall(t *testing.T) {
+	// class Dog extends Animal with speak() calling super.speak()
+	stmt := &ast.ClassDeclaration{
+		Token:   lexer.Token{Type: lexer.CLASS, Literal: "class"},
+		Name:    &ast.Identifier{Value: "Dog"},
+		Extends: &ast.Identifier{Value: "Animal"},
+		Methods: []*ast.FunctionDeclaration{
+			{
+				Token: lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+				Name:  &ast.Identifier{Value: "speak"},
+				Body: &ast.BlockStatement{
+					Statements: []ast.Statement{
+						&ast.ExpressionStatement{
+							Expression: &ast.CallExpression{
+								Function: &ast.DotExpression{
+									Left:  &ast.SuperExpression{Token: lexer.Token{Type: lexer.IDENT, Literal: "super"}},
+									Right: &ast.Identifier{Value: "speak"},
+								},
+								Arguments: []ast.Expression{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+
+	expectedParts := []string{
+		"function Dog:speak()",
+		"Animal.speak(self)",
+	}
+
+	for _, part := range expectedParts {
+		if !strings.Contains(result, part) {
+			t.Errorf("Expected output to contain:\n%s\nGot:\n%s", part, result)
+		}
+	}
+}
+
+func TestGenerateSuperMethodCallWithArguments(t *testing.T) {
+	// super:greet(name) inside class Dog extends Animal
+	stmt := &ast.ClassDeclaration{
+		Token:   lexer.Token{Type: lexer.CLASS, Literal: "class"},
+		Name:    &ast.Identifier{Value: "Dog"},
+		Extends: &ast.Identifier{Value: "Animal"},
+		Methods: []*ast.FunctionDeclaration{
+			{
+				Token:      lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+				Name:       &ast.Identifier{Value: "greet"},
+				Parameters: []*ast.Parameter{{Name: &ast.Identifier{Value: "name"}}},
+				Body: &ast.BlockStatement{
+					Statements: []ast.Statement{
+						&ast.ReturnStatement{
+							Token: lexer.Token{Type: lexer.RETURN, Literal: "return"},
+							ReturnValue: &ast.CallExpression{
+								Function: &ast.MethodExpression{
+									Left:  &ast.SuperExpression{Token: lexer.Token{Type: lexer.IDENT, Literal: "super"}},
+									Right: &ast.Identifier{Value: "greet"},
+								},
+								Arguments: []ast.Expression{&ast.Identifier{Value: "name"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+
+	if !strings.Contains(result, "return Animal.greet(self, name)") {
+		t.Errorf("Expected the parent method to be called on self, got:\n%s", result)
+	}
+}
+
+func TestGenerateSuperInConstructor(t *testing.T) {
+	// constructor(name) that calls super(name) inside an if
+	superCall := func(arg string) *ast.BlockStatement {
+		return &ast.BlockStatement{
+			Statements: []ast.Statement{
+				&ast.ExpressionStatement{
+					Expression: &ast.CallExpression{
+						Function:  &ast.SuperExpression{Token: lexer.Token{Type: lexer.IDENT, Literal: "super"}},
+						Arguments: []ast.Expression{&ast.Identifier{Value: arg}},
+					},
+				},
+			},
+		}
+	}
+	stmt := &ast.ClassDeclaration{
+		Token:   lexer.Token{Type: lexer.CLASS, Literal: "class"},
+		Name:    &ast.Identifier{Value: "Dog"},
+		Extends: &ast.Identifier{Value: "Animal"},
+		Constructor: &ast.ConstructorDeclaration{
+			Token:      lexer.Token{Type: lexer.CONSTRUCTOR, Literal: "constructor"},
+			Parameters: []*ast.Parameter{{Name: &ast.Identifier{Value: "name"}}},
+			Body: &ast.BlockStatement{
+				Statements: []ast.Statement{
+					&ast.IfStatement{
+						Token:       lexer.Token{Type: lexer.IF, Literal: "if"},
+						Condition:   &ast.Identifier{Value: "name"},
+						Consequence: superCall("name"),
+						Alternative: superCall("default"),
+					},
+				},
+			},
+		},
+		Methods: []*ast.FunctionDeclaration{},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+
+	expectedParts := []string{
+		"        for key, value in pairs(Animal.new(name)) do\n            self[key] = value\n        end\n",
+		"        for key, value in pairs(Animal.new(default)) do\n",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(result, part) {
+			t.Errorf("Expected output to contain:\n%s\nGot:\n%s", part, result)
+		}
+	}
+	if strings.Contains(result, "Animal(") {
+		t.Errorf("Expected super(...) not to call the class table, got:\n%s", result)
+	}
+}
+
+func TestTypeOnlyImportGeneratesNoCode(t *testing.T) {
+	stmt := &ast.ImportStatement{
+		Token:      lexer.Token{Type: lexer.IMPORT, Literal: "import"},
+		Names:      []*ast.Identifier{{Value: "Point"}},
+		Module:     "./shapes",
+		IsTypeOnly: true,
+	}
+
+	g := New()
+	result := g.Generate([]ast.Statement{stmt})
+
+	if strings.Contains(result, "require") {
+		t.Errorf("Expected no require for a type-only import, got:\n%s", result)
+	}
+	if result != "" {
+		t.Errorf("Expected no output, got:\n%s", result)
+	}
+}
+
+func TestGenerateWrapsLongTableLiteral(t *testing.T) {
+	str := func(s string) ast.Expression {
+		return &ast.StringLiteral{Token: lexer.Token{Type: lexer.STRING, Literal: s}, Value: s}
+	}
+	table := func(values ...ast.Expression) *ast.ExpressionStatement {
+		return &ast.ExpressionStatement{
+			Expression: &ast.CallExpression{
+				Function:  &ast.Identifier{Value: "print"},
+				Arguments: []ast.Expression{&ast.TableLiteral{Values: values}},
+			},
+		}
+	}
+
+	g := NewWithOptions(Options{MaxLineLength: 30})
+
+	short := g.generateStatement(table(str("a"), str("b")))
+	if short != "print({\"a\", \"b\"})\n" {
+		t.Errorf("Expected short table to stay inline, got:\n%s", short)
+	}
+
+	long := g.generateStatement(table(str("alpha"), str("bravo"), str("charlie"), str("delta")))
+	expected := `print({
+    "alpha",
+    "bravo",
+    "charlie",
+    "delta"
+})
+`
+	if long != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, long)
+	}
+}
+
+func TestGenerateWrapsTableAfterLongPrefix(t *testing.T) {
+	str := func(s string) ast.Expression {
+		return &ast.StringLiteral{Token: lexer.Token{Type: lexer.STRING, Literal: s}, Value: s}
+	}
+	// The table alone fits in 40 columns, but not after the declaration
+	stmt := &ast.VariableDeclaration{
+		Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+		Name:  &ast.Identifier{Value: "aVeryLongVariableNameForTesting"},
+		Value: &ast.TableLiteral{Values: []ast.Expression{str("alpha"), str("bravo"), str("charlie")}},
+	}
+
+	g := NewWithOptions(Options{MaxLineLength: 40})
+	result := g.generateStatement(stmt)
+	expected := `local aVeryLongVariableNameForTesting = {
+    "alpha",
+    "bravo",
+    "charlie"
+}
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateWrapsDeeplyNestedTables(t *testing.T) {
+	// Each level holds a number and the next level; generating the items
+	// of every level twice would take 2^depth steps
+	var table ast.Expression = &ast.NumberLiteral{Token: lexer.Token{Literal: "0"}, Value: 0}
+	for i := 0; i < 40; i++ {
+		table = &ast.TableLiteral{Values: []ast.Expression{
+			&ast.NumberLiteral{Token: lexer.Token{Literal: "1"}, Value: 1},
+			table,
+		}}
+	}
+
+	g := NewWithOptions(Options{MaxLineLength: 20})
+	result := g.generateStatement(&ast.ExpressionStatement{Expression: table})
+	for _, line := range strings.Split(strings.TrimSuffix(result, "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			t.Errorf("Expected no blank lines, got:\n%s", result)
+			break
+		}
+	}
+	if strings.Count(result, "{") != 40 || strings.Count(result, "}") != 40 {
+		t.Errorf("Expected 40 nested tables, got:\n%s", result)
+	}
+}
+
+func TestGenerateWrapsListAfterNestedList(t *testing.T) {
+	num := func(s string) ast.Expression {
+		return &ast.NumberLiteral{Token: lexer.Token{Type: lexer.NUMBER, Literal: s}}
+	}
+	// The nested table wraps, and the items after it don't fit on the line
+	// it ends
+	inner := &ast.TableLiteral{Values: []ast.Expression{num("1111111111"), num("2222222222"), num("3333333333")}}
+	outer := &ast.TableLiteral{Values: []ast.Expression{
+		inner, num("4444444444"), num("5555555555"), num("6666666666"), num("7777777777"), num("8888888888"),
+	}}
+	stmt := &ast.VariableDeclaration{
+		Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+		Name:  &ast.Identifier{Value: "values"},
+		Value: outer,
+	}
+
+	g := NewWithOptions(Options{MaxLineLength: 40})
+	result := g.generateStatement(stmt)
+	expected := `local values = {
+    {
+        1111111111,
+        2222222222,
+        3333333333
+    },
+    4444444444,
+    5555555555,
+    6666666666,
+    7777777777,
+    8888888888
+}
+`
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+	for _, line := range strings.Split(result, "\n") {
+		if len(line) > 40 {
+			t.Errorf("Expected lines within 40 columns, got %d: %s", len(line), line)
+		}
+	}
+}
+
+func TestGenerateConstructorParameterProperties(t *testing.T) {
+	// constructor(private balance: number, note: string)
+	stmt := &ast.ClassDeclaration{
+		Token: lexer.Token{Type: lexer.CLASS, Literal: "class"},
+		Name:  &ast.Identifier{Value: "Account"},
+		Constructor: &ast.ConstructorDeclaration{
+			Token: lexer.Token{Type: lexer.CONSTRUCTOR, Literal: "constructor"},
+			Parameters: []*ast.Parameter{
+				{Name: &ast.Identifier{Value: "balance"}, Visibility: "private"},
+				{Name: &ast.Identifier{Value: "note"}},
+			},
+			Body: &ast.BlockStatement{},
+		},
+	}
+
+	g := New()
+	result := g.generateStatement(stmt)
+
+	expected := `function Account.new(balance, note)
+    local self = setmetatable({}, Account)
+    self.balance = balance
+    return self
+end`
+	if !strings.Contains(result, expected) {
+		t.Errorf("Expected output to contain:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateEnumComparison(t *testing.T) {
+	// enum Status { Idle, Active = "active" } compared in an if condition
+	program := func(isConst bool) []ast.Statement {
+		return []ast.Statement{
+			&ast.EnumDeclaration{
+				Token: lexer.Token{Type: lexer.ENUM, Literal: "enum"},
+				Name:  &ast.Identifier{Value: "Status"},
+				Members: []*ast.EnumMember{
+					{Name: &ast.Identifier{Value: "Idle"}},
+					{Name: &ast.Identifier{Value: "Active"}, Value: &ast.StringLiteral{Value: "active"}},
+				},
+				IsConst: isConst,
+			},
+			&ast.IfStatement{
+				Token: lexer.Token{Type: lexer.IF, Literal: "if"},
+				Condition: &ast.InfixExpression{
+					Left:     &ast.Identifier{Value: "status"},
+					Operator: "==",
+					Right: &ast.DotExpression{
+						Left:  &ast.Identifier{Value: "Status"},
+						Right: &ast.Identifier{Value: "Active"},
+					},
+				},
+				Consequence: &ast.BlockStatement{},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		isConst  bool
+		expected string
+	}{
+		{"runtime table", false, "if status == Status.Active then"},
+		{"const enum", true, `if status == "active" then`},
+	}
+
+	for _, tt := range tests {
+		g := New()
+		result := g.Generate(program(tt.isConst))
+
+		if !strings.Contains(result, tt.expected) {
+			t.Errorf("%s: expected output to contain:\n%s\nGot:\n%s", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestGenerateNumberLiteralForms(t *testing.T) {
+	tests := []struct {
+		literal  string
+		value    float64
+		expected string
+	}{
+		{"0xFF", 255, "0xFF"},
+		{"0b1010", 10, "0xa"},
+		{"42", 42, "42"},
+		{"1.5e-4", 1.5e-4, "1.5e-4"},
+		{"2E+2", 2e2, "2E+2"},
+		{"1_000_000", 1000000, "1000000"},
+		{"3.141_592", 3.141592, "3.141592"},
+		{"0xFF_FF", 65535, "0xFFFF"},
+		{"0b1010_1010", 170, "0xaa"},
+		{"0b" + strings.Repeat("1", 64), 1 << 64, "0xffffffffffffffff"},
+		{"0b1" + strings.Repeat("0", 62) + "1", 1 << 63, "0x8000000000000001"},
+	}
+
+	for _, tt := range tests {
+		expr := &ast.NumberLiteral{Token: lexer.Token{Type: lexer.NUMBER, Literal: tt.literal}, Value: tt.value}
+
+		g := New()
+		result := g.generateExpression(expr)
+
+		if result != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.literal, tt.expected, result)
+		}
+	}
+}
+
+func TestGenerateCheckedIndex(t *testing.T) {
+	// xs[1] = xs[2]
+	// print(xs[3])
+	index := func(i float64) *ast.IndexExpression {
+		return &ast.IndexExpression{
+			Left:  &ast.Identifier{Value: "xs"},
+			Index: &ast.NumberLiteral{Token: lexer.Token{Literal: fmt.Sprint(i)}, Value: i},
+		}
+	}
+	statements := []ast.Statement{
+		&ast.AssignmentStatement{
+			Token: lexer.Token{Type: lexer.ASSIGN, Literal: "="},
+			Name:  index(1),
+			Value: index(2),
+		},
+		&ast.ExpressionStatement{
+			Expression: &ast.CallExpression{
+				Function:  &ast.Identifier{Value: "print"},
+				Arguments: []ast.Expression{index(3)},
+			},
+		},
+	}
+
+	unchecked := New().Generate(statements)
+	if strings.Contains(unchecked, "__lunar_index") {
+		t.Errorf("Expected no checked index without the option, got:\n%s", unchecked)
+	}
+
+	result := NewWithOptions(Options{CheckedIndex: true}).Generate(statements)
+	if !strings.HasPrefix(result, "local function __lunar_index(t, i)\n") {
+		t.Errorf("Expected the helper at the top of the module, got:\n%s", result)
+	}
+	if count := strings.Count(result, "function __lunar_index"); count != 1 {
+		t.Errorf("Expected the helper to be defined once, got %d definitions", count)
+	}
+	for _, line := range []string{
+		"xs[1] = __lunar_index(xs, 2)\n",
+		"print(__lunar_index(xs, 3))\n",
+	} {
+		if !strings.Contains(result, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, result)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package codegen
+
+import (
+	"fmt"
+	"lunar/internal/ast"
+	"strings"
+)
+
+// LuaLS annotations are comments such as "---@param name string" that the
+// Lua language server reads for completion and type checking in plain Lua
+// editors. They're emitted when Options.EmitLuaLS is set
+
+// luaLSTypeNames maps built-in Lunar types that LuaLS spells differently
+var luaLSTypeNames = map[string]string{
+	"int":   "integer",
+	"float": "number",
+	"void":  "nil",
+}
+
+// luaLSType writes a Lunar type expression in LuaLS type syntax
+func luaLSType(expr ast.Expression) string {
+	switch node := expr.(type) {
+	case *ast.Identifier:
+		if name, ok := luaLSTypeNames[node.Value]; ok {
+			return name
+		}
+		return node.Value
+	case *ast.OptionalType:
+		return luaLSOperand(node.Type) + "?"
+	case *ast.ArrayType:
+		return luaLSOperand(node.ElementType) + "[]"
+	case *ast.TableType:
+		return fmt.Sprintf("table<%s, %s>", luaLSType(node.KeyType), luaLSType(node.ValueType))
+	case *ast.UnionType:
+		types := make([]string, len(node.Types))
+		for i, typ := range node.Types {
+			types[i] = luaLSType(typ)
+		}
+		return strings.Join(types, "|")
+	case *ast.IntersectionType:
+		// LuaLS has no intersection type, so a value is annotated as its
+		// first member
+		if len(node.Types) == 0 {
+			return "any"
+		}
+		return luaLSType(node.Types[0])
+	case *ast.FunctionType:
+		params := make([]string, len(node.Parameters))
+		for i, param := range node.Parameters {
+			params[i] = param.Name.Value + ": " + luaLSParameterType(param)
+		}
+		function := "fun(" + strings.Join(params, ", ") + ")"
+		if returns := luaLSReturnTypes(node.ReturnType); len(returns) > 0 {
+			function += ": " + strings.Join(returns, ", ")
+		}
+		return function
+	case *ast.GenericType:
+		// LuaLS has no bounded numbers, so number<0, 100> is just a number
+		if base, ok := node.BaseType.(*ast.Identifier); ok && (base.Value == "number" || base.Value == "int") {
+			return luaLSType(base)
+		}
+		args := make([]string, len(node.TypeArguments))
+		for i, arg := range node.TypeArguments {
+			args[i] = luaLSType(arg)
+		}
+		return fmt.Sprintf("%s<%s>", luaLSType(node.BaseType), strings.Join(args, ", "))
+	case *ast.ObjectShapeType:
+		fields := make([]string, len(node.Properties))
+		for i, prop := range node.Properties {
+			fields[i] = prop.Name.Value + ": " + luaLSPropertyType(prop)
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	case *ast.TupleType:
+		// LuaLS has no tuple type; tuples only make sense as multiple returns
+		return "table"
+	case *ast.StringLiteral:
+		return fmt.Sprintf("%q", node.Value)
+	case *ast.NumberLiteral:
+		return generateNumberLiteral(node)
+	}
+	return "any"
+}
+
+// luaLSOperand writes a type that a suffix such as "[]" or "?" applies to,
+// parenthesizing unions and function types
+func luaLSOperand(expr ast.Expression) string {
+	switch expr.(type) {
+	case *ast.UnionType, *ast.FunctionType:
+		return "(" + luaLSType(expr) + ")"
+	}
+	return luaLSType(expr)
+}
+
+// luaLSParameterType is the LuaLS type of a parameter, any when untyped
+func luaLSParameterType(param *ast.Parameter) string {
+	if param.Type == nil {
+		return "any"
+	}
+	return luaLSType(param.Type)
+}
+
+// luaLSPropertyType is the LuaLS type of a property, marked optional with
+// "?" when it is declared name?: Type
+func luaLSPropertyType(prop *ast.PropertyDeclaration) string {
+	if prop.Optional {
+		return luaLSOperand(prop.Type) + "?"
+	}
+	return luaLSType(prop.Type)
+}
+
+// luaLSReturnTypes lists the LuaLS types a function returns: one per tuple
+// element, none for void or a missing return type
+func luaLSReturnTypes(returnType ast.Expression) []string {
+	switch node := returnType.(type) {
+	case nil:
+		return nil
+	case *ast.Identifier:
+		if node.Value == "void" {
+			return nil
+		}
+	case *ast.TupleType:
+		types := make([]string, len(node.Types))
+		for i, typ := range node.Types {
+			types[i] = luaLSType(typ)
+		}
+		return types
+	}
+	return []string{luaLSType(returnType)}
+}
+
+// generateFunctionAnnotations generates the ---@generic, ---@param and
+// ---@return lines for a function, or nothing unless LuaLS output is on
+func (g *Generator) generateFunctionAnnotations(generics []*ast.GenericParameter, params []*ast.Parameter, returnType ast.Expression) string {
+	if !g.options.EmitLuaLS {
+		return ""
+	}
+
+	var output strings.Builder
+	if len(generics) > 0 {
+		names := make([]string, len(generics))
+		for i, generic := range generics {
+			names[i] = generic.Name.Value
+			if generic.Constraint != nil {
+				names[i] += " : " + luaLSType(generic.Constraint)
+			}
+		}
+		output.WriteString(g.generateIndent())
+		output.WriteString("---@generic " + strings.Join(names, ", ") + "\n")
+	}
+	for _, param := range params {
+		output.WriteString(g.generateIndent())
+		output.WriteString(fmt.Sprintf("---@param %s %s\n", param.Name.Value, luaLSParameterType(param)))
+	}
+	for _, typ := range luaLSReturnTypes(returnType) {
+		output.WriteString(g.generateIndent())
+		output.WriteString("---@return " + typ + "\n")
+	}
+	return output.String()
+}
+
+// generateClassAnnotations generates the ---@class line and a ---@field line
+// per declared property of a class, or nothing unless LuaLS output is on
+func (g *Generator) generateClassAnnotations(node *ast.ClassDeclaration) string {
+	if !g.options.EmitLuaLS {
+		return ""
+	}
+
+	var output strings.Builder
+	output.WriteString(g.generateIndent())
+	output.WriteString("---@class " + node.Name.Value)
+	if node.Extends != nil {
+		output.WriteString(" : " + luaLSType(node.Extends))
+	}
+	output.WriteString("\n")
+
+	for _, prop := range node.Properties {
+		output.WriteString(g.generateIndent())
+		output.WriteString(fmt.Sprintf("---@field %s %s\n", prop.Name.Value, luaLSPropertyType(prop)))
+	}
+	if node.Constructor != nil {
+		for _, param := range node.Constructor.Parameters {
+			if param.IsProperty() {
+				output.WriteString(g.generateIndent())
+				output.WriteString(fmt.Sprintf("---@field %s %s\n", param.Name.Value, luaLSParameterType(param)))
+			}
+		}
+	}
+	return output.String()
+}
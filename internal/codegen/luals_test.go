@@ -0,0 +1,109 @@
+package codegen
+
+import (
+	"lunar/internal/ast"
+	"lunar/internal/lexer"
+	"testing"
+)
+
+func TestGenerateLuaLSFunctionAnnotations(t *testing.T) {
+	ident := func(name string) *ast.Identifier { return &ast.Identifier{Value: name} }
+	param := func(name string, typ ast.Expression) *ast.Parameter {
+		return &ast.Parameter{Name: ident(name), Type: typ}
+	}
+
+	// function find(items: string[], key: string | nil, limit: int, fallback): (number, boolean)
+	stmt := &ast.FunctionDeclaration{
+		Token: lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+		Name:  ident("find"),
+		Parameters: []*ast.Parameter{
+			param("items", &ast.ArrayType{ElementType: ident("string")}),
+			param("key", &ast.UnionType{Types: []ast.Expression{ident("string"), ident("nil")}}),
+			param("limit", ident("int")),
+			param("fallback", nil),
+		},
+		ReturnType: &ast.TupleType{Types: []ast.Expression{ident("number"), ident("boolean")}},
+		Body:       &ast.BlockStatement{},
+	}
+
+	g := NewWithOptions(Options{EmitLuaLS: true})
+	result := g.generateStatement(stmt)
+	expected := `---@param items string[]
+---@param key string|nil
+---@param limit integer
+---@param fallback any
+---@return number
+---@return boolean
+function find(items, key, limit, fallback)
+end
+`
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+
+	// Without the option no annotations are emitted
+	result = New().generateStatement(stmt)
+	if expected := "function find(items, key, limit, fallback)\nend\n"; result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateLuaLSGenericFunctionAnnotations(t *testing.T) {
+	ident := func(name string) *ast.Identifier { return &ast.Identifier{Value: name} }
+
+	// function apply<T, K extends string>(value: T, key: K, f: (x: T) => T): void
+	stmt := &ast.FunctionDeclaration{
+		Token: lexer.Token{Type: lexer.FUNCTION, Literal: "function"},
+		Name:  ident("apply"),
+		GenericParams: []*ast.GenericParameter{
+			{Name: ident("T")},
+			{Name: ident("K"), Constraint: ident("string")},
+		},
+		Parameters: []*ast.Parameter{
+			{Name: ident("value"), Type: ident("T")},
+			{Name: ident("key"), Type: ident("K")},
+			{Name: ident("f"), Type: &ast.FunctionType{
+				Parameters: []*ast.Parameter{{Name: ident("x"), Type: ident("T")}},
+				ReturnType: ident("T"),
+			}},
+		},
+		ReturnType: ident("void"),
+		Body:       &ast.BlockStatement{},
+	}
+
+	g := NewWithOptions(Options{EmitLuaLS: true})
+	result := g.generateStatement(stmt)
+	expected := `---@generic T, K : string
+---@param value T
+---@param key K
+---@param f fun(x: T): T
+function apply(value, key, f)
+end
+`
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateLuaLSTypeAnnotation(t *testing.T) {
+	// local scores: table<string, number?> = {}
+	stmt := &ast.VariableDeclaration{
+		Token: lexer.Token{Type: lexer.LOCAL, Literal: "local"},
+		Name:  &ast.Identifier{Value: "scores"},
+		Type: &ast.TableType{
+			KeyType:   &ast.Identifier{Value: "string"},
+			ValueType: &ast.OptionalType{Type: &ast.Identifier{Value: "number"}},
+		},
+		Value: &ast.TableLiteral{},
+	}
+
+	g := NewWithOptions(Options{EmitLuaLS: true})
+	result := g.generateStatement(stmt)
+	expected := "---@type table<string, number?>\nlocal scores = {}\n"
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
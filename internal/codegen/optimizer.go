@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"lunar/internal/ast"
 	"lunar/internal/lexer"
+	"math"
 	"strconv"
 )
 
@@ -50,6 +51,9 @@ func (o *Optimizer) optimizeStatement(stmt ast.Statement) ast.Statement {
 		if node.ReturnValue != nil {
 			node.ReturnValue = o.optimizeExpression(node.ReturnValue)
 		}
+		for i, value := range node.ReturnValues {
+			node.ReturnValues[i] = o.optimizeExpression(value)
+		}
 		return node
 
 	case *ast.ExpressionStatement:
@@ -57,6 +61,12 @@ func (o *Optimizer) optimizeStatement(stmt ast.Statement) ast.Statement {
 		return node
 
 	case *ast.AssignmentStatement:
+		if node.Names != nil {
+			for i, value := range node.Values {
+				node.Values[i] = o.optimizeExpression(value)
+			}
+			return node
+		}
 		node.Value = o.optimizeExpression(node.Value)
 		return node
 
@@ -72,6 +82,9 @@ func (o *Optimizer) optimizeStatement(stmt ast.Statement) ast.Statement {
 					Token:      node.Token,
 					Statements: node.Consequence.Statements,
 				}
+			} else if elseIf := promoteElseIf(node); elseIf != nil {
+				// Condition is always false, the first elseif becomes the if
+				return o.optimizeStatement(elseIf)
 			} else if node.Alternative != nil {
 				// Condition is always false, replace with alternative
 				return &ast.BlockStatement{
@@ -86,6 +99,10 @@ func (o *Optimizer) optimizeStatement(stmt ast.Statement) ast.Statement {
 
 		// Optimize blocks
 		node.Consequence = o.optimizeBlock(node.Consequence)
+		for _, elseIf := range node.ElseIfs {
+			elseIf.Condition = o.optimizeExpression(elseIf.Condition)
+			elseIf.Consequence = o.optimizeBlock(elseIf.Consequence)
+		}
 		if node.Alternative != nil {
 			node.Alternative = o.optimizeBlock(node.Alternative)
 		}
@@ -96,6 +113,11 @@ func (o *Optimizer) optimizeStatement(stmt ast.Statement) ast.Statement {
 		node.Body = o.optimizeBlock(node.Body)
 		return node
 
+	case *ast.RepeatStatement:
+		node.Body = o.optimizeBlock(node.Body)
+		node.Condition = o.optimizeExpression(node.Condition)
+		return node
+
 	case *ast.ForStatement:
 		if node.Start != nil {
 			node.Start = o.optimizeExpression(node.Start)
@@ -172,11 +194,56 @@ func (o *Optimizer) optimizeExpression(expr ast.Expression) ast.Expression {
 		}
 		return node
 
+	case *ast.FunctionLiteral:
+		node.Body = o.optimizeBlock(node.Body)
+		return node
+
+	case *ast.DoExpression:
+		node.Body = o.optimizeBlock(node.Body)
+		return node
+
 	default:
 		return expr
 	}
 }
 
+// foldConcatenation joins the adjacent string literals in a chain of ..
+// operators into single literals, however the chain is nested. The chain is
+// rebuilt left to right, as the parser nests it, only when something folded
+func foldConcatenation(node *ast.InfixExpression) ast.Expression {
+	operands := concatOperands(node, nil)
+
+	folded := make([]ast.Expression, 0, len(operands))
+	for _, operand := range operands {
+		if str, ok := operand.(*ast.StringLiteral); ok && len(folded) > 0 {
+			if prev, ok := folded[len(folded)-1].(*ast.StringLiteral); ok {
+				folded[len(folded)-1] = &ast.StringLiteral{Token: prev.Token, Value: prev.Value + str.Value}
+				continue
+			}
+		}
+		folded = append(folded, operand)
+	}
+	if len(folded) == len(operands) {
+		return node
+	}
+
+	result := folded[0]
+	for _, operand := range folded[1:] {
+		result = &ast.InfixExpression{Token: node.Token, Left: result, Operator: "..", Right: operand}
+	}
+	return result
+}
+
+// concatOperands appends the operands of a chain of .. operators to operands,
+// in order
+func concatOperands(expr ast.Expression, operands []ast.Expression) []ast.Expression {
+	if infix, ok := expr.(*ast.InfixExpression); ok && infix.Operator == ".." {
+		operands = concatOperands(infix.Left, operands)
+		return concatOperands(infix.Right, operands)
+	}
+	return append(operands, expr)
+}
+
 // optimizeInfixExpression performs constant folding on infix expressions
 func (o *Optimizer) optimizeInfixExpression(node *ast.InfixExpression) ast.Expression {
 	// Optimize left and right first
@@ -192,14 +259,8 @@ func (o *Optimizer) optimizeInfixExpression(node *ast.InfixExpression) ast.Expre
 	}
 
 	// String concatenation
-	leftStr, leftIsStr := node.Left.(*ast.StringLiteral)
-	rightStr, rightIsStr := node.Right.(*ast.StringLiteral)
-
-	if leftIsStr && rightIsStr && node.Operator == ".." {
-		return &ast.StringLiteral{
-			Token: node.Token,
-			Value: leftStr.Value + rightStr.Value,
-		}
+	if node.Operator == ".." {
+		return foldConcatenation(node)
 	}
 
 	// Boolean constant folding
@@ -272,6 +333,17 @@ func (o *Optimizer) foldNumericOperation(left, right *ast.NumberLiteral, operato
 			}
 		}
 		result = leftVal / rightVal
+	case "//":
+		if rightVal == 0 {
+			// Don't fold floor division by zero
+			return &ast.InfixExpression{
+				Token:    token,
+				Left:     left,
+				Operator: operator,
+				Right:    right,
+			}
+		}
+		result = math.Floor(leftVal / rightVal)
 	case "%":
 		if rightVal == 0 {
 			// Don't fold modulo by zero
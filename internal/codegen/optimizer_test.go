@@ -0,0 +1,77 @@
+package codegen
+
+import (
+	"lunar/internal/ast"
+	"lunar/internal/lexer"
+	"testing"
+)
+
+func TestFoldFloorDivision(t *testing.T) {
+	number := func(lit string, value float64) *ast.NumberLiteral {
+		return &ast.NumberLiteral{Token: lexer.Token{Literal: lit}, Value: value}
+	}
+
+	tests := []struct {
+		left, right *ast.NumberLiteral
+		expected    string
+	}{
+		{number("7", 7), number("2", 2), "3"},
+		{number("-7", -7), number("2", 2), "-4"},
+		{number("7.5", 7.5), number("2", 2), "3"},
+		// Division by zero is left for Lua to evaluate
+		{number("7", 7), number("0", 0), "7 // 0"},
+	}
+
+	for _, tt := range tests {
+		o := NewOptimizer(true)
+		expr := o.optimizeExpression(&ast.InfixExpression{
+			Token:    lexer.Token{Type: lexer.FLOOR_DIV, Literal: "//"},
+			Left:     tt.left,
+			Operator: "//",
+			Right:    tt.right,
+		})
+
+		result := New().generateExpression(expr)
+		if result != tt.expected {
+			t.Errorf("%s // %s: expected %q, got %q", tt.left.Token.Literal, tt.right.Token.Literal, tt.expected, result)
+		}
+	}
+}
+
+func TestFoldConcatenationChains(t *testing.T) {
+	str := func(value string) ast.Expression {
+		return &ast.StringLiteral{Token: lexer.Token{Type: lexer.STRING, Literal: value}, Value: value}
+	}
+	ident := func(name string) ast.Expression { return &ast.Identifier{Value: name} }
+	concat := func(left, right ast.Expression) ast.Expression {
+		return &ast.InfixExpression{Token: lexer.Token{Type: lexer.CONCAT, Literal: ".."}, Left: left, Operator: "..", Right: right}
+	}
+
+	tests := []struct {
+		name     string
+		expr     ast.Expression
+		expected string
+	}{
+		{"left nested", concat(concat(str("a"), str("b")), str("c")), `"abc"`},
+		{"right nested", concat(str("a"), concat(str("b"), str("c"))), `"abc"`},
+		{
+			"non-constant in the middle",
+			concat(concat(concat(concat(str("a"), str("b")), ident("name")), str("c")), str("d")),
+			`"ab" .. name .. "cd"`,
+		},
+		{
+			"mixed nesting",
+			concat(concat(str("a"), ident("x")), concat(str("b"), concat(str("c"), ident("y")))),
+			`"a" .. x .. ("bc" .. y)`,
+		},
+		{"nothing to fold", concat(concat(ident("x"), str("a")), ident("y")), `x .. "a" .. y`},
+	}
+
+	for _, tt := range tests {
+		o := NewOptimizer(true)
+		result := New().generateExpression(o.optimizeExpression(tt.expr))
+		if result != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, result)
+		}
+	}
+}
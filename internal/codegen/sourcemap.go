@@ -0,0 +1,88 @@
+package codegen
+
+import (
+	"lunar/internal/ast"
+	"lunar/internal/lexer"
+	"lunar/internal/sourcemap"
+)
+
+// GenerateWithSourceMap generates Lua like Generate, along with a source map
+// from the first generated line of each top-level statement back to where
+// the statement starts in sourceFile. The map embeds source, the text of
+// sourceFile
+func (g *Generator) GenerateWithSourceMap(statements []ast.Statement, sourceFile, source, generatedFile string) (string, *sourcemap.SourceMap) {
+	builder := sourcemap.NewBuilder(sourceFile, source, generatedFile)
+	luaCode := g.generate(statements, builder)
+	return luaCode, builder.Build()
+}
+
+// statementStart returns the token a statement starts at. Assignments and
+// expression statements record a later token, so their leftmost expression
+// is used instead
+func statementStart(stmt ast.Statement) lexer.Token {
+	switch node := stmt.(type) {
+	case *ast.ExpressionStatement:
+		if start := expressionStart(node.Expression); start.Line > 0 {
+			return start
+		}
+		return node.Token
+	case *ast.AssignmentStatement:
+		if node.Name != nil {
+			return expressionStart(node.Name)
+		}
+		if len(node.Names) > 0 {
+			return expressionStart(node.Names[0])
+		}
+		return node.Token
+	case *ast.VariableDeclaration:
+		return node.Token
+	case *ast.DestructuringDeclaration:
+		return node.Token
+	case *ast.FunctionDeclaration:
+		return node.Token
+	case *ast.ReturnStatement:
+		return node.Token
+	case *ast.IfStatement:
+		return node.Token
+	case *ast.WhileStatement:
+		return node.Token
+	case *ast.RepeatStatement:
+		return node.Token
+	case *ast.ForStatement:
+		return node.Token
+	case *ast.DoStatement:
+		return node.Token
+	case *ast.BreakStatement:
+		return node.Token
+	case *ast.ClassDeclaration:
+		return node.Token
+	case *ast.EnumDeclaration:
+		return node.Token
+	case *ast.ExportStatement:
+		return node.Token
+	case *ast.ImportStatement:
+		return node.Token
+	}
+	return lexer.Token{}
+}
+
+// expressionStart returns the token of the leftmost part of an expression
+func expressionStart(expr ast.Expression) lexer.Token {
+	switch node := expr.(type) {
+	case *ast.Identifier:
+		return node.Token
+	case *ast.CallExpression:
+		return expressionStart(node.Function)
+	case *ast.DotExpression:
+		return expressionStart(node.Left)
+	case *ast.MethodExpression:
+		return expressionStart(node.Left)
+	case *ast.IndexExpression:
+		return expressionStart(node.Left)
+	case *ast.InfixExpression:
+		return expressionStart(node.Left)
+	case *ast.SuperExpression:
+		return node.Token
+	}
+	return lexer.Token{}
+}
@@ -1,5 +1,11 @@
 package lexer
 
+import (
+	"fmt"
+	"lunar/internal/target"
+	"unicode/utf8"
+)
+
 type Lexer struct {
 	input        string
 	position     int
@@ -7,15 +13,91 @@ type Lexer struct {
 	ch           byte
 	line         int
 	column       int
+	errors       []string
+	options      Options
+
+	// tokenStart is where the token being read begins, after any whitespace
+	// and comments before it
+	tokenStart int
+}
+
+// Options configures a Lexer
+type Options struct {
+	// Target is the Lua version being compiled for ("5.1" to "5.4").
+	// Operators the target doesn't have are reported where they're read.
+	// Empty means no specific target
+	Target string
+
+	// PreserveTrivia records where each token starts and the whitespace and
+	// comments before it, so a file can be printed back exactly as written
+	PreserveTrivia bool
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input, line: 1, column: 0}
+	return NewWithOptions(input, Options{})
+}
+
+// NewWithOptions creates a lexer for input configured by opts
+func NewWithOptions(input string, opts Options) *Lexer {
+	l := &Lexer{input: input, line: 1, column: 0, options: opts}
 	l.readChar()
 
 	return l
 }
 
+// targetOperators maps the operators added in later Lua versions to the
+// version that introduced them. '|', '&' and '>>' are in infixTargetOperators
+// instead, since they also appear in union and intersection types and
+// nested type arguments
+var targetOperators = map[TokenType]string{
+	FLOOR_DIV:  "5.3",
+	TILDE:      "5.3",
+	SHIFT_LEFT: "5.3",
+}
+
+// infixTargetOperators are the operators of targetOperators the parser
+// checks, once it has read them as binary operators
+var infixTargetOperators = map[TokenType]string{
+	PIPE:        "5.3",
+	AMPERSAND:   "5.3",
+	SHIFT_RIGHT: "5.3",
+}
+
+// checkTarget reports an operator the target Lua version doesn't support,
+// at the position it starts
+func (l *Lexer) checkTarget(tok Token) {
+	l.reportTarget(tok, targetOperators)
+}
+
+// CheckInfixTarget reports a binary operator the target Lua version doesn't
+// support, for the operators the lexer can't tell from type syntax
+func (l *Lexer) CheckInfixTarget(tok Token) {
+	l.reportTarget(tok, infixTargetOperators)
+}
+
+// reportTarget reports tok if operators holds it and the target Lua version
+// is older than the one that introduced it
+func (l *Lexer) reportTarget(tok Token, operators map[TokenType]string) {
+	version, ok := operators[tok.Type]
+	if !ok || !target.IsValid(l.options.Target) || target.AtLeast(l.options.Target, version) {
+		return
+	}
+	l.errors = append(l.errors, fmt.Sprintf("operator '%s' not supported by target %s at line %d, column %d",
+		tok.Literal, l.options.Target, tok.Line, tok.Column-len(tok.Literal)+1))
+}
+
+// Errors returns the problems found in the input read so far, such as
+// invalid escape sequences
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+// addError records a problem at the current position
+func (l *Lexer) addError(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.errors = append(l.errors, fmt.Sprintf("%s at line %d, column %d", msg, l.line, l.column))
+}
+
 func (l *Lexer) readChar() {
 	if l.readPosition >= len(l.input) {
 		l.ch = 0 // ASCII code for "NUL"
@@ -34,8 +116,39 @@ func (l *Lexer) readChar() {
 }
 
 func (l *Lexer) NextToken() Token {
+	triviaStart := l.offset()
+	tok := l.nextToken()
+	if l.options.PreserveTrivia {
+		tok.Offset = l.tokenStart
+		tok.Trivia = l.input[triviaStart:l.tokenStart]
+	}
+	return tok
+}
+
+// PreservesTrivia reports whether tokens record their trivia
+func (l *Lexer) PreservesTrivia() bool {
+	return l.options.PreserveTrivia
+}
+
+// Slice returns the input between two byte offsets, such as the Offset of
+// two tokens read with PreserveTrivia
+func (l *Lexer) Slice(start, end int) string {
+	return l.input[start:end]
+}
+
+// offset is the byte offset of the current character, or the length of the
+// input once it has all been read
+func (l *Lexer) offset() int {
+	if l.position > len(l.input) {
+		return len(l.input)
+	}
+	return l.position
+}
+
+func (l *Lexer) nextToken() Token {
 	var tok Token
 	l.skipWhitespace()
+	l.tokenStart = l.offset()
 
 	tok.Line = l.line
 	tok.Column = l.column
@@ -46,7 +159,7 @@ func (l *Lexer) NextToken() Token {
 	case '-':
 		if l.peekChar() == '-' {
 			l.skipComment()
-			return l.NextToken()
+			return l.nextToken()
 		}
 		tok = newToken(MINUS, l.ch, l.line, l.column)
 	case '~':
@@ -54,7 +167,7 @@ func (l *Lexer) NextToken() Token {
 			l.readChar()
 			tok = Token{Type: NOT_EQ_LUA, Literal: "~=", Line: l.line, Column: l.column}
 		} else {
-			tok = newToken(ILLEGAL, l.ch, l.line, l.column)
+			tok = newToken(TILDE, l.ch, l.line, l.column)
 		}
 	case '!':
 		if l.peekChar() == '=' {
@@ -76,11 +189,26 @@ func (l *Lexer) NextToken() Token {
 	case '?':
 		tok = newToken(QUESTION, l.ch, l.line, l.column)
 	case '|':
-		tok = newToken(PIPE, l.ch, l.line, l.column)
+		if l.peekChar() == '|' {
+			l.readChar()
+			tok = Token{Type: LOGICAL_OR, Literal: "||", Line: l.line, Column: l.column}
+		} else {
+			tok = newToken(PIPE, l.ch, l.line, l.column)
+		}
+	case '&':
+		if l.peekChar() == '&' {
+			l.readChar()
+			tok = Token{Type: LOGICAL_AND, Literal: "&&", Line: l.line, Column: l.column}
+		} else {
+			tok = newToken(AMPERSAND, l.ch, l.line, l.column)
+		}
 	case '<':
 		if l.peekChar() == '=' {
 			l.readChar()
 			tok = Token{Type: LT_EQ, Literal: "<=", Line: l.line, Column: l.column}
+		} else if l.peekChar() == '<' {
+			l.readChar()
+			tok = Token{Type: SHIFT_LEFT, Literal: "<<", Line: l.line, Column: l.column}
 		} else {
 			tok = newToken(LT, l.ch, l.line, l.column)
 		}
@@ -88,17 +216,32 @@ func (l *Lexer) NextToken() Token {
 		if l.peekChar() == '=' {
 			l.readChar()
 			tok = Token{Type: GT_EQ, Literal: ">=", Line: l.line, Column: l.column}
+		} else if l.peekChar() == '>' {
+			// Also closes nested type arguments (Array<Array<number>>); the
+			// parser splits it there
+			l.readChar()
+			tok = Token{Type: SHIFT_RIGHT, Literal: ">>", Line: l.line, Column: l.column}
 		} else {
 			tok = newToken(GT, l.ch, l.line, l.column)
 		}
 	case '*':
 		tok = newToken(ASTERISK, l.ch, l.line, l.column)
 	case '/':
-		tok = newToken(SLASH, l.ch, l.line, l.column)
+		if l.peekChar() == '/' {
+			l.readChar()
+			tok = Token{Type: FLOOR_DIV, Literal: "//", Line: l.line, Column: l.column}
+		} else {
+			tok = newToken(SLASH, l.ch, l.line, l.column)
+		}
 	case '%':
 		tok = newToken(MODULO, l.ch, l.line, l.column)
 	case '.':
-		if l.peekChar() == '.' {
+		if l.peekChar() == '.' && l.peekCharAt(1) == '.' {
+			column := l.column
+			l.readChar()
+			l.readChar()
+			tok = Token{Type: ELLIPSIS, Literal: "...", Line: l.line, Column: column}
+		} else if l.peekChar() == '.' {
 			l.readChar()
 			tok = Token{Type: CONCAT, Literal: "..", Line: l.line, Column: l.column}
 		} else {
@@ -106,6 +249,8 @@ func (l *Lexer) NextToken() Token {
 		}
 	case ',':
 		tok = newToken(COMMA, l.ch, l.line, l.column)
+	case ';':
+		tok = newToken(SEMICOLON, l.ch, l.line, l.column)
 	case ':':
 		tok = newToken(COLON, l.ch, l.line, l.column)
 	case '(':
@@ -113,6 +258,15 @@ func (l *Lexer) NextToken() Token {
 	case ')':
 		tok = newToken(RPAREN, l.ch, l.line, l.column)
 	case '[':
+		if level, ok := l.longBracketLevel(); ok {
+			tok.Type = STRING
+			contents, terminated := l.readLongString(level)
+			if !terminated {
+				tok.Type = ILLEGAL
+			}
+			tok.Literal = contents
+			return tok
+		}
 		tok = newToken(LBRACKET, l.ch, l.line, l.column)
 	case ']':
 		tok = newToken(RBRACKET, l.ch, l.line, l.column)
@@ -141,20 +295,37 @@ func (l *Lexer) NextToken() Token {
 		}
 	}
 
+	l.checkTarget(tok)
 	l.readChar()
 	return tok
 }
 
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' || l.ch == '\n' {
-		if l.ch == '\n' {
-			l.line++
-			l.column = 0
+	for l.ch == ' ' || l.ch == '\t' || isNewline(l.ch) {
+		if isNewline(l.ch) {
+			l.newline()
 		}
 		l.readChar()
 	}
 }
 
+// isNewline reports whether ch starts a line break
+func isNewline(ch byte) bool {
+	return ch == '\n' || ch == '\r'
+}
+
+// newline counts the line break at the current character. As in Lua, a
+// "\r\n" or "\n\r" pair is a single break, so files with Windows line
+// endings keep their line numbers. The pair's first character is consumed,
+// leaving the current character on the break's last one
+func (l *Lexer) newline() {
+	if next := l.peekChar(); isNewline(next) && next != l.ch {
+		l.readChar()
+	}
+	l.line++
+	l.column = 0
+}
+
 func (l *Lexer) skipComment() {
 	l.readChar() // skip first '-'
 	l.readChar() // skip second '-'
@@ -180,9 +351,8 @@ func (l *Lexer) skipMultiLineComment() {
 			return
 		}
 
-		if l.ch == '\n' {
-			l.line++
-			l.column = 0
+		if isNewline(l.ch) {
+			l.newline()
 		}
 		l.readChar()
 	}
@@ -190,7 +360,7 @@ func (l *Lexer) skipMultiLineComment() {
 
 func (l *Lexer) skipSingleLineComment() {
 	// Skip until newline but don't consume it
-	for l.ch != '\n' && l.ch != 0 {
+	for !isNewline(l.ch) && l.ch != 0 {
 		l.readChar()
 	}
 }
@@ -204,42 +374,89 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
+// readNumber reads a number literal. Underscores between digits (1_000) are
+// read as part of it; the parser checks where they appear
 func (l *Lexer) readNumber() string {
 	position := l.position
-	for isDigit(l.ch) {
-		l.readChar()
+
+	// Hexadecimal (0xFF) and binary (0b1010) literals
+	if l.ch == '0' {
+		switch l.peekChar() {
+		case 'x', 'X':
+			l.readChar()
+			l.readChar()
+			for isHexDigit(l.ch) || l.ch == '_' {
+				l.readChar()
+			}
+			return l.input[position:l.position]
+		case 'b', 'B':
+			l.readChar()
+			l.readChar()
+			bits := 0
+			for l.ch == '0' || l.ch == '1' || l.ch == '_' {
+				// Leading zeros don't count towards the width
+				if l.ch == '1' || (l.ch == '0' && bits > 0) {
+					bits++
+				}
+				l.readChar()
+			}
+			if bits > 64 {
+				l.addError("Binary literal '%s' is longer than 64 bits", l.input[position:l.position])
+			}
+			return l.input[position:l.position]
+		}
 	}
 
+	l.readDigits()
+
 	if l.ch == '.' && isDigit(l.peekChar()) {
 		l.readChar()
-		for isDigit(l.ch) {
-			l.readChar()
+		l.readDigits()
+	}
+
+	// Exponent: 1e10, 3.14e-2, 2E+5. The e only belongs to the number when
+	// digits follow it
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		if next == '+' || next == '-' {
+			next = l.peekCharAt(1)
+		}
+		if isDigit(next) {
+			l.readChar() // consume e
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			l.readDigits()
 		}
 	}
 
 	return l.input[position:l.position]
 }
 
+// readDigits reads a run of decimal digits and digit separators
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+}
+
+// readString reads a double-quoted string, processing Lua's escape
+// sequences: \n, \t and the other single-letter escapes, \xNN, \ddd and
+// \u{XXXX}
 func (l *Lexer) readString() string {
 	var result []byte
 
 	for {
 		l.readChar()
 
+		if l.ch == 0 {
+			l.addError("Unterminated string")
+			break
+		}
+
 		if l.ch == '\\' {
 			l.readChar()
-			switch l.ch {
-			case 'n':
-				result = append(result, '\n')
-			case 't':
-				result = append(result, '\t')
-			case '"':
-				result = append(result, '"')
-			case '\\':
-				result = append(result, '\\')
-			default:
-				result = append(result, l.ch)
-			}
+			result = l.readEscape(result)
 			continue
 		}
 
@@ -248,14 +465,174 @@ func (l *Lexer) readString() string {
 			break
 		}
 
-		if l.ch != 0 {
-			result = append(result, l.ch)
+		if isNewline(l.ch) {
+			l.newline()
+			result = append(result, '\n')
+			continue
 		}
+
+		result = append(result, l.ch)
 	}
 
 	return string(result)
 }
 
+// simpleEscapes maps the single-character escapes to the bytes they stand for
+var simpleEscapes = map[byte]byte{
+	'a':  '\a',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'v':  '\v',
+	'\\': '\\',
+	'"':  '"',
+	'\'': '\'',
+}
+
+// readEscape appends the bytes of the escape sequence starting at the current
+// character, which follows a backslash. It's left on the sequence's last
+// character
+func (l *Lexer) readEscape(result []byte) []byte {
+	if b, ok := simpleEscapes[l.ch]; ok {
+		return append(result, b)
+	}
+
+	switch {
+	case isNewline(l.ch):
+		// A backslash before a line break continues the string on the
+		// next line, keeping the break
+		l.newline()
+		return append(result, '\n')
+
+	case l.ch == 'x':
+		// \xNN: exactly two hex digits
+		if !isHexDigit(l.peekChar()) || !isHexDigit(l.peekCharAt(1)) {
+			l.addError("Invalid escape sequence '\\x': expected two hex digits")
+			return result
+		}
+		l.readChar()
+		value := hexValue(l.ch) << 4
+		l.readChar()
+		return append(result, byte(value|hexValue(l.ch)))
+
+	case isDigit(l.ch):
+		// \ddd: up to three decimal digits
+		value := int(l.ch - '0')
+		for i := 0; i < 2 && isDigit(l.peekChar()); i++ {
+			l.readChar()
+			value = value*10 + int(l.ch-'0')
+		}
+		if value > 255 {
+			l.addError("Decimal escape '\\%d' is larger than 255", value)
+			return result
+		}
+		return append(result, byte(value))
+
+	case l.ch == 'u':
+		// \u{XXXX}: a code point, encoded as UTF-8
+		if l.peekChar() != '{' || !isHexDigit(l.peekCharAt(1)) {
+			l.addError("Invalid escape sequence '\\u': expected '{' and hex digits")
+			return result
+		}
+		l.readChar() // {
+		value := 0
+		for isHexDigit(l.peekChar()) {
+			l.readChar()
+			if value <= utf8.MaxRune {
+				value = value<<4 | hexValue(l.ch)
+			}
+		}
+		if l.peekChar() != '}' {
+			l.addError("Invalid escape sequence '\\u': missing '}'")
+			return result
+		}
+		l.readChar() // }
+		if !utf8.ValidRune(rune(value)) {
+			l.addError("UTF-8 escape is not a valid code point")
+			return result
+		}
+		return utf8.AppendRune(result, rune(value))
+
+	case l.ch == 0:
+		// The unterminated string is reported by readString
+		return result
+	}
+
+	l.addError("Invalid escape sequence '\\%c'", l.ch)
+	return result
+}
+
+// hexValue returns the value of a hex digit
+func hexValue(ch byte) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	default:
+		return int(ch-'A') + 10
+	}
+}
+
+// longBracketLevel reports whether the current '[' opens a long bracket
+// string ([[, [=[, [==[, ...) and how many '=' signs its delimiters use
+func (l *Lexer) longBracketLevel() (int, bool) {
+	level := 0
+	for l.peekCharAt(level) == '=' {
+		level++
+	}
+	return level, l.peekCharAt(level) == '['
+}
+
+// readLongString reads a long bracket string opened at the current '[',
+// returning its raw contents. As in Lua, escapes aren't processed, line
+// breaks read as \n and a newline directly after the opening bracket is
+// dropped. It reports false
+// when the input ends before the closing bracket
+func (l *Lexer) readLongString(level int) (string, bool) {
+	// Skip [, the = signs and the second [
+	for i := 0; i < level+2; i++ {
+		l.readChar()
+	}
+	if isNewline(l.ch) {
+		l.newline()
+		l.readChar()
+	}
+
+	var result []byte
+	for l.ch != 0 {
+		if l.ch == ']' && l.closesLongBracket(level) {
+			for i := 0; i < level+2; i++ {
+				l.readChar()
+			}
+			return string(result), true
+		}
+		if isNewline(l.ch) {
+			// Any line break, \r\n, \n\r or a lone \r, reads as \n
+			l.newline()
+			result = append(result, '\n')
+		} else {
+			result = append(result, l.ch)
+		}
+		l.readChar()
+	}
+
+	return string(result), false
+}
+
+// closesLongBracket reports whether the current ']' closes a long bracket
+// string of the given level
+func (l *Lexer) closesLongBracket(level int) bool {
+	for i := 0; i < level; i++ {
+		if l.peekCharAt(i) != '=' {
+			return false
+		}
+	}
+	return l.peekCharAt(level) == ']'
+}
+
 func (l *Lexer) peekChar() byte {
 	if l.readPosition >= len(l.input) {
 		return 0
@@ -264,6 +641,16 @@ func (l *Lexer) peekChar() byte {
 	return l.input[l.readPosition]
 }
 
+// peekCharAt returns the character offset places after the next one,
+// without consuming anything
+func (l *Lexer) peekCharAt(offset int) byte {
+	if l.readPosition+offset >= len(l.input) {
+		return 0
+	}
+
+	return l.input[l.readPosition+offset]
+}
+
 func newToken(tokenType TokenType, ch byte, line, column int) Token {
 	return Token{
 		Type:    tokenType,
@@ -280,3 +667,7 @@ func isLetter(ch byte) bool {
 func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
+
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -69,6 +70,51 @@ func TestNextToken(t *testing.T) {
 	}
 }
 
+func TestLineEndings(t *testing.T) {
+	// The same program with Unix, Windows and classic Mac line endings
+	source := "local a = 1\n" +
+		"--[[ a comment\n" +
+		"over two lines ]]\n" +
+		"local s = [[\n" +
+		"long\n" +
+		"string]]\n" +
+		"local q = \"split\\\n" +
+		"string\"\n" +
+		"-- trailing comment\n" +
+		"\n" +
+		"local z = 2"
+
+	expected := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"local", 1, 1}, {"a", 1, 7}, {"=", 1, 9}, {"1", 1, 11},
+		{"local", 4, 1}, {"s", 4, 7}, {"=", 4, 9}, {"long\nstring", 4, 11},
+		{"local", 7, 1}, {"q", 7, 7}, {"=", 7, 9}, {"split\nstring", 7, 11},
+		{"local", 11, 1}, {"z", 11, 7}, {"=", 11, 9}, {"2", 11, 11},
+		{"", 11, 12},
+	}
+
+	for name, newline := range map[string]string{"LF": "\n", "CRLF": "\r\n", "CR": "\r"} {
+		l := New(strings.ReplaceAll(source, "\n", newline))
+
+		for i, tt := range expected {
+			tok := l.NextToken()
+			if tok.Literal != tt.literal {
+				t.Fatalf("%s: tests[%d] - literal is wrong, expected=%q, got=%q", name, i, tt.literal, tok.Literal)
+			}
+			if tok.Line != tt.line || tok.Column != tt.column {
+				t.Errorf("%s: tests[%d] %q - position is wrong, expected=%d:%d, got=%d:%d",
+					name, i, tt.literal, tt.line, tt.column, tok.Line, tok.Column)
+			}
+		}
+		if len(l.Errors()) > 0 {
+			t.Errorf("%s: unexpected errors: %v", name, l.Errors())
+		}
+	}
+}
+
 func TestNumberTokens(t *testing.T) {
 	input := `42
 	3.14
@@ -109,6 +155,193 @@ func TestNumberTokens(t *testing.T) {
 	}
 }
 
+func TestHexAndBinaryNumberTokens(t *testing.T) {
+	input := `0xff
+	0X1A
+	0b101 0B11
+	0x`
+
+	tests := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{TokenType(NUMBER), "0xff", 1},
+		{TokenType(NUMBER), "0X1A", 2},
+		{TokenType(NUMBER), "0b101", 3},
+		{TokenType(NUMBER), "0B11", 3},
+		{TokenType(NUMBER), "0x", 4},
+		{TokenType(EOF), "", 4},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine {
+			t.Fatalf("tests[%d] - line is wrong, expected=%d, got=%d",
+				i, tt.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestBinaryLiteralWidth(t *testing.T) {
+	tests := []struct {
+		input  string
+		errors int
+	}{
+		{"0b" + strings.Repeat("1", 64), 0},
+		{"0b000" + strings.Repeat("1", 64), 0},
+		{"0b1111_1111_" + strings.Repeat("0", 56), 0},
+		{"0b1" + strings.Repeat("0", 64), 1},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for l.NextToken().Type != EOF {
+		}
+
+		if len(l.Errors()) != tt.errors {
+			t.Errorf("%s: expected %d errors, got %v", tt.input, tt.errors, l.Errors())
+		}
+	}
+
+	l := New("0b1" + strings.Repeat("0", 64))
+	l.NextToken()
+	if len(l.Errors()) == 1 && !strings.Contains(l.Errors()[0], "is longer than 64 bits") {
+		t.Errorf("unexpected error: %s", l.Errors()[0])
+	}
+}
+
+func TestScientificNumberTokens(t *testing.T) {
+	input := `1e3
+	1.5e-4
+	2E+2
+	3e`
+
+	tests := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{TokenType(NUMBER), "1e3", 1},
+		{TokenType(NUMBER), "1.5e-4", 2},
+		{TokenType(NUMBER), "2E+2", 3},
+		{TokenType(NUMBER), "3", 4},
+		{TokenType(IDENT), "e", 4},
+		{TokenType(EOF), "", 4},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine {
+			t.Fatalf("tests[%d] - line is wrong, expected=%d, got=%d",
+				i, tt.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLongBracketStringTokens(t *testing.T) {
+	input := "[[hello]] [==[a]]b]==] [[\nsay \"hi\"\n\\n]] [=[x]=]\nx [1]"
+
+	tests := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{TokenType(STRING), "hello", 1},
+		{TokenType(STRING), "a]]b", 1},
+		{TokenType(STRING), "say \"hi\"\n\\n", 1},
+		{TokenType(STRING), "x", 3},
+		{TokenType(IDENT), "x", 4},
+		{TokenType(LBRACKET), "[", 4},
+		{TokenType(NUMBER), "1", 4},
+		{TokenType(RBRACKET), "]", 4},
+		{TokenType(EOF), "", 4},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine {
+			t.Fatalf("tests[%d] - line is wrong, expected=%d, got=%d",
+				i, tt.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestUnterminatedLongBracketString(t *testing.T) {
+	for _, input := range []string{"[[hello", "[==[a]]b]=]", "[=[\n"} {
+		l := New(input)
+
+		if tok := l.NextToken(); tok.Type != ILLEGAL {
+			t.Errorf("%q: expected an ILLEGAL token, got %q", input, tok.Type)
+		}
+		if tok := l.NextToken(); tok.Type != EOF {
+			t.Errorf("%q: expected EOF after the unterminated string, got %q", input, tok.Type)
+		}
+	}
+}
+
+func TestDigitSeparatorTokens(t *testing.T) {
+	input := `1_000 3.141_592 0xFF_FF 0b1_0 1__0`
+
+	expected := []string{"1_000", "3.141_592", "0xFF_FF", "0b1_0", "1__0"}
+
+	l := New(input)
+
+	for i, literal := range expected {
+		tok := l.NextToken()
+
+		if tok.Type != NUMBER {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, NUMBER, tok.Type)
+		}
+		if tok.Literal != literal {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, literal, tok.Literal)
+		}
+	}
+
+	if tok := l.NextToken(); tok.Type != EOF {
+		t.Fatalf("expected EOF, got %q", tok.Type)
+	}
+}
+
 func TestStringTokens(t *testing.T) {
 	input := `"simple string"
     "string with \"quotes\""
@@ -128,8 +361,8 @@ func TestStringTokens(t *testing.T) {
 		{TokenType(STRING), "string with \n newline", 3},
 		{TokenType(STRING), "string with \t tab", 4},
 		{TokenType(STRING), "multiple\n    lines", 5},
-		{TokenType(STRING), "escaped \\backslash", 6},
-		{TokenType(EOF), "", 6},
+		{TokenType(STRING), "escaped \\backslash", 7},
+		{TokenType(EOF), "", 7},
 	}
 
 	l := New(input)
@@ -153,6 +386,111 @@ func TestStringTokens(t *testing.T) {
 	}
 }
 
+func TestBitwiseOperatorTokens(t *testing.T) {
+	input := `a & b | c ~ d ~= ~e << 1 >> 2 && f`
+
+	expected := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+	}{
+		{IDENT, "a"},
+		{AMPERSAND, "&"},
+		{IDENT, "b"},
+		{PIPE, "|"},
+		{IDENT, "c"},
+		{TILDE, "~"},
+		{IDENT, "d"},
+		{NOT_EQ_LUA, "~="},
+		{TILDE, "~"},
+		{IDENT, "e"},
+		{SHIFT_LEFT, "<<"},
+		{NUMBER, "1"},
+		{SHIFT_RIGHT, ">>"},
+		{NUMBER, "2"},
+		{LOGICAL_AND, "&&"},
+		{IDENT, "f"},
+		{EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"\x41\x62"`, "Ab"},
+		{`"\xff"`, "\xff"},
+		{`"\65\066\0677"`, "ABC7"},
+		{`"nul\0byte"`, "nul\x00byte"},
+		{`"\255"`, "\xff"},
+		{`"\u{48}\u{e9}\u{20AC}\u{1F600}"`, "H\u00e9\u20ac\U0001F600"},
+		{`"\a\b\f\r\v\'"`, "\a\b\f\r\v'"},
+		{"\"line\\\ncontinued\"", "line\ncontinued"},
+		{"\"line\\\r\ncontinued\"", "line\ncontinued"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != STRING {
+			t.Fatalf("%s: tokentype wrong. expected=%q, got=%q", tt.input, STRING, tok.Type)
+		}
+		if tok.Literal != tt.expected {
+			t.Errorf("%s: literal wrong. expected=%q, got=%q", tt.input, tt.expected, tok.Literal)
+		}
+		if len(l.Errors()) > 0 {
+			t.Errorf("%s: unexpected errors: %v", tt.input, l.Errors())
+		}
+		if next := l.NextToken(); next.Type != EOF {
+			t.Errorf("%s: expected EOF after the string, got %q", tt.input, next.Type)
+		}
+	}
+}
+
+func TestInvalidStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"\x"`, "Invalid escape sequence '\\x'"},
+		{`"\x4"`, "Invalid escape sequence '\\x'"},
+		{`"\256"`, "Decimal escape '\\256' is larger than 255"},
+		{`"\u41"`, "Invalid escape sequence '\\u'"},
+		{`"\u{41"`, "Invalid escape sequence '\\u': missing '}'"},
+		{`"\u{110000}"`, "UTF-8 escape is not a valid code point"},
+		{`"\q"`, "Invalid escape sequence '\\q'"},
+		{`"unterminated`, "Unterminated string"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for l.NextToken().Type != EOF {
+		}
+
+		if len(l.Errors()) != 1 {
+			t.Errorf("%s: expected 1 error, got %v", tt.input, l.Errors())
+			continue
+		}
+		if !strings.HasPrefix(l.Errors()[0], tt.expected) {
+			t.Errorf("%s: expected error starting with %q, got %q", tt.input, tt.expected, l.Errors()[0])
+		}
+	}
+}
+
 func TestComments(t *testing.T) {
 	input := `-- Single line comment
 local x = 5 -- Inline comment
@@ -200,7 +538,7 @@ local y = 10`
 }
 
 func TestOperators(t *testing.T) {
-	input := `+ - * / %
+	input := `+ - * / // %
 == ~= != < > <= >=
 and or not
 .. "concat" .. "strings"`
@@ -213,6 +551,7 @@ and or not
 		{TokenType(MINUS), "-"},
 		{TokenType(ASTERISK), "*"},
 		{TokenType(SLASH), "/"},
+		{TokenType(FLOOR_DIV), "//"},
 		{TokenType(MODULO), "%"},
 		{TokenType(EQ), "=="},
 		{TokenType(NOT_EQ_LUA), "~="},
@@ -248,6 +587,83 @@ and or not
 	}
 }
 
+func TestEllipsisToken(t *testing.T) {
+	input := `f(...) a .. ... .b`
+
+	tests := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{TokenType(IDENT), "f", 1},
+		{TokenType(LPAREN), "(", 2},
+		{TokenType(ELLIPSIS), "...", 3},
+		{TokenType(RPAREN), ")", 6},
+		{TokenType(IDENT), "a", 8},
+		{TokenType(CONCAT), "..", 11},
+		{TokenType(ELLIPSIS), "...", 13},
+		{TokenType(DOT), ".", 17},
+		{TokenType(IDENT), "b", 18},
+		{TokenType(EOF), "", 19},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - column wrong. expected=%d, got=%d",
+				i, tt.expectedColumn, tok.Column)
+		}
+	}
+}
+
+func TestLogicalOperatorTokens(t *testing.T) {
+	input := `a && b || !c | d`
+
+	tests := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+	}{
+		{TokenType(IDENT), "a"},
+		{TokenType(LOGICAL_AND), "&&"},
+		{TokenType(IDENT), "b"},
+		{TokenType(LOGICAL_OR), "||"},
+		{TokenType(BANG), "!"},
+		{TokenType(IDENT), "c"},
+		{TokenType(PIPE), "|"},
+		{TokenType(IDENT), "d"},
+		{TokenType(EOF), ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
 func TestDelimiters(t *testing.T) {
 	input := `([]),:
 local point: Point = {x: 10, y: 20}`
@@ -537,3 +953,73 @@ func TestTypeAnnotations(t *testing.T) {
 		}
 	}
 }
+
+func TestTargetOperators(t *testing.T) {
+	tokenize := func(input, target string) []string {
+		l := NewWithOptions(input, Options{Target: target})
+		for l.NextToken().Type != EOF {
+		}
+		return l.Errors()
+	}
+
+	errors := tokenize("local q = a // b", "5.1")
+	expected := "operator '//' not supported by target 5.1 at line 1, column 13"
+	if len(errors) != 1 || errors[0] != expected {
+		t.Errorf("expected [%q], got %q", expected, errors)
+	}
+
+	errors = tokenize("x = a\ny = ~a << 2", "5.2")
+	expectedErrors := []string{
+		"operator '~' not supported by target 5.2 at line 2, column 5",
+		"operator '<<' not supported by target 5.2 at line 2, column 8",
+	}
+	if len(errors) != len(expectedErrors) {
+		t.Fatalf("expected %d errors, got %q", len(expectedErrors), errors)
+	}
+	for i, msg := range expectedErrors {
+		if errors[i] != msg {
+			t.Errorf("error %d: expected %q, got %q", i, msg, errors[i])
+		}
+	}
+
+	// Newer targets, or no target at all, accept the operators
+	for _, target := range []string{"5.3", "5.4", ""} {
+		if errors := tokenize("local q = a // b & c ~ d << 1", target); len(errors) > 0 {
+			t.Errorf("target %q: expected no errors, got %q", target, errors)
+		}
+	}
+
+	// ~= is inequality in every version, and & also joins intersection types
+	if errors := tokenize("if a ~= b then end\nlocal p: A & B = q", "5.1"); len(errors) > 0 {
+		t.Errorf("expected no errors for ~= and &, got %q", errors)
+	}
+}
+
+func TestPreserveTrivia(t *testing.T) {
+	input := "local x -- note\n\n--[[ block ]] = 1 "
+	expected := []struct {
+		literal string
+		offset  int
+		trivia  string
+	}{
+		{"local", 0, ""},
+		{"x", 6, " "},
+		{"=", 31, " -- note\n\n--[[ block ]] "},
+		{"1", 33, " "},
+		{"", 35, " "},
+	}
+
+	l := NewWithOptions(input, Options{PreserveTrivia: true})
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Literal != tt.literal || tok.Offset != tt.offset || tok.Trivia != tt.trivia {
+			t.Errorf("token %d: expected %q at %d after %q, got %q at %d after %q",
+				i, tt.literal, tt.offset, tt.trivia, tok.Literal, tok.Offset, tok.Trivia)
+		}
+	}
+
+	// Without the option tokens carry no trivia
+	if tok := New(" x").NextToken(); tok.Offset != 0 || tok.Trivia != "" {
+		t.Errorf("expected no trivia by default, got %q at %d", tok.Trivia, tok.Offset)
+	}
+}
@@ -20,6 +20,8 @@ const (
 	SLASH    = "/"
 	MODULO   = "%"
 
+	FLOOR_DIV = "//"
+
 	//comparison
 	EQ         = "=="
 	NOT_EQ_LUA = "~="
@@ -34,19 +36,34 @@ const (
 	OR  = "or"
 	NOT = "not"
 
+	// C-style logical operators (aliases for and/or)
+	LOGICAL_AND = "&&"
+	LOGICAL_OR  = "||"
+
 	//concat operator
 	CONCAT = ".."
 
+	// varargs of a variadic function
+	ELLIPSIS = "..."
+
+	// bitwise operators (Lua 5.3+). '|' is PIPE, shared with union types,
+	// and '~' is both bitwise not and exclusive or
+	AMPERSAND   = "&"
+	TILDE       = "~"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
+
 	//delimeters
-	COMMA    = ","
-	COLON    = ":"
-	DOT      = "."
-	LPAREN   = "("
-	RPAREN   = ")"
-	LBRACKET = "["
-	RBRACKET = "]"
-	LBRACE   = "{"
-	RBRACE   = "}"
+	COMMA     = ","
+	SEMICOLON = ";"
+	COLON     = ":"
+	DOT       = "."
+	LPAREN    = "("
+	RPAREN    = ")"
+	LBRACKET  = "["
+	RBRACKET  = "]"
+	LBRACE    = "{"
+	RBRACE    = "}"
 
 	// keywords specific to lunar
 	CLASS       = "class"
@@ -62,9 +79,12 @@ const (
 	RETURN      = "return"
 	IF          = "if"
 	ELSE        = "else"
+	ELSEIF      = "elseif"
 	THEN        = "then"
 	FOR         = "for"
 	WHILE       = "while"
+	REPEAT      = "repeat"
+	UNTIL       = "until"
 	DO          = "do"
 	BREAK       = "break"
 	IN          = "in"
@@ -109,9 +129,12 @@ var keywords = map[string]TokenType{
 	"return":      RETURN,
 	"if":          IF,
 	"else":        ELSE,
+	"elseif":      ELSEIF,
 	"then":        THEN,
 	"for":         FOR,
 	"while":       WHILE,
+	"repeat":      REPEAT,
+	"until":       UNTIL,
 	"do":          DO,
 	"break":       BREAK,
 	"in":          IN,
@@ -143,6 +166,12 @@ type Token struct {
 	Literal string
 	Line    int
 	Column  int
+
+	// Offset and Trivia are only recorded with Options.PreserveTrivia: the
+	// byte offset the token starts at, and the whitespace and comments
+	// between it and the token before
+	Offset int
+	Trivia string
 }
 
 func LookupIdent(ident string) TokenType {
@@ -5,6 +5,7 @@ import (
 	"lunar/internal/ast"
 	"lunar/internal/lexer"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -14,6 +15,10 @@ const (
 	AND_PREC    // and
 	EQUALS      // ==
 	LESSGREATER // > OR <
+	BIT_OR      // |
+	BIT_XOR     // ~
+	BIT_AND     // &
+	SHIFT       // << >>
 	SUM         // +
 	PRODUCT     // * / %
 	PREFIX      // -X OR !X OR not
@@ -22,24 +27,33 @@ const (
 )
 
 var precedences = map[lexer.TokenType]int{
-	lexer.OR:         OR_PREC,
-	lexer.AND:        AND_PREC,
-	lexer.EQ:         EQUALS,
-	lexer.NOT_EQ:     EQUALS,
-	lexer.NOT_EQ_LUA: EQUALS,
-	lexer.LT:         LESSGREATER,
-	lexer.GT:         LESSGREATER,
-	lexer.LT_EQ:      LESSGREATER,
-	lexer.GT_EQ:      LESSGREATER,
-	lexer.PLUS:       SUM,
-	lexer.MINUS:      SUM,
-	lexer.ASTERISK:   PRODUCT,
-	lexer.SLASH:      PRODUCT,
-	lexer.MODULO:     PRODUCT,
-	lexer.DOT:        DOT,
-	lexer.LBRACKET:   CALL, // index has same precedence as function call
-	lexer.LPAREN:     CALL,
-	lexer.CONCAT:     SUM,
+	lexer.OR:          OR_PREC,
+	lexer.LOGICAL_OR:  OR_PREC,
+	lexer.AND:         AND_PREC,
+	lexer.LOGICAL_AND: AND_PREC,
+	lexer.EQ:          EQUALS,
+	lexer.NOT_EQ:      EQUALS,
+	lexer.NOT_EQ_LUA:  EQUALS,
+	lexer.LT:          LESSGREATER,
+	lexer.GT:          LESSGREATER,
+	lexer.LT_EQ:       LESSGREATER,
+	lexer.GT_EQ:       LESSGREATER,
+	lexer.PIPE:        BIT_OR,
+	lexer.TILDE:       BIT_XOR,
+	lexer.AMPERSAND:   BIT_AND,
+	lexer.SHIFT_LEFT:  SHIFT,
+	lexer.SHIFT_RIGHT: SHIFT,
+	lexer.PLUS:        SUM,
+	lexer.MINUS:       SUM,
+	lexer.ASTERISK:    PRODUCT,
+	lexer.SLASH:       PRODUCT,
+	lexer.FLOOR_DIV:   PRODUCT,
+	lexer.MODULO:      PRODUCT,
+	lexer.DOT:         DOT,
+	lexer.COLON:       DOT,
+	lexer.LBRACKET:    CALL, // index has same precedence as function call
+	lexer.LPAREN:      CALL,
+	lexer.CONCAT:      SUM,
 }
 
 type prefixParseFn func() ast.Expression
@@ -51,8 +65,20 @@ type Parser struct {
 	curToken  lexer.Token
 	peekToken lexer.Token
 
+	// Tokens to read before asking the lexer for more, left over from
+	// splitting a token in two
+	pending []lexer.Token
+
 	errors []string
 
+	// trivia holds the source around each statement when the lexer
+	// preserves trivia, and is nil otherwise
+	trivia      map[ast.Statement]*ast.Trivia
+	claimed     lexer.Token                      // token whose trivia starts with a statement's trailing trivia
+	endTrivia   string                           // trivia after the last statement
+	blocks      []*blockTrivia                   // blocks in the order they were closed
+	outerBlocks map[ast.Statement][]*blockTrivia // the blocks in each statement not nested in another
+
 	prefixParseFns map[lexer.TokenType]prefixParseFn
 	infixParseFns  map[lexer.TokenType]infixParseFn
 }
@@ -62,6 +88,10 @@ func New(l *lexer.Lexer) *Parser {
 		l:      l,
 		errors: []string{},
 	}
+	if l.PreservesTrivia() {
+		p.trivia = map[ast.Statement]*ast.Trivia{}
+		p.outerBlocks = map[ast.Statement][]*blockTrivia{}
+	}
 
 	//register prefix parse functions
 	p.prefixParseFns = make(map[lexer.TokenType]prefixParseFn)
@@ -75,8 +105,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(lexer.BANG, p.parsePrefixExpression)
 	p.registerPrefix(lexer.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(lexer.NOT, p.parsePrefixExpression)
+	p.registerPrefix(lexer.TILDE, p.parsePrefixExpression)
 	p.registerPrefix(lexer.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(lexer.LBRACE, p.parseTableLiteral)
+	p.registerPrefix(lexer.ELLIPSIS, p.parseVarargExpression)
+	p.registerPrefix(lexer.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(lexer.DO, p.parseDoExpression)
 
 	//register infix operators
 	p.infixParseFns = make(map[lexer.TokenType]infixParseFn)
@@ -84,6 +118,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(lexer.MINUS, p.parseInfixExpression)
 	p.registerInfix(lexer.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(lexer.SLASH, p.parseInfixExpression)
+	p.registerInfix(lexer.FLOOR_DIV, p.parseInfixExpression)
 	p.registerInfix(lexer.MODULO, p.parseInfixExpression)
 	p.registerInfix(lexer.EQ, p.parseInfixExpression)
 	p.registerInfix(lexer.NOT_EQ, p.parseInfixExpression)
@@ -94,10 +129,18 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(lexer.GT_EQ, p.parseInfixExpression)
 	p.registerInfix(lexer.AND, p.parseInfixExpression)
 	p.registerInfix(lexer.OR, p.parseInfixExpression)
+	p.registerInfix(lexer.LOGICAL_AND, p.parseInfixExpression)
+	p.registerInfix(lexer.LOGICAL_OR, p.parseInfixExpression)
 	p.registerInfix(lexer.LBRACKET, p.parseIndexExpression)
 	p.registerInfix(lexer.LPAREN, p.parseCallExpression)
 	p.registerInfix(lexer.DOT, p.parseDotExpression)
+	p.registerInfix(lexer.COLON, p.parseMethodExpression)
 	p.registerInfix(lexer.CONCAT, p.parseInfixExpression)
+	p.registerInfix(lexer.PIPE, p.parseInfixExpression)
+	p.registerInfix(lexer.TILDE, p.parseInfixExpression)
+	p.registerInfix(lexer.AMPERSAND, p.parseInfixExpression)
+	p.registerInfix(lexer.SHIFT_LEFT, p.parseInfixExpression)
+	p.registerInfix(lexer.SHIFT_RIGHT, p.parseInfixExpression)
 
 	// read to tokens to initialize curtoken
 	p.nextToken()
@@ -108,10 +151,31 @@ func New(l *lexer.Lexer) *Parser {
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
+	if len(p.pending) > 0 {
+		p.peekToken = p.pending[0]
+		p.pending = p.pending[1:]
+		return
+	}
 	p.peekToken = p.l.NextToken()
 }
 
+// expectClosingAngle expects the '>' that closes a list of type arguments.
+// Nested lists end in '>>', which the lexer reads as a shift operator, so it
+// is split into two '>' tokens here
+func (p *Parser) expectClosingAngle() bool {
+	if p.peekTokenIs(lexer.SHIFT_RIGHT) {
+		shift := p.peekToken
+		p.peekToken = lexer.Token{Type: lexer.GT, Literal: ">", Line: shift.Line, Column: shift.Column - 1, Offset: shift.Offset, Trivia: shift.Trivia}
+		p.pending = append([]lexer.Token{{Type: lexer.GT, Literal: ">", Line: shift.Line, Column: shift.Column, Offset: shift.Offset + 1}}, p.pending...)
+	}
+	return p.expectPeek(lexer.GT)
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
+	// super refers to the parent class inside a subclass
+	if p.curToken.Literal == "super" {
+		return &ast.SuperExpression{Token: p.curToken}
+	}
 	return &ast.Identifier{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
@@ -119,14 +183,77 @@ func (p *Parser) parseIdentifier() ast.Expression {
 }
 
 func (p *Parser) parseNumberLiteral() ast.Expression {
-	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	literal, ok := stripDigitSeparators(p.curToken.Literal)
+	if !ok {
+		msg := fmt.Sprintf("Invalid digit separator in number %q at line %d, column %d",
+			p.curToken.Literal, p.curToken.Line, p.curToken.Column)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	value, err := parseNumber(literal)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as number", p.curToken.Literal)
 		p.errors = append(p.errors, msg)
 		return nil
 	}
 
-	return &ast.NumberLiteral{Token: p.curToken, Value: value}
+	return &ast.NumberLiteral{Token: p.curToken, Value: value, IsFloat: isFloatLiteral(literal)}
+}
+
+// parseNumber converts a number literal to its value. Hexadecimal (0x) and
+// binary (0b) literals are integers
+func parseNumber(literal string) (float64, error) {
+	if len(literal) > 1 && literal[0] == '0' {
+		switch literal[1] {
+		case 'x', 'X', 'b', 'B':
+			value, err := strconv.ParseUint(literal, 0, 64)
+			return float64(value), err
+		}
+	}
+	return strconv.ParseFloat(literal, 64)
+}
+
+// isFloatLiteral reports whether a number literal is written as a float,
+// with a decimal point or an exponent, which Lua 5.3 reads as a float even
+// when its value is whole. Hexadecimal and binary literals are integers
+func isFloatLiteral(literal string) bool {
+	if len(literal) > 1 && literal[0] == '0' {
+		switch literal[1] {
+		case 'x', 'X', 'b', 'B':
+			return false
+		}
+	}
+	return strings.ContainsAny(literal, ".eE")
+}
+
+// stripDigitSeparators removes the underscores from a number literal such as
+// 1_000_000. It fails when an underscore doesn't sit between two digits, as
+// in 1__0, 1_ or 0x_FF
+func stripDigitSeparators(literal string) (string, bool) {
+	if !strings.Contains(literal, "_") {
+		return literal, true
+	}
+
+	hex := len(literal) > 1 && literal[0] == '0' && (literal[1] == 'x' || literal[1] == 'X')
+	isDigit := func(ch byte) bool {
+		if hex && (('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')) {
+			return true
+		}
+		return '0' <= ch && ch <= '9'
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '_' {
+			out.WriteByte(literal[i])
+			continue
+		}
+		if i == 0 || i == len(literal)-1 || !isDigit(literal[i-1]) || !isDigit(literal[i+1]) {
+			return "", false
+		}
+	}
+	return out.String(), true
 }
 
 func (p *Parser) parseStringLiteral() ast.Expression {
@@ -144,6 +271,10 @@ func (p *Parser) parseNilLiteral() ast.Expression {
 	return &ast.NilLiteral{Token: p.curToken}
 }
 
+func (p *Parser) parseVarargExpression() ast.Expression {
+	return &ast.VarargExpression{Token: p.curToken}
+}
+
 func (p *Parser) registerPrefix(tokenType lexer.TokenType, fn prefixParseFn) {
 	p.prefixParseFns[tokenType] = fn
 }
@@ -248,8 +379,28 @@ func (p *Parser) parseDotExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseMethodExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MethodExpression{
+		Token: p.curToken,
+		Left:  left,
+	}
+
+	// Right side of a method reference must be the method name
+	if !p.expectPeek(lexer.IDENT) {
+		return nil
+	}
+
+	exp.Right = &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+
+	return exp
+}
+
 func (p *Parser) peekError(t lexer.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
+	msg := fmt.Sprintf("expected next token to be %s, got %s instead at line %d, column %d",
+		t, p.peekToken.Type, p.peekToken.Line, p.peekToken.Column)
 	p.errors = append(p.errors, msg)
 }
 
@@ -270,12 +421,14 @@ func (p *Parser) curPrecedence() int {
 }
 
 func (p *Parser) noPrefixParseFnError(t lexer.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
+	msg := fmt.Sprintf("no prefix parse function for %s found at line %d, column %d", t, p.curToken.Line, p.curToken.Column)
 	p.errors = append(p.errors, msg)
 }
 
+// Errors returns the lexer's errors followed by the parser's own
 func (p *Parser) Errors() []string {
-	return p.errors
+	errors := append([]string{}, p.l.Errors()...)
+	return append(errors, p.errors...)
 }
 
 // Parse parses the entire program and returns a slice of statements
@@ -292,6 +445,9 @@ func (p *Parser) Parse() []ast.Statement {
 		}
 		p.nextToken()
 	}
+	if p.trivia != nil {
+		p.endTrivia = p.unclaimedTrivia(p.curToken)
+	}
 
 	return statements
 }
@@ -302,6 +458,7 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Operator: p.curToken.Literal,
 		Left:     left,
 	}
+	p.l.CheckInfixTarget(p.curToken)
 
 	precedence := p.curPrecedence()
 	p.nextToken()
@@ -351,24 +508,88 @@ func (p *Parser) parseVariableDeclaration() *ast.VariableDeclaration {
 	return decl
 }
 
+// parseDestructuringDeclaration parses local { name, name = default } = value
+func (p *Parser) parseDestructuringDeclaration() ast.Statement {
+	decl := &ast.DestructuringDeclaration{Token: p.curToken}
+	p.nextToken() // move to '{'
+
+	for !p.peekTokenIs(lexer.RBRACE) {
+		if !p.expectPeek(lexer.IDENT) {
+			return nil
+		}
+		field := &ast.DestructuringField{
+			Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		}
+		if p.peekTokenIs(lexer.ASSIGN) {
+			p.nextToken() // consume '='
+			p.nextToken() // move to the default value
+			field.Default = p.parseExpression(LOWEST)
+		}
+		decl.Fields = append(decl.Fields, field)
+
+		if !p.peekTokenIs(lexer.COMMA) {
+			break
+		}
+		p.nextToken() // consume ','
+	}
+	if !p.expectPeek(lexer.RBRACE) {
+		return nil
+	}
+	if len(decl.Fields) == 0 {
+		msg := fmt.Sprintf("Expected at least one field to destructure at line %d, column %d",
+			p.curToken.Line, p.curToken.Column)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	if !p.expectPeek(lexer.ASSIGN) {
+		return nil
+	}
+	p.nextToken() // move to the value
+	decl.Value = p.parseExpression(LOWEST)
+	return decl
+}
+
 func (p *Parser) parseType() ast.Expression {
 	var typeExpr ast.Expression
 
 	switch p.curToken.Type {
 	case lexer.LPAREN:
 		// Could be tuple type or function type
-		return p.parseTupleOrFunctionType()
+		typeExpr = p.parseTupleOrFunctionType()
+		if typeExpr == nil {
+			return nil
+		}
 	case lexer.TABLE:
 		// table<K, V>
 		typeExpr = p.parseTableType()
+	case lexer.LBRACE:
+		// Inline object shape: { name: Type, ... }
+		typeExpr = p.parseObjectShapeType()
+		if typeExpr == nil {
+			return nil
+		}
 	case lexer.STRING:
 		// String literal in type position (for literal types)
 		typeExpr = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 	case lexer.NUMBER:
 		// Number literal in type position (for literal types)
 		value, _ := strconv.ParseFloat(p.curToken.Literal, 64)
-		typeExpr = &ast.NumberLiteral{Token: p.curToken, Value: value}
+		typeExpr = &ast.NumberLiteral{Token: p.curToken, Value: value, IsFloat: isFloatLiteral(p.curToken.Literal)}
+	case lexer.MINUS:
+		// Negative number literal, such as a bound of number<-1, 1>
+		typeExpr = p.parseNegativeNumberType()
+		if typeExpr == nil {
+			return nil
+		}
 	case lexer.IDENT, lexer.STRING_TYPE, lexer.NUMBER_TYPE, lexer.BOOLEAN, lexer.ANY, lexer.VOID, lexer.NIL:
+		if p.curTokenIsReadonlyModifier() {
+			typeExpr = p.parseReadonlyArrayType()
+			if typeExpr == nil {
+				return nil
+			}
+			break
+		}
 		typeExpr = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	default:
 		return nil
@@ -378,6 +599,77 @@ func (p *Parser) parseType() ast.Expression {
 	return p.parseTypeSuffix(typeExpr)
 }
 
+// curTokenIsReadonlyModifier reports whether the current token is readonly
+// modifying the type after it. readonly is only a modifier in this
+// position, so a type may still be named readonly
+func (p *Parser) curTokenIsReadonlyModifier() bool {
+	if p.curToken.Literal != "readonly" {
+		return false
+	}
+	switch p.peekToken.Type {
+	case lexer.IDENT, lexer.STRING_TYPE, lexer.NUMBER_TYPE, lexer.BOOLEAN, lexer.ANY, lexer.VOID, lexer.NIL, lexer.LPAREN, lexer.TABLE, lexer.LBRACE:
+		return true
+	}
+	return false
+}
+
+// parseReadonlyArrayType parses readonly T[]. The modifier applies to the
+// array type that follows it, so readonly T[] | nil is an optional
+// read-only array
+func (p *Parser) parseReadonlyArrayType() ast.Expression {
+	readonlyToken := p.curToken
+	p.nextToken() // move past 'readonly'
+
+	typeExpr := p.parseNonUnionType()
+	array, ok := typeExpr.(*ast.ArrayType)
+	if optional, isOptional := typeExpr.(*ast.OptionalType); isOptional {
+		array, ok = optional.Type.(*ast.ArrayType)
+	}
+	if !ok {
+		msg := fmt.Sprintf("'readonly' can only be applied to array types at line %d, column %d",
+			readonlyToken.Line, readonlyToken.Column)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	array.Readonly = true
+	return typeExpr
+}
+
+// parseObjectShapeType parses an inline object shape such as
+// { id: number, name: string } or { [key: string]: number }. Fields may be
+// separated by commas or just whitespace
+func (p *Parser) parseObjectShapeType() ast.Expression {
+	shape := &ast.ObjectShapeType{Token: p.curToken}
+
+	for !p.peekTokenIs(lexer.RBRACE) {
+		if p.peekTokenIs(lexer.LBRACKET) {
+			p.nextToken() // move to '['
+			sig := p.parseIndexSignature()
+			if sig == nil || sig.ValueType == nil {
+				return nil
+			}
+			shape.IndexSignatures = append(shape.IndexSignatures, sig)
+		} else {
+			if !p.expectPeek(lexer.IDENT) {
+				return nil
+			}
+
+			prop := p.parsePropertySignature()
+			if prop == nil || prop.Type == nil {
+				return nil
+			}
+			shape.Properties = append(shape.Properties, prop)
+		}
+
+		if p.peekTokenIs(lexer.COMMA) {
+			p.nextToken()
+		}
+	}
+
+	p.nextToken() // consume '}'
+	return shape
+}
+
 func (p *Parser) parseSimpleType() ast.Expression {
 	switch p.curToken.Type {
 	case lexer.LPAREN:
@@ -398,37 +690,28 @@ func (p *Parser) parseTypeSuffix(baseType ast.Expression) ast.Expression {
 	// These bind tighter than union types
 	for {
 		switch {
-		case p.peekTokenIs(lexer.LBRACKET):
-			// Array type: T[]
+		case p.peekTokenIs(lexer.LBRACKET) && p.peekToken.Line == p.curToken.Line:
+			// Array type: T[]. A '[' on the next line starts an index
+			// signature instead
 			p.nextToken() // consume '['
 			if !p.expectPeek(lexer.RBRACKET) {
 				return nil
 			}
 			currentType = &ast.ArrayType{
-				Token:       baseType.(*ast.Identifier).Token,
+				Token:       typeToken(currentType),
 				ElementType: currentType,
 			}
 
 		case p.peekTokenIs(lexer.LT):
 			// Generic type: T<U>
 			p.nextToken() // consume '<'
-			p.nextToken() // move to first type argument
-
-			typeArgs := []ast.Expression{}
-			typeArgs = append(typeArgs, p.parseType())
-
-			for p.peekTokenIs(lexer.COMMA) {
-				p.nextToken() // consume comma
-				p.nextToken() // move to next type
-				typeArgs = append(typeArgs, p.parseType())
-			}
-
-			if !p.expectPeek(lexer.GT) {
+			typeArgs := p.parseTypeArguments()
+			if typeArgs == nil {
 				return nil
 			}
 
 			currentType = &ast.GenericType{
-				Token:         baseType.(*ast.Identifier).Token,
+				Token:         typeToken(baseType),
 				BaseType:      baseType,
 				TypeArguments: typeArgs,
 			}
@@ -448,6 +731,9 @@ func (p *Parser) parseTypeSuffix(baseType ast.Expression) ast.Expression {
 	}
 
 checkUnion:
+	// Intersections bind tighter than unions: A & B | C is (A & B) | C
+	currentType = p.parseIntersectionSuffix(currentType)
+
 	// Second pass: handle union types (lowest precedence)
 	if p.peekTokenIs(lexer.PIPE) {
 		types := []ast.Expression{currentType}
@@ -470,6 +756,78 @@ checkUnion:
 	return currentType
 }
 
+// parseIntersectionSuffix parses the rest of an intersection type whose
+// first member is firstType, or returns firstType when no '&' follows
+func (p *Parser) parseIntersectionSuffix(firstType ast.Expression) ast.Expression {
+	if !p.peekTokenIs(lexer.AMPERSAND) {
+		return firstType
+	}
+
+	intersection := &ast.IntersectionType{
+		Token: p.peekToken,
+		Types: []ast.Expression{firstType},
+	}
+	for p.peekTokenIs(lexer.AMPERSAND) {
+		p.nextToken() // consume '&'
+		p.nextToken() // move to next type
+		// Members parse their own '&' suffix, so flatten A & (B & C)
+		switch nextType := p.parseNonUnionType().(type) {
+		case nil:
+		case *ast.IntersectionType:
+			intersection.Types = append(intersection.Types, nextType.Types...)
+		default:
+			intersection.Types = append(intersection.Types, nextType)
+		}
+	}
+	return intersection
+}
+
+// parseNegativeNumberType parses a '-' followed by a number in a type
+func (p *Parser) parseNegativeNumberType() ast.Expression {
+	minusToken := p.curToken
+	if !p.expectPeek(lexer.NUMBER) {
+		return nil
+	}
+
+	value, _ := strconv.ParseFloat(p.curToken.Literal, 64)
+	token := minusToken
+	token.Type = lexer.NUMBER
+	token.Literal = "-" + p.curToken.Literal
+	return &ast.NumberLiteral{Token: token, Value: -value, IsFloat: isFloatLiteral(p.curToken.Literal)}
+}
+
+// typeToken returns the token recorded on a type expression, or a zero token
+// for an expression that isn't a type
+func typeToken(expr ast.Expression) lexer.Token {
+	switch node := expr.(type) {
+	case *ast.Identifier:
+		return node.Token
+	case *ast.ArrayType:
+		return node.Token
+	case *ast.GenericType:
+		return node.Token
+	case *ast.OptionalType:
+		return node.Token
+	case *ast.UnionType:
+		return node.Token
+	case *ast.IntersectionType:
+		return node.Token
+	case *ast.TableType:
+		return node.Token
+	case *ast.FunctionType:
+		return node.Token
+	case *ast.TupleType:
+		return node.Token
+	case *ast.ObjectShapeType:
+		return node.Token
+	case *ast.StringLiteral:
+		return node.Token
+	case *ast.NumberLiteral:
+		return node.Token
+	}
+	return lexer.Token{}
+}
+
 // parseNonUnionType parses a type with all suffixes EXCEPT union types
 // This is used when parsing union members to avoid nested union structures
 func (p *Parser) parseNonUnionType() ast.Expression {
@@ -478,18 +836,36 @@ func (p *Parser) parseNonUnionType() ast.Expression {
 	switch p.curToken.Type {
 	case lexer.LPAREN:
 		// Could be tuple type or function type
-		return p.parseTupleOrFunctionType()
+		typeExpr = p.parseTupleOrFunctionType()
+		if typeExpr == nil {
+			return nil
+		}
 	case lexer.TABLE:
 		// table<K, V>
 		typeExpr = p.parseTableType()
+	case lexer.LBRACE:
+		// Inline object shape: { name: Type, ... }
+		typeExpr = p.parseObjectShapeType()
+		if typeExpr == nil {
+			return nil
+		}
 	case lexer.STRING:
 		// String literal in type position (for literal types)
 		typeExpr = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 	case lexer.NUMBER:
 		// Number literal in type position (for literal types)
 		value, _ := strconv.ParseFloat(p.curToken.Literal, 64)
-		typeExpr = &ast.NumberLiteral{Token: p.curToken, Value: value}
+		typeExpr = &ast.NumberLiteral{Token: p.curToken, Value: value, IsFloat: isFloatLiteral(p.curToken.Literal)}
+	case lexer.MINUS:
+		// Negative number literal, such as a bound of number<-1, 1>
+		typeExpr = p.parseNegativeNumberType()
+		if typeExpr == nil {
+			return nil
+		}
 	case lexer.IDENT, lexer.STRING_TYPE, lexer.NUMBER_TYPE, lexer.BOOLEAN, lexer.ANY, lexer.VOID, lexer.NIL:
+		if p.curTokenIsReadonlyModifier() {
+			return p.parseReadonlyArrayType()
+		}
 		typeExpr = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	default:
 		return nil
@@ -500,37 +876,28 @@ func (p *Parser) parseNonUnionType() ast.Expression {
 	// Handle high-precedence suffixes (arrays, generics, optional) but NOT unions
 	for {
 		switch {
-		case p.peekTokenIs(lexer.LBRACKET):
-			// Array type: T[]
+		case p.peekTokenIs(lexer.LBRACKET) && p.peekToken.Line == p.curToken.Line:
+			// Array type: T[]. A '[' on the next line starts an index
+			// signature instead
 			p.nextToken() // consume '['
 			if !p.expectPeek(lexer.RBRACKET) {
 				return nil
 			}
 			currentType = &ast.ArrayType{
-				Token:       p.curToken,
+				Token:       typeToken(currentType),
 				ElementType: currentType,
 			}
 
 		case p.peekTokenIs(lexer.LT):
 			// Generic type: T<U>
 			p.nextToken() // consume '<'
-			p.nextToken() // move to first type argument
-
-			typeArgs := []ast.Expression{}
-			typeArgs = append(typeArgs, p.parseType())
-
-			for p.peekTokenIs(lexer.COMMA) {
-				p.nextToken() // consume comma
-				p.nextToken() // move to next type
-				typeArgs = append(typeArgs, p.parseType())
-			}
-
-			if !p.expectPeek(lexer.GT) {
+			typeArgs := p.parseTypeArguments()
+			if typeArgs == nil {
 				return nil
 			}
 
 			currentType = &ast.GenericType{
-				Token:         typeExpr.(*ast.Identifier).Token,
+				Token:         typeToken(typeExpr),
 				BaseType:      typeExpr,
 				TypeArguments: typeArgs,
 			}
@@ -545,7 +912,7 @@ func (p *Parser) parseNonUnionType() ast.Expression {
 
 		default:
 			// No more high-precedence suffixes, return without processing unions
-			return currentType
+			return p.parseIntersectionSuffix(currentType)
 		}
 	}
 }
@@ -570,7 +937,7 @@ func (p *Parser) parseTableType() ast.Expression {
 	valueType := p.parseType()
 
 	// Expect '>'
-	if !p.expectPeek(lexer.GT) {
+	if !p.expectClosingAngle() {
 		return nil
 	}
 
@@ -634,6 +1001,10 @@ func (p *Parser) parseTupleOrFunctionType() ast.Expression {
 						Type:  t,
 					})
 				}
+			} else if len(types) == 1 {
+				// A single parenthesized type such as (() => void) only
+				// groups it, so a suffix like [] applies to the whole type
+				return types[0]
 			} else {
 				// It's a tuple type
 				return &ast.TupleType{
@@ -679,9 +1050,38 @@ func (p *Parser) peekTokenIs(t lexer.TokenType) bool {
 }
 
 func (p *Parser) parseParameter() *ast.Parameter {
+	// Variadic parameter: ... or ...: T
+	if p.curTokenIs(lexer.ELLIPSIS) {
+		param := &ast.Parameter{
+			Token:      p.curToken,
+			Name:       &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+			IsVariadic: true,
+		}
+		if p.peekTokenIs(lexer.COLON) {
+			p.nextToken() // consumes :
+			p.nextToken() // moves onto type
+			param.Type = p.parseType()
+		}
+		return param
+	}
+
+	// Parameter property modifiers: public/private/protected and readonly
+	visibility := ""
+	if p.curTokenIs(lexer.PUBLIC) || p.curTokenIs(lexer.PRIVATE) || p.curTokenIs(lexer.PROTECTED) {
+		visibility = p.curToken.Literal
+		p.nextToken() // move past visibility
+	}
+	readonly := false
+	if p.curToken.Literal == "readonly" && p.peekTokenIs(lexer.IDENT) {
+		readonly = true
+		p.nextToken() // move to parameter name
+	}
+
 	param := &ast.Parameter{
-		Token: p.curToken,
-		Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		Token:      p.curToken,
+		Name:       &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		Visibility: visibility,
+		Readonly:   readonly,
 	}
 	if p.peekTokenIs(lexer.COLON) {
 		p.nextToken() // consumes :
@@ -692,6 +1092,18 @@ func (p *Parser) parseParameter() *ast.Parameter {
 	return param
 }
 
+// rejectParameterProperties reports parameter property modifiers outside of
+// a constructor, where there is no class property for them to declare
+func (p *Parser) rejectParameterProperties(params []*ast.Parameter) {
+	for _, param := range params {
+		if param != nil && param.IsProperty() {
+			msg := fmt.Sprintf("Parameter properties are only allowed in constructors at line %d, column %d",
+				param.Token.Line, param.Token.Column)
+			p.errors = append(p.errors, msg)
+		}
+	}
+}
+
 func (p *Parser) parseFunctionParameters() []*ast.Parameter {
 	params := []*ast.Parameter{}
 
@@ -717,6 +1129,15 @@ func (p *Parser) parseFunctionParameters() []*ast.Parameter {
 		return nil
 	}
 
+	// Extra arguments can only be collected after every named parameter
+	for _, param := range params[:len(params)-1] {
+		if param.IsVariadic {
+			msg := fmt.Sprintf("Variadic parameter must be the last parameter at line %d, column %d",
+				param.Token.Line, param.Token.Column)
+			p.errors = append(p.errors, msg)
+		}
+	}
+
 	return params
 }
 
@@ -742,11 +1163,12 @@ func (p *Parser) parseFunctionDeclaration() *ast.FunctionDeclaration {
 		return nil
 	}
 	fd.Parameters = p.parseFunctionParameters()
+	p.rejectParameterProperties(fd.Parameters)
 
 	if p.peekTokenIs(lexer.COLON) {
 		p.nextToken() //consume :
 		p.nextToken() // move onto return type
-		fd.ReturnType = p.parseType()
+		fd.ReturnType = p.parseReturnType()
 	}
 
 	fd.Body = p.parseBlockStatement()
@@ -754,6 +1176,28 @@ func (p *Parser) parseFunctionDeclaration() *ast.FunctionDeclaration {
 	return fd
 }
 
+// parseFunctionLiteral parses an anonymous function expression such as
+// function(x: number): number return x * 2 end
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	fl := &ast.FunctionLiteral{Token: p.curToken}
+
+	if !p.expectPeek(lexer.LPAREN) {
+		return nil
+	}
+	fl.Parameters = p.parseFunctionParameters()
+	p.rejectParameterProperties(fl.Parameters)
+
+	if p.peekTokenIs(lexer.COLON) {
+		p.nextToken() //consume :
+		p.nextToken() // move onto return type
+		fl.ReturnType = p.parseReturnType()
+	}
+
+	fl.Body = p.parseBlockStatement()
+
+	return fl
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{
 		Token:      p.curToken,
@@ -761,25 +1205,87 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	}
 
 	p.nextToken()
+	first := p.curToken
 
 	for !p.curTokenIs(lexer.END) && !p.curTokenIs(lexer.EOF) {
+		startToken := p.curToken
 		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+		if stmt == nil {
+			p.synchronize(startToken, lexer.END)
+			continue
 		}
+		block.Statements = append(block.Statements, stmt)
 		p.nextToken()
 	}
 
+	p.recordBlock(block, first)
 	return block
 }
 
+// statementStarts lists the keywords that can begin a statement; they are
+// used as resynchronization points after a malformed statement
+var statementStarts = map[lexer.TokenType]bool{
+	lexer.FUNCTION:  true,
+	lexer.RETURN:    true,
+	lexer.LOCAL:     true,
+	lexer.CONST:     true,
+	lexer.IF:        true,
+	lexer.WHILE:     true,
+	lexer.REPEAT:    true,
+	lexer.FOR:       true,
+	lexer.DO:        true,
+	lexer.BREAK:     true,
+	lexer.CLASS:     true,
+	lexer.INTERFACE: true,
+	lexer.ENUM:      true,
+	lexer.TYPE:      true,
+	lexer.EXPORT:    true,
+	lexer.IMPORT:    true,
+	lexer.DECLARE:   true,
+}
+
+// synchronize records an error for the statement starting at startToken and
+// skips ahead to the next statement-starting keyword or block terminator, so
+// a single malformed statement doesn't swallow the rest of the block
+func (p *Parser) synchronize(startToken lexer.Token, terminators ...lexer.TokenType) {
+	msg := fmt.Sprintf("skipping malformed statement at line %d, column %d", startToken.Line, startToken.Column)
+	p.errors = append(p.errors, msg)
+
+	p.nextToken()
+	for !p.curTokenIs(lexer.EOF) && !statementStarts[p.curToken.Type] {
+		for _, t := range terminators {
+			if p.curTokenIs(t) {
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
+	// A bare return ends its block
+	if p.peekTokenIs(lexer.END) || p.peekTokenIs(lexer.ELSE) || p.peekTokenIs(lexer.ELSEIF) ||
+		p.peekTokenIs(lexer.UNTIL) || p.peekTokenIs(lexer.EOF) {
+		return stmt
+	}
+
 	p.nextToken() // move past 'return'
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
+	// return a, b returns several values
+	if p.peekTokenIs(lexer.COMMA) {
+		stmt.ReturnValues = []ast.Expression{stmt.ReturnValue}
+		stmt.ReturnValue = nil
+		for p.peekTokenIs(lexer.COMMA) {
+			p.nextToken() // consume comma
+			p.nextToken() // move to next value
+			stmt.ReturnValues = append(stmt.ReturnValues, p.parseExpression(LOWEST))
+		}
+	}
+
 	return stmt
 }
 
@@ -787,61 +1293,154 @@ func (p *Parser) parseExpressionStatement() ast.Statement {
 	// Try to parse as expression first
 	expr := p.parseExpression(LOWEST)
 
+	// A comma after it starts the target list of a multiple assignment
+	if p.peekTokenIs(lexer.COMMA) {
+		names := []ast.Expression{expr}
+		for p.peekTokenIs(lexer.COMMA) {
+			p.nextToken() // consume comma
+			p.nextToken() // move to next target
+			names = append(names, p.parseExpression(LOWEST))
+		}
+		if !p.expectPeek(lexer.ASSIGN) {
+			return nil
+		}
+		return p.parseAssignment(names)
+	}
+
 	// Check if this is an assignment
 	if p.peekTokenIs(lexer.ASSIGN) {
-		assignToken := p.peekToken
 		p.nextToken() // consume '='
-		p.nextToken() // move to value expression
-
-		return &ast.AssignmentStatement{
-			Token: assignToken,
-			Name:  expr,
-			Value: p.parseExpression(LOWEST),
-		}
+		return p.parseAssignment([]ast.Expression{expr})
 	}
 
 	// Otherwise, it's just an expression statement
+	if expr == nil {
+		return nil
+	}
 	return &ast.ExpressionStatement{
 		Token:      p.curToken,
 		Expression: expr,
 	}
 }
 
+// parseAssignment parses the values assigned to names, starting on the '='
+// token. Anything but one target and one value is a multiple assignment
+func (p *Parser) parseAssignment(names []ast.Expression) *ast.AssignmentStatement {
+	stmt := &ast.AssignmentStatement{Token: p.curToken}
+
+	p.nextToken() // move to value expression
+	values := []ast.Expression{p.parseExpression(LOWEST)}
+	for p.peekTokenIs(lexer.COMMA) {
+		p.nextToken() // consume comma
+		p.nextToken() // move to next value
+		values = append(values, p.parseExpression(LOWEST))
+	}
+
+	if len(names) == 1 && len(values) == 1 {
+		stmt.Name = names[0]
+		stmt.Value = values[0]
+	} else {
+		stmt.Names = names
+		stmt.Values = values
+	}
+	return stmt
+}
+
 func (p *Parser) parseStatement() ast.Statement {
+	start := p.curToken
+	leading := ""
+	if p.trivia != nil {
+		leading = p.unclaimedTrivia(start)
+	}
+	blocks := len(p.blocks)
+	stmt := p.parseBareStatement()
+
+	// As in Lua, a statement may end with a semicolon
+	if p.peekTokenIs(lexer.SEMICOLON) && stmt != nil {
+		p.nextToken()
+	}
+	if p.trivia != nil && stmt != nil {
+		p.recordTrivia(stmt, start, leading, p.blocks[blocks:])
+	}
+	return stmt
+}
+
+// parseBareStatement parses a statement, without its optional semicolon.
+// Parse functions return a nil pointer for a malformed statement, which is
+// checked for here so the caller gets an untyped nil it can compare with nil
+func (p *Parser) parseBareStatement() ast.Statement {
 	switch p.curToken.Type {
 	case lexer.FUNCTION:
-		return p.parseFunctionDeclaration()
+		if stmt := p.parseFunctionDeclaration(); stmt != nil {
+			return stmt
+		}
 	case lexer.RETURN:
 		return p.parseReturnStatement()
 	case lexer.LOCAL, lexer.CONST:
-		return p.parseVariableDeclaration()
+		if p.curTokenIs(lexer.CONST) && p.peekTokenIs(lexer.ENUM) {
+			if stmt := p.parseConstEnumDeclaration(); stmt != nil {
+				return stmt
+			}
+			return nil
+		}
+		if p.curTokenIs(lexer.LOCAL) && p.peekTokenIs(lexer.LBRACE) {
+			return p.parseDestructuringDeclaration()
+		}
+		if stmt := p.parseVariableDeclaration(); stmt != nil {
+			return stmt
+		}
 	case lexer.IF:
-		return p.parseIfStatement()
+		if stmt := p.parseIfStatement(); stmt != nil {
+			return stmt
+		}
 	case lexer.WHILE:
-		return p.parseWhileStatement()
+		if stmt := p.parseWhileStatement(); stmt != nil {
+			return stmt
+		}
+	case lexer.REPEAT:
+		if stmt := p.parseRepeatStatement(); stmt != nil {
+			return stmt
+		}
 	case lexer.FOR:
-		return p.parseForStatement()
+		if stmt := p.parseForStatement(); stmt != nil {
+			return stmt
+		}
 	case lexer.DO:
 		return p.parseDoStatement()
 	case lexer.BREAK:
 		return p.parseBreakStatement()
 	case lexer.CLASS:
-		return p.parseClassDeclaration()
+		if stmt := p.parseClassDeclaration(); stmt != nil {
+			return stmt
+		}
 	case lexer.INTERFACE:
-		return p.parseInterfaceDeclaration()
+		if stmt := p.parseInterfaceDeclaration(); stmt != nil {
+			return stmt
+		}
 	case lexer.ENUM:
-		return p.parseEnumDeclaration()
+		if stmt := p.parseEnumDeclaration(); stmt != nil {
+			return stmt
+		}
 	case lexer.TYPE:
-		return p.parseTypeDeclaration()
+		if stmt := p.parseTypeDeclaration(); stmt != nil {
+			return stmt
+		}
 	case lexer.EXPORT:
-		return p.parseExportStatement()
+		if stmt := p.parseExportStatement(); stmt != nil {
+			return stmt
+		}
 	case lexer.IMPORT:
-		return p.parseImportStatement()
+		if stmt := p.parseImportStatement(); stmt != nil {
+			return stmt
+		}
 	case lexer.DECLARE:
-		return p.parseDeclareStatement()
+		if stmt := p.parseDeclareStatement(); stmt != nil {
+			return stmt
+		}
 	default:
 		return p.parseExpressionStatement()
 	}
+	return nil
 }
 
 func (p *Parser) parseIfStatement() *ast.IfStatement {
@@ -857,9 +1456,23 @@ func (p *Parser) parseIfStatement() *ast.IfStatement {
 		return nil
 	}
 
-	// Parse consequence block (stops at 'else' or 'end')
+	// Parse consequence block (stops at 'elseif', 'else' or 'end')
 	stmt.Consequence = p.parseIfBlockStatement()
 
+	// Parse elseif branches
+	for p.curTokenIs(lexer.ELSEIF) {
+		elseIf := &ast.ElseIfClause{Token: p.curToken}
+
+		p.nextToken() // move to condition
+		elseIf.Condition = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(lexer.THEN) {
+			return nil
+		}
+		elseIf.Consequence = p.parseIfBlockStatement()
+		stmt.ElseIfs = append(stmt.ElseIfs, elseIf)
+	}
+
 	// Check for else
 	if p.curTokenIs(lexer.ELSE) {
 		stmt.Alternative = p.parseBlockStatement()
@@ -875,15 +1488,20 @@ func (p *Parser) parseIfBlockStatement() *ast.BlockStatement {
 	}
 
 	p.nextToken()
+	first := p.curToken
 
-	for !p.curTokenIs(lexer.END) && !p.curTokenIs(lexer.ELSE) && !p.curTokenIs(lexer.EOF) {
+	for !p.curTokenIs(lexer.END) && !p.curTokenIs(lexer.ELSE) && !p.curTokenIs(lexer.ELSEIF) && !p.curTokenIs(lexer.EOF) {
+		startToken := p.curToken
 		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+		if stmt == nil {
+			p.synchronize(startToken, lexer.END, lexer.ELSE, lexer.ELSEIF)
+			continue
 		}
+		block.Statements = append(block.Statements, stmt)
 		p.nextToken()
 	}
 
+	p.recordBlock(block, first)
 	return block
 }
 
@@ -906,6 +1524,43 @@ func (p *Parser) parseWhileStatement() *ast.WhileStatement {
 	return stmt
 }
 
+func (p *Parser) parseRepeatStatement() *ast.RepeatStatement {
+	stmt := &ast.RepeatStatement{Token: p.curToken}
+	stmt.Body = &ast.BlockStatement{
+		Token:      p.curToken,
+		Statements: []ast.Statement{},
+	}
+
+	p.nextToken()
+	first := p.curToken
+
+	// Parse body (stops at 'until')
+	for !p.curTokenIs(lexer.UNTIL) && !p.curTokenIs(lexer.EOF) {
+		startToken := p.curToken
+		s := p.parseStatement()
+		if s == nil {
+			p.synchronize(startToken, lexer.UNTIL)
+			continue
+		}
+		stmt.Body.Statements = append(stmt.Body.Statements, s)
+		p.nextToken()
+	}
+	p.recordBlock(stmt.Body, first)
+
+	if !p.curTokenIs(lexer.UNTIL) {
+		msg := fmt.Sprintf("expected until to close repeat at line %d, column %d", stmt.Token.Line, stmt.Token.Column)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	p.nextToken() // move to condition
+
+	// Parse condition
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	return stmt
+}
+
 func (p *Parser) parseForStatement() *ast.ForStatement {
 	stmt := &ast.ForStatement{Token: p.curToken}
 
@@ -970,6 +1625,11 @@ func (p *Parser) parseDoStatement() *ast.DoStatement {
 	return stmt
 }
 
+// parseDoExpression parses a do ... end block in expression position
+func (p *Parser) parseDoExpression() ast.Expression {
+	return &ast.DoExpression{Token: p.curToken, Body: p.parseBlockStatement()}
+}
+
 func (p *Parser) parseBreakStatement() *ast.BreakStatement {
 	return &ast.BreakStatement{Token: p.curToken}
 }
@@ -1055,8 +1715,22 @@ func (p *Parser) parseClassDeclaration() *ast.ClassDeclaration {
 		class.GenericParams = p.parseGenericParameters()
 	}
 
-	// Parse implements clause
-	if p.peekTokenIs(lexer.IMPLEMENTS) {
+	// Parse the extends and implements clauses, which may come in either order
+	for p.peekTokenIs(lexer.EXTENDS) || p.peekTokenIs(lexer.IMPLEMENTS) {
+		if p.peekTokenIs(lexer.EXTENDS) {
+			p.nextToken() // consume 'extends'
+			if class.Extends != nil {
+				msg := fmt.Sprintf("Class %s can only extend one class at line %d, column %d",
+					class.Name.Value, p.curToken.Line, p.curToken.Column)
+				p.errors = append(p.errors, msg)
+			}
+			if !p.expectPeek(lexer.IDENT) {
+				return nil
+			}
+			class.Extends = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			continue
+		}
+
 		p.nextToken() // consume 'implements'
 		p.nextToken() // move to first interface
 
@@ -1081,12 +1755,18 @@ func (p *Parser) parseClassDeclaration() *ast.ClassDeclaration {
 	// Parse class body
 	for !p.curTokenIs(lexer.END) && !p.curTokenIs(lexer.EOF) {
 		switch p.curToken.Type {
-		case lexer.PUBLIC, lexer.PRIVATE:
-			// Property or method with visibility
+		case lexer.PUBLIC, lexer.PRIVATE, lexer.PROTECTED:
+			// Property, method or constructor with visibility
 			visibility := p.curToken.Literal
 			p.nextToken()
 
-			if p.curTokenIs(lexer.IDENT) && p.peekTokenIs(lexer.COLON) {
+			if p.curTokenIs(lexer.CONSTRUCTOR) {
+				class.Constructor = p.parseConstructorDeclaration()
+				if class.Constructor != nil {
+					class.Constructor.Visibility = visibility
+				}
+				p.nextToken()
+			} else if p.atPropertyDeclaration() {
 				// It's a property
 				prop := p.parsePropertyDeclaration()
 				prop.Visibility = visibility
@@ -1094,7 +1774,11 @@ func (p *Parser) parseClassDeclaration() *ast.ClassDeclaration {
 			} else if p.curTokenIs(lexer.IDENT) && p.peekTokenIs(lexer.LPAREN) {
 				// It's a method
 				method := p.parseMethodDeclaration()
+				if method != nil {
+					method.Visibility = visibility
+				}
 				class.Methods = append(class.Methods, method)
+				p.nextToken() // move past the method's 'end'
 			} else {
 				p.nextToken()
 			}
@@ -1105,7 +1789,7 @@ func (p *Parser) parseClassDeclaration() *ast.ClassDeclaration {
 
 		case lexer.IDENT:
 			// Property without visibility modifier
-			if p.peekTokenIs(lexer.COLON) {
+			if p.atPropertyDeclaration() {
 				prop := p.parsePropertyDeclaration()
 				class.Properties = append(class.Properties, prop)
 			} else {
@@ -1121,9 +1805,46 @@ func (p *Parser) parseClassDeclaration() *ast.ClassDeclaration {
 }
 
 func (p *Parser) parsePropertyDeclaration() *ast.PropertyDeclaration {
+	prop := p.parsePropertySignature()
+	if prop == nil {
+		return nil
+	}
+
+	p.nextToken() // move past type
+	return prop
+}
+
+// atPropertyDeclaration reports whether the current identifier begins a
+// property: "name:", "name?:" or "readonly name..."
+func (p *Parser) atPropertyDeclaration() bool {
+	if !p.curTokenIs(lexer.IDENT) {
+		return false
+	}
+	if p.curToken.Literal == "readonly" && p.peekTokenIs(lexer.IDENT) {
+		return true
+	}
+	return p.peekTokenIs(lexer.COLON) || p.peekTokenIs(lexer.QUESTION)
+}
+
+// parsePropertySignature parses [readonly] name[?]: Type, leaving curToken on
+// the last token of the type. readonly is only a modifier in this position,
+// so it stays usable as an ordinary identifier elsewhere
+func (p *Parser) parsePropertySignature() *ast.PropertyDeclaration {
+	readonly := false
+	if p.curToken.Literal == "readonly" && p.peekTokenIs(lexer.IDENT) {
+		readonly = true
+		p.nextToken() // move to property name
+	}
+
 	prop := &ast.PropertyDeclaration{
-		Token: p.curToken,
-		Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		Token:    p.curToken,
+		Name:     &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		Readonly: readonly,
+	}
+
+	if p.peekTokenIs(lexer.QUESTION) {
+		p.nextToken() // consume '?'
+		prop.Optional = true
 	}
 
 	// Expect colon
@@ -1134,7 +1855,6 @@ func (p *Parser) parsePropertyDeclaration() *ast.PropertyDeclaration {
 	p.nextToken() // move to type
 	prop.Type = p.parseType()
 
-	p.nextToken() // move past type
 	return prop
 }
 
@@ -1149,12 +1869,13 @@ func (p *Parser) parseMethodDeclaration() *ast.FunctionDeclaration {
 		return nil
 	}
 	method.Parameters = p.parseFunctionParameters()
+	p.rejectParameterProperties(method.Parameters)
 
 	// Parse return type
 	if p.peekTokenIs(lexer.COLON) {
 		p.nextToken() // consume ':'
 		p.nextToken() // move to return type
-		method.ReturnType = p.parseType()
+		method.ReturnType = p.parseReturnType()
 	}
 
 	// Parse body
@@ -1218,8 +1939,14 @@ func (p *Parser) parseInterfaceDeclaration() *ast.InterfaceDeclaration {
 
 	// Parse interface body
 	for !p.curTokenIs(lexer.END) && !p.curTokenIs(lexer.EOF) {
-		if p.curTokenIs(lexer.IDENT) {
-			if p.peekTokenIs(lexer.COLON) {
+		if p.curTokenIs(lexer.LBRACKET) {
+			// Index signature
+			if sig := p.parseIndexSignature(); sig != nil {
+				iface.IndexSignatures = append(iface.IndexSignatures, sig)
+			}
+			p.nextToken() // move past value type
+		} else if p.curTokenIs(lexer.IDENT) {
+			if p.atPropertyDeclaration() {
 				// Property
 				prop := p.parsePropertyDeclaration()
 				iface.Properties = append(iface.Properties, prop)
@@ -1238,6 +1965,34 @@ func (p *Parser) parseInterfaceDeclaration() *ast.InterfaceDeclaration {
 	return iface
 }
 
+// parseIndexSignature parses [name: KeyType]: ValueType, leaving curToken on
+// the last token of the value type
+func (p *Parser) parseIndexSignature() *ast.IndexSignature {
+	sig := &ast.IndexSignature{Token: p.curToken}
+
+	if !p.expectPeek(lexer.IDENT) {
+		return nil
+	}
+	sig.KeyName = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(lexer.COLON) {
+		return nil
+	}
+	p.nextToken() // move to key type
+	sig.KeyType = p.parseType()
+
+	if !p.expectPeek(lexer.RBRACKET) {
+		return nil
+	}
+	if !p.expectPeek(lexer.COLON) {
+		return nil
+	}
+	p.nextToken() // move to value type
+	sig.ValueType = p.parseType()
+
+	return sig
+}
+
 func (p *Parser) parseInterfaceMethod() *ast.InterfaceMethod {
 	method := &ast.InterfaceMethod{
 		Token: p.curToken,
@@ -1249,18 +2004,51 @@ func (p *Parser) parseInterfaceMethod() *ast.InterfaceMethod {
 		return nil
 	}
 	method.Parameters = p.parseFunctionParameters()
+	p.rejectParameterProperties(method.Parameters)
 
 	// Parse return type
 	if p.peekTokenIs(lexer.COLON) {
 		p.nextToken() // consume ':'
 		p.nextToken() // move to return type
-		method.ReturnType = p.parseType()
+		method.ReturnType = p.parseReturnType()
+	}
+
+	// A body after the signature would otherwise be misread as further
+	// members; report it once and skip over it
+	if p.interfaceMethodHasBody() {
+		msg := fmt.Sprintf("Interface methods cannot have a body at line %d, column %d",
+			p.peekToken.Line, p.peekToken.Column)
+		p.errors = append(p.errors, msg)
+		p.parseBlockStatement() // consume the body up to its 'end'
 	}
 
 	p.nextToken() // move past method signature
 	return method
 }
 
+// interfaceMethodHasBody reports whether the tokens after an interface method
+// signature look like a function body rather than the next member
+func (p *Parser) interfaceMethodHasBody() bool {
+	if statementStarts[p.peekToken.Type] || p.peekTokenIs(lexer.SELF) {
+		return true
+	}
+	if p.peekTokenIs(lexer.IDENT) {
+		// Members start with "name:" or "name("
+		next := p.peekSecondToken().Type
+		return next != lexer.COLON && next != lexer.LPAREN
+	}
+	return false
+}
+
+// peekSecondToken returns the token after peekToken without consuming it
+func (p *Parser) peekSecondToken() lexer.Token {
+	if len(p.pending) > 0 {
+		return p.pending[0]
+	}
+	l := *p.l
+	return l.NextToken()
+}
+
 func (p *Parser) parseEnumDeclaration() *ast.EnumDeclaration {
 	enum := &ast.EnumDeclaration{
 		Token:   p.curToken,
@@ -1299,6 +2087,18 @@ func (p *Parser) parseEnumDeclaration() *ast.EnumDeclaration {
 	return enum
 }
 
+// parseConstEnumDeclaration parses const enum Name ... end
+func (p *Parser) parseConstEnumDeclaration() *ast.EnumDeclaration {
+	p.nextToken() // move to 'enum'
+
+	enum := p.parseEnumDeclaration()
+	if enum == nil {
+		return nil
+	}
+	enum.IsConst = true
+	return enum
+}
+
 func (p *Parser) parseTypeDeclaration() *ast.TypeDeclaration {
 	typeDecl := &ast.TypeDeclaration{
 		Token: p.curToken,
@@ -1327,18 +2127,17 @@ func (p *Parser) parseTypeDeclaration() *ast.TypeDeclaration {
 		// Object shape: type Name { properties } end
 		// Parse properties similar to interface
 		for !p.curTokenIs(lexer.END) && !p.curTokenIs(lexer.EOF) {
-			if p.curTokenIs(lexer.IDENT) {
-				prop := &ast.PropertyDeclaration{
-					Token: p.curToken,
-					Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+			if p.curTokenIs(lexer.LBRACKET) {
+				sig := p.parseIndexSignature()
+				if sig == nil {
+					return nil
 				}
-
-				if !p.expectPeek(lexer.COLON) {
+				typeDecl.IndexSignatures = append(typeDecl.IndexSignatures, sig)
+			} else if p.curTokenIs(lexer.IDENT) {
+				prop := p.parsePropertySignature()
+				if prop == nil {
 					return nil
 				}
-
-				p.nextToken() // move to type
-				prop.Type = p.parseType()
 				typeDecl.Properties = append(typeDecl.Properties, prop)
 			}
 			p.nextToken()
@@ -1353,6 +2152,52 @@ func (p *Parser) parseExportStatement() *ast.ExportStatement {
 		Token: p.curToken,
 	}
 
+	// Re-export: export * from "module"
+	if p.peekTokenIs(lexer.ASTERISK) {
+		p.nextToken() // move to '*'
+		exportStmt.IsWildcard = true
+
+		if !p.expectPeek(lexer.FROM) {
+			return nil
+		}
+		if !p.expectPeek(lexer.STRING) {
+			return nil
+		}
+		exportStmt.Module = p.curToken.Literal
+
+		return exportStmt
+	}
+
+	// Default export: export default function(...) ... end or
+	// export default class ... end
+	if p.peekTokenIs(lexer.IDENT) && p.peekToken.Literal == "default" {
+		p.nextToken() // move to 'default'
+		exportStmt.IsDefault = true
+
+		if !p.peekTokenIs(lexer.FUNCTION) && !p.peekTokenIs(lexer.CLASS) {
+			msg := fmt.Sprintf("Expected a function or class after 'export default' at line %d, column %d",
+				p.peekToken.Line, p.peekToken.Column)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		p.nextToken() // move to 'function' or 'class'
+
+		// An anonymous declaration is given an internal name, so it parses
+		// and binds like a named one
+		if !p.peekTokenIs(lexer.IDENT) {
+			p.pending = append([]lexer.Token{p.peekToken}, p.pending...)
+			p.peekToken = lexer.Token{
+				Type:    lexer.IDENT,
+				Literal: ast.DefaultExportName,
+				Line:    p.curToken.Line,
+				Column:  p.curToken.Column,
+			}
+		}
+
+		exportStmt.Statement = p.parseStatement()
+		return exportStmt
+	}
+
 	p.nextToken() // move past 'export'
 
 	// Parse the statement being exported
@@ -1368,6 +2213,17 @@ func (p *Parser) parseImportStatement() *ast.ImportStatement {
 
 	p.nextToken() // move past 'import'
 
+	// Type-only import: import type { Name } from "module"
+	if p.curTokenIs(lexer.TYPE) {
+		importStmt.IsTypeOnly = true
+		p.nextToken() // move past 'type'
+
+		if !p.curTokenIs(lexer.LBRACE) {
+			p.errors = append(p.errors, "expected '{' after 'import type'")
+			return nil
+		}
+	}
+
 	// Check for wildcard import (import * from "module")
 	if p.curTokenIs(lexer.ASTERISK) {
 		importStmt.IsWildcard = true
@@ -1451,9 +2307,50 @@ func (p *Parser) parseDeclareStatement() *ast.DeclareStatement {
 	return declareStmt
 }
 
-// parseGenericParameters parses generic type parameters: <T, U, V>
-func (p *Parser) parseGenericParameters() []*ast.Identifier {
-	params := []*ast.Identifier{}
+// parseReturnType parses a function's return type, which may be a type
+// predicate (x is Dog). is is only a keyword in this position
+func (p *Parser) parseReturnType() ast.Expression {
+	if p.curTokenIs(lexer.IDENT) && p.peekTokenIs(lexer.IDENT) && p.peekToken.Literal == "is" {
+		predicate := &ast.TypePredicate{
+			Token:     p.curToken,
+			Parameter: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		}
+		p.nextToken() // move to 'is'
+		p.nextToken() // move to the guarded type
+		predicate.Type = p.parseType()
+		return predicate
+	}
+	return p.parseType()
+}
+
+// parseTypeArguments parses the type arguments of a generic type after its
+// '<', leaving curToken on the closing '>'. The list may be empty (Box<>) to
+// use every parameter's default
+func (p *Parser) parseTypeArguments() []ast.Expression {
+	typeArgs := []ast.Expression{}
+	if p.peekTokenIs(lexer.GT) {
+		p.nextToken() // move to '>'
+		return typeArgs
+	}
+
+	p.nextToken() // move to first type argument
+	typeArgs = append(typeArgs, p.parseType())
+
+	for p.peekTokenIs(lexer.COMMA) {
+		p.nextToken() // consume comma
+		p.nextToken() // move to next type
+		typeArgs = append(typeArgs, p.parseType())
+	}
+
+	if !p.expectClosingAngle() {
+		return nil
+	}
+	return typeArgs
+}
+
+// parseGenericParameters parses generic type parameters: <T, U = string>
+func (p *Parser) parseGenericParameters() []*ast.GenericParameter {
+	params := []*ast.GenericParameter{}
 
 	p.nextToken() // move past '<' to first parameter
 
@@ -1463,13 +2360,33 @@ func (p *Parser) parseGenericParameters() []*ast.Identifier {
 			return nil
 		}
 
-		params = append(params, &ast.Identifier{
+		param := &ast.GenericParameter{
 			Token: p.curToken,
-			Value: p.curToken.Literal,
-		})
+			Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		}
 
 		p.nextToken()
 
+		// Constraint: T extends Type
+		if p.curTokenIs(lexer.EXTENDS) {
+			p.nextToken() // move to constraint type
+			param.Constraint = p.parseType()
+			p.nextToken() // move past constraint type
+		}
+
+		// Default type: T = Type
+		if p.curTokenIs(lexer.ASSIGN) {
+			p.nextToken() // move to default type
+			param.Default = p.parseType()
+			p.nextToken() // move past default type
+		} else if len(params) > 0 && params[len(params)-1].Default != nil {
+			msg := fmt.Sprintf("Required type parameter '%s' cannot follow a parameter with a default at line %d, column %d",
+				param.Name.Value, param.Token.Line, param.Token.Column)
+			p.errors = append(p.errors, msg)
+		}
+
+		params = append(params, param)
+
 		if p.curTokenIs(lexer.COMMA) {
 			p.nextToken() // move past comma to next parameter
 		}
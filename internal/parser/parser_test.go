@@ -57,6 +57,102 @@ func TestNumberLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestHexAndBinaryNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"0xff", 255},
+		{"0X1A", 26},
+		{"0b101", 5},
+		{"0B0", 0},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+
+		literal := p.parseNumberLiteral()
+		number, ok := literal.(*ast.NumberLiteral)
+		if !ok {
+			t.Fatalf("literal not *ast.NumberLiteral for %q. got=%T (errors: %v)", tt.input, literal, p.Errors())
+		}
+		if number.Value != tt.expected {
+			t.Errorf("value of %q wrong. expected=%f, got=%f", tt.input, tt.expected, number.Value)
+		}
+		if number.TokenLiteral() != tt.input {
+			t.Errorf("TokenLiteral wrong. expected=%q, got=%q", tt.input, number.TokenLiteral())
+		}
+	}
+}
+
+func TestMalformedHexNumberLiteral(t *testing.T) {
+	l := lexer.New("0x")
+	p := New(l)
+	p.Parse()
+
+	if len(p.Errors()) == 0 {
+		t.Fatal("expected an error for a hex literal without digits")
+	}
+}
+
+func TestDigitSeparatorNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1_000_000", 1000000},
+		{"3.141_592", 3.141592},
+		{"1_0e1_0", 1e11},
+		{"0xFF_FF", 65535},
+		{"0b1010_1010", 170},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+
+		literal := p.parseNumberLiteral()
+		number, ok := literal.(*ast.NumberLiteral)
+		if !ok {
+			t.Fatalf("literal not *ast.NumberLiteral for %q. got=%T (errors: %v)", tt.input, literal, p.Errors())
+		}
+		if number.Value != tt.expected {
+			t.Errorf("value of %q wrong. expected=%f, got=%f", tt.input, tt.expected, number.Value)
+		}
+	}
+}
+
+func TestMalformedDigitSeparators(t *testing.T) {
+	for _, input := range []string{"1__0", "1_", "1_000_", "0x_FF", "0b_1", "1_.5"} {
+		l := lexer.New("local x = " + input)
+		p := New(l)
+		p.Parse()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected an error for %q", input)
+			continue
+		}
+		if !strings.Contains(p.Errors()[0], "Invalid digit separator") {
+			t.Errorf("expected a digit separator error for %q, got %q", input, p.Errors()[0])
+		}
+	}
+}
+
+func TestLexerErrorsAreReported(t *testing.T) {
+	l := lexer.New(`local s = "bad \x escape"`)
+	p := New(l)
+	p.Parse()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", errors)
+	}
+	if !strings.Contains(errors[0], "Invalid escape sequence") {
+		t.Errorf("expected the lexer's escape error, got %q", errors[0])
+	}
+}
+
 func TestStringLiteralExpression(t *testing.T) {
 	input := `"hello world";`
 
@@ -124,6 +220,57 @@ func TestOperatorPrecedence(t *testing.T) {
 	}
 }
 
+func TestBitwiseOperatorPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a | b ~ c & d", "(a | (b ~ (c & d)))"},
+		{"a & b << 2", "(a & (b << 2))"},
+		{"a << b + 1", "(a << (b + 1))"},
+		{"a >> 1 >> 2", "((a >> 1) >> 2)"},
+		{"a | b == c", "((a | b) == c)"},
+		{"x & 0xFF ~= 0", "((x & 0xFF) ~= 0)"},
+		{"~a & b", "((~a) & b)"},
+		{"a ~ ~b", "(a ~ (~b))"},
+		{"a ~= b", "(a ~= b)"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		expression := p.parseExpression(LOWEST)
+
+		if expression == nil {
+			t.Fatalf("tests[%d] - parseExpression() returned nil", i)
+		}
+		if len(p.Errors()) > 0 {
+			t.Fatalf("tests[%d] - parser errors: %v", i, p.Errors())
+		}
+
+		actual := expression.String()
+		if actual != tt.expected {
+			t.Errorf("tests[%d] - expected=%q, got=%q", i, tt.expected, actual)
+		}
+	}
+}
+
+func TestNestedTypeArgumentsAfterShiftToken(t *testing.T) {
+	l := lexer.New("local m: table<string, Array<number>> = {}\nlocal n: number = 1 >> 2")
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+	if statements[1].String() != "local n: number = (1 >> 2)" {
+		t.Errorf("unexpected shift statement %q", statements[1].String())
+	}
+}
+
 func TestPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -237,6 +384,165 @@ func TestCallExpressions(t *testing.T) {
 	}
 }
 
+func TestFunctionLiteralArgument(t *testing.T) {
+	input := `map(items, function(x: number): number return x * 2 end)`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got=%d", len(statements))
+	}
+
+	stmt, ok := statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ExpressionStatement, got=%T", statements[0])
+	}
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("expected *ast.CallExpression, got=%T", stmt.Expression)
+	}
+	if len(call.Arguments) != 2 {
+		t.Fatalf("expected 2 arguments, got=%d", len(call.Arguments))
+	}
+
+	fn, ok := call.Arguments[1].(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("expected *ast.FunctionLiteral, got=%T", call.Arguments[1])
+	}
+	expected := "function(x: number): number\n    return (x * 2)\nend"
+	if fn.String() != expected {
+		t.Errorf("expected=%q, got=%q", expected, fn.String())
+	}
+}
+
+func TestFunctionLiteralValue(t *testing.T) {
+	input := `local add = function(a, b)
+    return a + b
+end
+local n = add(1, 2)`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got=%d", len(statements))
+	}
+
+	decl, ok := statements[0].(*ast.VariableDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.VariableDeclaration, got=%T", statements[0])
+	}
+	fn, ok := decl.Value.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("expected *ast.FunctionLiteral, got=%T", decl.Value)
+	}
+	if len(fn.Parameters) != 2 || fn.ReturnType != nil {
+		t.Errorf("expected 2 parameters and no return type, got=%q", fn.String())
+	}
+}
+
+func TestDoExpression(t *testing.T) {
+	input := `local x = do local t = compute(); return t + 1 end
+do print(x) end`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got=%d", len(statements))
+	}
+
+	decl, ok := statements[0].(*ast.VariableDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.VariableDeclaration, got=%T", statements[0])
+	}
+	block, ok := decl.Value.(*ast.DoExpression)
+	if !ok {
+		t.Fatalf("expected *ast.DoExpression, got=%T", decl.Value)
+	}
+	if len(block.Body.Statements) != 2 {
+		t.Fatalf("expected 2 statements in the block, got=%d", len(block.Body.Statements))
+	}
+	if _, ok := block.Body.Statements[1].(*ast.ReturnStatement); !ok {
+		t.Errorf("expected the block to end with *ast.ReturnStatement, got=%T", block.Body.Statements[1])
+	}
+
+	// A do block in statement position is still a statement
+	if _, ok := statements[1].(*ast.DoStatement); !ok {
+		t.Errorf("expected *ast.DoStatement, got=%T", statements[1])
+	}
+}
+
+func TestBareReturnStatement(t *testing.T) {
+	input := `
+function stop(done: boolean): void
+	if done then
+		return
+	elseif not done then
+		return
+	else
+		return
+	end
+	repeat
+		return
+	until done
+	return
+end
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	fn, ok := statements[0].(*ast.FunctionDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.FunctionDeclaration, got=%T", statements[0])
+	}
+	if len(fn.Body.Statements) != 3 {
+		t.Fatalf("expected 3 statements in the body, got=%d", len(fn.Body.Statements))
+	}
+	ret, ok := fn.Body.Statements[2].(*ast.ReturnStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ReturnStatement, got=%T", fn.Body.Statements[2])
+	}
+	if ret.ReturnValue != nil || ret.ReturnValues != nil {
+		t.Errorf("expected a bare return, got=%q", ret.String())
+	}
+}
+
+func TestSemicolonSeparatedStatements(t *testing.T) {
+	input := `local a = 1; local b = 2;
+a = b; print(a)`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(statements) != 4 {
+		t.Fatalf("expected 4 statements, got=%d", len(statements))
+	}
+}
+
 func TestDotExpressionCalls(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -312,6 +618,116 @@ func TestVariableDeclaration(t *testing.T) {
 	}
 }
 
+func TestMultiLineBinaryExpression(t *testing.T) {
+	l := lexer.New("local x = a +\n b")
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(statements))
+	}
+
+	decl, ok := statements[0].(*ast.VariableDeclaration)
+	if !ok {
+		t.Fatalf("statement is not *ast.VariableDeclaration. got=%T", statements[0])
+	}
+	infix, ok := decl.Value.(*ast.InfixExpression)
+	if !ok {
+		t.Fatalf("value is not *ast.InfixExpression. got=%T", decl.Value)
+	}
+	if infix.String() != "(a + b)" {
+		t.Errorf("expected (a + b), got %s", infix.String())
+	}
+}
+
+func TestMultiLineStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"local x = a\n\t* b\n\t+ c\nlocal y = 1", []string{"local x = ((a * b) + c)", "local y = 1"}},
+		{"x =\n\ty\ny = 2", []string{"x = y", "y = 2"}},
+		{"t\n\t.field\n\t= 1", []string{"t.field = 1"}},
+		{"local ok = a and\n\tb or\n\tc", []string{"local ok = ((a and b) or c)"}},
+		{"f(a,\n\tb)\ng()", []string{"f(a, b)", "g()"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Errorf("%q: parser errors: %v", tt.input, p.Errors())
+			continue
+		}
+		if len(statements) != len(tt.expected) {
+			t.Errorf("%q: expected %d statements, got %d", tt.input, len(tt.expected), len(statements))
+			continue
+		}
+		for i, stmt := range statements {
+			if stmt.String() != tt.expected[i] {
+				t.Errorf("%q: statement %d expected=%q, got=%q", tt.input, i, tt.expected[i], stmt.String())
+			}
+		}
+	}
+}
+
+func TestMultipleAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		names    int
+		values   int
+	}{
+		{"a, b = b, a", "a, b = b, a", 2, 2},
+		{"t.x, t[1], y = 1, 2 + 3, f()", "t.x, t[1], y = 1, (2 + 3), f()", 3, 3},
+		{"a, b = 1", "a, b = 1", 2, 1},
+		{"a = 1, 2", "a = 1, 2", 1, 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Errorf("%q: parser errors: %v", tt.input, p.Errors())
+			continue
+		}
+		if len(statements) != 1 {
+			t.Errorf("%q: expected 1 statement, got %d", tt.input, len(statements))
+			continue
+		}
+
+		stmt, ok := statements[0].(*ast.AssignmentStatement)
+		if !ok {
+			t.Errorf("%q: expected *ast.AssignmentStatement, got=%T", tt.input, statements[0])
+			continue
+		}
+		if len(stmt.Names) != tt.names || len(stmt.Values) != tt.values {
+			t.Errorf("%q: expected %d names and %d values, got %d and %d",
+				tt.input, tt.names, tt.values, len(stmt.Names), len(stmt.Values))
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestMultipleAssignmentWithoutValues(t *testing.T) {
+	l := lexer.New("a, b\nlocal c = 1")
+	p := New(l)
+	p.Parse()
+
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected a parser error for a target list without '='")
+	}
+}
+
 func TestFunctionDeclaration(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -331,6 +747,30 @@ end`,
 end`,
 			`function greet(name: string)
     return ("Hello, " .. name)
+end`,
+		},
+		{
+			`function divmod(a: number, b: number): (number, number)
+    return a // b, a % b
+end`,
+			`function divmod(a: number, b: number): (number, number)
+    return (a // b), (a % b)
+end`,
+		},
+		{
+			`function log(level: string, ...: any)
+    print(level, ...)
+end`,
+			`function log(level: string, ...: any)
+    print(level, ...)
+end`,
+		},
+		{
+			`function count(...)
+    return select("#", ...)
+end`,
+			`function count(...)
+    return select("#", ...)
 end`,
 		},
 	}
@@ -351,6 +791,40 @@ end`,
 	}
 }
 
+func TestVariadicParameter(t *testing.T) {
+	l := lexer.New(`function f(a, ...: number) end`)
+	p := New(l)
+	stmt := p.parseFunctionDeclaration()
+
+	if stmt == nil {
+		t.Fatalf("parseFunctionDeclaration() returned nil. Parser errors: %v", p.Errors())
+	}
+	if len(stmt.Parameters) != 2 {
+		t.Fatalf("expected 2 parameters, got=%d", len(stmt.Parameters))
+	}
+	if stmt.Parameters[0].IsVariadic {
+		t.Errorf("expected parameter a not to be variadic")
+	}
+	variadic := stmt.Parameters[1]
+	if !variadic.IsVariadic || variadic.Name.Value != "..." {
+		t.Errorf("expected a variadic ... parameter, got=%q", variadic.String())
+	}
+	if variadic.Type == nil || variadic.Type.String() != "number" {
+		t.Errorf("expected variadic element type number, got=%v", variadic.Type)
+	}
+}
+
+func TestVariadicParameterMustBeLast(t *testing.T) {
+	l := lexer.New(`function f(..., a) end`)
+	p := New(l)
+	p.Parse()
+
+	expected := "Variadic parameter must be the last parameter at line 1, column 12"
+	if len(p.Errors()) != 1 || p.Errors()[0] != expected {
+		t.Errorf("expected error %q, got=%v", expected, p.Errors())
+	}
+}
+
 func TestIfStatement(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -374,6 +848,26 @@ end`,
     return x
 else
     return 0
+end`,
+		},
+		{
+			`if x > 0 then
+    return 1
+elseif x < 0 then
+    return -1
+elseif x == 0 then
+    return 0
+else
+    return nil
+end`,
+			`if (x > 0) then
+    return 1
+elseif (x < 0) then
+    return (-1)
+elseif (x == 0) then
+    return 0
+else
+    return nil
 end`,
 		},
 	}
@@ -394,15 +888,58 @@ end`,
 	}
 }
 
-func TestWhileStatement(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{
-			`while x > 0 do
-    x = x - 1
-end`,
+func TestElseIfChain(t *testing.T) {
+	input := `
+if a then
+	x = 1
+elseif b then
+	x = 2
+elseif c then
+	x = 3
+end
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got=%d", len(statements))
+	}
+
+	stmt, ok := statements[0].(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("expected *ast.IfStatement, got=%T", statements[0])
+	}
+	if stmt.Alternative != nil {
+		t.Errorf("expected no else branch, got=%q", stmt.Alternative.String())
+	}
+	if len(stmt.ElseIfs) != 2 {
+		t.Fatalf("expected 2 elseif branches, got=%d", len(stmt.ElseIfs))
+	}
+	for i, expected := range []string{"b", "c"} {
+		elseIf := stmt.ElseIfs[i]
+		if elseIf.Condition.String() != expected {
+			t.Errorf("elseif %d: expected condition %q, got=%q", i, expected, elseIf.Condition.String())
+		}
+		if len(elseIf.Consequence.Statements) != 1 {
+			t.Errorf("elseif %d: expected 1 statement, got=%d", i, len(elseIf.Consequence.Statements))
+		}
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`while x > 0 do
+    x = x - 1
+end`,
 			`while (x > 0) do
     x = (x - 1)
 end`,
@@ -425,6 +962,61 @@ end`,
 	}
 }
 
+func TestRepeatStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`repeat
+    x = x - 1
+until x <= 0`,
+			`repeat
+    x = (x - 1)
+until (x <= 0)`,
+		},
+		{
+			`repeat
+    local line = read()
+until line == nil`,
+			`repeat
+    local line = read()
+until (line == nil)`,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		stmt := p.parseRepeatStatement()
+
+		if stmt == nil {
+			t.Errorf("parseRepeatStatement() returned nil. Parser errors: %v", p.Errors())
+			continue
+		}
+
+		if stmt.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestRepeatWithoutUntil(t *testing.T) {
+	l := lexer.New(`repeat
+    x = x - 1
+`)
+	p := New(l)
+	p.Parse()
+
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected a parser error for repeat without until")
+	}
+	expected := "expected until to close repeat at line 1, column 1"
+	if p.Errors()[0] != expected {
+		t.Errorf("expected error %q, got=%q", expected, p.Errors()[0])
+	}
+}
+
 func TestForStatement(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -630,6 +1222,10 @@ func TestLogicalOperators(t *testing.T) {
 		{"true or false", "(true or false)"},
 		{"x > 0 and x < 10", "((x > 0) and (x < 10))"},
 		{"a or b and c", "(a or (b and c))"}, // and has higher precedence
+		{"a && b", "(a && b)"},
+		{"a || b", "(a || b)"},
+		{"a || b && c", "(a || (b && c))"}, // && binds like and
+		{"a and b || c", "((a and b) || c)"},
 	}
 
 	for _, tt := range tests {
@@ -665,6 +1261,31 @@ func TestModuloOperator(t *testing.T) {
 	}
 }
 
+func TestFloorDivisionOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"7 // 2", "(7 // 2)"},
+		{"a / b // c", "((a / b) // c)"},
+		{"a + b // c", "(a + (b // c))"},
+		{"a // b * c", "((a // b) * c)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		exp := p.parseExpression(LOWEST)
+
+		if exp == nil {
+			t.Fatalf("parseExpression() returned nil. Errors: %v", p.Errors())
+		}
+		if exp.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, exp.String())
+		}
+	}
+}
+
 func TestComplexTypes(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -690,6 +1311,10 @@ func TestComplexTypes(t *testing.T) {
 		{"local stack: Stack<number>", "local stack: Stack<number>"},
 		{"local map: Map<string, User>", "local map: Map<string, User>"},
 
+		// Range types
+		{"local percent: number<0, 100>", "local percent: number<0, 100>"},
+		{"local offset: int<-1, 1>", "local offset: int<-1, 1>"},
+
 		// Optional types (existing feature, but testing with complex types)
 		{"local users: User[]?", "local users: User[]?"},
 		{"local cache: table<string, number>?", "local cache: table<string, number>?"},
@@ -715,6 +1340,152 @@ func TestComplexTypes(t *testing.T) {
 	}
 }
 
+func TestArrayOfNonIdentifierTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		element  string
+	}{
+		{"local handlers: (() => void)[]", "local handlers: (() => void)[]", "*ast.FunctionType"},
+		{"local maps: table<string, number>[]", "local maps: table<string, number>[]", "*ast.TableType"},
+		{"local ids: (string | number)[]", "local ids: (string | number)[]", "*ast.UnionType"},
+		{"local grid: table<string, number>[][]", "local grid: table<string, number>[][]", "*ast.ArrayType"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		stmt := p.parseVariableDeclaration()
+
+		if stmt == nil {
+			t.Errorf("parseVariableDeclaration() returned nil for input %q. Errors: %v", tt.input, p.Errors())
+			continue
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("input=%q: expected=%q, got=%q", tt.input, tt.expected, stmt.String())
+		}
+
+		array, ok := stmt.Type.(*ast.ArrayType)
+		if !ok {
+			t.Errorf("input=%q: expected *ast.ArrayType, got=%T", tt.input, stmt.Type)
+			continue
+		}
+		if element := fmt.Sprintf("%T", array.ElementType); element != tt.element {
+			t.Errorf("input=%q: expected element type %s, got=%s", tt.input, tt.element, element)
+		}
+	}
+}
+
+func TestIntersectionTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		members  int
+	}{
+		{"local p: Named & Aged", "local p: Named & Aged", 2},
+		{"local p: A & B & C", "local p: A & B & C", 3},
+		{"local p: Named & { age: number }", "local p: Named & { age: number }", 2},
+		{"local p: User[] & Tagged?", "local p: User[] & Tagged?", 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		stmt := p.parseVariableDeclaration()
+
+		if stmt == nil {
+			t.Errorf("parseVariableDeclaration() returned nil for input %q. Errors: %v", tt.input, p.Errors())
+			continue
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("input=%q: expected=%q, got=%q", tt.input, tt.expected, stmt.String())
+		}
+
+		intersection, ok := stmt.Type.(*ast.IntersectionType)
+		if !ok {
+			t.Errorf("input=%q: expected *ast.IntersectionType, got=%T", tt.input, stmt.Type)
+			continue
+		}
+		if len(intersection.Types) != tt.members {
+			t.Errorf("input=%q: expected %d members, got=%d", tt.input, tt.members, len(intersection.Types))
+		}
+	}
+}
+
+func TestIntersectionBindsTighterThanUnion(t *testing.T) {
+	for _, input := range []string{"local p: A & B | C", "local p: C | A & B"} {
+		l := lexer.New(input)
+		p := New(l)
+		stmt := p.parseVariableDeclaration()
+		if stmt == nil {
+			t.Fatalf("parseVariableDeclaration() returned nil for input %q. Errors: %v", input, p.Errors())
+		}
+
+		union, ok := stmt.Type.(*ast.UnionType)
+		if !ok {
+			t.Fatalf("input=%q: expected *ast.UnionType, got=%T", input, stmt.Type)
+		}
+		if len(union.Types) != 2 {
+			t.Fatalf("input=%q: expected 2 union members, got=%d", input, len(union.Types))
+		}
+
+		found := false
+		for _, member := range union.Types {
+			if intersection, ok := member.(*ast.IntersectionType); ok {
+				found = true
+				if intersection.String() != "A & B" {
+					t.Errorf("input=%q: expected intersection A & B, got=%q", input, intersection.String())
+				}
+			}
+		}
+		if !found {
+			t.Errorf("input=%q: expected an intersection among the union members", input)
+		}
+	}
+}
+
+func TestReadonlyArrayTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"local xs: readonly number[]", "readonly number[]"},
+		{"local xs: readonly number[] | nil", "readonly number[] | nil"},
+		{"local xs: string | readonly number[]", "string | readonly number[]"},
+		{"local xs: readonly Dog[]?", "readonly Dog[]?"},
+		{"local xs: readonly (string | number)[]", "readonly (string | number)[]"},
+		{"local xs: readonly", "readonly"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		stmt := p.parseVariableDeclaration()
+		if stmt == nil {
+			t.Fatalf("parseVariableDeclaration() returned nil for input %q. Errors: %v", tt.input, p.Errors())
+		}
+		if len(p.Errors()) > 0 {
+			t.Fatalf("input=%q: parser errors: %v", tt.input, p.Errors())
+		}
+		if stmt.Type.String() != tt.expected {
+			t.Errorf("input=%q: expected type %q, got=%q", tt.input, tt.expected, stmt.Type.String())
+		}
+	}
+}
+
+func TestReadonlyRequiresArrayType(t *testing.T) {
+	l := lexer.New("local xs: readonly number")
+	p := New(l)
+	p.Parse()
+
+	if len(p.Errors()) == 0 {
+		t.Fatal("expected an error for readonly on a non-array type")
+	}
+	if !strings.Contains(p.Errors()[0], "'readonly' can only be applied to array types") {
+		t.Errorf("unexpected error: %s", p.Errors()[0])
+	}
+}
+
 func TestFunctionTypes(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -764,6 +1535,13 @@ end`,
 end`,
 			`function getUser(id: number): User?
 
+end`,
+		},
+		{
+			`function makeUser(id: number): { id: number, name: string }
+end`,
+			`function makeUser(id: number): { id: number, name: string }
+
 end`,
 		},
 	}
@@ -827,6 +1605,106 @@ end`
 	}
 }
 
+func TestClassWithExtends(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedImplements []string
+	}{
+		{"class Dog extends Animal\nend", nil},
+		{"class Dog extends Animal implements Pet\nend", []string{"Pet"}},
+		{"class Dog implements Pet, Friend extends Animal\nend", []string{"Pet", "Friend"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, p.Errors())
+		}
+
+		if len(statements) != 1 {
+			t.Fatalf("%q: expected 1 statement, got=%d", tt.input, len(statements))
+		}
+
+		class, ok := statements[0].(*ast.ClassDeclaration)
+		if !ok {
+			t.Fatalf("%q: expected *ast.ClassDeclaration, got=%T", tt.input, statements[0])
+		}
+
+		parent, ok := class.Extends.(*ast.Identifier)
+		if !ok || parent.Value != "Animal" {
+			t.Errorf("%q: expected to extend Animal, got=%v", tt.input, class.Extends)
+		}
+
+		if len(class.Implements) != len(tt.expectedImplements) {
+			t.Fatalf("%q: expected %d implements, got=%d", tt.input, len(tt.expectedImplements), len(class.Implements))
+		}
+		for i, name := range tt.expectedImplements {
+			if class.Implements[i].String() != name {
+				t.Errorf("%q: implements[%d] wrong. expected=%s, got=%s", tt.input, i, name, class.Implements[i].String())
+			}
+		}
+	}
+}
+
+func TestClassExtendsOnlyOneClass(t *testing.T) {
+	input := "class Dog extends Animal extends Pet\nend"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.Parse()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got=%d: %v", len(errors), errors)
+	}
+	expected := "Class Dog can only extend one class at line 1, column 26"
+	if errors[0] != expected {
+		t.Errorf("error wrong. expected=%q, got=%q", expected, errors[0])
+	}
+}
+
+func TestMethodWithInlineShapeReturnType(t *testing.T) {
+	input := `class Repo
+    public find(id: number): { id: number
+                              name: string }
+        return { id = id, name = "user" }
+    end
+end`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got=%d", len(statements))
+	}
+
+	class, ok := statements[0].(*ast.ClassDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.ClassDeclaration, got=%T", statements[0])
+	}
+
+	if len(class.Methods) != 1 {
+		t.Fatalf("expected 1 method, got=%d", len(class.Methods))
+	}
+
+	shape, ok := class.Methods[0].ReturnType.(*ast.ObjectShapeType)
+	if !ok {
+		t.Fatalf("expected *ast.ObjectShapeType return type, got=%T", class.Methods[0].ReturnType)
+	}
+
+	if shape.String() != "{ id: number, name: string }" {
+		t.Errorf("shape wrong. expected=%q, got=%q", "{ id: number, name: string }", shape.String())
+	}
+}
+
 func TestInterfaceDeclaration(t *testing.T) {
 	input := `interface Vehicle
     brand: string
@@ -856,37 +1734,128 @@ end`
 	}
 }
 
-func TestInterfaceWithExtends(t *testing.T) {
-	input := `interface ElectricVehicle extends Vehicle
-    batteryLevel: number
-    charge(duration: number): void
+func TestInterfaceMethodWithBody(t *testing.T) {
+	input := `interface Vehicle
+    start(): void
+        self.running = true
+        if self.fast then
+            self.speed = 10
+        end
+    end
+    stop(): void
+    speed: number
 end`
 
 	l := lexer.New(input)
 	p := New(l)
-	stmt := p.parseInterfaceDeclaration()
+	statements := p.Parse()
 
-	if stmt == nil {
-		t.Fatalf("parseInterfaceDeclaration() returned nil. Errors: %v", p.Errors())
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 parser error, got=%d: %v", len(errors), errors)
 	}
 
-	if stmt.Name.Value != "ElectricVehicle" {
-		t.Errorf("interface name wrong. expected=ElectricVehicle, got=%s", stmt.Name.Value)
+	expected := "Interface methods cannot have a body at line 3, column 9"
+	if errors[0] != expected {
+		t.Errorf("error wrong. expected=%q, got=%q", expected, errors[0])
 	}
 
-	if len(stmt.Extends) != 1 {
-		t.Errorf("expected 1 parent, got=%d", len(stmt.Extends))
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got=%d", len(statements))
+	}
+
+	iface, ok := statements[0].(*ast.InterfaceDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.InterfaceDeclaration, got=%T", statements[0])
+	}
+
+	if len(iface.Methods) != 2 {
+		t.Errorf("expected 2 methods, got=%d", len(iface.Methods))
+	}
+
+	if len(iface.Properties) != 1 {
+		t.Errorf("expected 1 property, got=%d", len(iface.Properties))
 	}
 }
 
-func TestEnumDeclaration(t *testing.T) {
-	tests := []struct {
-		input           string
-		expectedName    string
-		expectedMembers int
-	}{
-		{
-			`enum Direction
+func TestReadonlyOptionalProperties(t *testing.T) {
+	input := `interface User
+    readonly id: number
+    nickname?: string
+    readonly name?: string
+end`
+
+	l := lexer.New(input)
+	p := New(l)
+	stmt := p.parseInterfaceDeclaration()
+
+	if stmt == nil {
+		t.Fatalf("parseInterfaceDeclaration() returned nil. Errors: %v", p.Errors())
+	}
+
+	tests := []struct {
+		name     string
+		readonly bool
+		optional bool
+		str      string
+	}{
+		{"id", true, false, "readonly id: number"},
+		{"nickname", false, true, "nickname?: string"},
+		{"name", true, true, "readonly name?: string"},
+	}
+
+	if len(stmt.Properties) != len(tests) {
+		t.Fatalf("expected %d properties, got=%d", len(tests), len(stmt.Properties))
+	}
+
+	for i, tt := range tests {
+		prop := stmt.Properties[i]
+		if prop.Name.Value != tt.name {
+			t.Errorf("property %d name wrong. expected=%s, got=%s", i, tt.name, prop.Name.Value)
+		}
+		if prop.Readonly != tt.readonly {
+			t.Errorf("property %s readonly wrong. expected=%t, got=%t", tt.name, tt.readonly, prop.Readonly)
+		}
+		if prop.Optional != tt.optional {
+			t.Errorf("property %s optional wrong. expected=%t, got=%t", tt.name, tt.optional, prop.Optional)
+		}
+		if prop.String() != tt.str {
+			t.Errorf("property %s String() wrong. expected=%q, got=%q", tt.name, tt.str, prop.String())
+		}
+	}
+}
+
+func TestInterfaceWithExtends(t *testing.T) {
+	input := `interface ElectricVehicle extends Vehicle
+    batteryLevel: number
+    charge(duration: number): void
+end`
+
+	l := lexer.New(input)
+	p := New(l)
+	stmt := p.parseInterfaceDeclaration()
+
+	if stmt == nil {
+		t.Fatalf("parseInterfaceDeclaration() returned nil. Errors: %v", p.Errors())
+	}
+
+	if stmt.Name.Value != "ElectricVehicle" {
+		t.Errorf("interface name wrong. expected=ElectricVehicle, got=%s", stmt.Name.Value)
+	}
+
+	if len(stmt.Extends) != 1 {
+		t.Errorf("expected 1 parent, got=%d", len(stmt.Extends))
+	}
+}
+
+func TestEnumDeclaration(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedName    string
+		expectedMembers int
+	}{
+		{
+			`enum Direction
     North
     South
     East
@@ -926,6 +1895,42 @@ end`,
 	}
 }
 
+func TestConstEnumDeclaration(t *testing.T) {
+	input := `const enum Color
+    Red
+    Green
+end`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got=%d", len(statements))
+	}
+
+	enum, ok := statements[0].(*ast.EnumDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.EnumDeclaration, got=%T", statements[0])
+	}
+
+	if !enum.IsConst {
+		t.Error("expected IsConst to be true")
+	}
+
+	if enum.Name.Value != "Color" {
+		t.Errorf("enum name wrong. expected=Color, got=%s", enum.Name.Value)
+	}
+
+	if len(enum.Members) != 2 {
+		t.Errorf("expected 2 members, got=%d", len(enum.Members))
+	}
+}
+
 func TestTypeDeclaration(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -952,3 +1957,661 @@ func TestTypeDeclaration(t *testing.T) {
 		}
 	}
 }
+
+func TestBlockRecoversFromMalformedStatement(t *testing.T) {
+	input := `function f()
+    local = 5
+    local y = 10
+    return y
+end`
+
+	l := lexer.New(input)
+	p := New(l)
+	stmt := p.parseFunctionDeclaration()
+
+	if stmt == nil {
+		t.Fatalf("parseFunctionDeclaration() returned nil. Errors: %v", p.Errors())
+	}
+
+	if len(p.Errors()) == 0 {
+		t.Error("expected a parse error for the malformed statement")
+	}
+
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("expected 2 statements after recovery, got=%d", len(stmt.Body.Statements))
+	}
+
+	decl, ok := stmt.Body.Statements[0].(*ast.VariableDeclaration)
+	if !ok {
+		t.Fatalf("statement 0 not *ast.VariableDeclaration. got=%T", stmt.Body.Statements[0])
+	}
+	if decl.Name.Value != "y" {
+		t.Errorf("expected variable y, got=%s", decl.Name.Value)
+	}
+
+	if _, ok := stmt.Body.Statements[1].(*ast.ReturnStatement); !ok {
+		t.Errorf("statement 1 not *ast.ReturnStatement. got=%T", stmt.Body.Statements[1])
+	}
+}
+
+func TestMalformedStatementIsNil(t *testing.T) {
+	p := New(lexer.New("while x\nlocal y = 10"))
+	statements := p.Parse()
+
+	if len(p.Errors()) == 0 {
+		t.Error("expected a parse error for the malformed statement")
+	}
+	for i, stmt := range statements {
+		if stmt == nil {
+			t.Fatalf("statement %d is nil", i)
+		}
+		if stmt.TokenLiteral() == "while" {
+			t.Errorf("statement %d is the malformed while statement", i)
+		}
+	}
+
+	if stmt := New(lexer.New("if x end")).parseBareStatement(); stmt != nil {
+		t.Errorf("expected an untyped nil for a malformed statement, got=%#v", stmt)
+	}
+}
+
+func TestReExportStatement(t *testing.T) {
+	input := `export * from "./util"`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got=%d", len(statements))
+	}
+
+	stmt, ok := statements[0].(*ast.ExportStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ExportStatement, got=%T", statements[0])
+	}
+
+	if !stmt.IsWildcard {
+		t.Error("expected IsWildcard to be true")
+	}
+
+	if stmt.Module != "./util" {
+		t.Errorf("module wrong. expected=%q, got=%q", "./util", stmt.Module)
+	}
+
+	if stmt.String() != input {
+		t.Errorf("String() wrong. expected=%q, got=%q", input, stmt.String())
+	}
+}
+
+func TestDefaultExportStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // name bound by the declaration
+	}{
+		{"export default function(a: number): number return a end", ast.DefaultExportName},
+		{"export default function double(a: number): number return a * 2 end", "double"},
+		{"export default class public name: string end", ast.DefaultExportName},
+		{"export default class Point public x: number end", "Point"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, p.Errors())
+		}
+		if len(statements) != 1 {
+			t.Fatalf("%q: expected 1 statement, got=%d", tt.input, len(statements))
+		}
+
+		stmt, ok := statements[0].(*ast.ExportStatement)
+		if !ok {
+			t.Fatalf("%q: expected *ast.ExportStatement, got=%T", tt.input, statements[0])
+		}
+		if !stmt.IsDefault {
+			t.Errorf("%q: expected IsDefault to be true", tt.input)
+		}
+
+		var name string
+		switch decl := stmt.Statement.(type) {
+		case *ast.FunctionDeclaration:
+			name = decl.Name.Value
+		case *ast.ClassDeclaration:
+			name = decl.Name.Value
+		default:
+			t.Fatalf("%q: expected a function or class declaration, got=%T", tt.input, stmt.Statement)
+		}
+		if name != tt.expected {
+			t.Errorf("%q: expected name %q, got=%q", tt.input, tt.expected, name)
+		}
+	}
+}
+
+func TestDefaultExportRequiresDeclaration(t *testing.T) {
+	l := lexer.New("export default 42")
+	p := New(l)
+	p.Parse()
+
+	if len(p.Errors()) == 0 {
+		t.Fatal("expected an error for export default of an expression")
+	}
+	expected := "Expected a function or class after 'export default' at line 1, column 16"
+	if p.Errors()[0] != expected {
+		t.Errorf("expected error %q, got=%q", expected, p.Errors()[0])
+	}
+}
+
+func TestMethodExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"counter:add", "counter:add"},
+		{"counter:add(1)", "counter:add(1)"},
+		{"a.b:c(x, y)", "a.b:c(x, y)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, p.Errors())
+		}
+
+		if len(statements) != 1 {
+			t.Fatalf("expected 1 statement, got=%d", len(statements))
+		}
+
+		stmt, ok := statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("expected *ast.ExpressionStatement, got=%T", statements[0])
+		}
+
+		if stmt.Expression.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, stmt.Expression.String())
+		}
+	}
+}
+
+func TestTypeOnlyImportStatement(t *testing.T) {
+	input := `import type { Point, Name } from "./shapes"`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got=%d", len(statements))
+	}
+
+	stmt, ok := statements[0].(*ast.ImportStatement)
+	if !ok {
+		t.Fatalf("expected *ast.ImportStatement, got=%T", statements[0])
+	}
+
+	if !stmt.IsTypeOnly {
+		t.Error("expected IsTypeOnly to be true")
+	}
+
+	if len(stmt.Names) != 2 {
+		t.Fatalf("expected 2 names, got=%d", len(stmt.Names))
+	}
+
+	if stmt.String() != input {
+		t.Errorf("String() wrong. expected=%q, got=%q", input, stmt.String())
+	}
+}
+
+func TestParseEmptyProgram(t *testing.T) {
+	inputs := []string{"", "  \n\t\n", "-- comment only\n"}
+
+	for _, input := range inputs {
+		l := lexer.New(input)
+		p := New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Errorf("parser errors for %q: %v", input, p.Errors())
+		}
+
+		if len(statements) != 0 {
+			t.Errorf("expected no statements for %q, got=%d", input, len(statements))
+		}
+	}
+}
+
+func TestInterfaceIndexSignature(t *testing.T) {
+	input := `
+interface NumberList
+	[index: number]: number
+	length: number
+end
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	iface, ok := statements[0].(*ast.InterfaceDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.InterfaceDeclaration, got=%T", statements[0])
+	}
+
+	if len(iface.IndexSignatures) != 1 {
+		t.Fatalf("expected an index signature, got=%d", len(iface.IndexSignatures))
+	}
+
+	if iface.IndexSignatures[0].String() != "[index: number]: number" {
+		t.Errorf("index signature wrong. got=%q", iface.IndexSignatures[0].String())
+	}
+
+	if len(iface.Properties) != 1 || iface.Properties[0].Name.Value != "length" {
+		t.Errorf("expected a single 'length' property, got=%d properties", len(iface.Properties))
+	}
+}
+
+func TestStringIndexSignatures(t *testing.T) {
+	input := `
+interface Dict
+	[key: string]: number
+	[index: number]: string
+end
+
+type Scores
+	[name: string]: number
+	total: number
+end
+
+local counts: { [word: string]: number, total: number } = {}
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	iface, ok := statements[0].(*ast.InterfaceDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.InterfaceDeclaration, got=%T", statements[0])
+	}
+	if len(iface.IndexSignatures) != 2 {
+		t.Fatalf("expected 2 index signatures, got=%d", len(iface.IndexSignatures))
+	}
+	if iface.IndexSignatures[0].String() != "[key: string]: number" {
+		t.Errorf("index signature wrong. got=%q", iface.IndexSignatures[0].String())
+	}
+
+	shape, ok := statements[1].(*ast.TypeDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.TypeDeclaration, got=%T", statements[1])
+	}
+	if len(shape.IndexSignatures) != 1 || len(shape.Properties) != 1 {
+		t.Errorf("expected 1 index signature and 1 property, got=%d and %d",
+			len(shape.IndexSignatures), len(shape.Properties))
+	}
+
+	varDecl, ok := statements[2].(*ast.VariableDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.VariableDeclaration, got=%T", statements[2])
+	}
+	if varDecl.Type.String() != "{ [word: string]: number, total: number }" {
+		t.Errorf("object shape wrong. got=%q", varDecl.Type.String())
+	}
+}
+
+func TestGenericParameterDefaults(t *testing.T) {
+	input := `
+type Pair<A, B = A> = A | B
+local x: Pair<> = nil
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	typeDecl, ok := statements[0].(*ast.TypeDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.TypeDeclaration, got=%T", statements[0])
+	}
+
+	if len(typeDecl.GenericParams) != 2 {
+		t.Fatalf("expected 2 generic parameters, got=%d", len(typeDecl.GenericParams))
+	}
+	if typeDecl.GenericParams[0].Default != nil {
+		t.Errorf("expected A to have no default, got=%q", typeDecl.GenericParams[0].Default.String())
+	}
+	if typeDecl.GenericParams[1].String() != "B = A" {
+		t.Errorf("expected B = A, got=%q", typeDecl.GenericParams[1].String())
+	}
+
+	varDecl, ok := statements[1].(*ast.VariableDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.VariableDeclaration, got=%T", statements[1])
+	}
+	generic, ok := varDecl.Type.(*ast.GenericType)
+	if !ok {
+		t.Fatalf("expected *ast.GenericType, got=%T", varDecl.Type)
+	}
+	if len(generic.TypeArguments) != 0 {
+		t.Errorf("expected no type arguments, got=%d", len(generic.TypeArguments))
+	}
+}
+
+func TestGenericParameterDefaultOrder(t *testing.T) {
+	input := `type Pair<A = string, B> = A | B`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.Parse()
+
+	if len(p.Errors()) == 0 {
+		t.Fatal("expected an error for a required parameter after a defaulted one")
+	}
+	if !strings.Contains(p.Errors()[0], "Required type parameter 'B' cannot follow a parameter with a default") {
+		t.Errorf("unexpected error: %s", p.Errors()[0])
+	}
+}
+
+func TestGenericParameterConstraints(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"function largest<T extends Comparable>(a: T, b: T): T return a end", []string{"T extends Comparable"}},
+		{"class Box<T extends number | string, U> end", []string{"T extends number | string", "U"}},
+		{"type Keyed<K extends string = string, V = any> = table<K, V>", []string{"K extends string = string", "V = any"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, p.Errors())
+		}
+
+		var params []*ast.GenericParameter
+		switch stmt := statements[0].(type) {
+		case *ast.FunctionDeclaration:
+			params = stmt.GenericParams
+		case *ast.ClassDeclaration:
+			params = stmt.GenericParams
+		case *ast.TypeDeclaration:
+			params = stmt.GenericParams
+		default:
+			t.Fatalf("%q: unexpected statement %T", tt.input, statements[0])
+		}
+
+		if len(params) != len(tt.expected) {
+			t.Fatalf("%q: expected %d generic parameters, got=%d", tt.input, len(tt.expected), len(params))
+		}
+		for i, expected := range tt.expected {
+			if params[i].String() != expected {
+				t.Errorf("%q: expected parameter %q, got=%q", tt.input, expected, params[i].String())
+			}
+		}
+	}
+}
+
+func TestTypePredicateReturnType(t *testing.T) {
+	input := `
+function isDog(animal: Animal): animal is Dog
+	return true
+end
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	fn, ok := statements[0].(*ast.FunctionDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.FunctionDeclaration, got=%T", statements[0])
+	}
+
+	predicate, ok := fn.ReturnType.(*ast.TypePredicate)
+	if !ok {
+		t.Fatalf("expected *ast.TypePredicate, got=%T", fn.ReturnType)
+	}
+
+	if predicate.String() != "animal is Dog" {
+		t.Errorf("predicate wrong. got=%q", predicate.String())
+	}
+}
+
+func TestConstructorParameterProperties(t *testing.T) {
+	input := `
+class Account
+	constructor(private balance: number, public readonly owner: string, note: string)
+	end
+end
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	class, ok := statements[0].(*ast.ClassDeclaration)
+	if !ok {
+		t.Fatalf("expected *ast.ClassDeclaration, got=%T", statements[0])
+	}
+
+	params := class.Constructor.Parameters
+	if len(params) != 3 {
+		t.Fatalf("expected 3 parameters, got=%d", len(params))
+	}
+
+	tests := []struct {
+		expected   string
+		isProperty bool
+	}{
+		{"private balance: number", true},
+		{"public readonly owner: string", true},
+		{"note: string", false},
+	}
+
+	for i, tt := range tests {
+		if params[i].String() != tt.expected {
+			t.Errorf("param %d wrong. expected=%q, got=%q", i, tt.expected, params[i].String())
+		}
+		if params[i].IsProperty() != tt.isProperty {
+			t.Errorf("param %d IsProperty wrong. expected=%t", i, tt.isProperty)
+		}
+	}
+}
+
+func TestConstructorVisibility(t *testing.T) {
+	tests := []struct {
+		modifier string
+		expected string
+	}{
+		{"", ""},
+		{"public ", "public"},
+		{"protected ", "protected"},
+		{"private ", "private"},
+	}
+
+	for _, tt := range tests {
+		input := "class Shape\n\t" + tt.modifier + "constructor(name: string)\n\t\tsuper(name)\n\tend\nend"
+
+		l := lexer.New(input)
+		p := New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Fatalf("%q: parser errors: %v", tt.modifier, p.Errors())
+		}
+
+		class, ok := statements[0].(*ast.ClassDeclaration)
+		if !ok || class.Constructor == nil {
+			t.Fatalf("%q: expected a class with a constructor, got=%T", tt.modifier, statements[0])
+		}
+		if class.Constructor.Visibility != tt.expected {
+			t.Errorf("%q: expected visibility %q, got=%q", tt.modifier, tt.expected, class.Constructor.Visibility)
+		}
+
+		stmt, ok := class.Constructor.Body.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("%q: expected an expression statement, got=%T", tt.modifier, class.Constructor.Body.Statements[0])
+		}
+		call, ok := stmt.Expression.(*ast.CallExpression)
+		if !ok {
+			t.Fatalf("%q: expected a call, got=%T", tt.modifier, stmt.Expression)
+		}
+		if _, ok := call.Function.(*ast.SuperExpression); !ok {
+			t.Errorf("%q: expected super to parse as *ast.SuperExpression, got=%T", tt.modifier, call.Function)
+		}
+	}
+}
+
+func TestParameterPropertiesOutsideConstructor(t *testing.T) {
+	input := `
+function open(private balance: number): void
+end
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.Parse()
+
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected 1 parser error, got=%d: %v", len(p.Errors()), p.Errors())
+	}
+	if !strings.Contains(p.Errors()[0], "Parameter properties are only allowed in constructors") {
+		t.Errorf("unexpected error: %s", p.Errors()[0])
+	}
+}
+
+func TestDestructuringDeclaration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		defaults []bool // whether each field has a default
+	}{
+		{"local { timeout, retries } = options", "local { timeout, retries } = options", []bool{false, false}},
+		{"local { timeout = 30, retries = 3 } = options", "local { timeout = 30, retries = 3 } = options", []bool{true, true}},
+		{"local { name, port = 8080, } = load()", "local { name, port = 8080 } = load()", []bool{false, true}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, p.Errors())
+		}
+		if len(statements) != 1 {
+			t.Fatalf("%q: expected 1 statement, got=%d", tt.input, len(statements))
+		}
+
+		stmt, ok := statements[0].(*ast.DestructuringDeclaration)
+		if !ok {
+			t.Fatalf("%q: expected *ast.DestructuringDeclaration, got=%T", tt.input, statements[0])
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, stmt.String())
+		}
+		if len(stmt.Fields) != len(tt.defaults) {
+			t.Fatalf("%q: expected %d fields, got=%d", tt.input, len(tt.defaults), len(stmt.Fields))
+		}
+		for i, field := range stmt.Fields {
+			if hasDefault := field.Default != nil; hasDefault != tt.defaults[i] {
+				t.Errorf("%q: field %s: expected default=%v, got=%v", tt.input, field.Name.Value, tt.defaults[i], hasDefault)
+			}
+		}
+	}
+}
+
+func TestDestructuringDeclarationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"local {} = options", "Expected at least one field to destructure at line 1, column 8"},
+		{"local { timeout = 30 }", "expected next token to be =, got EOF instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.Parse()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected a parser error", tt.input)
+			continue
+		}
+		if !strings.Contains(errors[0], tt.expected) {
+			t.Errorf("%q: expected error containing %q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestBitwiseOperatorsRequireTarget(t *testing.T) {
+	parseErrors := func(input, target string) []string {
+		p := New(lexer.NewWithOptions(input, lexer.Options{Target: target}))
+		p.Parse()
+		return p.Errors()
+	}
+
+	input := `local x = a & b
+local y = a | b
+local z = a >> 1
+local p: A & B | C = q
+local m: Map<string, Array<number>> = n`
+
+	errors := parseErrors(input, "5.1")
+	expected := []string{
+		"operator '&' not supported by target 5.1 at line 1, column 13",
+		"operator '|' not supported by target 5.1 at line 2, column 13",
+		"operator '>>' not supported by target 5.1 at line 3, column 13",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %q", len(expected), errors)
+	}
+	for i, msg := range expected {
+		if errors[i] != msg {
+			t.Errorf("error %d: expected %q, got %q", i, msg, errors[i])
+		}
+	}
+
+	for _, target := range []string{"5.3", "5.4", ""} {
+		if errors := parseErrors(input, target); len(errors) > 0 {
+			t.Errorf("target %q: expected no errors, got %q", target, errors)
+		}
+	}
+}
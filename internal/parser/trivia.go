@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"lunar/internal/ast"
+	"lunar/internal/lexer"
+	"strings"
+)
+
+// Trivia returns the source text around a statement, recorded when the
+// parser reads from a lexer that preserves trivia
+func (p *Parser) Trivia(stmt ast.Statement) (*ast.Trivia, bool) {
+	trivia, ok := p.trivia[stmt]
+	return trivia, ok
+}
+
+// Print writes the statements of a parsed file back out as source. Parsed
+// statements come out exactly as written, with the comments and blank lines
+// around them, so an unchanged file is reproduced byte for byte. A statement
+// whose node has been edited since is printed from the AST between its
+// comments; when only statements in its blocks were edited, it's kept as
+// written and just those are. Statements without trivia, such as ones a
+// codemod added, are printed from the AST on a line of their own
+func (p *Parser) Print(statements []ast.Statement) string {
+	var output strings.Builder
+	p.printStatements(&output, statements)
+	output.WriteString(p.endTrivia)
+	return output.String()
+}
+
+// printStatements writes statements with the trivia around them
+func (p *Parser) printStatements(output *strings.Builder, statements []ast.Statement) {
+	for _, stmt := range statements {
+		trivia, ok := p.trivia[stmt]
+		if !ok {
+			if output.Len() > 0 && !strings.HasSuffix(output.String(), "\n") {
+				output.WriteString("\n")
+			}
+			output.WriteString(stmt.String())
+			continue
+		}
+		output.WriteString(trivia.Leading)
+		p.printStatement(output, stmt, trivia)
+		output.WriteString(trivia.Trailing)
+	}
+}
+
+// printStatement writes a parsed statement. One edited itself is printed
+// from the AST; otherwise its text is kept and the statements of its blocks
+// are printed in place, between the trivia that opens and closes each block
+func (p *Parser) printStatement(output *strings.Builder, stmt ast.Statement, trivia *ast.Trivia) {
+	blocks := p.outerBlocks[stmt]
+	if printShell(stmt, blocks) != trivia.Printed {
+		output.WriteString(stmt.String())
+		return
+	}
+
+	written := 0
+	for _, block := range blocks {
+		output.WriteString(trivia.Text[written : block.start-trivia.Offset])
+		p.printStatements(output, block.block.Statements)
+		output.WriteString(block.closing)
+		written = block.end - trivia.Offset
+	}
+	output.WriteString(trivia.Text[written:])
+}
+
+// blockTrivia is where a block's statements sit in the source: from the
+// leading trivia of its first statement to the keyword that closes it.
+// closing is the trivia before that keyword which isn't the trailing trivia
+// of the block's last statement
+type blockTrivia struct {
+	block   *ast.BlockStatement
+	start   int
+	end     int
+	closing string
+}
+
+// recordBlock notes where a block whose first token was first, and which is
+// closed by the current token, sits in the source
+func (p *Parser) recordBlock(block *ast.BlockStatement, first lexer.Token) {
+	if p.trivia == nil {
+		return
+	}
+	p.blocks = append(p.blocks, &blockTrivia{
+		block:   block,
+		start:   first.Offset - len(first.Trivia),
+		end:     p.curToken.Offset,
+		closing: p.unclaimedTrivia(p.curToken),
+	})
+}
+
+// outermostBlocks returns the blocks, given in the order they were closed,
+// that aren't nested in another of them, in source order
+func outermostBlocks(blocks []*blockTrivia) []*blockTrivia {
+	var outer []*blockTrivia
+	// A block closes after the blocks nested in it, so walking back from the
+	// last one, each block either comes before the last outer one or is
+	// nested in it
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if len(outer) == 0 || blocks[i].end <= outer[len(outer)-1].start {
+			outer = append(outer, blocks[i])
+		}
+	}
+	for i, j := 0, len(outer)-1; i < j; i, j = i+1, j-1 {
+		outer[i], outer[j] = outer[j], outer[i]
+	}
+	return outer
+}
+
+// blockMarker stands in for the statements of a block in printShell
+var blockMarker = []ast.Statement{&ast.ExpressionStatement{Expression: &ast.Identifier{Value: "..."}}}
+
+// printShell prints stmt with the statements of its blocks replaced by a
+// marker, so the result only changes when the statement itself is edited or
+// one of its blocks is replaced, not when statements in them are
+func printShell(stmt ast.Statement, blocks []*blockTrivia) string {
+	saved := make([][]ast.Statement, len(blocks))
+	for i, block := range blocks {
+		saved[i] = block.block.Statements
+		block.block.Statements = blockMarker
+	}
+	printed := stmt.String()
+	for i, block := range blocks {
+		block.block.Statements = saved[i]
+	}
+	return printed
+}
+
+// recordTrivia attaches the source around a statement that began at start
+// and ends at the current token. What follows the statement on its last
+// line is its trailing trivia; the rest belongs to whatever comes next.
+// blocks are the blocks closed while parsing the statement
+func (p *Parser) recordTrivia(stmt ast.Statement, start lexer.Token, leading string, blocks []*blockTrivia) {
+	next := p.peekToken
+	end := next.Offset - len(next.Trivia)
+
+	trailing := ""
+	if newline := strings.IndexByte(next.Trivia, '\n'); newline >= 0 {
+		trailing = next.Trivia[:newline]
+	} else if next.Type == lexer.EOF {
+		trailing = next.Trivia
+	}
+
+	outer := outermostBlocks(blocks)
+	if len(outer) > 0 {
+		p.outerBlocks[stmt] = outer
+	}
+	p.trivia[stmt] = &ast.Trivia{
+		Leading:  leading,
+		Text:     p.l.Slice(start.Offset, end),
+		Offset:   start.Offset,
+		Trailing: trailing,
+		Printed:  printShell(stmt, outer),
+	}
+	p.claimed = next
+	p.claimed.Trivia = trailing
+}
+
+// unclaimedTrivia is the trivia before a token that isn't already the
+// trailing trivia of the statement before it
+func (p *Parser) unclaimedTrivia(tok lexer.Token) string {
+	if tok.Offset == p.claimed.Offset && tok.Type == p.claimed.Type {
+		return tok.Trivia[len(p.claimed.Trivia):]
+	}
+	return tok.Trivia
+}
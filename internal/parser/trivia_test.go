@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"lunar/internal/ast"
+	"lunar/internal/lexer"
+	"strings"
+	"testing"
+)
+
+func parseWithTrivia(t *testing.T, input string) (*Parser, []ast.Statement) {
+	t.Helper()
+
+	p := New(lexer.NewWithOptions(input, lexer.Options{PreserveTrivia: true}))
+	statements := p.Parse()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return p, statements
+}
+
+const triviaSource = `-- Greeting helpers
+--[[ kept as written ]]
+
+local greeting: string = "hello\tworld" -- the default
+
+-- Joins a greeting and a name
+function greet(name: string): string
+	-- nested comments stay inside the function
+	return greeting .. ", " .. name
+end
+
+local a = 1; local b = 2
+print(greet("you"))   -- trailing
+
+-- end of file
+`
+
+func TestPrintReproducesSource(t *testing.T) {
+	p, statements := parseWithTrivia(t, triviaSource)
+
+	if result := p.Print(statements); result != triviaSource {
+		t.Errorf("expected the source back unchanged.\nexpected:\n%s\ngot:\n%s", triviaSource, result)
+	}
+}
+
+func TestTriviaAttachedToStatements(t *testing.T) {
+	p, statements := parseWithTrivia(t, triviaSource)
+
+	if len(statements) != 5 {
+		t.Fatalf("expected 5 statements, got=%d", len(statements))
+	}
+
+	tests := []struct {
+		leading  string
+		text     string
+		trailing string
+	}{
+		{"-- Greeting helpers\n--[[ kept as written ]]\n\n", `local greeting: string = "hello\tworld"`, " -- the default"},
+		{"\n\n-- Joins a greeting and a name\n", "function greet(name: string): string\n\t-- nested comments stay inside the function\n\treturn greeting .. \", \" .. name\nend", ""},
+		{"\n\n", "local a = 1;", ""},
+		{" ", "local b = 2", ""},
+		{"\n", `print(greet("you"))`, "   -- trailing"},
+	}
+
+	for i, tt := range tests {
+		trivia, ok := p.Trivia(statements[i])
+		if !ok {
+			t.Fatalf("statement %d: expected trivia", i)
+		}
+		if trivia.Leading != tt.leading {
+			t.Errorf("statement %d: leading wrong. expected=%q, got=%q", i, tt.leading, trivia.Leading)
+		}
+		if trivia.Text != tt.text {
+			t.Errorf("statement %d: text wrong. expected=%q, got=%q", i, tt.text, trivia.Text)
+		}
+		if trivia.Trailing != tt.trailing {
+			t.Errorf("statement %d: trailing wrong. expected=%q, got=%q", i, tt.trailing, trivia.Trailing)
+		}
+	}
+
+	// Statements nested in a block have trivia of their own
+	body := statements[1].(*ast.FunctionDeclaration).Body.Statements
+	trivia, ok := p.Trivia(body[0])
+	if !ok {
+		t.Fatal("expected trivia for the nested return statement")
+	}
+	if trivia.Leading != "\n\t-- nested comments stay inside the function\n\t" {
+		t.Errorf("nested leading wrong, got=%q", trivia.Leading)
+	}
+}
+
+func TestPrintKeepsCommentsAroundNewStatements(t *testing.T) {
+	input := `-- first
+local x = 1 -- one
+
+-- last
+local z = 3
+`
+	p, statements := parseWithTrivia(t, input)
+
+	added := New(lexer.New("local y = 2")).Parse()
+	statements = []ast.Statement{statements[0], added[0], statements[1]}
+
+	expected := `-- first
+local x = 1 -- one
+local y = 2
+
+-- last
+local z = 3
+`
+	if result := p.Print(statements); result != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestPrintEditedStatementsFromTheAST(t *testing.T) {
+	input := `-- answer
+local x = 1 -- one
+
+function first<T>(items: T[]): T
+	-- nested
+	if items[1] != nil then
+		return items[1] -- the head
+	end
+	return nil
+end
+`
+	p, statements := parseWithTrivia(t, input)
+
+	statements[0].(*ast.VariableDeclaration).Value = &ast.NumberLiteral{Token: lexer.Token{Literal: "42"}, Value: 42}
+	body := statements[1].(*ast.FunctionDeclaration).Body.Statements
+	body[0].(*ast.IfStatement).Consequence.Statements[0].(*ast.ReturnStatement).ReturnValue = &ast.NumberLiteral{Token: lexer.Token{Literal: "2"}, Value: 2}
+
+	expected := `-- answer
+local x = 42 -- one
+
+function first<T>(items: T[]): T
+	-- nested
+	if items[1] != nil then
+		return 2 -- the head
+	end
+	return nil
+end
+`
+	if result := p.Print(statements); result != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestPrintEditedBlock(t *testing.T) {
+	input := `while true do
+	-- wait
+	step()
+end
+`
+	p, statements := parseWithTrivia(t, input)
+
+	loop := statements[0].(*ast.WhileStatement)
+	added := New(lexer.New("stop()")).Parse()
+	loop.Body.Statements = append(loop.Body.Statements, added[0])
+
+	expected := `while true do
+	-- wait
+	step()
+stop()
+end
+`
+	if result := p.Print(statements); result != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+
+	// Swapping the block for another reprints the whole statement
+	loop.Body = &ast.BlockStatement{}
+	if result := p.Print(statements); strings.Contains(result, "step()") {
+		t.Errorf("expected the replaced block to be printed from the AST, got:\n%s", result)
+	}
+}
+
+func TestNoTriviaByDefault(t *testing.T) {
+	p := New(lexer.New("-- comment\nlocal x = 1"))
+	statements := p.Parse()
+
+	if _, ok := p.Trivia(statements[0]); ok {
+		t.Error("expected no trivia without PreserveTrivia")
+	}
+}
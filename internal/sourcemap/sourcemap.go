@@ -10,17 +10,19 @@ import (
 // SourceMap represents a source map following the Source Map v3 specification
 // https://sourcemaps.info/spec.html
 type SourceMap struct {
-	Version    int      `json:"version"`
-	File       string   `json:"file"`
-	SourceRoot string   `json:"sourceRoot,omitempty"`
-	Sources    []string `json:"sources"`
-	Names      []string `json:"names,omitempty"`
-	Mappings   string   `json:"mappings"`
+	Version        int      `json:"version"`
+	File           string   `json:"file"`
+	SourceRoot     string   `json:"sourceRoot,omitempty"`
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent,omitempty"` // the text of each source, so tools needn't fetch it
+	Names          []string `json:"names,omitempty"`
+	Mappings       string   `json:"mappings"`
 }
 
 // Builder helps construct source maps incrementally
 type Builder struct {
 	sourceFile    string
+	sourceContent string
 	generatedFile string
 	mappings      []Mapping
 	names         map[string]int
@@ -36,10 +38,12 @@ type Mapping struct {
 	Name            string
 }
 
-// NewBuilder creates a new source map builder
-func NewBuilder(sourceFile, generatedFile string) *Builder {
+// NewBuilder creates a new source map builder for the generated file
+// compiled from sourceFile, whose text is sourceContent
+func NewBuilder(sourceFile, sourceContent, generatedFile string) *Builder {
 	return &Builder{
 		sourceFile:    sourceFile,
+		sourceContent: sourceContent,
 		generatedFile: generatedFile,
 		mappings:      []Mapping{},
 		names:         make(map[string]int),
@@ -70,11 +74,12 @@ func (b *Builder) AddMapping(genLine, genCol, srcLine, srcCol int, name string)
 // Build generates the final source map
 func (b *Builder) Build() *SourceMap {
 	return &SourceMap{
-		Version:  3,
-		File:     b.generatedFile,
-		Sources:  []string{b.sourceFile},
-		Names:    b.namesList,
-		Mappings: b.encodeMappings(),
+		Version:        3,
+		File:           b.generatedFile,
+		Sources:        []string{b.sourceFile},
+		SourcesContent: []string{b.sourceContent},
+		Names:          b.namesList,
+		Mappings:       b.encodeMappings(),
 	}
 }
 
@@ -0,0 +1,23 @@
+package target
+
+// Versions lists the Lua versions Lunar can target, oldest first
+var Versions = []string{"5.1", "5.2", "5.3", "5.4"}
+
+// IsValid reports whether version is a supported Lua target
+func IsValid(version string) bool {
+	for _, v := range Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// AtLeast reports whether version is min or newer. An empty version means no
+// target was specified, which never satisfies a minimum
+func AtLeast(version, min string) bool {
+	if !IsValid(version) {
+		return false
+	}
+	return version >= min
+}
@@ -0,0 +1,145 @@
+package types
+
+import "testing"
+
+func TestReadonlyArrayIsCovariant(t *testing.T) {
+	input := petClasses + `
+function list(dogs: Dog[]): void
+	local animals: readonly Animal[] = dogs
+	local first: Animal = animals[1]
+	local same: Dog[] = dogs
+	local frozen: readonly Dog[] = dogs
+	local maybe: readonly Animal[]? = frozen
+end
+`
+	for _, err := range checkWithOptions(t, input, Options{}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestArrayVarianceErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`function list(dogs: Dog[]): void
+	local animals: Animal[] = dogs
+end`,
+			"Cannot assign type 'Dog[]' to variable of type 'Animal[]'",
+		},
+		{
+			`function list(dogs: readonly Dog[]): void
+	local copy: Dog[] = dogs
+end`,
+			"Cannot assign type 'readonly Dog[]' to variable of type 'Dog[]'",
+		},
+		{
+			`function replace(dogs: readonly Dog[], dog: Dog): void
+	dogs[1] = dog
+end`,
+			"Cannot assign to an element of 'readonly Dog[]' because it is a read-only array",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, petClasses+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			for _, err := range errors {
+				t.Errorf("  %s", err.Message)
+			}
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	input := petClasses + `
+local nums: number[] = {1, 2, 3}
+local none: string[] = {}
+local grid: number[][] = {{1, 2}, {3}}
+local inferred = {1, "two"}
+local mixed: (number | string)[] = inferred
+
+function adopt(dog: Dog, animal: Animal): Animal[]
+	local pets: Animal[] = {dog, animal}
+	pets = {animal}
+	return {dog}
+end
+
+function total(values: number[]): number
+	return 0
+end
+
+local sum: number = total({4, 5})
+`
+	for _, err := range checkWithOptions(t, input, Options{}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestArrayLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`local nums: number[] = {1, 2, "x"}`,
+			"Array element 3: cannot assign type '\"x\"' to element type 'number'",
+		},
+		{
+			`local grid: number[][] = {{1}, {true}}`,
+			"Array element 1: cannot assign type 'boolean' to element type 'number'",
+		},
+		{
+			`local inferred = {1, "two"}
+local nums: number[] = inferred`,
+			"Cannot assign type '(number | string)[]' to variable of type 'number[]'",
+		},
+		{
+			`function total(values: number[]): number
+	return 0
+end
+local sum: number = total({1, nil})`,
+			"Array element 2: cannot assign type 'nil' to element type 'number'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, petClasses+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			for _, err := range errors {
+				t.Errorf("  %s", err.Message)
+			}
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestArrayLiteralOfNumbersWithIntegerTarget(t *testing.T) {
+	input := `
+function sum(values: number[]): number
+	return 0
+end
+
+local xs = {1, 2, 3}
+local total: number = sum(xs)
+local halves = {0.5, 1.5}
+total = sum(halves)
+
+local n: int = 1
+local ints = {n, n}
+local counts: int[] = ints
+`
+	for _, err := range checkWithOptions(t, input, Options{Target: "5.3"}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
@@ -0,0 +1,91 @@
+package types
+
+import "testing"
+
+func TestMultipleAssignment(t *testing.T) {
+	input := `
+local a: number = 1
+local b: number = 2
+a, b = b, a
+
+local name: string = "x"
+local point: { x: number, y: number } = { x = 1, y = 2 }
+name, point.x, point.y = "y", point.y, point.x
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestMultipleAssignmentFromCall(t *testing.T) {
+	// A call returning several values spreads them when it comes last, and
+	// only its first value is used otherwise
+	input := `
+function pair(): (number, string)
+	return 1, "one"
+end
+
+local n: number = 0
+local s: string = ""
+local m: number = 0
+n, s = pair()
+m, n, s = pair(), pair()
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestMultipleAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			`local a: number = 1
+local b: string = "b"
+a, b = b, a`,
+			[]string{
+				"Cannot assign type 'string' to type 'number'",
+				"Cannot assign type 'number' to type 'string'",
+			},
+		},
+		{
+			`local a: number = 1
+const LIMIT: number = 10
+a, LIMIT = 2, 3`,
+			[]string{"Cannot assign to const variable 'LIMIT'"},
+		},
+		{
+			`local a: number = 1
+local b: number = 2
+a, b = 3`,
+			[]string{"Assignment to 2 target(s) has 1 value(s)"},
+		},
+		{
+			`local a: number = 1
+a = 1, missing`,
+			[]string{
+				"Undefined variable 'missing'",
+				"Assignment to 1 target(s) has 2 value(s)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) != len(tt.expected) {
+			t.Errorf("%q: expected %d type errors, got %d: %v", tt.input, len(tt.expected), len(errors), errors)
+			continue
+		}
+		for i, message := range tt.expected {
+			if errors[i].Message != message {
+				t.Errorf("%q: error %d: expected %q, got %q", tt.input, i, message, errors[i].Message)
+			}
+		}
+	}
+}
@@ -2,8 +2,13 @@ package types
 
 import (
 	"fmt"
+	"io"
 	"lunar/internal/ast"
 	"lunar/internal/lexer"
+	"lunar/internal/target"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // TypeError represents a type error
@@ -21,7 +26,12 @@ func (e *TypeError) Error() string {
 type Environment struct {
 	store     map[string]Type
 	constVars map[string]bool // tracks which variables are const
+	narrowed  map[string]bool // variables narrowed here from a wider declared type
 	outer     *Environment
+
+	// Type aliases declared with '=' in this scope that haven't been
+	// resolved yet
+	aliases map[string]*ast.TypeDeclaration
 }
 
 // NewEnvironment creates a new environment
@@ -60,6 +70,57 @@ func (e *Environment) SetConst(name string, typ Type) {
 	e.constVars[name] = true
 }
 
+// Narrow gives a variable of an outer scope a narrower type within this
+// environment, such as User for a User? that was checked against nil
+func (e *Environment) Narrow(name string, typ Type) {
+	if e.narrowed == nil {
+		e.narrowed = make(map[string]bool)
+	}
+	e.store[name] = typ
+	e.narrowed[name] = true
+}
+
+// GetDeclared retrieves the type a variable was declared with, ignoring any
+// narrowing. Assignments are checked against it
+func (e *Environment) GetDeclared(name string) (Type, bool) {
+	if typ, ok := e.store[name]; ok && !e.narrowed[name] {
+		return typ, true
+	}
+	if e.outer != nil {
+		return e.outer.GetDeclared(name)
+	}
+	return nil, false
+}
+
+// Widen drops any narrowing of a variable between this environment and the
+// scope declaring it, so it has its declared type again
+func (e *Environment) Widen(name string) {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok && !env.narrowed[name] {
+			return
+		}
+		if env.narrowed[name] {
+			delete(env.store, name)
+			delete(env.narrowed, name)
+		}
+	}
+}
+
+// pendingAlias finds the unresolved alias declaration name refers to and
+// the scope declaring it. It returns nil when a nearer scope binds name, or
+// when no scope declares such an alias
+func (e *Environment) pendingAlias(name string) (*ast.TypeDeclaration, *Environment) {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			return nil, nil
+		}
+		if decl, ok := env.aliases[name]; ok {
+			return decl, env
+		}
+	}
+	return nil, nil
+}
+
 // IsConst checks if a variable is const
 func (e *Environment) IsConst(name string) bool {
 	isConst, ok := e.constVars[name]
@@ -74,22 +135,142 @@ func (e *Environment) IsConst(name string) bool {
 
 // Checker performs type checking on an AST
 type Checker struct {
-	env    *Environment
-	errors []*TypeError
+	env      *Environment
+	errors   []*TypeError
+	warnings []*TypeError
 
-	// Type definitions (classes, interfaces, enums, type aliases)
+	// Top-level type definitions (classes, interfaces, enums, type aliases).
+	// Those declared in nested blocks are only bound in their block's scope
 	classes            map[string]*ClassType
 	interfaces         map[string]*InterfaceType
 	enums              map[string]*EnumType
 	typeAliases        map[string]Type
 	genericTypeAliases map[string]*GenericTypeAlias
 
+	// Type declared by each class, interface and enum declaration
+	definitions map[ast.Statement]Type
+
+	// Type aliases being resolved (to catch aliases that refer to themselves)
+	resolvingAliases map[*ast.TypeDeclaration]bool
+
+	// Generic alias instantiations by alias and type arguments, including
+	// those still being resolved, and which of them those are
+	instantiations     map[string]Type
+	resolvingInstances map[string]bool
+
 	// Current function return type (for checking return statements)
 	currentFunctionReturnType Type
+
+	// Return types of the current function, when it has no return type
+	// annotation and its return type is being inferred
+	returnInference *returnInference
+
+	// Type of each value of '...' in the current function, nil when the
+	// function isn't variadic
+	currentVarargs Type
+
+	// Number of loops enclosing the current statement within its function
+	// (for checking break statements)
+	loopDepth int
+
+	// Number of generic alias instantiations being expanded, to stop
+	// self-expanding aliases. Once too deep, the whole instantiation is
+	// abandoned rather than only its deepest branch
+	instantiationDepth   int
+	instantiationTooDeep bool
+
+	// Nesting of the resolution step being traced, for indenting the trace
+	traceDepth int
+
+	// Class whose constructor is being checked (readonly properties may be
+	// initialized there)
+	currentConstructorClass *ClassType
+
+	// Class whose constructor or methods are being checked (for resolving
+	// super)
+	currentClass *ClassType
+
+	// Whether the int/float number subtypes are available (Lua 5.3+)
+	numberSubtypes bool
+
+	// Names exported by the module being checked
+	exports map[string]Type
+
+	// Signatures of the top-level functions, resolved before any statement
+	// is checked. Function bodies run after the whole chunk has, so they may
+	// call top-level functions declared after them, as mutual recursion does
+	functionTypes    map[*ast.FunctionDeclaration]*FunctionType
+	hoistedFunctions map[string]*FunctionType
+
+	// Modules checked or being checked, shared with the checkers of
+	// imported modules, so each module is checked once and an import cycle
+	// doesn't recurse forever
+	imports *importGraph
+
+	// Whether Check is running. An import cycle finds the module it leads
+	// back to in this state, with only part of its types registered
+	checking bool
+
+	// Types of the elements of each array-style table literal checked, for
+	// checking a literal against the array type it is assigned to
+	arrayLiterals map[*ast.TableLiteral][]Type
+
+	// Symbol table, kept when Options.CollectSymbols is set. scopes maps
+	// each environment to its scope in the table
+	symbols *Scope
+	scopes  map[*Environment]*Scope
+
+	options Options
+}
+
+// ModuleResolver locates the module imported as path from the file from,
+// returning the resolved file name and its parsed statements
+type ModuleResolver func(from, path string) (string, []ast.Statement, error)
+
+// Options configures a Checker
+type Options struct {
+	// Target is the Lua version being compiled for ("5.1" to "5.4").
+	// Empty means no specific target
+	Target string
+
+	// File is the path of the module being checked, used to resolve
+	// relative module paths
+	File string
+
+	// ResolveModule loads other modules for re-exports and type-only
+	// imports. When nil, modules are not resolved
+	ResolveModule ModuleResolver
+
+	// LuaCoercion accepts string operands in arithmetic, which Lua converts
+	// to numbers at runtime ("10" + 5 is 15). Off by default, since a
+	// non-numeric string fails at runtime
+	LuaCoercion bool
+
+	// CollectSymbols keeps every declared name, its type and its position
+	// in a symbol table, for editor tooling. See Checker.Symbols
+	CollectSymbols bool
+
+	// StrictClassInit requires a class's constructor to assign every
+	// property that isn't optional, on every path through it
+	StrictClassInit bool
+
+	// NoImplicitAny reports parameters and variables whose type is any
+	// because an annotation is missing. An explicit any is still allowed
+	NoImplicitAny bool
+
+	// Trace receives a line for each step of resolving type expressions,
+	// generic type arguments and imports, showing where resolution falls
+	// back to any. When nil, nothing is traced
+	Trace io.Writer
 }
 
 // NewChecker creates a new type checker
 func NewChecker() *Checker {
+	return NewCheckerWithOptions(Options{})
+}
+
+// NewCheckerWithOptions creates a new type checker configured by opts
+func NewCheckerWithOptions(opts Options) *Checker {
 	env := NewEnvironment()
 
 	// Register built-in types
@@ -98,26 +279,71 @@ func NewChecker() *Checker {
 	env.Set("boolean", Boolean)
 	env.Set("nil", Nil)
 	env.Set("void", Void)
+	env.Set("never", Never)
 	env.Set("any", Any)
 
-	return &Checker{
+	// Lua 5.3 introduced a distinct integer representation
+	numberSubtypes := target.AtLeast(opts.Target, "5.3")
+	if numberSubtypes {
+		env.Set("int", Int)
+		env.Set("float", Float)
+	}
+
+	c := &Checker{
 		env:                env,
 		errors:             []*TypeError{},
+		warnings:           []*TypeError{},
 		classes:            make(map[string]*ClassType),
 		interfaces:         make(map[string]*InterfaceType),
 		enums:              make(map[string]*EnumType),
 		typeAliases:        make(map[string]Type),
 		genericTypeAliases: make(map[string]*GenericTypeAlias),
+		definitions:        make(map[ast.Statement]Type),
+		resolvingAliases:   make(map[*ast.TypeDeclaration]bool),
+		instantiations:     make(map[string]Type),
+		resolvingInstances: make(map[string]bool),
+		numberSubtypes:     numberSubtypes,
+		exports:            make(map[string]Type),
+		functionTypes:      make(map[*ast.FunctionDeclaration]*FunctionType),
+		hoistedFunctions:   make(map[string]*FunctionType),
+		arrayLiterals:      make(map[*ast.TableLiteral][]Type),
+		imports:            &importGraph{modules: make(map[string]*Checker)},
+		options:            opts,
+	}
+	if opts.CollectSymbols {
+		c.symbols = &Scope{}
+		c.scopes = make(map[*Environment]*Scope)
 	}
+	return c
+}
+
+// Exports returns the names exported by the checked module and their types
+func (c *Checker) Exports() map[string]Type {
+	return c.exports
+}
+
+// Warnings returns problems found by Check that don't stop compilation,
+// such as unreachable branches
+func (c *Checker) Warnings() []*TypeError {
+	return c.warnings
 }
 
 // Check performs type checking on a list of statements
 func (c *Checker) Check(statements []ast.Statement) []*TypeError {
-	// First pass: register all type definitions
-	for _, stmt := range statements {
-		c.registerTypeDefinition(stmt)
+	c.checking = true
+	defer func() { c.checking = false }()
+	if c.options.File != "" {
+		file := filepath.Clean(c.options.File)
+		c.imports.modules[file] = c
+		c.imports.chain = append(c.imports.chain, file)
+		defer func() { c.imports.chain = c.imports.chain[:len(c.imports.chain)-1] }()
 	}
 
+	// First pass: register the top-level type definitions. Those in nested
+	// blocks are registered as each block is checked
+	c.declareTypes(statements)
+	c.hoistFunctions(statements)
+
 	// Second pass: check all statements
 	for _, stmt := range statements {
 		c.checkStatement(stmt)
@@ -126,6 +352,93 @@ func (c *Checker) Check(statements []ast.Statement) []*TypeError {
 	return c.errors
 }
 
+// hoistFunctions resolves the signature of every top-level function, so the
+// bodies of functions declared earlier can call it
+func (c *Checker) hoistFunctions(statements []ast.Statement) {
+	for _, stmt := range statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			stmt = export.Statement
+		}
+		node, ok := stmt.(*ast.FunctionDeclaration)
+		if !ok {
+			continue
+		}
+		funcType := c.functionType(node)
+		c.functionTypes[node] = funcType
+		if _, ok := c.hoistedFunctions[node.Name.Value]; !ok {
+			c.hoistedFunctions[node.Name.Value] = funcType
+		}
+	}
+}
+
+// declareTypes registers the type definitions among the statements of a
+// block in the current scope. Every type is declared before any is filled
+// in, so types may refer to types declared after them in the same block
+func (c *Checker) declareTypes(statements []ast.Statement) {
+	for _, stmt := range statements {
+		c.declareTypeDefinition(stmt)
+	}
+	for _, stmt := range statements {
+		c.registerTypeDefinition(stmt)
+	}
+}
+
+// declareTypeDefinition makes the name of a class, interface, enum, or type
+// alias known before any definition is filled in by registerTypeDefinition
+func (c *Checker) declareTypeDefinition(stmt ast.Statement) {
+	topLevel := c.env.outer == nil
+	switch node := stmt.(type) {
+	case *ast.ClassDeclaration:
+		classType := &ClassType{
+			Name:       node.Name.Value,
+			Properties: make(map[string]Type),
+			Methods:    make(map[string]*FunctionType),
+			Implements: []*InterfaceType{},
+		}
+		for _, param := range node.GenericParams {
+			classType.TypeParams = append(classType.TypeParams, param.Name.Value)
+			classType.Constraints = append(classType.Constraints, param.Constraint)
+			classType.Defaults = append(classType.Defaults, param.Default)
+		}
+		if topLevel {
+			c.classes[classType.Name] = classType
+		}
+		c.definitions[node] = classType
+		c.env.Set(classType.Name, classType)
+		c.recordSymbol(node.Name, classType)
+	case *ast.InterfaceDeclaration:
+		interfaceType := newInterfaceType(node.Name.Value)
+		if topLevel {
+			c.interfaces[interfaceType.Name] = interfaceType
+		}
+		c.definitions[node] = interfaceType
+		c.env.Set(interfaceType.Name, interfaceType)
+		c.recordSymbol(node.Name, interfaceType)
+	case *ast.EnumDeclaration:
+		enumType := &EnumType{
+			Name:    node.Name.Value,
+			Members: make(map[string]Type),
+			IsConst: node.IsConst,
+		}
+		if topLevel {
+			c.enums[enumType.Name] = enumType
+		}
+		c.definitions[node] = enumType
+		c.env.Set(enumType.Name, enumType)
+		c.recordSymbol(node.Name, enumType)
+	case *ast.TypeDeclaration:
+		c.declareTypeAlias(node)
+	case *ast.DeclareStatement:
+		if node.Declaration != nil {
+			c.declareTypeDefinition(node.Declaration)
+		}
+	case *ast.ExportStatement:
+		if node.Statement != nil {
+			c.declareTypeDefinition(node.Statement)
+		}
+	}
+}
+
 // registerTypeDefinition registers classes, interfaces, enums, and type aliases
 func (c *Checker) registerTypeDefinition(stmt ast.Statement) {
 	switch node := stmt.(type) {
@@ -142,39 +455,60 @@ func (c *Checker) registerTypeDefinition(stmt ast.Statement) {
 		if node.Declaration != nil {
 			c.registerTypeDefinition(node.Declaration)
 		}
+	case *ast.ExportStatement:
+		if node.Statement != nil {
+			c.registerTypeDefinition(node.Statement)
+		}
+	case *ast.ImportStatement:
+		c.registerImport(node)
 	}
 }
 
 // registerClass registers a class type
 func (c *Checker) registerClass(node *ast.ClassDeclaration) {
-	classType := &ClassType{
-		Name:       node.Name.Value,
-		Properties: make(map[string]Type),
-		Methods:    make(map[string]*FunctionType),
-		Implements: []*InterfaceType{},
-	}
+	classType := c.definitions[node].(*ClassType)
 
 	// Add generic type parameters to scope temporarily
 	prevEnv := c.env
 	if len(node.GenericParams) > 0 {
 		c.env = NewEnclosedEnvironment(prevEnv)
-		for _, genericParam := range node.GenericParams {
-			c.env.Set(genericParam.Value, Any)
-		}
+		c.declareTypeParams(node.GenericParams)
+		c.checkTypeParamDefaults(node.GenericParams)
 	}
 
 	// Register properties
 	for _, prop := range node.Properties {
-		propType := c.resolveTypeExpression(prop.Type)
-		classType.Properties[prop.Name.Value] = propType
+		classType.Properties[prop.Name.Value] = c.resolvePropertyType(prop)
+		classType.Readonly = markReadonly(classType.Readonly, prop)
+		markVisibility(classType, prop.Name.Value, prop.Visibility)
+	}
+
+	// Constructor parameter properties declare properties too
+	if node.Constructor != nil {
+		for _, param := range node.Constructor.Parameters {
+			if !param.IsProperty() {
+				continue
+			}
+			if _, exists := classType.Properties[param.Name.Value]; exists {
+				c.addError(fmt.Sprintf("Duplicate property '%s'", param.Name.Value), param.Token)
+				continue
+			}
+			prop := &ast.PropertyDeclaration{
+				Token:      param.Token,
+				Visibility: param.Visibility,
+				Name:       param.Name,
+				Type:       param.Type,
+				Readonly:   param.Readonly,
+			}
+			classType.Properties[prop.Name.Value] = c.resolvePropertyType(prop)
+			classType.Readonly = markReadonly(classType.Readonly, prop)
+			markVisibility(classType, prop.Name.Value, prop.Visibility)
+		}
 	}
 
 	// Register methods
 	for _, method := range node.Methods {
-		params := make([]Type, len(method.Parameters))
-		for i, param := range method.Parameters {
-			params[i] = c.resolveTypeExpression(param.Type)
-		}
+		params, variadic := c.parameterTypes(method.Parameters)
 		var returnType Type = Void
 		if method.ReturnType != nil {
 			returnType = c.resolveTypeExpression(method.ReturnType)
@@ -182,13 +516,15 @@ func (c *Checker) registerClass(node *ast.ClassDeclaration) {
 		classType.Methods[method.Name.Value] = &FunctionType{
 			Parameters: params,
 			ReturnType: returnType,
+			Variadic:   variadic,
 		}
+		markVisibility(classType, method.Name.Value, method.Visibility)
 	}
 
 	// Resolve implements clause
 	for _, impl := range node.Implements {
 		if ident, ok := impl.(*ast.Identifier); ok {
-			if interfaceType, exists := c.interfaces[ident.Value]; exists {
+			if interfaceType, exists := c.lookupInterface(ident.Value); exists {
 				classType.Implements = append(classType.Implements, interfaceType)
 			} else {
 				c.addError(fmt.Sprintf("Interface '%s' not found", ident.Value), ident.Token)
@@ -196,36 +532,78 @@ func (c *Checker) registerClass(node *ast.ClassDeclaration) {
 		}
 	}
 
+	// Resolve the parent class
+	if ident, ok := node.Extends.(*ast.Identifier); ok {
+		if parent, exists := c.lookupClass(ident.Value); exists {
+			// The parent chain is walked for inherited members, so it
+			// mustn't loop back to this class
+			if parent.Equals(classType) || parent.IsSubclassOf(classType) {
+				c.addError(fmt.Sprintf("Class '%s' cannot extend '%s': it would inherit from itself", classType.Name, parent.Name), ident.Token)
+			} else {
+				classType.Parent = parent
+			}
+		} else {
+			c.addError(fmt.Sprintf("Class '%s' not found", ident.Value), ident.Token)
+		}
+	}
+
+	// Record the constructor signature, for super(...) calls from subclasses
+	if node.Constructor != nil {
+		params, variadic := c.parameterTypes(node.Constructor.Parameters)
+		classType.Constructor = &FunctionType{Parameters: params, ReturnType: classType, Variadic: variadic}
+		classType.ConstructorVisibility = node.Constructor.Visibility
+	}
+
 	// Restore environment
 	if len(node.GenericParams) > 0 {
 		c.env = prevEnv
 	}
+}
 
-	c.classes[classType.Name] = classType
-	c.env.Set(classType.Name, classType)
+// registerIndexSignatures records the number and string index signatures of
+// an interface or object shape
+func (c *Checker) registerIndexSignatures(iface *InterfaceType, signatures []*ast.IndexSignature) {
+	for _, sig := range signatures {
+		keyType := c.resolveTypeExpression(sig.KeyType)
+		var index *Type
+		switch {
+		case keyType.Equals(Number):
+			index = &iface.NumberIndex
+		case keyType.Equals(String):
+			index = &iface.StringIndex
+		default:
+			c.addError(
+				fmt.Sprintf("Index signature key must be 'number' or 'string', got '%s'", keyType.String()),
+				sig.Token,
+			)
+			continue
+		}
+		if *index != nil {
+			c.addError(
+				fmt.Sprintf("Duplicate '%s' index signature", keyType.String()),
+				sig.Token,
+			)
+			continue
+		}
+		*index = c.resolveTypeExpression(sig.ValueType)
+	}
 }
 
 // registerInterface registers an interface type
 func (c *Checker) registerInterface(node *ast.InterfaceDeclaration) {
-	interfaceType := &InterfaceType{
-		Name:       node.Name.Value,
-		Methods:    make(map[string]*FunctionType),
-		Properties: make(map[string]Type),
-		Extends:    []*InterfaceType{},
-	}
+	interfaceType := c.definitions[node].(*InterfaceType)
 
 	// Register properties
 	for _, prop := range node.Properties {
-		propType := c.resolveTypeExpression(prop.Type)
-		interfaceType.Properties[prop.Name.Value] = propType
+		interfaceType.Properties[prop.Name.Value] = c.resolvePropertyType(prop)
+		interfaceType.Readonly = markReadonly(interfaceType.Readonly, prop)
 	}
 
+	c.registerIndexSignatures(interfaceType, node.IndexSignatures)
+
 	// Register methods
 	for _, method := range node.Methods {
-		params := make([]Type, len(method.Parameters))
-		for i, param := range method.Parameters {
-			params[i] = c.resolveTypeExpression(param.Type)
-		}
+		params, variadic := c.parameterTypes(method.Parameters)
 		var returnType Type = Void
 		if method.ReturnType != nil {
 			returnType = c.resolveTypeExpression(method.ReturnType)
@@ -233,94 +611,276 @@ func (c *Checker) registerInterface(node *ast.InterfaceDeclaration) {
 		interfaceType.Methods[method.Name.Value] = &FunctionType{
 			Parameters: params,
 			ReturnType: returnType,
+			Variadic:   variadic,
 		}
 	}
 
-	// Resolve extends clause
+	// Resolve extends clause. Extending a class requires its public members
 	for _, ext := range node.Extends {
 		if ident, ok := ext.(*ast.Identifier); ok {
-			if extInterface, exists := c.interfaces[ident.Value]; exists {
+			if extInterface, exists := c.lookupInterface(ident.Value); exists {
 				interfaceType.Extends = append(interfaceType.Extends, extInterface)
+			} else if extClass, exists := c.lookupClass(ident.Value); exists {
+				interfaceType.Classes = append(interfaceType.Classes, extClass)
 			} else {
 				c.addError(fmt.Sprintf("Interface '%s' not found", ident.Value), ident.Token)
 			}
 		}
 	}
+}
+
+// lookupClass finds the class a name refers to in the current scope
+func (c *Checker) lookupClass(name string) (*ClassType, bool) {
+	if typ, ok := c.env.Get(name); ok {
+		classType, isClass := typ.(*ClassType)
+		return classType, isClass
+	}
+	classType, ok := c.classes[name]
+	return classType, ok
+}
 
-	c.interfaces[interfaceType.Name] = interfaceType
-	c.env.Set(interfaceType.Name, interfaceType)
+// lookupInterface finds the interface a name refers to in the current scope
+func (c *Checker) lookupInterface(name string) (*InterfaceType, bool) {
+	if typ, ok := c.env.Get(name); ok {
+		interfaceType, isInterface := typ.(*InterfaceType)
+		return interfaceType, isInterface
+	}
+	interfaceType, ok := c.interfaces[name]
+	return interfaceType, ok
 }
 
-// registerEnum registers an enum type
-func (c *Checker) registerEnum(node *ast.EnumDeclaration) {
-	enumType := &EnumType{
-		Name:    node.Name.Value,
-		Members: make(map[string]Type),
+// newInterfaceType creates an interface type with no members yet
+func newInterfaceType(name string) *InterfaceType {
+	return &InterfaceType{
+		Name:       name,
+		Methods:    make(map[string]*FunctionType),
+		Properties: make(map[string]Type),
+		Extends:    []*InterfaceType{},
 	}
+}
 
-	// First, register the enum type itself so members can reference it
-	c.enums[enumType.Name] = enumType
-	c.env.Set(enumType.Name, enumType)
+// registerEnum registers an enum type
+func (c *Checker) registerEnum(node *ast.EnumDeclaration) {
+	enumType := c.definitions[node].(*EnumType)
 
-	for _, member := range node.Members {
+	var valueTypes []Type
+	var hasNumbers, hasStrings, afterString bool
+	for i, member := range node.Members {
+		if member.Value == nil && afterString {
+			// The generated Lua falls back to the member's name
+			c.addError(
+				fmt.Sprintf("Enum member '%s.%s' must have a value, since it follows a member with a string value",
+					node.Name.Value, member.Name.Value),
+				member.Token,
+			)
+			valueTypes = appendDistinct(valueTypes, String)
+		} else if member.Value == nil {
+			// Members without a value are numbered from 0
+			valueTypes = appendDistinct(valueTypes, c.widenLiteral(&NumberLiteralType{Value: float64(i)}))
+			hasNumbers = true
+		}
 		if member.Value != nil {
-			// Validate the value expression (should be number or string)
-			_ = c.checkExpression(member.Value)
+			// Values are numbers or strings
+			valueType := c.widenLiteral(c.checkExpression(member.Value))
+			_, afterString = member.Value.(*ast.StringLiteral)
+			switch {
+			case IsNumericType(valueType):
+				hasNumbers = true
+				valueTypes = appendDistinct(valueTypes, valueType)
+			case IsStringType(valueType):
+				hasStrings = true
+				valueTypes = appendDistinct(valueTypes, valueType)
+			case valueType.Equals(Any):
+				// Not known until runtime
+			default:
+				c.addError(
+					fmt.Sprintf("Enum member '%s.%s' must have a number or string value, got '%s'",
+						node.Name.Value, member.Name.Value, valueType.String()),
+					member.Token,
+				)
+			}
+
+			// Const enum members are inlined, so they need compile-time values
+			if node.IsConst && !isConstantEnumValue(member.Value) {
+				c.addError(
+					fmt.Sprintf("Const enum member '%s.%s' must have a constant value",
+						node.Name.Value, member.Name.Value),
+					member.Token,
+				)
+			}
 		}
 		// All enum members have the enum type itself, not the value type
 		// This ensures type safety: Color.Red has type Color, not number
 		enumType.Members[member.Name.Value] = enumType
 	}
+	if len(valueTypes) > 0 {
+		enumType.ValueType = unionOf(valueTypes)
+	}
+	// Mixing numbers and strings is allowed, but is usually a value missing
+	// its quotes
+	if hasNumbers && hasStrings {
+		c.addWarning(
+			fmt.Sprintf("Enum '%s' mixes number and string values", node.Name.Value),
+			node.Name.Token,
+		)
+	}
 }
 
-// registerTypeAlias registers a type alias
-func (c *Checker) registerTypeAlias(node *ast.TypeDeclaration) {
-	// Check if this is a generic type alias
-	if len(node.GenericParams) > 0 {
+// isConstantEnumValue reports whether expr is a literal that can be inlined
+func isConstantEnumValue(expr ast.Expression) bool {
+	switch node := expr.(type) {
+	case *ast.NumberLiteral, *ast.StringLiteral:
+		return true
+	case *ast.PrefixExpression:
+		_, isNumber := node.Right.(*ast.NumberLiteral)
+		return node.Operator == "-" && isNumber
+	}
+	return false
+}
+
+// resolvePropertyType resolves a property's declared type. Optional
+// properties (name?: T) may be absent, so they also accept nil
+func (c *Checker) resolvePropertyType(prop *ast.PropertyDeclaration) Type {
+	propType := c.resolveTypeExpression(prop.Type)
+	if prop.Optional && !IsOptionalProperty(propType) {
+		return &UnionType{Types: []Type{propType, Nil}}
+	}
+	return propType
+}
+
+// markReadonly records prop in the readonly set if it is declared readonly,
+// creating the set on first use
+func markReadonly(readonly map[string]bool, prop *ast.PropertyDeclaration) map[string]bool {
+	if !prop.Readonly {
+		return readonly
+	}
+	if readonly == nil {
+		readonly = make(map[string]bool)
+	}
+	readonly[prop.Name.Value] = true
+	return readonly
+}
+
+// markVisibility records a class member in the hidden set if it is private
+// or protected, and in the private set if it is private, creating the sets on
+// first use
+func markVisibility(class *ClassType, name, visibility string) {
+	if visibility != "private" && visibility != "protected" {
+		return
+	}
+	if class.Hidden == nil {
+		class.Hidden = make(map[string]bool)
+	}
+	class.Hidden[name] = true
+	if visibility != "private" {
+		return
+	}
+	if class.Private == nil {
+		class.Private = make(map[string]bool)
+	}
+	class.Private[name] = true
+}
+
+// declareTypeAlias makes a type alias known. Generic aliases are expanded
+// where they're used and object shapes are filled in by registerTypeAlias;
+// aliases of other types are resolved when first needed, as they may refer
+// to aliases declared after them
+func (c *Checker) declareTypeAlias(node *ast.TypeDeclaration) {
+	switch {
+	case len(node.GenericParams) > 0:
 		// Generic type alias: type Name<T, U> = Type
 		typeParams := make([]string, len(node.GenericParams))
+		constraints := make([]ast.Expression, len(node.GenericParams))
+		defaults := make([]ast.Expression, len(node.GenericParams))
 		for i, param := range node.GenericParams {
-			typeParams[i] = param.Value
+			typeParams[i] = param.Name.Value
+			constraints[i] = param.Constraint
+			defaults[i] = param.Default
 		}
 
 		genericAlias := &GenericTypeAlias{
-			Name:       node.Name.Value,
-			TypeParams: typeParams,
-			Body:       node.Type,
+			Name:        node.Name.Value,
+			TypeParams:  typeParams,
+			Constraints: constraints,
+			Defaults:    defaults,
+			Body:        node.Type,
 		}
 
-		c.genericTypeAliases[node.Name.Value] = genericAlias
+		if c.env.outer == nil {
+			c.genericTypeAliases[node.Name.Value] = genericAlias
+		}
 		c.env.Set(node.Name.Value, genericAlias)
-		return
+		c.recordSymbol(node.Name, genericAlias)
+
+	case node.Type != nil:
+		// Regular type alias: type Name = Type
+		if c.env.aliases == nil {
+			c.env.aliases = make(map[string]*ast.TypeDeclaration)
+		}
+		c.env.aliases[node.Name.Value] = node
+
+	default:
+		// Object shape: type Name ... end
+		var aliasType Type = Any
+		if len(node.Properties) > 0 || len(node.IndexSignatures) > 0 {
+			aliasType = newInterfaceType(node.Name.Value)
+		}
+		if c.env.outer == nil {
+			c.typeAliases[node.Name.Value] = aliasType
+		}
+		c.env.Set(node.Name.Value, aliasType)
+		c.recordSymbol(node.Name, aliasType)
 	}
+}
 
-	var aliasType Type
+// registerTypeAlias registers a type alias
+func (c *Checker) registerTypeAlias(node *ast.TypeDeclaration) {
+	if len(node.GenericParams) > 0 {
+		return
+	}
 
 	if node.Type != nil {
-		// Regular type alias: type Name = Type
-		aliasType = c.resolveTypeExpression(node.Type)
-	} else if len(node.Properties) > 0 {
-		// Object shape: type Name ... end
-		interfaceType := &InterfaceType{
-			Name:       node.Name.Value,
-			Properties: make(map[string]Type),
-			Methods:    make(map[string]*FunctionType),
-			Extends:    []*InterfaceType{},
+		// Already resolved if an earlier definition needed it
+		if c.env.aliases[node.Name.Value] == node {
+			c.resolveAlias(node, c.env)
 		}
+		return
+	}
 
-		// Register properties
+	// Register the properties of an object shape
+	aliasType, _ := c.env.Get(node.Name.Value)
+	if interfaceType, ok := aliasType.(*InterfaceType); ok {
 		for _, prop := range node.Properties {
-			propType := c.resolveTypeExpression(prop.Type)
-			interfaceType.Properties[prop.Name.Value] = propType
+			interfaceType.Properties[prop.Name.Value] = c.resolvePropertyType(prop)
+			interfaceType.Readonly = markReadonly(interfaceType.Readonly, prop)
 		}
+		c.registerIndexSignatures(interfaceType, node.IndexSignatures)
+	}
+}
 
-		aliasType = interfaceType
-	} else {
-		aliasType = Any
+// resolveAlias resolves the type a pending alias stands for. It's resolved
+// in env, the scope declaring it, whatever scope first needed it
+func (c *Checker) resolveAlias(node *ast.TypeDeclaration, env *Environment) Type {
+	name := node.Name.Value
+	if c.resolvingAliases[node] {
+		c.addError(fmt.Sprintf("Type alias '%s' circularly references itself", name), node.Name.Token)
+		return Any
 	}
 
-	c.typeAliases[node.Name.Value] = aliasType
-	c.env.Set(node.Name.Value, aliasType)
+	prevEnv := c.env
+	c.env = env
+	c.resolvingAliases[node] = true
+	aliasType := c.resolveTypeExpression(node.Type)
+	delete(c.resolvingAliases, node)
+	delete(env.aliases, name)
+
+	if env.outer == nil {
+		c.typeAliases[name] = aliasType
+	}
+	c.env.Set(name, aliasType)
+	c.recordSymbol(node.Name, aliasType)
+	c.env = prevEnv
+	return aliasType
 }
 
 // resolveTypeExpression resolves a type expression to a Type
@@ -328,32 +888,53 @@ func (c *Checker) resolveTypeExpression(expr ast.Expression) Type {
 	if expr == nil {
 		return Any
 	}
+	if c.options.Trace != nil {
+		return c.traceResolution(expr)
+	}
+	return c.resolveType(expr)
+}
 
+// resolveType resolves a type expression that isn't nil, resolving the
+// expressions nested in it through resolveTypeExpression
+func (c *Checker) resolveType(expr ast.Expression) Type {
 	switch node := expr.(type) {
 	case *ast.Identifier:
+		if decl, env := c.env.pendingAlias(node.Value); decl != nil {
+			c.traceLookup(node, "as a type alias not yet resolved")
+			return c.resolveAlias(decl, env)
+		}
 		// Check for built-in types
 		if typ, ok := c.env.Get(node.Value); ok {
+			c.traceLookup(node, "in scope")
 			return typ
 		}
 		// Check for user-defined types
 		if classType, ok := c.classes[node.Value]; ok {
+			c.traceLookup(node, "as a class")
 			return classType
 		}
 		if interfaceType, ok := c.interfaces[node.Value]; ok {
+			c.traceLookup(node, "as an interface")
 			return interfaceType
 		}
 		if enumType, ok := c.enums[node.Value]; ok {
+			c.traceLookup(node, "as an enum")
 			return enumType
 		}
 		if aliasType, ok := c.typeAliases[node.Value]; ok {
+			c.traceLookup(node, "as a type alias")
 			return aliasType
 		}
+		c.traceLookup(node, "")
 		c.addError(fmt.Sprintf("Unknown type '%s'", node.Value), node.Token)
 		return Any
 
+	case *ast.OptionalType:
+		return &OptionalType{BaseType: c.resolveTypeExpression(node.Type)}
+
 	case *ast.ArrayType:
 		elementType := c.resolveTypeExpression(node.ElementType)
-		return &ArrayType{ElementType: elementType}
+		return &ArrayType{ElementType: elementType, Readonly: node.Readonly}
 
 	case *ast.TableType:
 		keyType := c.resolveTypeExpression(node.KeyType)
@@ -373,6 +954,19 @@ func (c *Checker) resolveTypeExpression(expr ast.Expression) Type {
 		}
 		return &UnionType{Types: types}
 
+	case *ast.IntersectionType:
+		types := make([]Type, 0, len(node.Types))
+		for _, t := range node.Types {
+			resolvedType := c.resolveTypeExpression(t)
+			// Flatten nested intersections, such as those from type aliases
+			if intersection, ok := resolvedType.(*IntersectionType); ok {
+				types = append(types, intersection.Types...)
+			} else {
+				types = append(types, resolvedType)
+			}
+		}
+		return &IntersectionType{Types: types}
+
 	case *ast.TupleType:
 		elements := make([]Type, len(node.Types))
 		for i, elem := range node.Types {
@@ -391,33 +985,53 @@ func (c *Checker) resolveTypeExpression(expr ast.Expression) Type {
 		}
 		return &FunctionType{Parameters: params, ReturnType: returnType}
 
+	case *ast.TypePredicate:
+		// A type guard returns a boolean; the narrowing it describes is
+		// recorded separately by resolveTypeGuard
+		return Boolean
+
 	case *ast.GenericType:
 		// Check if this is a generic type alias instantiation like Nullable<string>
 		if baseIdent, ok := node.BaseType.(*ast.Identifier); ok {
-			if genericAlias, exists := c.genericTypeAliases[baseIdent.Value]; exists {
+			if genericAlias, exists := c.lookupGenericAlias(baseIdent.Value); exists {
 				// Resolve type arguments
 				typeArgs := make([]Type, len(node.TypeArguments))
 				for i, arg := range node.TypeArguments {
 					typeArgs[i] = c.resolveTypeExpression(arg)
 				}
 
-				// Check parameter count matches
-				if len(typeArgs) != len(genericAlias.TypeParams) {
-					c.addError(
-						fmt.Sprintf("Generic type '%s' expects %d type arguments, got %d",
-							genericAlias.Name, len(genericAlias.TypeParams), len(typeArgs)),
-						lexer.Token{},
-					)
+				// Defaulted parameters may be left out
+				typeArgs, ok := c.completeTypeArguments(genericAlias.Name, genericAlias.TypeParams, genericAlias.Defaults, typeArgs, node.Token)
+				if !ok {
 					return Any
 				}
 
-				// Create substitution map and resolve the body
-				return c.substituteTypeParams(genericAlias.Body, genericAlias.TypeParams, typeArgs)
+				c.checkTypeArguments(genericAlias.TypeParams, genericAlias.Constraints, typeArgs, node.Token)
+
+				return c.instantiateAlias(genericAlias, typeArgs, node.Token)
+			}
+
+			// A generic class isn't instantiated, as its members use each
+			// type parameter's constraint, but the type arguments given are
+			// counted and checked. The defaults of those left out were
+			// checked against their constraints with the class
+			if classType, exists := c.lookupClass(baseIdent.Value); exists && len(classType.TypeParams) > 0 {
+				typeArgs := make([]Type, len(node.TypeArguments))
+				for i, arg := range node.TypeArguments {
+					typeArgs[i] = c.resolveTypeExpression(arg)
+				}
+				if typeArgs, ok := c.completeTypeArguments(classType.Name, classType.TypeParams, classType.Defaults, typeArgs, node.Token); ok {
+					c.checkTypeArguments(classType.TypeParams, classType.Constraints[:len(node.TypeArguments)], typeArgs, node.Token)
+				}
+				return classType
 			}
 		}
 
 		// Not a generic type alias, try regular type resolution
 		baseType := c.resolveTypeExpression(node.BaseType)
+		if baseType.Equals(Number) || baseType.Equals(Int) {
+			return c.resolveRangeType(node, baseType)
+		}
 		return baseType
 
 	case *ast.StringLiteral:
@@ -426,7 +1040,35 @@ func (c *Checker) resolveTypeExpression(expr ast.Expression) Type {
 
 	case *ast.NumberLiteral:
 		// Number literal in type position becomes a literal type
-		return &NumberLiteralType{Value: node.Value}
+		return &NumberLiteralType{Value: node.Value, IsFloat: node.IsFloat}
+
+	case *ast.ObjectShapeType:
+		// Inline object shapes are anonymous structural interfaces
+		shape := &InterfaceType{
+			Properties: make(map[string]Type),
+			Methods:    make(map[string]*FunctionType),
+			Extends:    []*InterfaceType{},
+		}
+		c.registerIndexSignatures(shape, node.IndexSignatures)
+		var fields []string
+		for _, sig := range node.IndexSignatures {
+			fields = append(fields, sig.String())
+		}
+		for _, prop := range node.Properties {
+			propType := c.resolvePropertyType(prop)
+			shape.Properties[prop.Name.Value] = propType
+			shape.Readonly = markReadonly(shape.Readonly, prop)
+			field := fmt.Sprintf("%s: %s", prop.Name.Value, propType.String())
+			if prop.Readonly {
+				field = "readonly " + field
+			}
+			fields = append(fields, field)
+		}
+		shape.Name = "{}"
+		if len(fields) > 0 {
+			shape.Name = "{ " + strings.Join(fields, ", ") + " }"
+		}
+		return shape
 
 	default:
 		c.addError(fmt.Sprintf("Cannot resolve type expression: %T", expr), lexer.Token{})
@@ -434,12 +1076,119 @@ func (c *Checker) resolveTypeExpression(expr ast.Expression) Type {
 	}
 }
 
-// substituteTypeParams substitutes type parameters in a type expression
-// For example: substituting T with string in (nil | T) yields (nil | string)
-func (c *Checker) substituteTypeParams(body ast.Expression, typeParams []string, typeArgs []Type) Type {
-	if body == nil {
-		return Any
+// lookupGenericAlias finds the generic type alias a name refers to in the
+// current scope
+func (c *Checker) lookupGenericAlias(name string) (*GenericTypeAlias, bool) {
+	if typ, ok := c.env.Get(name); ok {
+		genericAlias, isGeneric := typ.(*GenericTypeAlias)
+		return genericAlias, isGeneric
 	}
+	genericAlias, ok := c.genericTypeAliases[name]
+	return genericAlias, ok
+}
+
+// resolveRangeType resolves a bounded number such as number<0, 100> or
+// int<1, 6>, whose type arguments are its minimum and maximum
+func (c *Checker) resolveRangeType(node *ast.GenericType, baseType Type) Type {
+	bounds := make([]float64, 0, 2)
+	for _, arg := range node.TypeArguments {
+		if number, ok := arg.(*ast.NumberLiteral); ok {
+			bounds = append(bounds, number.Value)
+		}
+	}
+	if len(node.TypeArguments) != 2 || len(bounds) != 2 {
+		c.addError(
+			fmt.Sprintf("Range type '%s' expects a minimum and a maximum number", node.String()),
+			node.Token,
+		)
+		return baseType
+	}
+
+	if bounds[0] > bounds[1] {
+		c.addError(
+			fmt.Sprintf("Range type '%s' has a minimum greater than its maximum", node.String()),
+			node.Token,
+		)
+		return baseType
+	}
+
+	return &RangeType{Base: baseType, Min: bounds[0], Max: bounds[1]}
+}
+
+// instantiateAlias resolves a generic alias with its type arguments. Each
+// instantiation is resolved once, so an argument that nests the alias in
+// itself isn't expanded again at every level. An object shape is made known
+// before its properties are resolved, so a property referring back to the
+// same instantiation, as in type Tree<T> = { left: Tree<T>? }, refers to
+// the shape itself
+func (c *Checker) instantiateAlias(alias *GenericTypeAlias, typeArgs []Type, token lexer.Token) Type {
+	args := make([]string, len(typeArgs))
+	for i, arg := range typeArgs {
+		args[i] = arg.String()
+	}
+	name := fmt.Sprintf("%s<%s>", alias.Name, strings.Join(args, ", "))
+	key := fmt.Sprintf("%p %s", alias, name)
+	if typ, ok := c.instantiations[key]; ok {
+		return typ
+	}
+	if c.resolvingInstances[key] {
+		c.addError(fmt.Sprintf("Type alias '%s' circularly references itself", name), token)
+		return Any
+	}
+
+	_, isShape := alias.Body.(*ast.ObjectShapeType)
+	if !isShape {
+		c.resolvingInstances[key] = true
+		typ := c.substituteTypeParams(alias.Body, alias.TypeParams, typeArgs, token)
+		delete(c.resolvingInstances, key)
+		c.instantiations[key] = typ
+		return typ
+	}
+
+	shape := newInterfaceType(name)
+	c.instantiations[key] = shape
+	resolved, ok := c.substituteTypeParams(alias.Body, alias.TypeParams, typeArgs, token).(*InterfaceType)
+	if !ok {
+		// Abandoned as too deep
+		delete(c.instantiations, key)
+		return Any
+	}
+	*shape = *resolved
+	return shape
+}
+
+// maxInstantiationDepth bounds how deeply generic aliases may expand within
+// one another before the expansion is assumed to be infinite
+const maxInstantiationDepth = 64
+
+// substituteTypeParams substitutes type parameters in a type expression
+// For example: substituting T with string in (nil | T) yields (nil | string).
+// Parameters are bound in a scope rather than rewritten in the AST, so any
+// body shape (arrays, tables, tuples, functions, object shapes) resolves
+// through the same path. token locates the instantiation for errors
+func (c *Checker) substituteTypeParams(body ast.Expression, typeParams []string, typeArgs []Type, token lexer.Token) Type {
+	if body == nil {
+		return Any
+	}
+
+	// An alias that instantiates itself with ever larger arguments, like
+	// type Grow<T> = Grow<T[]>, would otherwise expand forever
+	if c.instantiationTooDeep {
+		return Any
+	}
+	if c.instantiationDepth >= maxInstantiationDepth {
+		c.addError("Generic type instantiation too deep (possible infinite expansion)", token)
+		c.instantiationTooDeep = true
+		return Any
+	}
+	c.instantiationDepth++
+	defer func() {
+		c.instantiationDepth--
+		if c.instantiationDepth == 0 {
+			c.instantiationTooDeep = false
+		}
+	}()
+	c.traceSubstitution(typeParams, typeArgs)
 
 	// Create a substitution map
 	substitutions := make(map[string]Type)
@@ -460,9 +1209,90 @@ func (c *Checker) substituteTypeParams(body ast.Expression, typeParams []string,
 	// Restore environment
 	c.env = prevEnv
 
+	if c.instantiationTooDeep {
+		return Any
+	}
 	return result
 }
 
+// declareTypeParams adds the type parameters of a generic declaration to the
+// current scope. Within the declaration a constrained parameter stands for
+// its constraint, so its values can be used as one; an unconstrained
+// parameter is any
+func (c *Checker) declareTypeParams(params []*ast.GenericParameter) {
+	for _, param := range params {
+		var typ Type = Any
+		if param.Constraint != nil {
+			typ = c.resolveTypeExpression(param.Constraint)
+		}
+		c.env.Set(param.Name.Value, typ)
+	}
+}
+
+// checkTypeParamDefaults reports the defaults of type parameters that don't
+// satisfy their constraints, where the parameters are declared
+func (c *Checker) checkTypeParamDefaults(params []*ast.GenericParameter) {
+	for _, param := range params {
+		if param.Default == nil || param.Constraint == nil {
+			continue
+		}
+		defaultType := c.resolveTypeExpression(param.Default)
+		constraintType := c.resolveTypeExpression(param.Constraint)
+		if !defaultType.IsAssignableTo(constraintType) {
+			c.addError(
+				fmt.Sprintf("Default type '%s' does not satisfy the constraint '%s' of type parameter '%s'",
+					defaultType.String(), constraintType.String(), param.Name.Value),
+				param.Token,
+			)
+		}
+	}
+}
+
+// completeTypeArguments checks the number of type arguments given to a
+// generic type and fills in the defaults of the parameters left out. A
+// default may refer to the parameters before it. It reports false, having
+// reported the error, when the number is wrong
+func (c *Checker) completeTypeArguments(name string, typeParams []string, defaults []ast.Expression, typeArgs []Type, token lexer.Token) ([]Type, bool) {
+	required := requiredTypeParams(typeParams, defaults)
+	total := len(typeParams)
+	if len(typeArgs) < required || len(typeArgs) > total {
+		expected := fmt.Sprintf("%d", total)
+		if required < total {
+			expected = fmt.Sprintf("%d to %d", required, total)
+		}
+		c.addError(
+			fmt.Sprintf("Generic type '%s' expects %s type arguments, got %d",
+				name, expected, len(typeArgs)),
+			token,
+		)
+		return nil, false
+	}
+
+	for i := len(typeArgs); i < total; i++ {
+		typeArgs = append(typeArgs, c.substituteTypeParams(defaults[i], typeParams[:i], typeArgs, token))
+	}
+	return typeArgs, true
+}
+
+// checkTypeArguments reports type arguments that aren't assignable to the
+// constraints of their type parameters. A constraint may refer to the
+// parameters before it
+func (c *Checker) checkTypeArguments(typeParams []string, constraints []ast.Expression, typeArgs []Type, token lexer.Token) {
+	for i, constraint := range constraints {
+		if constraint == nil || i >= len(typeArgs) {
+			continue
+		}
+		constraintType := c.substituteTypeParams(constraint, typeParams[:i], typeArgs[:i], token)
+		if !typeArgs[i].IsAssignableTo(constraintType) {
+			c.addError(
+				fmt.Sprintf("Type '%s' does not satisfy the constraint '%s' of type parameter '%s'",
+					typeArgs[i].String(), constraintType.String(), typeParams[i]),
+				token,
+			)
+		}
+	}
+}
+
 // checkStatement checks a statement
 func (c *Checker) checkStatement(stmt ast.Statement) {
 	if stmt == nil {
@@ -472,6 +1302,8 @@ func (c *Checker) checkStatement(stmt ast.Statement) {
 	switch node := stmt.(type) {
 	case *ast.VariableDeclaration:
 		c.checkVariableDeclaration(node)
+	case *ast.DestructuringDeclaration:
+		c.checkDestructuringDeclaration(node)
 	case *ast.FunctionDeclaration:
 		c.checkFunctionDeclaration(node)
 	case *ast.ExpressionStatement:
@@ -482,12 +1314,16 @@ func (c *Checker) checkStatement(stmt ast.Statement) {
 		c.checkIfStatement(node)
 	case *ast.WhileStatement:
 		c.checkWhileStatement(node)
+	case *ast.RepeatStatement:
+		c.checkRepeatStatement(node)
 	case *ast.ForStatement:
 		c.checkForStatement(node)
 	case *ast.DoStatement:
 		c.checkDoStatement(node)
 	case *ast.BreakStatement:
-		// Nothing to check for break
+		if c.loopDepth == 0 {
+			c.addError("break statement not within a loop", node.Token)
+		}
 	case *ast.BlockStatement:
 		c.checkBlockStatement(node)
 	case *ast.AssignmentStatement:
@@ -506,7 +1342,7 @@ func (c *Checker) checkStatement(stmt ast.Statement) {
 	case *ast.ExportStatement:
 		c.checkExportStatement(node)
 	case *ast.ImportStatement:
-		c.checkImportStatement(node)
+		// Imported names were bound with the type definitions
 	}
 }
 
@@ -519,19 +1355,23 @@ func (c *Checker) checkVariableDeclaration(node *ast.VariableDeclaration) {
 
 	var valueType Type
 	if node.Value != nil {
-		valueType = c.checkExpression(node.Value)
+		valueType = singleValue(node.Value, c.checkExpression(node.Value))
 	} else {
 		valueType = Nil
 	}
 
 	// If type is declared, check if value is assignable
 	if declaredType != nil {
-		if !valueType.IsAssignableTo(declaredType) {
+		if c.checkArrayLiteral(node.Value, declaredType) {
+			// Checked element by element
+		} else if !valueType.IsAssignableTo(declaredType) {
 			c.addError(
 				fmt.Sprintf("Cannot assign type '%s' to variable of type '%s'",
 					valueType.String(), declaredType.String()),
 				node.Token,
 			)
+		} else {
+			c.checkExcessProperties(node.Value, declaredType)
 		}
 		// Use SetConst if variable is declared as const
 		if node.IsConstant {
@@ -539,73 +1379,480 @@ func (c *Checker) checkVariableDeclaration(node *ast.VariableDeclaration) {
 		} else {
 			c.env.Set(node.Name.Value, declaredType)
 		}
+		c.recordSymbol(node.Name, declaredType)
 	} else {
 		// Infer type from value
+		if c.options.NoImplicitAny && IsImplicitAny(valueType) {
+			c.addError(fmt.Sprintf("Variable '%s' implicitly has type 'any'", node.Name.Value), node.Name.Token)
+		}
 		if node.IsConstant {
 			c.env.SetConst(node.Name.Value, valueType)
 		} else {
 			c.env.Set(node.Name.Value, valueType)
 		}
+		c.recordSymbol(node.Name, valueType)
+	}
+}
+
+// checkDestructuringDeclaration checks local { name = default } = value.
+// Each field must be a property of the value, and its default must be
+// assignable to the property. A field with a default isn't nil
+func (c *Checker) checkDestructuringDeclaration(node *ast.DestructuringDeclaration) {
+	valueType := c.checkExpression(node.Value)
+
+	// Like a run of local statements, each default can use the fields
+	// before it
+	for _, field := range node.Fields {
+		fieldType := c.destructuredFieldType(valueType, field.Name)
+		if field.Default != nil {
+			if nonNil, ok := withoutNil(fieldType); ok {
+				fieldType = nonNil
+			}
+			defaultType := c.checkExpression(field.Default)
+			if !defaultType.IsAssignableTo(fieldType) {
+				c.addError(
+					fmt.Sprintf("Cannot use type '%s' as the default of '%s', which has type '%s'",
+						defaultType.String(), field.Name.Value, fieldType.String()),
+					field.Name.Token,
+				)
+			}
+		}
+		c.env.Set(field.Name.Value, fieldType)
+		c.recordSymbol(field.Name, fieldType)
+	}
+}
+
+// destructuredFieldType returns the type of a field destructured from a
+// value, reporting fields the value's type doesn't have
+func (c *Checker) destructuredFieldType(valueType Type, name *ast.Identifier) Type {
+	switch typ := valueType.(type) {
+	case *ClassType, *InterfaceType, *IntersectionType:
+		if propType, ok := typ.(memberLookup).GetProperty(name.Value); ok {
+			return propType
+		}
+		c.addError(
+			fmt.Sprintf("Type '%s' has no property '%s' to destructure", typ.String(), name.Value),
+			name.Token,
+		)
+		return Any
+	case *TableType:
+		if _, isAny := typ.ValueType.(*AnyType); isAny {
+			return typ.ValueType
+		}
+		// A missing key reads as nil
+		if String.IsAssignableTo(typ.KeyType) {
+			return &OptionalType{BaseType: typ.ValueType}
+		}
+		c.addError(
+			fmt.Sprintf("Cannot destructure '%s' from type '%s', whose keys aren't strings", name.Value, typ.String()),
+			name.Token,
+		)
+		return Any
+	case *OptionalType:
+		c.addError(
+			fmt.Sprintf("Cannot destructure '%s' from type '%s', which may be nil; check it against nil first", name.Value, typ.String()),
+			name.Token,
+		)
+		return Any
 	}
+	// Plain tables and any may hold anything
+	return Any
 }
 
 // checkFunctionDeclaration checks a function declaration
 func (c *Checker) checkFunctionDeclaration(node *ast.FunctionDeclaration) {
-	// Add generic type parameters to current scope first (for type resolution)
+	// Top-level functions were resolved up front by hoistFunctions
+	funcType, ok := c.functionTypes[node]
+	if !ok {
+		funcType = c.functionType(node)
+	}
+	params, variadic := funcType.Parameters, funcType.Variadic
+	returnType := funcType.ReturnType
+
 	prevEnv := c.env
-	if len(node.GenericParams) > 0 {
-		c.env = NewEnclosedEnvironment(prevEnv)
-		for _, genericParam := range node.GenericParams {
-			c.env.Set(genericParam.Value, Any)
-		}
+	c.env.Set(node.Name.Value, funcType)
+	c.recordSymbol(node.Name, funcType)
+
+	// Check function body in new scope. Loops outside the function don't
+	// enclose its body
+	prevReturnType := c.currentFunctionReturnType
+	prevInference := c.returnInference
+	prevVarargs := c.currentVarargs
+	prevLoopDepth := c.loopDepth
+	c.env = NewEnclosedEnvironment(c.env)
+	c.currentFunctionReturnType = returnType
+	c.returnInference = nil
+	if node.ReturnType == nil {
+		c.returnInference = &returnInference{}
 	}
+	c.currentVarargs = variadic
+	c.loopDepth = 0
 
-	// Create function type
-	params := make([]Type, len(node.Parameters))
-	for i, param := range node.Parameters {
-		if param.Type != nil {
-			params[i] = c.resolveTypeExpression(param.Type)
-		} else {
-			params[i] = Any
+	// Add generic type parameters to scope
+	c.declareTypeParams(node.GenericParams)
+
+	// Add parameters to scope
+	c.declareParameters(node.Parameters, params)
+
+	// Check body
+	c.checkBlockStatement(node.Body)
+
+	// Callers checked from here on see the return type the body implies.
+	// Falling off the end of the body returns nil
+	if c.returnInference != nil {
+		if len(c.returnInference.types) > 0 && reachesEnd(node.Body.Statements) {
+			c.returnInference.add(Nil)
+		}
+		funcType.ReturnType = c.returnInference.returnType()
+		if bare := c.returnInference.bare; bare != nil && len(c.returnInference.types) > 0 {
+			c.addError(
+				fmt.Sprintf("Missing return value; the other return statements of '%s' return '%s'",
+					node.Name.Value, funcType.ReturnType.String()),
+				bare.Token,
+			)
 		}
 	}
 
-	var returnType Type = Void
+	c.env = prevEnv
+	c.currentFunctionReturnType = prevReturnType
+	c.returnInference = prevInference
+	c.currentVarargs = prevVarargs
+	c.loopDepth = prevLoopDepth
+}
+
+// functionType resolves the signature of a function declaration
+func (c *Checker) functionType(node *ast.FunctionDeclaration) *FunctionType {
+	// Add generic type parameters to a scope first (for type resolution)
+	prevEnv := c.env
+	if len(node.GenericParams) > 0 {
+		c.env = NewEnclosedEnvironment(prevEnv)
+		c.declareTypeParams(node.GenericParams)
+	}
+	defer func() { c.env = prevEnv }()
+
+	params, variadic := c.parameterTypes(node.Parameters)
+
+	// Without an annotation the return type is inferred once the body has
+	// been checked. Until then, as when the function calls itself, it may
+	// return anything
+	var returnType Type = Any
 	if node.ReturnType != nil {
 		returnType = c.resolveTypeExpression(node.ReturnType)
 	}
 
-	funcType := &FunctionType{
+	return &FunctionType{
 		Parameters: params,
 		ReturnType: returnType,
+		Guard:      c.resolveTypeGuard(node.Parameters, node.ReturnType),
+		Variadic:   variadic,
+		Generic:    genericSignature(node),
 	}
+}
 
-	// Restore environment and register function
-	if len(node.GenericParams) > 0 {
-		c.env = prevEnv
+// genericSignature records the declared signature of a function with type
+// parameters, or returns nil for a function without them
+func genericSignature(node *ast.FunctionDeclaration) *GenericSignature {
+	if len(node.GenericParams) == 0 {
+		return nil
+	}
+	signature := &GenericSignature{ReturnType: node.ReturnType}
+	for _, genericParam := range node.GenericParams {
+		signature.TypeParams = append(signature.TypeParams, genericParam.Name.Value)
+		signature.Constraints = append(signature.Constraints, genericParam.Constraint)
+		signature.Defaults = append(signature.Defaults, genericParam.Default)
+	}
+	for _, param := range node.Parameters {
+		if !param.IsVariadic {
+			signature.Parameters = append(signature.Parameters, param.Type)
+		}
+	}
+	return signature
+}
+
+// returnInference collects what the return statements of a function without
+// a return type annotation return
+type returnInference struct {
+	types []Type
+	bare  *ast.ReturnStatement // the first return statement without a value
+}
+
+// add records the type of a returned value, once per distinct type
+func (r *returnInference) add(typ Type) {
+	r.types = appendDistinct(r.types, typ)
+}
+
+// returnType is the union of the distinct returned types, the type itself
+// when every return statement returns the same type, and void when none
+// returns a value
+func (r *returnInference) returnType() Type {
+	if len(r.types) == 0 {
+		return Void
+	}
+	for _, typ := range r.types {
+		if _, isAny := typ.(*AnyType); isAny {
+			return typ
+		}
+	}
+	return unionOf(r.types)
+}
+
+// appendDistinct appends typ to types unless an equal type is already there
+func appendDistinct(types []Type, typ Type) []Type {
+	for _, existing := range types {
+		if existing.Equals(typ) {
+			return types
+		}
+	}
+	return append(types, typ)
+}
+
+// unionOf returns the union of one or more types, or the type itself when
+// there is only one
+func unionOf(types []Type) Type {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return &UnionType{Types: types}
+}
+
+// inferReturn records what a return statement returns in a function whose
+// return type is being inferred. Literal types are widened, so a function
+// returning 5 returns a number rather than only 5
+func (c *Checker) inferReturn(node *ast.ReturnStatement) {
+	switch {
+	case node.ReturnValues != nil:
+		valueTypes := c.checkValueList(node.ReturnValues)
+		for i, typ := range valueTypes {
+			valueTypes[i] = c.widenLiteral(typ)
+		}
+		c.returnInference.add(&TupleType{Elements: valueTypes})
+	case node.ReturnValue != nil:
+		c.returnInference.add(c.widenLiteral(c.checkExpression(node.ReturnValue)))
+	default:
+		if c.returnInference.bare == nil {
+			c.returnInference.bare = node
+		}
+	}
+}
+
+// reachesEnd reports whether control can reach the end of statements rather
+// than leaving them by a return or a break
+func reachesEnd(statements []ast.Statement) bool {
+	for _, stmt := range statements {
+		switch node := stmt.(type) {
+		case *ast.ReturnStatement, *ast.BreakStatement:
+			return false
+		case *ast.IfStatement:
+			// Without an else, skipping every branch reaches the end
+			if node.Alternative == nil || reachesEnd(node.Consequence.Statements) || reachesEnd(node.Alternative.Statements) {
+				continue
+			}
+			leaves := true
+			for _, elseIf := range node.ElseIfs {
+				leaves = leaves && !reachesEnd(elseIf.Consequence.Statements)
+			}
+			if leaves {
+				return false
+			}
+		case *ast.DoStatement:
+			if !reachesEnd(node.Body.Statements) {
+				return false
+			}
+		case *ast.WhileStatement:
+			// Only while true without a break never ends
+			if isTrue(node.Condition) && !breaks(node.Body.Statements) {
+				return false
+			}
+		case *ast.RepeatStatement:
+			if !reachesEnd(node.Body.Statements) && !breaks(node.Body.Statements) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// breaks reports whether statements of a loop body hold a break out of it,
+// outside any loop nested in them
+func breaks(statements []ast.Statement) bool {
+	for _, stmt := range statements {
+		switch node := stmt.(type) {
+		case *ast.BreakStatement:
+			return true
+		case *ast.IfStatement:
+			if breaks(node.Consequence.Statements) || (node.Alternative != nil && breaks(node.Alternative.Statements)) {
+				return true
+			}
+			for _, elseIf := range node.ElseIfs {
+				if breaks(elseIf.Consequence.Statements) {
+					return true
+				}
+			}
+		case *ast.DoStatement:
+			if breaks(node.Body.Statements) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isTrue reports whether expr is the literal true
+func isTrue(expr ast.Expression) bool {
+	boolean, ok := expr.(*ast.BooleanLiteral)
+	return ok && boolean.Value
+}
+
+// widenLiteral returns the primitive type of a literal type: string for a
+// string literal, and number, or int or float with the number subtypes,
+// for a number literal
+func (c *Checker) widenLiteral(typ Type) Type {
+	switch t := typ.(type) {
+	case *StringLiteralType:
+		return String
+	case *NumberLiteralType:
+		if !c.numberSubtypes {
+			return Number
+		}
+		if t.IsInteger() {
+			return Int
+		}
+		return Float
+	}
+	return typ
+}
+
+// checkFunctionLiteral checks an anonymous function and returns its type.
+// Without a return type annotation it may return anything, since it's
+// usually a callback whose result the caller decides what to do with
+func (c *Checker) checkFunctionLiteral(node *ast.FunctionLiteral) Type {
+	params, variadic := c.parameterTypes(node.Parameters)
+
+	var returnType Type = ImplicitAny
+	if node.ReturnType != nil {
+		returnType = c.resolveTypeExpression(node.ReturnType)
 	}
-	c.env.Set(node.Name.Value, funcType)
 
-	// Check function body in new scope
+	// The body runs later, when the function is called, so it isn't part of
+	// any loop or constructor around the expression
+	prevEnv := c.env
 	prevReturnType := c.currentFunctionReturnType
+	prevInference := c.returnInference
+	prevVarargs := c.currentVarargs
+	prevLoopDepth := c.loopDepth
+	prevConstructorClass := c.currentConstructorClass
 	c.env = NewEnclosedEnvironment(c.env)
 	c.currentFunctionReturnType = returnType
+	c.returnInference = nil
+	c.currentVarargs = variadic
+	c.loopDepth = 0
+	c.currentConstructorClass = nil
 
-	// Add generic type parameters to scope
-	for _, genericParam := range node.GenericParams {
-		c.env.Set(genericParam.Value, Any)
-	}
+	c.declareParameters(node.Parameters, params)
 
-	// Add parameters to scope
-	for i, param := range node.Parameters {
-		c.env.Set(param.Name.Value, params[i])
+	c.checkBlockStatement(node.Body)
+
+	c.env = prevEnv
+	c.currentFunctionReturnType = prevReturnType
+	c.returnInference = prevInference
+	c.currentVarargs = prevVarargs
+	c.loopDepth = prevLoopDepth
+	c.currentConstructorClass = prevConstructorClass
+
+	return &FunctionType{
+		Parameters: params,
+		ReturnType: returnType,
+		Variadic:   variadic,
 	}
+}
 
-	// Check body
-	c.checkBlockStatement(node.Body)
+// checkDoExpression checks a do ... end block used as a value. The block
+// runs as a function of its own, so returns inside it leave only the block.
+// Its type is that of the value its final return or expression statement
+// yields, nil when there is none
+func (c *Checker) checkDoExpression(node *ast.DoExpression) Type {
+	prevEnv := c.env
+	prevReturnType := c.currentFunctionReturnType
+	prevInference := c.returnInference
+	prevVarargs := c.currentVarargs
+	prevLoopDepth := c.loopDepth
+	c.env = NewEnclosedEnvironment(c.env)
+	c.currentFunctionReturnType = Any
+	c.returnInference = nil
+	c.currentVarargs = nil
+	c.loopDepth = 0
+
+	var result Type = Nil
+	statements := node.Body.Statements
+	c.declareTypes(statements)
+	for i, stmt := range statements {
+		if i < len(statements)-1 {
+			c.checkStatement(stmt)
+			continue
+		}
+		result = c.checkBlockValue(stmt)
+	}
 
 	c.env = prevEnv
 	c.currentFunctionReturnType = prevReturnType
+	c.returnInference = prevInference
+	c.currentVarargs = prevVarargs
+	c.loopDepth = prevLoopDepth
+
+	return result
+}
+
+// checkBlockValue checks the final statement of a do expression and returns
+// the type of the value it yields
+func (c *Checker) checkBlockValue(stmt ast.Statement) Type {
+	switch node := stmt.(type) {
+	case *ast.ReturnStatement:
+		if node.ReturnValues != nil {
+			return &TupleType{Elements: c.checkValueList(node.ReturnValues)}
+		}
+		if node.ReturnValue != nil {
+			return c.checkExpression(node.ReturnValue)
+		}
+	case *ast.ExpressionStatement:
+		return c.checkExpression(node.Expression)
+	}
+	c.checkStatement(stmt)
+	return Nil
+}
+
+// parameterTypes resolves the types of a function's parameters, an implicit
+// any where there is no annotation. A trailing ... parameter isn't included; the type
+// of its values is returned separately, nil when there is no ... parameter
+func (c *Checker) parameterTypes(params []*ast.Parameter) ([]Type, Type) {
+	types := make([]Type, 0, len(params))
+	var variadic Type
+	for _, param := range params {
+		var paramType Type = ImplicitAny
+		if param.Type != nil {
+			paramType = c.resolveTypeExpression(param.Type)
+		}
+		if param.IsVariadic {
+			variadic = paramType
+			continue
+		}
+		types = append(types, paramType)
+	}
+	return types, variadic
+}
+
+// declareParameters adds a function's parameters to the current scope, given
+// the types parameterTypes resolved for them
+func (c *Checker) declareParameters(params []*ast.Parameter, types []Type) {
+	for i, param := range params {
+		if c.options.NoImplicitAny && param.Type == nil {
+			c.addError(fmt.Sprintf("Parameter '%s' implicitly has type 'any'", param.Name.Value), param.Token)
+		}
+		// A trailing ... parameter is read through ... expressions instead
+		if i < len(types) {
+			c.env.Set(param.Name.Value, types[i])
+			c.recordSymbol(param.Name, types[i])
+		}
+	}
 }
 
 // checkReturnStatement checks a return statement
@@ -614,6 +1861,15 @@ func (c *Checker) checkReturnStatement(node *ast.ReturnStatement) {
 		c.addError("Return statement outside of function", node.Token)
 		return
 	}
+	if c.returnInference != nil {
+		c.inferReturn(node)
+		return
+	}
+
+	if node.ReturnValues != nil {
+		c.checkReturnValues(node)
+		return
+	}
 
 	if node.ReturnValue == nil {
 		if !IsVoidType(c.currentFunctionReturnType) {
@@ -627,6 +1883,9 @@ func (c *Checker) checkReturnStatement(node *ast.ReturnStatement) {
 	}
 
 	returnType := c.checkExpression(node.ReturnValue)
+	if c.checkArrayLiteral(node.ReturnValue, c.currentFunctionReturnType) {
+		return
+	}
 	if !returnType.IsAssignableTo(c.currentFunctionReturnType) {
 		c.addError(
 			fmt.Sprintf("Cannot return type '%s' from function with return type '%s'",
@@ -636,20 +1895,472 @@ func (c *Checker) checkReturnStatement(node *ast.ReturnStatement) {
 	}
 }
 
-// checkIfStatement checks an if statement
-func (c *Checker) checkIfStatement(node *ast.IfStatement) {
-	condType := c.checkExpression(node.Condition)
-	if !IsBooleanType(condType) && !condType.Equals(Any) {
+// checkReturnValues checks return a, b against a tuple return type such as
+// (number, string), one value per element
+func (c *Checker) checkReturnValues(node *ast.ReturnStatement) {
+	valueTypes := c.checkValueList(node.ReturnValues)
+	if c.currentFunctionReturnType.Equals(Any) {
+		return
+	}
+
+	tuple, ok := c.currentFunctionReturnType.(*TupleType)
+	if !ok || len(tuple.Elements) != len(valueTypes) {
 		c.addError(
-			fmt.Sprintf("If condition must be boolean, got '%s'", condType.String()),
+			fmt.Sprintf("Cannot return %d values from function with return type '%s'",
+				len(valueTypes), c.currentFunctionReturnType.String()),
 			node.Token,
 		)
+		return
+	}
+
+	for i, valueType := range valueTypes {
+		if !valueType.IsAssignableTo(tuple.Elements[i]) {
+			c.addError(
+				fmt.Sprintf("Return value %d: cannot return type '%s' as type '%s'",
+					i+1, valueType.String(), tuple.Elements[i].String()),
+				node.Token,
+			)
+		}
 	}
+}
 
-	c.checkBlockStatement(node.Consequence)
-	if node.Alternative != nil {
-		c.checkBlockStatement(node.Alternative)
+// checkValueList checks a comma-separated list of values and returns the
+// type of each value it produces. As in Lua, a call returning several
+// values spreads them when it comes last and is cut to its first otherwise
+func (c *Checker) checkValueList(values []ast.Expression) []Type {
+	types := make([]Type, 0, len(values))
+	for i, value := range values {
+		valueType := c.checkExpression(value)
+		tuple, isTuple := valueType.(*TupleType)
+		if _, isCall := value.(*ast.CallExpression); isCall && isTuple && i == len(values)-1 {
+			types = append(types, tuple.Elements...)
+			continue
+		}
+		types = append(types, singleValue(value, valueType))
 	}
+	return types
+}
+
+// singleValue returns the type of value where only one value is used, such
+// as a lone assignment target or an argument before the last: a call
+// returning several values is cut to its first, or nil if it returns none
+func singleValue(value ast.Expression, valueType Type) Type {
+	tuple, isTuple := valueType.(*TupleType)
+	if _, isCall := value.(*ast.CallExpression); !isCall || !isTuple {
+		return valueType
+	}
+	if len(tuple.Elements) == 0 {
+		return Nil
+	}
+	return tuple.Elements[0]
+}
+
+// resolveTypeGuard returns the narrowing described by a type predicate
+// return type (x is Dog), or nil for any other return type
+func (c *Checker) resolveTypeGuard(params []*ast.Parameter, returnType ast.Expression) *TypeGuard {
+	predicate, ok := returnType.(*ast.TypePredicate)
+	if !ok {
+		return nil
+	}
+
+	for i, param := range params {
+		if param.Name.Value == predicate.Parameter.Value {
+			return &TypeGuard{ParamIndex: i, Type: c.resolveTypeExpression(predicate.Type)}
+		}
+	}
+
+	c.addError(
+		fmt.Sprintf("Type predicate refers to unknown parameter '%s'", predicate.Parameter.Value),
+		predicate.Token,
+	)
+	return nil
+}
+
+// narrowedByGuard reports the variable a condition narrows and its narrowed
+// type, when the condition calls a user-defined type guard on a variable
+func (c *Checker) narrowedByGuard(condition ast.Expression) (string, Type, bool) {
+	call, ok := condition.(*ast.CallExpression)
+	if !ok {
+		return "", nil, false
+	}
+	callee, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return "", nil, false
+	}
+	calleeType, ok := c.env.Get(callee.Value)
+	if !ok {
+		return "", nil, false
+	}
+	fnType, ok := calleeType.(*FunctionType)
+	if !ok || fnType.Guard == nil || fnType.Guard.ParamIndex >= len(call.Arguments) {
+		return "", nil, false
+	}
+	arg, ok := call.Arguments[fnType.Guard.ParamIndex].(*ast.Identifier)
+	if !ok {
+		return "", nil, false
+	}
+	return arg.Value, fnType.Guard.Type, true
+}
+
+// nilCheck matches a condition comparing a variable that may be nil with
+// nil (x ~= nil, x == nil), returning the variable, its type without nil,
+// and whether the condition holds when the variable is nil
+func (c *Checker) nilCheck(condition ast.Expression) (string, Type, bool, bool) {
+	infix, ok := condition.(*ast.InfixExpression)
+	if !ok {
+		return "", nil, false, false
+	}
+
+	var whenNil bool
+	switch infix.Operator {
+	case "==":
+		whenNil = true
+	case "~=", "!=":
+	default:
+		return "", nil, false, false
+	}
+
+	ident, isIdent := infix.Left.(*ast.Identifier)
+	_, isNil := infix.Right.(*ast.NilLiteral)
+	if !isIdent || !isNil {
+		ident, isIdent = infix.Right.(*ast.Identifier)
+		_, isNil = infix.Left.(*ast.NilLiteral)
+	}
+	if !isIdent || !isNil {
+		return "", nil, false, false
+	}
+
+	typ, ok := c.env.Get(ident.Value)
+	if !ok {
+		return "", nil, false, false
+	}
+	nonNil, ok := withoutNil(typ)
+	if !ok {
+		return "", nil, false, false
+	}
+	return ident.Value, nonNil, whenNil, true
+}
+
+// withoutNil returns a type that may be nil with nil removed: T for T?, and
+// the remaining members of a union that includes nil
+func withoutNil(typ Type) (Type, bool) {
+	switch t := typ.(type) {
+	case *OptionalType:
+		return t.BaseType, true
+	case *UnionType:
+		var members []Type
+		for _, member := range t.Types {
+			if !member.Equals(Nil) {
+				members = append(members, member)
+			}
+		}
+		switch {
+		case len(members) == len(t.Types) || len(members) == 0:
+			return nil, false
+		case len(members) == 1:
+			return members[0], true
+		}
+		return &UnionType{Types: members}, true
+	}
+	return nil, false
+}
+
+// withoutType returns what remains of a type once the values of type removed
+// are ruled out: the union members that aren't removed, or never when none
+// are left. It fails when nothing is ruled out, or typ is any
+func withoutType(typ, removed Type) (Type, bool) {
+	var members []Type
+	switch t := typ.(type) {
+	case *AnyType:
+		return nil, false
+	case *UnionType:
+		members = t.Types
+	case *OptionalType:
+		members = []Type{t.BaseType, Nil}
+	default:
+		members = []Type{typ}
+	}
+
+	var remaining []Type
+	for _, member := range members {
+		if !member.IsAssignableTo(removed) {
+			remaining = append(remaining, member)
+		}
+	}
+	switch {
+	case len(remaining) == len(members):
+		return nil, false
+	case len(remaining) == 0:
+		return Never, true
+	case len(remaining) == 1:
+		return remaining[0], true
+	}
+	return &UnionType{Types: remaining}, true
+}
+
+// checkIfStatement checks an if statement
+func (c *Checker) checkIfStatement(node *ast.IfStatement) {
+	c.checkIfChain(node, map[booleanCheck]bool{}, &enumCases{members: map[string]bool{}})
+}
+
+// booleanCheck is a comparison of a boolean variable against true or false
+type booleanCheck struct {
+	name  string
+	value bool
+}
+
+// enumCases is the members of an enum that the conditions of an if chain
+// compared a variable with, while every condition is such a comparison
+type enumCases struct {
+	name    string
+	enum    *EnumType
+	members map[string]bool
+	token   lexer.Token // the first comparison's branch
+}
+
+// checkIfChain checks an if statement along with its elseif branches and the
+// else-if statements chained to it. checked holds the boolean comparisons
+// made by earlier conditions in the chain, so an else after both true and
+// false were checked is reported as unreachable. cases holds the enum members
+// matched so far, or is nil once a condition isn't an enum comparison
+func (c *Checker) checkIfChain(node *ast.IfStatement, checked map[booleanCheck]bool, cases *enumCases) {
+	outerEnv := c.env
+	defer func() { c.env = outerEnv }()
+
+	branches := append([]*ast.ElseIfClause{{
+		Token:       node.Token,
+		Condition:   node.Condition,
+		Consequence: node.Consequence,
+	}}, node.ElseIfs...)
+
+	for i, branch := range branches {
+		condType := c.checkExpression(branch.Condition)
+		if !IsBooleanType(condType) && !condType.Equals(Any) {
+			c.addError(
+				fmt.Sprintf("If condition must be boolean, got '%s'", condType.String()),
+				branch.Token,
+			)
+		}
+
+		if value, ok := constantCondition(branch.Condition); ok {
+			switch {
+			case !value:
+				c.addWarning("Unreachable if branch: condition is always false", branch.Token)
+			case i+1 < len(branches):
+				c.addWarning("Unreachable elseif branch: condition is always true", branches[i+1].Token)
+			case node.Alternative != nil:
+				c.addWarning("Unreachable else branch: condition is always true", node.Alternative.Token)
+			default:
+				c.addWarning("Condition is always true", branch.Token)
+			}
+		} else if check, ok := c.booleanComparison(branch.Condition); ok {
+			checked[check] = true
+		}
+		if cases != nil {
+			cases = c.addEnumCase(cases, branch)
+		}
+
+		// A type guard or a check against nil narrows its variable within
+		// the consequence
+		guardName, guarded, isGuard := c.narrowedByGuard(branch.Condition)
+		name, narrowed, narrows := guardName, guarded, isGuard
+		nilName, nonNil, whenNil, isNilCheck := c.nilCheck(branch.Condition)
+		if isNilCheck && !whenNil {
+			name, narrowed, narrows = nilName, nonNil, true
+		}
+		if narrows {
+			prevEnv := c.env
+			c.env = NewEnclosedEnvironment(prevEnv)
+			c.env.Narrow(name, narrowed)
+			c.checkBlockStatement(branch.Consequence)
+			c.env = prevEnv
+		} else {
+			c.checkBlockStatement(branch.Consequence)
+		}
+
+		// Once x == nil has failed, x isn't nil in the branches after it
+		if isNilCheck && whenNil {
+			c.env = NewEnclosedEnvironment(c.env)
+			c.env.Narrow(nilName, nonNil)
+		}
+
+		// Likewise once a type guard has failed, its variable isn't of the
+		// guarded type. When every member of a union has been ruled out, the
+		// variable is never
+		if isGuard {
+			if typ, ok := c.env.Get(guardName); ok {
+				if rest, ok := withoutType(typ, guarded); ok {
+					c.env = NewEnclosedEnvironment(c.env)
+					c.env.Narrow(guardName, rest)
+				}
+			}
+		}
+	}
+
+	if node.Alternative == nil {
+		c.checkEnumCases(cases)
+		return
+	}
+	if elseIf := chainedIf(node.Alternative); elseIf != nil {
+		c.checkIfChain(elseIf, checked, cases)
+		return
+	}
+
+	var exhausted []string
+	for check := range checked {
+		if check.value && checked[booleanCheck{check.name, false}] {
+			exhausted = append(exhausted, check.name)
+		}
+	}
+	if len(exhausted) > 0 {
+		sort.Strings(exhausted)
+		c.addWarning(
+			fmt.Sprintf("Unreachable else branch: '%s' was already checked for both true and false", exhausted[0]),
+			node.Alternative.Token,
+		)
+	}
+	c.checkBlockStatement(node.Alternative)
+}
+
+// addEnumCase records the enum member a branch's condition compares with, or
+// returns nil when the condition isn't a comparison of the same variable
+// with a member of its enum
+func (c *Checker) addEnumCase(cases *enumCases, branch *ast.ElseIfClause) *enumCases {
+	name, enum, member, ok := c.enumComparison(branch.Condition)
+	if !ok || (cases.enum != nil && (name != cases.name || enum != cases.enum)) {
+		return nil
+	}
+	if cases.enum == nil {
+		cases.name, cases.enum, cases.token = name, enum, branch.Token
+	}
+	cases.members[member] = true
+	return cases
+}
+
+// checkEnumCases warns about the members of an enum that an if chain with no
+// else branch never matched. A lone if is a test of one value rather than a
+// match over the enum, so it takes at least two members
+func (c *Checker) checkEnumCases(cases *enumCases) {
+	if cases == nil || len(cases.members) < 2 {
+		return
+	}
+	for _, member := range sortedKeys(cases.enum.Members) {
+		if !cases.members[member] {
+			c.addWarning(fmt.Sprintf("Enum member '%s' not handled", member), cases.token)
+		}
+	}
+}
+
+// enumComparison matches a condition comparing a variable of enum type with
+// one of the enum's members (x == Color.Red), returning the variable, the
+// enum and the member
+func (c *Checker) enumComparison(expr ast.Expression) (string, *EnumType, string, bool) {
+	infix, ok := expr.(*ast.InfixExpression)
+	if !ok || infix.Operator != "==" {
+		return "", nil, "", false
+	}
+
+	ident, isIdent := infix.Left.(*ast.Identifier)
+	access, isAccess := infix.Right.(*ast.DotExpression)
+	if !isIdent || !isAccess {
+		ident, isIdent = infix.Right.(*ast.Identifier)
+		access, isAccess = infix.Left.(*ast.DotExpression)
+	}
+	if !isIdent || !isAccess {
+		return "", nil, "", false
+	}
+
+	typ, ok := c.env.Get(ident.Value)
+	if !ok {
+		return "", nil, "", false
+	}
+	enum, ok := typ.(*EnumType)
+	if !ok {
+		return "", nil, "", false
+	}
+	enumName, ok := access.Left.(*ast.Identifier)
+	member, isMember := access.Right.(*ast.Identifier)
+	if !ok || !isMember || enumName.Value != enum.Name || !enum.HasMember(member.Value) {
+		return "", nil, "", false
+	}
+	return ident.Value, enum, member.Value, true
+}
+
+// chainedIf returns the if statement of an else-if, whose else block holds
+// nothing but another if statement
+func chainedIf(block *ast.BlockStatement) *ast.IfStatement {
+	if len(block.Statements) != 1 {
+		return nil
+	}
+	elseIf, _ := block.Statements[0].(*ast.IfStatement)
+	return elseIf
+}
+
+// booleanComparison matches a condition comparing a boolean variable with
+// true or false, returning the value it tests for
+func (c *Checker) booleanComparison(expr ast.Expression) (booleanCheck, bool) {
+	infix, ok := expr.(*ast.InfixExpression)
+	if !ok {
+		return booleanCheck{}, false
+	}
+
+	var negated bool
+	switch infix.Operator {
+	case "==":
+	case "~=", "!=":
+		negated = true
+	default:
+		return booleanCheck{}, false
+	}
+
+	ident, isIdent := infix.Left.(*ast.Identifier)
+	literal, isLiteral := infix.Right.(*ast.BooleanLiteral)
+	if !isIdent || !isLiteral {
+		ident, isIdent = infix.Right.(*ast.Identifier)
+		literal, isLiteral = infix.Left.(*ast.BooleanLiteral)
+	}
+	if !isIdent || !isLiteral {
+		return booleanCheck{}, false
+	}
+
+	// Only a plain boolean is exhausted by true and false; an optional one
+	// may still be nil
+	if typ, ok := c.env.Get(ident.Value); !ok || !typ.Equals(Boolean) {
+		return booleanCheck{}, false
+	}
+
+	return booleanCheck{name: ident.Value, value: literal.Value != negated}, true
+}
+
+// constantCondition evaluates a condition built only from boolean literals,
+// reporting whether it's statically known
+func constantCondition(expr ast.Expression) (bool, bool) {
+	switch node := expr.(type) {
+	case *ast.BooleanLiteral:
+		return node.Value, true
+	case *ast.PrefixExpression:
+		if node.Operator == "not" || node.Operator == "!" {
+			value, ok := constantCondition(node.Right)
+			return !value, ok
+		}
+	case *ast.InfixExpression:
+		left, leftOk := constantCondition(node.Left)
+		right, rightOk := constantCondition(node.Right)
+		switch node.Operator {
+		case "and", "&&":
+			// false and x is false whatever x is
+			if leftOk && !left {
+				return false, true
+			}
+			return right, leftOk && rightOk
+		case "or", "||":
+			if leftOk && left {
+				return true, true
+			}
+			return right, leftOk && rightOk
+		}
+	}
+	return false, false
 }
 
 // checkWhileStatement checks a while statement
@@ -662,7 +2373,33 @@ func (c *Checker) checkWhileStatement(node *ast.WhileStatement) {
 		)
 	}
 
+	c.loopDepth++
 	c.checkBlockStatement(node.Body)
+	c.loopDepth--
+}
+
+// checkRepeatStatement checks a repeat-until loop. As in Lua, the condition
+// is in the body's scope, so it can refer to locals declared in the body
+func (c *Checker) checkRepeatStatement(node *ast.RepeatStatement) {
+	prevEnv := c.env
+	c.env = NewEnclosedEnvironment(prevEnv)
+	c.declareTypes(node.Body.Statements)
+
+	c.loopDepth++
+	for _, stmt := range node.Body.Statements {
+		c.checkStatement(stmt)
+	}
+	c.loopDepth--
+
+	condType := c.checkExpression(node.Condition)
+	if !IsBooleanType(condType) && !condType.Equals(Any) {
+		c.addError(
+			fmt.Sprintf("Repeat condition must be boolean, got '%s'", condType.String()),
+			node.Token,
+		)
+	}
+
+	c.env = prevEnv
 }
 
 // checkForStatement checks a for statement
@@ -673,6 +2410,7 @@ func (c *Checker) checkForStatement(node *ast.ForStatement) {
 
 	// Check loop variable
 	c.env.Set(node.Variable.Value, Number)
+	c.recordSymbol(node.Variable, Number)
 
 	if node.IsGeneric {
 		// Generic for loop (for-in)
@@ -699,122 +2437,344 @@ func (c *Checker) checkForStatement(node *ast.ForStatement) {
 				node.Token,
 			)
 		}
-		if !IsNumericType(endType) && !endType.Equals(Any) {
+		if !IsNumericType(endType) && !endType.Equals(Any) {
+			c.addError(
+				fmt.Sprintf("For loop end must be number, got '%s'", endType.String()),
+				node.Token,
+			)
+		}
+
+		if node.Step != nil {
+			stepType := c.checkExpression(node.Step)
+			if !IsNumericType(stepType) && !stepType.Equals(Any) {
+				c.addError(
+					fmt.Sprintf("For loop step must be number, got '%s'", stepType.String()),
+					node.Token,
+				)
+			}
+		}
+	}
+
+	c.loopDepth++
+	c.checkBlockStatement(node.Body)
+	c.loopDepth--
+	c.env = prevEnv
+}
+
+// checkDoStatement checks a do statement
+func (c *Checker) checkDoStatement(node *ast.DoStatement) {
+	c.checkBlockStatement(node.Body)
+}
+
+// checkBlockStatement checks a block statement
+func (c *Checker) checkBlockStatement(node *ast.BlockStatement) {
+	if node == nil {
+		return
+	}
+
+	prevEnv := c.env
+	c.env = NewEnclosedEnvironment(prevEnv)
+	c.declareTypes(node.Statements)
+
+	for _, stmt := range node.Statements {
+		c.checkStatement(stmt)
+	}
+
+	c.env = prevEnv
+}
+
+// checkAssignmentStatement checks an assignment statement
+func (c *Checker) checkAssignmentStatement(node *ast.AssignmentStatement) {
+	if node.Names == nil {
+		targetType, ok := c.checkAssignmentTarget(node.Name, node.Token)
+		if !ok {
+			return
+		}
+		c.checkAssignedValue(node.Value, singleValue(node.Value, c.checkExpression(node.Value)), targetType, node.Token)
+		c.widenAssigned(node.Name)
+		return
+	}
+
+	// Lua evaluates every value before assigning any of them
+	valueTypes := c.checkValueList(node.Values)
+	if len(node.Names) != len(valueTypes) {
+		c.addError(
+			fmt.Sprintf("Assignment to %d target(s) has %d value(s)", len(node.Names), len(valueTypes)),
+			node.Token,
+		)
+	}
+
+	for i, target := range node.Names {
+		targetType, ok := c.checkAssignmentTarget(target, node.Token)
+		if ok && i < len(valueTypes) {
+			// Values spread from a trailing call have no expression of their own
+			var value ast.Expression
+			if i < len(node.Values) {
+				value = node.Values[i]
+			}
+			c.checkAssignedValue(value, valueTypes[i], targetType, node.Token)
+		}
+	}
+	for _, target := range node.Names {
+		c.widenAssigned(target)
+	}
+}
+
+// widenAssigned undoes the narrowing of an assigned variable: whatever held
+// for its old value needn't hold for the new one
+func (c *Checker) widenAssigned(target ast.Expression) {
+	if ident, ok := target.(*ast.Identifier); ok {
+		c.env.Widen(ident.Value)
+	}
+}
+
+// checkAssignmentTarget checks that an expression can be assigned to and
+// returns its type. It reports false for a const variable, whose type isn't
+// checked against the value
+func (c *Checker) checkAssignmentTarget(target ast.Expression, token lexer.Token) (Type, bool) {
+	// Check if trying to assign to a const variable
+	if ident, ok := target.(*ast.Identifier); ok {
+		if c.env.IsConst(ident.Value) {
+			c.addError(
+				fmt.Sprintf("Cannot assign to const variable '%s'", ident.Value),
+				token,
+			)
+			return nil, false
+		}
+	}
+
+	if dot, ok := target.(*ast.DotExpression); ok {
+		c.checkReadonlyAssignment(dot, token)
+	}
+	if index, ok := target.(*ast.IndexExpression); ok {
+		if array, isArray := c.lookupTargetType(index.Left).(*ArrayType); isArray && array.Readonly {
+			c.addError(
+				fmt.Sprintf("Cannot assign to an element of '%s' because it is a read-only array", array.String()),
+				token,
+			)
+		}
+	}
+
+	// A narrowed variable may still be assigned anything its declared type
+	// accepts
+	if ident, ok := target.(*ast.Identifier); ok {
+		if declared, ok := c.env.GetDeclared(ident.Value); ok {
+			return declared, true
+		}
+	}
+
+	return c.checkExpression(target), true
+}
+
+// checkAssignedValue reports a value that can't be assigned to its target.
+// value is the expression the value came from, or nil if there is none
+func (c *Checker) checkAssignedValue(value ast.Expression, valueType, targetType Type, token lexer.Token) {
+	if c.checkArrayLiteral(value, targetType) {
+		return
+	}
+	if !valueType.IsAssignableTo(targetType) {
+		c.addError(
+			fmt.Sprintf("Cannot assign type '%s' to type '%s'",
+				valueType.String(), targetType.String()),
+			token,
+		)
+		return
+	}
+	c.checkExcessProperties(value, targetType)
+}
+
+// checkArrayLiteral checks a table literal of array-style values, assigned
+// directly to an array type, one element at a time, reporting each element
+// that doesn't fit the element type. A new literal isn't shared with any
+// other array, so a Dog can go in an Animal[] even though a Dog[] can't. It
+// returns false, leaving the value to be checked as a whole, when value
+// isn't such a literal or target isn't an array
+func (c *Checker) checkArrayLiteral(value ast.Expression, target Type) bool {
+	table, ok := value.(*ast.TableLiteral)
+	if !ok || len(table.Pairs) > 0 {
+		return false
+	}
+	if optional, ok := target.(*OptionalType); ok {
+		target = optional.BaseType
+	}
+	array, ok := target.(*ArrayType)
+	if !ok {
+		return false
+	}
+	for i, elementType := range c.arrayLiterals[table] {
+		// A nested literal is checked against the inner array the same way
+		if c.checkArrayLiteral(table.Values[i], array.ElementType) {
+			continue
+		}
+		if !elementType.IsAssignableTo(array.ElementType) {
 			c.addError(
-				fmt.Sprintf("For loop end must be number, got '%s'", endType.String()),
-				node.Token,
+				fmt.Sprintf("Array element %d: cannot assign type '%s' to element type '%s'",
+					i+1, elementType.String(), array.ElementType.String()),
+				table.Token,
 			)
 		}
-
-		if node.Step != nil {
-			stepType := c.checkExpression(node.Step)
-			if !IsNumericType(stepType) && !stepType.Equals(Any) {
-				c.addError(
-					fmt.Sprintf("For loop step must be number, got '%s'", stepType.String()),
-					node.Token,
-				)
-			}
-		}
 	}
-
-	c.checkBlockStatement(node.Body)
-	c.env = prevEnv
+	return true
 }
 
-// checkDoStatement checks a do statement
-func (c *Checker) checkDoStatement(node *ast.DoStatement) {
-	c.checkBlockStatement(node.Body)
-}
-
-// checkBlockStatement checks a block statement
-func (c *Checker) checkBlockStatement(node *ast.BlockStatement) {
-	if node == nil {
+// checkExcessProperties reports the properties of a table literal, assigned
+// directly to an interface or object shape, that the target doesn't declare.
+// A value with extra properties is still assignable, but in a literal they
+// are usually a mistake such as a misspelled name
+func (c *Checker) checkExcessProperties(value ast.Expression, target Type) {
+	table, ok := value.(*ast.TableLiteral)
+	if !ok || len(table.Values) > 0 {
+		return
+	}
+	if optional, ok := target.(*OptionalType); ok {
+		target = optional.BaseType
+	}
+	var shape memberLookup
+	switch typ := target.(type) {
+	case *InterfaceType:
+		// Any key may be stored under a string index signature
+		if _, ok := typ.GetStringIndex(); ok {
+			return
+		}
+		shape = typ
+	case *IntersectionType:
+		// A key is expected when any member of the intersection declares it
+		shape = typ
+	default:
 		return
 	}
 
-	prevEnv := c.env
-	c.env = NewEnclosedEnvironment(prevEnv)
-
-	for _, stmt := range node.Statements {
-		c.checkStatement(stmt)
+	keys := make([]ast.Expression, 0, len(table.Pairs))
+	for key := range table.Pairs {
+		keys = append(keys, key)
 	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
 
-	c.env = prevEnv
+	for _, key := range keys {
+		ident, ok := key.(*ast.Identifier)
+		if !ok {
+			continue
+		}
+		if _, ok := shape.GetProperty(ident.Value); ok {
+			continue
+		}
+		if _, ok := shape.GetMethod(ident.Value); ok {
+			continue
+		}
+		c.addError(fmt.Sprintf("Object literal specifies unknown property '%s'.", ident.Value), ident.Token)
+	}
 }
 
-// checkAssignmentStatement checks an assignment statement
-func (c *Checker) checkAssignmentStatement(node *ast.AssignmentStatement) {
-	// Check if trying to assign to a const variable
-	if ident, ok := node.Name.(*ast.Identifier); ok {
-		if c.env.IsConst(ident.Value) {
-			c.addError(
-				fmt.Sprintf("Cannot assign to const variable '%s'", ident.Value),
-				node.Token,
-			)
+// checkReadonlyAssignment reports assignments to read-only properties.
+// A class's own constructor may still initialize them through self
+func (c *Checker) checkReadonlyAssignment(target *ast.DotExpression, token lexer.Token) {
+	prop, ok := target.Right.(*ast.Identifier)
+	if !ok {
+		return
+	}
+
+	readonly := false
+	switch typ := c.lookupTargetType(target.Left).(type) {
+	case *ClassType:
+		if self, isIdent := target.Left.(*ast.Identifier); isIdent && self.Value == "self" && typ == c.currentConstructorClass {
 			return
 		}
+		readonly = typ.IsReadonly(prop.Value)
+	case *InterfaceType:
+		readonly = typ.IsReadonly(prop.Value)
 	}
 
-	targetType := c.checkExpression(node.Name)
-	valueType := c.checkExpression(node.Value)
-
-	if !valueType.IsAssignableTo(targetType) {
+	if readonly {
 		c.addError(
-			fmt.Sprintf("Cannot assign type '%s' to type '%s'",
-				valueType.String(), targetType.String()),
-			node.Token,
+			fmt.Sprintf("Cannot assign to '%s' because it is a read-only property", prop.Value),
+			token,
 		)
 	}
 }
 
+// lookupTargetType finds the type of an assignment target's object without
+// reporting errors; checking the full target reports them once. Returns nil
+// when the type can't be determined this way
+func (c *Checker) lookupTargetType(expr ast.Expression) Type {
+	switch node := expr.(type) {
+	case *ast.Identifier:
+		typ, _ := c.env.Get(node.Value)
+		return typ
+	case *ast.DotExpression:
+		prop, ok := node.Right.(*ast.Identifier)
+		if !ok {
+			return nil
+		}
+		switch typ := c.lookupTargetType(node.Left).(type) {
+		case *ClassType:
+			propType, _ := typ.GetProperty(prop.Value)
+			return propType
+		case *InterfaceType:
+			propType, _ := typ.GetProperty(prop.Value)
+			return propType
+		}
+	}
+	return nil
+}
+
 // checkClassDeclaration checks a class declaration
 func (c *Checker) checkClassDeclaration(node *ast.ClassDeclaration) {
-	classType, ok := c.classes[node.Name.Value]
+	classType, ok := c.definitions[node].(*ClassType)
 	if !ok {
 		return
 	}
 
+	prevClass := c.currentClass
+	c.currentClass = classType
+	defer func() { c.currentClass = prevClass }()
+
 	// Check constructor if present
 	if node.Constructor != nil {
 		prevEnv := c.env
 		prevReturnType := c.currentFunctionReturnType
+		prevInference := c.returnInference
+		prevLoopDepth := c.loopDepth
 		c.env = NewEnclosedEnvironment(prevEnv)
 		c.currentFunctionReturnType = Void
+		c.returnInference = nil
+		c.loopDepth = 0
 
 		// Add generic type parameters to scope
-		for _, genericParam := range node.GenericParams {
-			c.env.Set(genericParam.Value, Any)
-		}
+		c.declareTypeParams(node.GenericParams)
 
 		// Add self to scope
 		c.env.Set("self", classType)
 
 		// Add parameters to scope
-		for _, param := range node.Constructor.Parameters {
-			var paramType Type = Any
-			if param.Type != nil {
-				paramType = c.resolveTypeExpression(param.Type)
-			}
-			c.env.Set(param.Name.Value, paramType)
-		}
+		params, variadic := c.parameterTypes(node.Constructor.Parameters)
+		c.declareParameters(node.Constructor.Parameters, params)
+		prevVarargs := c.currentVarargs
+		c.currentVarargs = variadic
 
 		// Check constructor body
+		prevConstructorClass := c.currentConstructorClass
+		c.currentConstructorClass = classType
 		c.checkBlockStatement(node.Constructor.Body)
+		c.currentConstructorClass = prevConstructorClass
 
 		c.env = prevEnv
 		c.currentFunctionReturnType = prevReturnType
+		c.returnInference = prevInference
+		c.currentVarargs = prevVarargs
+		c.loopDepth = prevLoopDepth
 	}
 
 	// Check methods
 	for _, method := range node.Methods {
 		prevEnv := c.env
 		prevReturnType := c.currentFunctionReturnType
+		prevInference := c.returnInference
+		prevLoopDepth := c.loopDepth
 		c.env = NewEnclosedEnvironment(prevEnv)
+		c.loopDepth = 0
 
 		// Add generic type parameters to scope
-		for _, genericParam := range node.GenericParams {
-			c.env.Set(genericParam.Value, Any)
-		}
+		c.declareTypeParams(node.GenericParams)
 
 		// Get method's return type
 		var returnType Type = Void
@@ -822,38 +2782,196 @@ func (c *Checker) checkClassDeclaration(node *ast.ClassDeclaration) {
 			returnType = c.resolveTypeExpression(method.ReturnType)
 		}
 		c.currentFunctionReturnType = returnType
+		c.returnInference = nil
 
 		// Add self to scope
 		c.env.Set("self", classType)
 
 		// Add parameters to scope
-		for _, param := range method.Parameters {
-			var paramType Type = Any
-			if param.Type != nil {
-				paramType = c.resolveTypeExpression(param.Type)
-			}
-			c.env.Set(param.Name.Value, paramType)
-		}
+		params, variadic := c.parameterTypes(method.Parameters)
+		c.declareParameters(method.Parameters, params)
+		prevVarargs := c.currentVarargs
+		c.currentVarargs = variadic
 
 		// Check method body
 		c.checkBlockStatement(method.Body)
+		c.currentVarargs = prevVarargs
 
 		c.env = prevEnv
 		c.currentFunctionReturnType = prevReturnType
+		c.returnInference = prevInference
+		c.loopDepth = prevLoopDepth
 	}
 
 	// Check if class implements all interface methods
 	for _, impl := range classType.Implements {
 		c.checkClassImplementsInterface(classType, impl, node.Token)
 	}
+
+	if c.options.StrictClassInit {
+		c.checkPropertyInitialization(node, classType)
+	}
+}
+
+// checkPropertyInitialization reports the properties of a class that its
+// constructor doesn't assign on every path. Optional properties may be left
+// unset, and parameter properties are assigned on entry
+func (c *Checker) checkPropertyInitialization(node *ast.ClassDeclaration, classType *ClassType) {
+	var required []*ast.PropertyDeclaration
+	for _, prop := range node.Properties {
+		if !IsOptionalProperty(classType.Properties[prop.Name.Value]) {
+			required = append(required, prop)
+		}
+	}
+	if len(required) == 0 {
+		return
+	}
+
+	// The property sets assigned where the constructor returns
+	var exits []map[string]bool
+	if node.Constructor == nil {
+		exits = append(exits, map[string]bool{})
+	} else {
+		assigned := map[string]bool{}
+		for _, param := range node.Constructor.Parameters {
+			if param.IsProperty() {
+				assigned[param.Name.Value] = true
+			}
+		}
+		var breaks []map[string]bool
+		if end, fallsThrough := assignedProperties(node.Constructor.Body.Statements, assigned, &exits, &breaks); fallsThrough {
+			exits = append(exits, end)
+		}
+	}
+
+	for _, prop := range required {
+		for _, assigned := range exits {
+			if !assigned[prop.Name.Value] {
+				c.addError(
+					fmt.Sprintf("Property '%s' of class '%s' is not assigned on every path through its constructor",
+						prop.Name.Value, classType.Name),
+					prop.Token,
+				)
+				break
+			}
+		}
+	}
+}
+
+// assignedProperties follows statements from a point where the properties in
+// assigned are definitely assigned to self, returning the set assigned at the
+// end and whether control can reach the end. The set at each return is added
+// to exits, and the set at each break to breaks. Loop bodies may not run, so
+// they only contribute their returns
+func assignedProperties(statements []ast.Statement, assigned map[string]bool, exits, breaks *[]map[string]bool) (map[string]bool, bool) {
+	current := make(map[string]bool, len(assigned))
+	for name := range assigned {
+		current[name] = true
+	}
+
+	for _, stmt := range statements {
+		switch node := stmt.(type) {
+		case *ast.AssignmentStatement:
+			for _, target := range node.Targets() {
+				if dot, ok := target.(*ast.DotExpression); ok {
+					self, isIdent := dot.Left.(*ast.Identifier)
+					prop, isProp := dot.Right.(*ast.Identifier)
+					if isIdent && isProp && self.Value == "self" {
+						current[prop.Value] = true
+					}
+				}
+			}
+		case *ast.ReturnStatement:
+			*exits = append(*exits, current)
+			return current, false
+		case *ast.BreakStatement:
+			*breaks = append(*breaks, current)
+			return current, false
+		case *ast.IfStatement:
+			// Each branch that reaches the end of the if statement leaves its
+			// own set; without an else, skipping every branch leaves current
+			blocks := []*ast.BlockStatement{node.Consequence}
+			for _, elseIf := range node.ElseIfs {
+				blocks = append(blocks, elseIf.Consequence)
+			}
+			var ends []map[string]bool
+			if node.Alternative != nil {
+				blocks = append(blocks, node.Alternative)
+			} else {
+				ends = append(ends, current)
+			}
+			for _, block := range blocks {
+				if end, fallsThrough := assignedProperties(block.Statements, current, exits, breaks); fallsThrough {
+					ends = append(ends, end)
+				}
+			}
+			if len(ends) == 0 {
+				return current, false
+			}
+			current = commonProperties(ends)
+		case *ast.DoStatement:
+			body, fallsThrough := assignedProperties(node.Body.Statements, current, exits, breaks)
+			if !fallsThrough {
+				return body, false
+			}
+			current = body
+		case *ast.WhileStatement:
+			var loopBreaks []map[string]bool
+			assignedProperties(node.Body.Statements, current, exits, &loopBreaks)
+		case *ast.RepeatStatement:
+			// The body runs at least once, but a break may leave it early
+			// with only what was assigned before the break
+			var loopBreaks []map[string]bool
+			body, fallsThrough := assignedProperties(node.Body.Statements, current, exits, &loopBreaks)
+			ends := loopBreaks
+			if fallsThrough {
+				ends = append(ends, body)
+			}
+			if len(ends) == 0 {
+				return current, false
+			}
+			current = commonProperties(ends)
+		case *ast.ForStatement:
+			var loopBreaks []map[string]bool
+			assignedProperties(node.Body.Statements, current, exits, &loopBreaks)
+		}
+	}
+	return current, true
+}
+
+// commonProperties returns the properties assigned in every one of sets
+func commonProperties(sets []map[string]bool) map[string]bool {
+	common := make(map[string]bool)
+	for name := range sets[0] {
+		inAll := true
+		for _, set := range sets[1:] {
+			inAll = inAll && set[name]
+		}
+		if inAll {
+			common[name] = true
+		}
+	}
+	return common
 }
 
 // checkClassImplementsInterface verifies a class implements an interface
 func (c *Checker) checkClassImplementsInterface(class *ClassType, iface *InterfaceType, token lexer.Token) {
 	// Check all interface methods are implemented
-	for methodName, ifaceMethod := range iface.Methods {
+	for _, methodName := range sortedKeys(iface.Methods) {
+		ifaceMethod := iface.Methods[methodName]
 		classMethod, ok := class.GetMethod(methodName)
 		if !ok {
+			// A function property taking the receiver first can stand in
+			if classProp, ok := class.GetProperty(methodName); ok {
+				if required := unboundMethod(iface, ifaceMethod); !classProp.IsAssignableTo(required) {
+					c.addError(
+						fmt.Sprintf("Property '%s' in class '%s' has type '%s' but interface '%s' requires '%s'",
+							methodName, class.Name, classProp.String(), iface.Name, required.String()),
+						token,
+					)
+				}
+				continue
+			}
 			c.addError(
 				fmt.Sprintf("Class '%s' does not implement method '%s' from interface '%s'",
 					class.Name, methodName, iface.Name),
@@ -873,9 +2991,25 @@ func (c *Checker) checkClassImplementsInterface(class *ClassType, iface *Interfa
 	}
 
 	// Check all interface properties are present
-	for propName, ifaceProp := range iface.Properties {
+	for _, propName := range sortedKeys(iface.Properties) {
+		ifaceProp := iface.Properties[propName]
 		classProp, ok := class.GetProperty(propName)
 		if !ok {
+			// A method can stand in for a function property that takes the
+			// receiver first, which is how the method is called through '.'
+			if classMethod, ok := class.GetMethod(propName); ok {
+				if method := unboundMethod(iface, classMethod); !method.IsAssignableTo(ifaceProp) {
+					c.addError(
+						fmt.Sprintf("Method '%s' in class '%s' has type '%s' but interface '%s' requires '%s'",
+							propName, class.Name, method.String(), iface.Name, ifaceProp.String()),
+						token,
+					)
+				}
+				continue
+			}
+			if IsOptionalProperty(ifaceProp) {
+				continue
+			}
 			c.addError(
 				fmt.Sprintf("Class '%s' does not implement property '%s' from interface '%s'",
 					class.Name, propName, iface.Name),
@@ -911,7 +3045,7 @@ func (c *Checker) checkExpression(expr ast.Expression) Type {
 		return c.checkIdentifier(node)
 	case *ast.NumberLiteral:
 		// Number literals infer as literal types for precision
-		return &NumberLiteralType{Value: node.Value}
+		return &NumberLiteralType{Value: node.Value, IsFloat: node.IsFloat}
 	case *ast.StringLiteral:
 		// String literals infer as literal types for precision
 		return &StringLiteralType{Value: node.Value}
@@ -929,17 +3063,50 @@ func (c *Checker) checkExpression(expr ast.Expression) Type {
 		return c.checkCallExpression(node)
 	case *ast.DotExpression:
 		return c.checkDotExpression(node)
+	case *ast.MethodExpression:
+		return c.checkMethodExpression(node)
 	case *ast.IndexExpression:
 		return c.checkIndexExpression(node)
+	case *ast.SuperExpression:
+		return c.checkSuperExpression(node)
+	case *ast.VarargExpression:
+		return c.checkVarargExpression(node)
+	case *ast.FunctionLiteral:
+		return c.checkFunctionLiteral(node)
+	case *ast.DoExpression:
+		return c.checkDoExpression(node)
 	default:
 		return Any
 	}
 }
 
+// checkVarargExpression checks '...', the extra arguments of a variadic
+// function. Outside any function it holds the arguments the chunk was run
+// or required with
+func (c *Checker) checkVarargExpression(node *ast.VarargExpression) Type {
+	if c.currentFunctionReturnType == nil {
+		return Any
+	}
+	if c.currentVarargs == nil {
+		c.addError("Cannot use '...' outside a variadic function", node.Token)
+		return Any
+	}
+	return c.currentVarargs
+}
+
 // checkIdentifier checks an identifier and returns its type
 func (c *Checker) checkIdentifier(node *ast.Identifier) Type {
 	typ, ok := c.env.Get(node.Value)
 	if !ok {
+		// self is only bound in the methods and constructor of a class
+		if node.Value == "self" {
+			c.addError("'self' can only be used inside a class method or constructor", node.Token)
+			return Any
+		}
+		// A function body may call a top-level function declared after it
+		if funcType, ok := c.hoistedFunctions[node.Value]; ok && c.currentFunctionReturnType != nil {
+			return funcType
+		}
 		c.addError(fmt.Sprintf("Undefined variable '%s'", node.Value), node.Token)
 		return Any
 	}
@@ -953,7 +3120,15 @@ func (c *Checker) checkTableLiteral(node *ast.TableLiteral) Type {
 		properties := make(map[string]Type)
 		isRecord := true
 
-		for key, value := range node.Pairs {
+		// Visit fields in a fixed order so diagnostics are deterministic
+		keys := make([]ast.Expression, 0, len(node.Pairs))
+		for key := range node.Pairs {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		for _, key := range keys {
+			value := node.Pairs[key]
 			// Check if key is an identifier (field name)
 			if ident, ok := key.(*ast.Identifier); ok {
 				valueType := c.checkExpression(value)
@@ -976,10 +3151,47 @@ func (c *Checker) checkTableLiteral(node *ast.TableLiteral) Type {
 		}
 	}
 
-	// For array-style or mixed tables, return a generic table type
+	if len(node.Values) > 0 && len(node.Pairs) == 0 {
+		return c.checkArrayValues(node)
+	}
+
+	// For mixed tables, return a generic table type
+	for _, value := range node.Values {
+		c.checkExpression(value)
+	}
 	return &TableType{KeyType: Any, ValueType: Any}
 }
 
+// checkArrayValues checks a table literal of array-style values such as
+// {1, 2, 3}. Its type is an array of the distinct element types, widened
+// like an inferred return type, so {1, "two"} is a (number | string)[].
+// Arrays are invariant, so number literals widen to number even from Lua
+// 5.3; as an int[], {1, 2} couldn't be passed where a number[] is expected
+func (c *Checker) checkArrayValues(node *ast.TableLiteral) Type {
+	elementTypes := make([]Type, len(node.Values))
+	var distinct []Type
+	for i, value := range node.Values {
+		elementTypes[i] = c.checkExpression(value)
+		if _, isAny := elementTypes[i].(*AnyType); isAny {
+			distinct = []Type{Any}
+		} else if len(distinct) == 0 || !distinct[0].Equals(Any) {
+			distinct = appendDistinct(distinct, c.widenArrayElement(elementTypes[i]))
+		}
+	}
+	c.arrayLiterals[node] = elementTypes
+	return &ArrayType{ElementType: unionOf(distinct)}
+}
+
+// widenArrayElement widens the type of an array literal's element like
+// widenLiteral, except that a number literal is a number rather than an int
+// or float
+func (c *Checker) widenArrayElement(typ Type) Type {
+	if _, ok := typ.(*NumberLiteralType); ok {
+		return Number
+	}
+	return c.widenLiteral(typ)
+}
+
 // checkPrefixExpression checks a prefix expression
 func (c *Checker) checkPrefixExpression(node *ast.PrefixExpression) Type {
 	rightType := c.checkExpression(node.Right)
@@ -992,9 +3204,20 @@ func (c *Checker) checkPrefixExpression(node *ast.PrefixExpression) Type {
 				node.Token,
 			)
 		}
+		// A negated literal such as -1 keeps a literal type, like 1 does
+		if literal, ok := rightType.(*NumberLiteralType); ok {
+			return &NumberLiteralType{Value: -literal.Value, IsFloat: literal.IsFloat}
+		}
+		// Negating an int gives an int, and a float a float
+		if kind := numberKind(rightType); kind != nil && c.numberSubtypes {
+			return kind
+		}
 		return Number
-	case "not":
+	case "not", "!":
 		return Boolean
+	case "~":
+		c.checkBitwiseOperands(node.Operator, node.Token, rightType)
+		return c.bitwiseResultType()
 	default:
 		return Any
 	}
@@ -1003,44 +3226,308 @@ func (c *Checker) checkPrefixExpression(node *ast.PrefixExpression) Type {
 // checkInfixExpression checks an infix expression
 func (c *Checker) checkInfixExpression(node *ast.InfixExpression) Type {
 	leftType := c.checkExpression(node.Left)
-	rightType := c.checkExpression(node.Right)
+	rightType := c.checkRightOperand(node)
 
 	switch node.Operator {
-	case "+", "-", "*", "/", "%", "^":
+	case "+", "-", "*", "/", "//", "%", "^":
 		// Arithmetic operators require numbers
-		if !IsNumericType(leftType) && !leftType.Equals(Any) {
+		if !c.isArithmeticOperand(leftType) {
 			c.addError(
 				fmt.Sprintf("Operator '%s' cannot be applied to type '%s'", node.Operator, leftType.String()),
 				node.Token,
 			)
 		}
-		if !IsNumericType(rightType) && !rightType.Equals(Any) {
+		if !c.isArithmeticOperand(rightType) {
 			c.addError(
 				fmt.Sprintf("Operator '%s' cannot be applied to type '%s'", node.Operator, rightType.String()),
 				node.Token,
 			)
 		}
-		return Number
+		return c.arithmeticResultType(node.Operator, leftType, rightType)
+
+	case "==", "~=", "!=":
+		// Equality is defined between any two values, but between types
+		// with no value in common it's always false
+		if !IsNilType(leftType) && !IsNilType(rightType) && !typesOverlap(leftType, rightType) {
+			c.addWarning(
+				fmt.Sprintf("This comparison appears unintentional; types '%s' and '%s' have no overlap.",
+					leftType.String(), rightType.String()),
+				node.Token,
+			)
+		}
+		c.checkSelfComparison(node, leftType)
+		return Boolean
+
+	case "<", "<=", ">", ">=":
+		// Comparison operators return boolean
+		return Boolean
+
+	case "and", "or", "&&", "||":
+		// Logical operators return boolean
+		return Boolean
+
+	case "..":
+		// String concatenation, which Lua also applies to numbers
+		for _, t := range []Type{leftType, rightType} {
+			if !isConcatOperand(t) {
+				c.addError(
+					fmt.Sprintf("Operator '..' cannot be applied to type '%s'", t.String()),
+					node.Token,
+				)
+			}
+		}
+		return String
+
+	case "&", "|", "~", "<<", ">>":
+		// Bitwise operators require numbers
+		c.checkBitwiseOperands(node.Operator, node.Token, leftType, rightType)
+		return c.bitwiseResultType()
+
+	default:
+		return Any
+	}
+}
+
+// checkSelfComparison warns about a value compared to itself, which is
+// always true with == and always false with ~=. The exception is x ~= x on a
+// number, the usual test for NaN, the one value not equal to itself
+func (c *Checker) checkSelfComparison(node *ast.InfixExpression, operandType Type) {
+	if !isPlainReference(node.Left) || node.Left.String() != node.Right.String() {
+		return
+	}
+	result := "true"
+	switch {
+	case node.Operator == "==" && IsNumericType(operandType):
+		result = "true, except for NaN"
+	case node.Operator != "==" && IsNumericType(operandType):
+		return
+	case node.Operator != "==":
+		result = "false"
+	}
+	c.addWarning(
+		fmt.Sprintf("Comparing '%s' to itself is always %s", node.Left.String(), result),
+		node.Token,
+	)
+}
+
+// isPlainReference reports whether expr reads a variable, or a field of one,
+// without calling anything, so that evaluating it twice gives the same value
+func isPlainReference(expr ast.Expression) bool {
+	switch node := expr.(type) {
+	case *ast.Identifier:
+		return true
+	case *ast.DotExpression:
+		return isPlainReference(node.Left)
+	case *ast.IndexExpression:
+		switch node.Index.(type) {
+		case *ast.NumberLiteral, *ast.StringLiteral:
+			return isPlainReference(node.Left)
+		}
+		return isPlainReference(node.Left) && isPlainReference(node.Index)
+	}
+	return false
+}
+
+// typesOverlap reports whether two types may have a value in common, so that
+// comparing them for equality can be true. Only primitive types, literal
+// types and enums are told apart; any other type may overlap with anything
+func typesOverlap(a, b Type) bool {
+	if !isPrimitiveLike(a) || !isPrimitiveLike(b) {
+		return true
+	}
+
+	for _, left := range typeMembers(a) {
+		for _, right := range typeMembers(b) {
+			if membersOverlap(left, right) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// membersOverlap reports whether two types that aren't unions may have a
+// value in common
+func membersOverlap(a, b Type) bool {
+	enumA, isEnumA := a.(*EnumType)
+	enumB, isEnumB := b.(*EnumType)
+	switch {
+	case isEnumA && isEnumB:
+		// Members of different enums are never meant to be compared
+		return enumA.Equals(enumB)
+	case isEnumA:
+		// An enum's members are its runtime values, such as numbers
+		return enumA.ValueType == nil || typesOverlap(enumA.ValueType, b)
+	case isEnumB:
+		return enumB.ValueType == nil || typesOverlap(a, enumB.ValueType)
+	}
+
+	// int and float values may be equal, as 1 == 1.0 is
+	literalA, isLiteralA := a.(*NumberLiteralType)
+	literalB, isLiteralB := b.(*NumberLiteralType)
+	if isLiteralA && isLiteralB {
+		return literalA.Value == literalB.Value
+	}
+	if IsNumericType(a) && IsNumericType(b) {
+		return true
+	}
+	return a.IsAssignableTo(b) || b.IsAssignableTo(a)
+}
+
+// typeMembers lists the members of a union or optional type, or the type
+// itself
+func typeMembers(typ Type) []Type {
+	switch t := typ.(type) {
+	case *UnionType:
+		var members []Type
+		for _, member := range t.Types {
+			members = append(members, typeMembers(member)...)
+		}
+		return members
+	case *OptionalType:
+		return append(typeMembers(t.BaseType), Nil)
+	}
+	return []Type{typ}
+}
+
+// isPrimitiveLike reports whether a type is made up only of primitive,
+// literal and enum types
+func isPrimitiveLike(typ Type) bool {
+	for _, member := range typeMembers(typ) {
+		switch member.(type) {
+		case *NumberType, *IntType, *FloatType, *RangeType, *NumberLiteralType,
+			*StringType, *StringLiteralType, *BooleanType, *NilType, *EnumType:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// checkRightOperand checks the right operand of an infix expression. It only
+// runs when x isn't nil after x ~= nil and, or x == nil or, so x is narrowed
+// to its type without nil there
+func (c *Checker) checkRightOperand(node *ast.InfixExpression) Type {
+	name, nonNil, whenNil, ok := c.nilCheck(node.Left)
+	switch {
+	case ok && !whenNil && (node.Operator == "and" || node.Operator == "&&"):
+	case ok && whenNil && (node.Operator == "or" || node.Operator == "||"):
+	default:
+		return c.checkExpression(node.Right)
+	}
+
+	prevEnv := c.env
+	c.env = NewEnclosedEnvironment(prevEnv)
+	c.env.Narrow(name, nonNil)
+	defer func() { c.env = prevEnv }()
+	return c.checkExpression(node.Right)
+}
+
+// checkBitwiseOperands reports operands of a bitwise operator that aren't
+// numbers. The operators were added in Lua 5.3, so an older target can't run
+// them at all
+func (c *Checker) checkBitwiseOperands(operator string, token lexer.Token, operands ...Type) {
+	if c.options.Target != "" && !target.AtLeast(c.options.Target, "5.3") {
+		c.addError(
+			fmt.Sprintf("Operator '%s' requires Lua 5.3 or later, but the target is %s", operator, c.options.Target),
+			token,
+		)
+		return
+	}
+	for _, t := range operands {
+		if !IsNumericType(t) && !t.Equals(Any) {
+			c.addError(
+				fmt.Sprintf("Operator '%s' cannot be applied to type '%s'", operator, t.String()),
+				token,
+			)
+		}
+	}
+}
+
+// bitwiseResultType is the type produced by a bitwise operator, which Lua
+// always computes on integers
+func (c *Checker) bitwiseResultType() Type {
+	if c.numberSubtypes {
+		return Int
+	}
+	return Number
+}
+
+// isArithmeticOperand reports whether a value of type t may be used with an
+// arithmetic operator
+func (c *Checker) isArithmeticOperand(t Type) bool {
+	if IsNumericType(t) || t.Equals(Any) {
+		return true
+	}
+	return c.options.LuaCoercion && t.IsAssignableTo(String)
+}
+
+// isConcatOperand reports whether a value of type t may be concatenated with
+// '..'. Lua converts numbers to strings when concatenating
+func isConcatOperand(t Type) bool {
+	if union, ok := t.(*UnionType); ok {
+		for _, member := range union.Types {
+			if !isConcatOperand(member) {
+				return false
+			}
+		}
+		return true
+	}
+	return IsNumericType(t) || t.IsAssignableTo(String) || t.Equals(Any)
+}
 
-	case "==", "!=", "<", "<=", ">", ">=":
-		// Comparison operators return boolean
-		return Boolean
+// arithmeticResultType determines the type produced by an arithmetic operator.
+// Without number subtypes everything is just number; with them, the result
+// follows Lua 5.3 semantics: / and ^ always produce floats, // produces an
+// int unless a float is involved, and the rest preserve int-ness
+func (c *Checker) arithmeticResultType(operator string, left, right Type) Type {
+	if !c.numberSubtypes {
+		return Number
+	}
 
-	case "and", "or":
-		// Logical operators return boolean
-		return Boolean
+	switch operator {
+	case "/", "^":
+		return Float
+	}
 
-	case "..":
-		// String concatenation
-		return String
+	leftKind := numberKind(left)
+	rightKind := numberKind(right)
+	switch {
+	case leftKind == Float || rightKind == Float:
+		return Float
+	case operator == "//" && leftKind != nil && rightKind != nil:
+		return Int
+	case leftKind == Int && rightKind == Int:
+		return Int
+	}
+	return Number
+}
 
-	default:
-		return Any
+// numberKind classifies a numeric type as Int or Float, or nil when it could
+// be either
+func numberKind(t Type) Type {
+	switch typ := t.(type) {
+	case *IntType:
+		return Int
+	case *FloatType:
+		return Float
+	case *NumberLiteralType:
+		if typ.IsInteger() {
+			return Int
+		}
+		return Float
+	case *RangeType:
+		return numberKind(typ.Base)
 	}
+	return nil
 }
 
 // checkCallExpression checks a function call
 func (c *Checker) checkCallExpression(node *ast.CallExpression) Type {
+	if super, ok := node.Function.(*ast.SuperExpression); ok {
+		return c.checkSuperCall(node, super)
+	}
+
 	funcType := c.checkExpression(node.Function)
 
 	// Check if it's a function type
@@ -1055,29 +3542,219 @@ func (c *Checker) checkCallExpression(node *ast.CallExpression) Type {
 		return Any
 	}
 
-	// Check argument count
-	if len(node.Arguments) != len(fnType.Parameters) {
+	if fnType.Generic != nil {
+		return c.checkGenericCall(node, fnType)
+	}
+
+	c.checkArguments(node, fnType.Parameters, fnType.Variadic)
+	return fnType.ReturnType
+}
+
+// checkGenericCall checks a call to a generic function. The type arguments
+// are inferred from the argument types, then the declared parameter and
+// return types are resolved with them, so identity("hi") returns a string.
+// A type parameter bound by an earlier argument stays bound, so later
+// arguments that disagree with it are reported
+func (c *Checker) checkGenericCall(node *ast.CallExpression, fnType *FunctionType) Type {
+	generic := fnType.Generic
+	if !c.checkArgumentCount(node, len(fnType.Parameters), fnType.Variadic) {
+		return fnType.ReturnType
+	}
+
+	argTypes := c.checkArgumentList(node.Arguments)
+
+	bindings := make(map[string]Type)
+	for i, paramExpr := range generic.Parameters {
+		c.inferTypeArguments(paramExpr, argTypes[i], generic.TypeParams, bindings)
+	}
+	// Type parameters no argument determines take their default, or stand
+	// for their constraint, or any when they have neither
+	typeArgs := make([]Type, len(generic.TypeParams))
+	for i, param := range generic.TypeParams {
+		if bound, ok := bindings[param]; ok {
+			typeArgs[i] = bound
+		} else if generic.Defaults[i] != nil {
+			typeArgs[i] = c.substituteTypeParams(generic.Defaults[i], generic.TypeParams[:i], typeArgs[:i], node.Token)
+		} else if generic.Constraints[i] != nil {
+			typeArgs[i] = c.substituteTypeParams(generic.Constraints[i], generic.TypeParams[:i], typeArgs[:i], node.Token)
+		} else {
+			typeArgs[i] = Any
+		}
+	}
+	c.traceInference(node, generic.TypeParams, typeArgs, bindings)
+	c.checkTypeArguments(generic.TypeParams, generic.Constraints, typeArgs, node.Token)
+
+	params := make([]Type, len(fnType.Parameters))
+	for i, paramExpr := range generic.Parameters {
+		params[i] = fnType.Parameters[i]
+		if paramExpr != nil {
+			params[i] = c.substituteTypeParams(paramExpr, generic.TypeParams, typeArgs, node.Token)
+		}
+	}
+	c.checkArgumentTypes(node, argTypes, params, fnType.Variadic)
+
+	if generic.ReturnType == nil {
+		return fnType.ReturnType
+	}
+	return c.substituteTypeParams(generic.ReturnType, generic.TypeParams, typeArgs, node.Token)
+}
+
+// inferTypeArguments binds the type parameters that appear in a declared
+// parameter type by matching it against the type of the argument passed.
+// Literal types are widened, so passing "hi" binds T to string
+func (c *Checker) inferTypeArguments(expr ast.Expression, arg Type, typeParams []string, bindings map[string]Type) {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		for _, param := range typeParams {
+			if param == e.Value {
+				if _, bound := bindings[param]; !bound {
+					bindings[param] = c.widenLiteral(arg)
+				}
+				return
+			}
+		}
+	case *ast.OptionalType:
+		if base, ok := withoutNil(arg); ok {
+			arg = base
+		}
+		c.inferTypeArguments(e.Type, arg, typeParams, bindings)
+	case *ast.ArrayType:
+		if array, ok := arg.(*ArrayType); ok {
+			c.inferTypeArguments(e.ElementType, array.ElementType, typeParams, bindings)
+		}
+	case *ast.TableType:
+		if table, ok := arg.(*TableType); ok {
+			c.inferTypeArguments(e.KeyType, table.KeyType, typeParams, bindings)
+			c.inferTypeArguments(e.ValueType, table.ValueType, typeParams, bindings)
+		}
+	case *ast.FunctionType:
+		if fn, ok := arg.(*FunctionType); ok {
+			for i, param := range e.Parameters {
+				if i < len(fn.Parameters) && param.Type != nil {
+					c.inferTypeArguments(param.Type, fn.Parameters[i], typeParams, bindings)
+				}
+			}
+			if e.ReturnType != nil {
+				c.inferTypeArguments(e.ReturnType, fn.ReturnType, typeParams, bindings)
+			}
+		}
+	}
+}
+
+// checkArguments checks the arguments of a call against parameter types.
+// A variadic function takes any number of extra arguments of type variadic
+func (c *Checker) checkArguments(node *ast.CallExpression, params []Type, variadic Type) {
+	if !c.checkArgumentCount(node, len(params), variadic) {
+		return
+	}
+
+	argTypes := c.checkArgumentList(node.Arguments)
+	c.checkArgumentTypes(node, argTypes, params, variadic)
+}
+
+// checkArgumentList checks a call's arguments and returns the type of each.
+// Every argument but the last is a single value, so a call among them only
+// passes its first return value
+func (c *Checker) checkArgumentList(args []ast.Expression) []Type {
+	argTypes := make([]Type, len(args))
+	for i, arg := range args {
+		argTypes[i] = c.checkExpression(arg)
+		if i < len(args)-1 {
+			argTypes[i] = singleValue(arg, argTypes[i])
+		}
+	}
+	return argTypes
+}
+
+// checkArgumentCount reports a call passing the wrong number of arguments
+// for params parameters, returning whether the count is right
+func (c *Checker) checkArgumentCount(node *ast.CallExpression, params int, variadic Type) bool {
+	if variadic != nil && len(node.Arguments) < params {
+		c.addError(
+			fmt.Sprintf("Function expects at least %d arguments, got %d",
+				params, len(node.Arguments)),
+			node.Token,
+		)
+		return false
+	}
+	if variadic == nil && len(node.Arguments) != params {
 		c.addError(
 			fmt.Sprintf("Function expects %d arguments, got %d",
-				len(fnType.Parameters), len(node.Arguments)),
+				params, len(node.Arguments)),
 			node.Token,
 		)
-		return fnType.ReturnType
+		return false
 	}
+	return true
+}
 
-	// Check argument types
+// checkArgumentTypes checks the already checked types of a call's arguments
+// against the parameter types
+func (c *Checker) checkArgumentTypes(node *ast.CallExpression, argTypes []Type, params []Type, variadic Type) {
 	for i, arg := range node.Arguments {
-		argType := c.checkExpression(arg)
-		if !argType.IsAssignableTo(fnType.Parameters[i]) {
+		argType := argTypes[i]
+		paramType := variadic
+		if i < len(params) {
+			paramType = params[i]
+		}
+		if c.checkArrayLiteral(arg, paramType) {
+			continue
+		}
+		if !argType.IsAssignableTo(paramType) {
 			c.addError(
 				fmt.Sprintf("Argument %d: cannot pass type '%s' to parameter of type '%s'",
-					i+1, argType.String(), fnType.Parameters[i].String()),
+					i+1, argType.String(), paramType.String()),
 				node.Token,
 			)
+			continue
 		}
+		c.checkExcessProperties(arg, paramType)
 	}
+}
 
-	return fnType.ReturnType
+// checkSuperExpression checks a reference to the parent class, which only
+// exists inside a subclass
+func (c *Checker) checkSuperExpression(node *ast.SuperExpression) Type {
+	if c.currentClass == nil || c.currentClass.Parent == nil {
+		c.addError("'super' can only be used inside a subclass", node.Token)
+		return Any
+	}
+	return c.currentClass.Parent
+}
+
+// checkSuperCall checks super(...), a call to the parent constructor from a
+// subclass constructor. A private constructor can't be called from outside
+// its own class, even through super
+func (c *Checker) checkSuperCall(node *ast.CallExpression, super *ast.SuperExpression) Type {
+	parent, ok := c.checkSuperExpression(super).(*ClassType)
+	if !ok {
+		for _, arg := range node.Arguments {
+			c.checkExpression(arg)
+		}
+		return Void
+	}
+
+	if c.currentConstructorClass == nil {
+		c.addError("'super(...)' can only be called inside a constructor", node.Token)
+	}
+
+	// A parent without a constructor of its own is built by its parent's
+	for parent.Constructor == nil && parent.Parent != nil {
+		parent = parent.Parent
+	}
+	if parent.ConstructorVisibility == "private" {
+		c.addError(fmt.Sprintf("Cannot call private constructor of '%s' via super.", parent.Name), node.Token)
+		return Void
+	}
+
+	var params []Type
+	var variadic Type
+	if parent.Constructor != nil {
+		params = parent.Constructor.Parameters
+		variadic = parent.Constructor.Variadic
+	}
+	c.checkArguments(node, params, variadic)
+	return Void
 }
 
 // checkDotExpression checks a dot expression (property access)
@@ -1096,13 +3773,18 @@ func (c *Checker) checkDotExpression(node *ast.DotExpression) Type {
 	// Check if left type has the property
 	switch typ := leftType.(type) {
 	case *ClassType:
+		c.checkMemberAccess(typ, propertyName, node.Token)
 		// Check properties
 		if propType, ok := typ.GetProperty(propertyName); ok {
 			return propType
 		}
 		// Check methods
 		if methodType, ok := typ.GetMethod(propertyName); ok {
-			return methodType
+			// super.method() passes the current self implicitly
+			if _, isSuper := node.Left.(*ast.SuperExpression); isSuper {
+				return methodType
+			}
+			return unboundMethod(typ, methodType)
 		}
 		c.addError(
 			fmt.Sprintf("Type '%s' has no property or method '%s'", typ.String(), propertyName),
@@ -1117,7 +3799,24 @@ func (c *Checker) checkDotExpression(node *ast.DotExpression) Type {
 		}
 		// Check methods
 		if methodType, ok := typ.GetMethod(propertyName); ok {
-			return methodType
+			return unboundMethod(typ, methodType)
+		}
+		// Any other name reads through a string index signature
+		if valueType, ok := typ.GetStringIndex(); ok {
+			return valueType
+		}
+		c.addError(
+			fmt.Sprintf("Type '%s' has no property or method '%s'", typ.String(), propertyName),
+			node.Token,
+		)
+		return Any
+
+	case *IntersectionType:
+		if propType, ok := typ.GetProperty(propertyName); ok {
+			return propType
+		}
+		if methodType, ok := typ.GetMethod(propertyName); ok {
+			return unboundMethod(typ, methodType)
 		}
 		c.addError(
 			fmt.Sprintf("Type '%s' has no property or method '%s'", typ.String(), propertyName),
@@ -1136,12 +3835,92 @@ func (c *Checker) checkDotExpression(node *ast.DotExpression) Type {
 		)
 		return Any
 
+	case *OptionalType:
+		c.addError(
+			fmt.Sprintf("Cannot access '%s' on type '%s', which may be nil; check it against nil first", propertyName, typ.String()),
+			node.Token,
+		)
+		return Any
+
 	default:
 		// For other types, allow any property access (could be table access)
 		return Any
 	}
 }
 
+// checkMethodExpression checks a method reference (obj:method). The receiver
+// is bound, so the result takes only the method's declared parameters
+func (c *Checker) checkMethodExpression(node *ast.MethodExpression) Type {
+	leftType := c.checkExpression(node.Left)
+
+	methodIdent, ok := node.Right.(*ast.Identifier)
+	if !ok {
+		c.addError("Right side of method reference must be an identifier", node.Token)
+		return Any
+	}
+
+	var methodType *FunctionType
+	found := false
+	switch typ := leftType.(type) {
+	case *ClassType:
+		c.checkMemberAccess(typ, methodIdent.Value, node.Token)
+		methodType, found = typ.GetMethod(methodIdent.Value)
+	case *InterfaceType:
+		methodType, found = typ.GetMethod(methodIdent.Value)
+	case *IntersectionType:
+		methodType, found = typ.GetMethod(methodIdent.Value)
+	default:
+		// Plain tables may hold functions expecting self
+		return Any
+	}
+
+	if !found {
+		c.addError(
+			fmt.Sprintf("Type '%s' has no method '%s'", leftType.String(), methodIdent.Value),
+			node.Token,
+		)
+		return Any
+	}
+	return methodType
+}
+
+// checkMemberAccess reports a private member used outside the class that
+// declares it, or a protected one used outside that class and its
+// subclasses. Whether code is inside a class is decided lexically, so a
+// function nested in a method, such as a callback, has the method's access
+func (c *Checker) checkMemberAccess(class *ClassType, name string, token lexer.Token) {
+	declaring, ok := class.declaringClass(name)
+	if !ok || !declaring.Hidden[name] {
+		return
+	}
+	inside := c.currentClass != nil && c.currentClass.Equals(declaring)
+	if declaring.Private[name] {
+		if !inside {
+			c.addError(
+				fmt.Sprintf("'%s' is private and only accessible within class '%s'", name, declaring.Name),
+				token,
+			)
+		}
+		return
+	}
+	if !inside && (c.currentClass == nil || !c.currentClass.IsSubclassOf(declaring)) {
+		c.addError(
+			fmt.Sprintf("'%s' is protected and only accessible within class '%s' and its subclasses",
+				name, declaring.Name),
+			token,
+		)
+	}
+}
+
+// unboundMethod returns the type of a method referenced with '.', which
+// leaves the receiver as an explicit first parameter
+func unboundMethod(receiver Type, method *FunctionType) *FunctionType {
+	params := make([]Type, 0, len(method.Parameters)+1)
+	params = append(params, receiver)
+	params = append(params, method.Parameters...)
+	return &FunctionType{Parameters: params, ReturnType: method.ReturnType, Variadic: method.Variadic}
+}
+
 // checkIndexExpression checks an index expression
 func (c *Checker) checkIndexExpression(node *ast.IndexExpression) Type {
 	leftType := c.checkExpression(node.Left)
@@ -1168,6 +3947,17 @@ func (c *Checker) checkIndexExpression(node *ast.IndexExpression) Type {
 		}
 		return typ.ValueType
 
+	case *InterfaceType:
+		// Numeric keys read through the numeric index signature, and string
+		// keys through the string one
+		if valueType, ok := typ.GetNumberIndex(); ok && IsNumericType(indexType) {
+			return valueType
+		}
+		if valueType, ok := typ.GetStringIndex(); ok && indexType.IsAssignableTo(String) {
+			return valueType
+		}
+		return Any
+
 	default:
 		// For other types, allow any index access
 		return Any
@@ -1183,24 +3973,312 @@ func (c *Checker) addError(message string, token lexer.Token) {
 	})
 }
 
+// addWarning records a problem that doesn't stop compilation
+func (c *Checker) addWarning(message string, token lexer.Token) {
+	c.warnings = append(c.warnings, &TypeError{
+		Message: message,
+		Line:    token.Line,
+		Column:  token.Column,
+	})
+}
+
 // checkExportStatement checks an export statement
 func (c *Checker) checkExportStatement(node *ast.ExportStatement) {
+	if node.IsWildcard {
+		c.checkReExport(node)
+		return
+	}
+
 	// Type check the underlying statement
 	c.checkStatement(node.Statement)
+
+	name := exportedName(node.Statement)
+	if name == "" {
+		return
+	}
+	// A default export is exported as default, whatever its own name
+	exportName := name
+	if node.IsDefault {
+		exportName = "default"
+	}
+	if _, exists := c.exports[exportName]; exists {
+		c.addError(fmt.Sprintf("Duplicate export '%s'", exportName), node.Token)
+		return
+	}
+	c.exports[exportName] = c.lookupExportedType(name)
+}
+
+// checkReExport adds every export of another module to this module's exports
+func (c *Checker) checkReExport(node *ast.ExportStatement) {
+	if c.options.ResolveModule == nil {
+		return
+	}
+
+	module := c.loadModule(node.Module, false, node.Token)
+	if module == nil {
+		return
+	}
+	for _, name := range sortedKeys(module.exports) {
+		if _, exists := c.exports[name]; exists {
+			c.addError(
+				fmt.Sprintf("Module '%s' re-exports '%s', which is already exported", node.Module, name),
+				node.Token,
+			)
+			continue
+		}
+		c.exports[name] = module.exports[name]
+	}
+}
+
+// sortedKeys returns the keys of a member map in alphabetical order, so
+// diagnostics driven by map iteration are reported deterministically
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// exportedName returns the name a statement binds, or "" if it binds none
+func exportedName(stmt ast.Statement) string {
+	switch node := stmt.(type) {
+	case *ast.VariableDeclaration:
+		return node.Name.Value
+	case *ast.FunctionDeclaration:
+		return node.Name.Value
+	case *ast.ClassDeclaration:
+		return node.Name.Value
+	case *ast.EnumDeclaration:
+		return node.Name.Value
+	case *ast.InterfaceDeclaration:
+		return node.Name.Value
+	case *ast.TypeDeclaration:
+		return node.Name.Value
+	}
+	return ""
+}
+
+// lookupExportedType finds the type of an exported name
+func (c *Checker) lookupExportedType(name string) Type {
+	if typ, ok := c.env.Get(name); ok {
+		return typ
+	}
+	if typ, ok := c.lookupNamedType(name); ok {
+		return typ
+	}
+	return Any
+}
+
+// lookupNamedType finds a class, interface, enum, or type alias by name
+func (c *Checker) lookupNamedType(name string) (Type, bool) {
+	if classType, ok := c.classes[name]; ok {
+		return classType, true
+	}
+	if interfaceType, ok := c.interfaces[name]; ok {
+		return interfaceType, true
+	}
+	if enumType, ok := c.enums[name]; ok {
+		return enumType, true
+	}
+	if aliasType, ok := c.typeAliases[name]; ok {
+		return aliasType, true
+	}
+	return nil, false
+}
+
+// importGraph tracks the modules reached through imports
+type importGraph struct {
+	// Modules checked or being checked, by file
+	modules map[string]*Checker
+	// Files being checked, from the one compiled to the one checked now
+	chain []string
+	// Whether each import along chain, from chain[i] to chain[i+1], is an
+	// import type, which is erased and so can't close a cycle at runtime
+	typeOnly []bool
+	// Import cycles found but not yet reported, each starting and ending
+	// with the file of the module that reports it
+	cycles [][]string
+}
+
+// loadModule resolves and checks the module imported by path, returning
+// its checker. The module reports its own errors when compiled; only its
+// exports matter here. It returns nil when the module can't be resolved,
+// which is reported. A module still being checked because the import leads
+// back to it is returned as it is, and the cycle is reported unless an
+// import type along it breaks it
+func (c *Checker) loadModule(path string, typeOnly bool, token lexer.Token) *Checker {
+	file, statements, err := c.options.ResolveModule(c.options.File, path)
+	if err != nil {
+		c.tracef("module '%s' from '%s' can't be resolved: %v", path, c.options.File, err)
+		c.addError(fmt.Sprintf("Cannot resolve module '%s': %v", path, err), token)
+		return nil
+	}
+	file = filepath.Clean(file)
+	module, ok := c.imports.modules[file]
+	switch {
+	case ok && module.checking:
+		c.tracef("module '%s' is '%s', which is still being checked", path, file)
+	case ok:
+		c.tracef("module '%s' is '%s', already checked", path, file)
+	default:
+		c.tracef("module '%s' is '%s', checking it", path, file)
+	}
+	if ok && module.checking {
+		for i, loading := range c.imports.chain {
+			if loading != file {
+				continue
+			}
+			erased := typeOnly
+			for _, edge := range c.imports.typeOnly[i:] {
+				erased = erased || edge
+			}
+			if erased {
+				c.tracef("the cycle back to '%s' goes through an import type, which is erased", file)
+				break
+			}
+			cycle := append([]string{}, c.imports.chain[i:]...)
+			c.imports.cycles = append(c.imports.cycles, append(cycle, file))
+			break
+		}
+	} else if !ok {
+		opts := c.options
+		opts.File = file
+		module = NewCheckerWithOptions(opts)
+		module.imports = c.imports
+		module.traceDepth = c.traceDepth + 1
+		c.imports.typeOnly = append(c.imports.typeOnly, typeOnly)
+		module.Check(statements)
+		c.imports.typeOnly = c.imports.typeOnly[:len(c.imports.typeOnly)-1]
+	}
+	c.reportImportCycles(token)
+	return module
+}
+
+// reportImportCycles warns about the import cycles found that start with
+// this module, at the import leading into them. A cycle is reported by the
+// first of its modules to be checked, where it is found whichever module
+// is compiled first
+func (c *Checker) reportImportCycles(token lexer.Token) {
+	file := filepath.Clean(c.options.File)
+	remaining := c.imports.cycles[:0]
+	for _, cycle := range c.imports.cycles {
+		if cycle[0] != file {
+			remaining = append(remaining, cycle)
+			continue
+		}
+		names := make([]string, len(cycle))
+		for i, module := range cycle {
+			names[i] = moduleName(filepath.Dir(file), module)
+		}
+		c.addWarning("Circular import detected: "+strings.Join(names, " -> "), token)
+	}
+	c.imports.cycles = remaining
+}
+
+// moduleName shortens the file of a module to its path from dir, without
+// the .lunar extension
+func moduleName(dir, file string) string {
+	if rel, err := filepath.Rel(dir, file); err == nil {
+		file = rel
+	}
+	return strings.TrimSuffix(file, ".lunar")
 }
 
-// checkImportStatement checks an import statement
-func (c *Checker) checkImportStatement(node *ast.ImportStatement) {
-	// For now, we skip type checking imports since we don't have module resolution
-	// In a full implementation, we would:
-	// 1. Resolve the module path
-	// 2. Load the module's type information
-	// 3. Add the imported names to the environment with their types
+// registerImport binds the names an import statement imports to what the
+// module exports under them. Exported types become available to
+// annotations; an import type statement binds nothing else, while other
+// imports also bind the exported values. Without module resolution, or
+// with a module that can't be loaded, the imported names are any
+func (c *Checker) registerImport(node *ast.ImportStatement) {
+	var module *Checker
+	if c.options.ResolveModule != nil {
+		module = c.loadModule(node.Module, node.IsTypeOnly, node.Token)
+	}
 
-	// For now, just add imported names as 'any' type so they don't cause undefined variable errors
 	for _, name := range node.Names {
-		c.env.Set(name.Value, Any)
+		if module == nil {
+			c.tracef("import '%s' from '%s' is any, as the module isn't resolved", name.Value, node.Module)
+			c.bindImport(node, name, Any, Any)
+			continue
+		}
+		if module.checking {
+			c.tracef("import '%s' from '%s' has only the types registered so far", name.Value, node.Module)
+			c.bindPartialImport(node, name, module)
+			continue
+		}
+
+		exported, isExported := module.exports[name.Value]
+		namedType, isType := module.lookupNamedType(name.Value)
+		if isExported {
+			c.tracef("import '%s' from '%s' is '%s'", name.Value, node.Module, exported)
+		} else {
+			c.tracef("import '%s' from '%s' isn't exported; using any", name.Value, node.Module)
+		}
+		switch {
+		case node.IsTypeOnly && (!isExported || !isType):
+			c.addError(
+				fmt.Sprintf("Module '%s' has no exported type '%s'", node.Module, name.Value),
+				name.Token,
+			)
+			c.bindImport(node, name, Any, nil)
+		case !isExported:
+			c.addError(
+				fmt.Sprintf("Module '%s' has no exported member '%s'", node.Module, name.Value),
+				name.Token,
+			)
+			c.bindImport(node, name, Any, Any)
+		case module.isTypeOnlyName(name.Value):
+			// Interfaces and type aliases have no runtime value
+			c.bindImport(node, name, namedType, nil)
+		case isType:
+			c.bindImport(node, name, namedType, exported)
+		default:
+			c.bindImport(node, name, nil, exported)
+		}
+	}
+}
+
+// bindPartialImport binds a name imported from a module that is still being
+// checked because of an import cycle. Its exports aren't known yet, so
+// nothing is reported as missing; the classes, interfaces, enums and type
+// aliases it has registered so far are bound, and anything else is any
+func (c *Checker) bindPartialImport(node *ast.ImportStatement, name *ast.Identifier, module *Checker) {
+	namedType, isType := module.lookupNamedType(name.Value)
+	switch {
+	case !isType:
+		c.bindImport(node, name, Any, Any)
+	case module.isTypeOnlyName(name.Value):
+		c.bindImport(node, name, namedType, nil)
+	default:
+		c.bindImport(node, name, namedType, namedType)
+	}
+}
+
+// bindImport binds an imported name as a type when typ isn't nil, and as a
+// value when value isn't nil and the import isn't type-only
+func (c *Checker) bindImport(node *ast.ImportStatement, name *ast.Identifier, typ, value Type) {
+	if typ != nil {
+		c.typeAliases[name.Value] = typ
+	}
+	if value != nil && !node.IsTypeOnly {
+		c.env.Set(name.Value, value)
+		c.recordSymbol(name, value)
+	}
+}
+
+// isTypeOnlyName reports whether name is an interface or type alias, which
+// exist only for type checking
+func (c *Checker) isTypeOnlyName(name string) bool {
+	if _, ok := c.interfaces[name]; ok {
+		return true
+	}
+	if _, ok := c.typeAliases[name]; ok {
+		return true
 	}
+	_, ok := c.genericTypeAliases[name]
+	return ok
 }
 
 // checkDeclareStatement handles ambient declarations
@@ -1220,21 +4298,16 @@ func (c *Checker) checkDeclareStatement(node *ast.DeclareStatement) {
 			} else {
 				c.env.Set(decl.Name.Value, declaredType)
 			}
+			c.recordSymbol(decl.Name, declaredType)
 		} else {
 			// No type annotation on ambient declaration - use any
 			c.env.Set(decl.Name.Value, Any)
+			c.recordSymbol(decl.Name, Any)
 		}
 
 	case *ast.FunctionDeclaration:
 		// Register the function signature without checking the body
-		params := make([]Type, len(decl.Parameters))
-		for i, param := range decl.Parameters {
-			if param.Type != nil {
-				params[i] = c.resolveTypeExpression(param.Type)
-			} else {
-				params[i] = Any
-			}
-		}
+		params, variadic := c.parameterTypes(decl.Parameters)
 
 		var returnType Type = Void
 		if decl.ReturnType != nil {
@@ -1244,8 +4317,11 @@ func (c *Checker) checkDeclareStatement(node *ast.DeclareStatement) {
 		funcType := &FunctionType{
 			Parameters: params,
 			ReturnType: returnType,
+			Guard:      c.resolveTypeGuard(decl.Parameters, decl.ReturnType),
+			Variadic:   variadic,
 		}
 		c.env.Set(decl.Name.Value, funcType)
+		c.recordSymbol(decl.Name, funcType)
 
 	// Class, Interface, Enum, Type declarations are already handled in registerTypeDefinition
 	}
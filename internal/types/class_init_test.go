@@ -0,0 +1,182 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrictClassInitPropertyAssignedInOneBranch(t *testing.T) {
+	input := `
+class Account
+	public balance: number
+	public owner: string
+	constructor(owner: string, balance: number)
+		self.owner = owner
+		if balance > 0 then
+			self.balance = balance
+		end
+	end
+end
+`
+
+	errors := checkWithOptions(t, input, Options{StrictClassInit: true})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if !strings.Contains(errors[0].Message, "Property 'balance' of class 'Account' is not assigned") {
+		t.Errorf("Expected error about 'balance', got: %s", errors[0].Message)
+	}
+	if errors[0].Line != 3 {
+		t.Errorf("Expected the error on the property at line 3, got line %d", errors[0].Line)
+	}
+
+	// Without the option the constructor is accepted
+	if errors := checkWithOptions(t, input, Options{}); len(errors) > 0 {
+		t.Errorf("Expected no type errors without strict init, got: %s", errors[0].Message)
+	}
+}
+
+func TestStrictClassInitPropertyAssignedUnconditionally(t *testing.T) {
+	input := `
+class Account
+	public balance: number
+	public owner: string
+	constructor(owner: string, balance: number)
+		self.owner = owner
+		if balance > 0 then
+			self.balance = balance
+		else
+			self.balance = 0
+		end
+	end
+end
+
+class Point
+	public x: number
+	constructor(public y: number)
+		do
+			self.x = 0
+		end
+	end
+end
+`
+
+	errors := checkWithOptions(t, input, Options{StrictClassInit: true})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestStrictClassInitOptionalPropertyLeftUnset(t *testing.T) {
+	input := `
+class Node
+	public value: number
+	public next?: Node
+	public label: string | nil
+	constructor(value: number)
+		self.value = value
+	end
+end
+`
+
+	errors := checkWithOptions(t, input, Options{StrictClassInit: true})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestStrictClassInitPropertyAssignedInLoop(t *testing.T) {
+	input := `
+class Counter
+	public count: number
+	constructor(n: number)
+		while n > 0 do
+			self.count = n
+			n = n - 1
+		end
+	end
+end
+
+class Empty
+	public size: number
+end
+`
+
+	errors := checkWithOptions(t, input, Options{StrictClassInit: true})
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 type errors, got %d", len(errors))
+	}
+	if !strings.Contains(errors[0].Message, "'count'") {
+		t.Errorf("Expected error about 'count', got: %s", errors[0].Message)
+	}
+	if !strings.Contains(errors[1].Message, "'size'") {
+		t.Errorf("Expected error about 'size', got: %s", errors[1].Message)
+	}
+}
+
+func TestStrictClassInitElseIfBranches(t *testing.T) {
+	input := `
+class Level
+	public name: string
+	public rank: number
+	constructor(score: number)
+		if score > 90 then
+			self.name = "gold"
+			self.rank = 1
+		elseif score > 50 then
+			self.name = "silver"
+		else
+			self.name = "bronze"
+			self.rank = 3
+		end
+	end
+end
+`
+
+	errors := checkWithOptions(t, input, Options{StrictClassInit: true})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if !strings.Contains(errors[0].Message, "'rank'") {
+		t.Errorf("Expected error about 'rank', got: %s", errors[0].Message)
+	}
+}
+
+func TestStrictClassInitRepeatLeftByBreak(t *testing.T) {
+	input := `
+class Early
+	public x: number
+	constructor(c: boolean)
+		repeat
+			if c then
+				break
+			end
+			self.x = 1
+		until true
+	end
+end
+
+class Late
+	public x: number
+	constructor(c: boolean)
+		repeat
+			self.x = 1
+			while c do
+				break
+			end
+			if c then
+				break
+			end
+		until true
+	end
+end
+`
+
+	errors := checkWithOptions(t, input, Options{StrictClassInit: true})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if !strings.Contains(errors[0].Message, "Property 'x' of class 'Early' is not assigned") {
+		t.Errorf("Expected error about 'x' of 'Early', got: %s", errors[0].Message)
+	}
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestConcatenationOperands(t *testing.T) {
+	input := `
+local name: string = "a"
+local count: number = 1
+local id: string | number = 2
+local mode: "fast" | "slow" = "fast"
+local anything: any = {}
+local s1: string = "a" .. 1
+local s2: string = name .. count .. id
+local s3: string = mode .. 1.5 .. anything
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestConcatenationRejectsOtherTypes(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{`true .. "x"`, "Operator '..' cannot be applied to type 'boolean'"},
+		{`"x" .. nil`, "Operator '..' cannot be applied to type 'nil'"},
+		{`"x" .. maybe`, "Operator '..' cannot be applied to type 'string | nil'"},
+	}
+
+	for _, tt := range tests {
+		input := "local maybe: string | nil = nil\nlocal s: string = " + tt.expression
+		errors := checkWithOptions(t, input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("%s: expected 1 type error, got %d", tt.expression, len(errors))
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.expression, tt.expected, errors[0].Message)
+		}
+	}
+}
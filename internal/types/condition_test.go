@@ -0,0 +1,337 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+// checkWarnings type checks input, failing on any error, and returns the
+// warnings it produced
+func checkWarnings(t *testing.T, input string) []*TypeError {
+	t.Helper()
+
+	checker := NewChecker()
+	errors := checker.Check(parseSource(t, input))
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+	return checker.Warnings()
+}
+
+func TestConstantTrueConditionWithElse(t *testing.T) {
+	input := `
+local x: number = 0
+if true then
+	x = 1
+else
+	x = 2
+end
+`
+
+	warnings := checkWarnings(t, input)
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	if !strings.Contains(warnings[0].Message, "Unreachable else branch") {
+		t.Errorf("Expected unreachable else warning, got: %s", warnings[0].Message)
+	}
+	if warnings[0].Line != 5 {
+		t.Errorf("Expected the warning on the else at line 5, got line %d", warnings[0].Line)
+	}
+}
+
+func TestConstantConditions(t *testing.T) {
+	tests := []struct {
+		condition string
+		expected  string
+	}{
+		{"false", "condition is always false"},
+		{"not true", "condition is always false"},
+		{"true and false", "condition is always false"},
+		{"false and flag", "condition is always false"},
+		{"true or flag", "Condition is always true"},
+	}
+
+	for _, tt := range tests {
+		input := "local flag: boolean = true\nif " + tt.condition + " then\n\tflag = false\nend\n"
+
+		warnings := checkWarnings(t, input)
+		if len(warnings) != 1 {
+			t.Errorf("%s: expected 1 warning, got %d", tt.condition, len(warnings))
+			continue
+		}
+		if !strings.Contains(warnings[0].Message, tt.expected) {
+			t.Errorf("%s: expected warning containing %q, got: %s", tt.condition, tt.expected, warnings[0].Message)
+		}
+	}
+}
+
+func TestNormalIfElseHasNoWarning(t *testing.T) {
+	input := `
+local flag: boolean = true
+local x: number = 0
+if flag then
+	x = 1
+else
+	x = 2
+end
+
+if flag == true then
+	x = 3
+else
+	x = 4
+end
+`
+
+	if warnings := checkWarnings(t, input); len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %d:", len(warnings))
+		for _, warning := range warnings {
+			t.Errorf("  %s", warning.Message)
+		}
+	}
+}
+
+func TestExhaustiveBooleanChainWithElse(t *testing.T) {
+	input := `
+local flag: boolean = true
+local x: number = 0
+if flag == true then
+	x = 1
+else
+	if true ~= flag then
+		x = 2
+	else
+		x = 3
+	end
+end
+`
+
+	warnings := checkWarnings(t, input)
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	if !strings.Contains(warnings[0].Message, "'flag' was already checked for both true and false") {
+		t.Errorf("Expected exhausted boolean warning, got: %s", warnings[0].Message)
+	}
+	if warnings[0].Line != 9 {
+		t.Errorf("Expected the warning on the inner else at line 9, got line %d", warnings[0].Line)
+	}
+}
+
+func TestOptionalBooleanChainHasNoWarning(t *testing.T) {
+	input := `
+local flag: boolean | nil = nil
+local x: number = 0
+if flag == true then
+	x = 1
+else
+	if flag == false then
+		x = 2
+	else
+		x = 3
+	end
+end
+`
+
+	if warnings := checkWarnings(t, input); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for a boolean that may be nil, got %d:", len(warnings))
+		for _, warning := range warnings {
+			t.Errorf("  %s", warning.Message)
+		}
+	}
+}
+
+func TestElseIfChain(t *testing.T) {
+	input := `
+local n: number = 0
+local flag: boolean = true
+local sign: number = 0
+if n > 0 then
+	sign = 1
+elseif n < 0 then
+	sign = -1
+elseif flag == true then
+	sign = 0
+elseif flag == false then
+	sign = 0
+else
+	sign = 2
+end
+`
+
+	warnings := checkWarnings(t, input)
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	if !strings.Contains(warnings[0].Message, "'flag' was already checked for both true and false") {
+		t.Errorf("Expected exhausted chain warning, got: %s", warnings[0].Message)
+	}
+	if warnings[0].Line != 13 {
+		t.Errorf("Expected the warning on the else at line 13, got line %d", warnings[0].Line)
+	}
+}
+
+func TestElseIfConditionMustBeBoolean(t *testing.T) {
+	input := `
+local n: number = 0
+if n > 0 then
+	n = 1
+elseif n then
+	n = 2
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if errors[0].Message != "If condition must be boolean, got 'number'" || errors[0].Line != 5 {
+		t.Errorf("Expected a condition error at line 5, got %q at line %d", errors[0].Message, errors[0].Line)
+	}
+}
+
+func TestConstantTrueConditionBeforeElseIf(t *testing.T) {
+	input := `
+local n: number = 0
+if true then
+	n = 1
+elseif n > 0 then
+	n = 2
+end
+`
+
+	warnings := checkWarnings(t, input)
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	if warnings[0].Message != "Unreachable elseif branch: condition is always true" || warnings[0].Line != 5 {
+		t.Errorf("Expected an unreachable elseif warning at line 5, got %q at line %d", warnings[0].Message, warnings[0].Line)
+	}
+}
+
+const comparedEnums = `
+enum Color
+	Red
+	Green
+end
+
+enum Status
+	Ok = 200
+	NotFound = 404
+end
+
+enum Size
+	Small = "s"
+	Large = "l"
+end
+
+local color: Color = Color.Red
+local status: Status = Status.Ok
+local size: Size = Size.Small
+local label: string = "red"
+local count: number = 1
+local flag: boolean = true
+local pending: boolean = false
+`
+
+func TestComparisonWithoutOverlap(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"pending = color == label", "This comparison appears unintentional; types 'Color' and 'string' have no overlap."},
+		{"pending = color == \"red\"", "This comparison appears unintentional; types 'Color' and '\"red\"' have no overlap."},
+		{"pending = size ~= 1", "This comparison appears unintentional; types 'Size' and '1' have no overlap."},
+		{"pending = color == status", "This comparison appears unintentional; types 'Color' and 'Status' have no overlap."},
+		{"pending = count == flag", "This comparison appears unintentional; types 'number' and 'boolean' have no overlap."},
+		{"pending = label != count", "This comparison appears unintentional; types 'string' and 'number' have no overlap."},
+	}
+
+	for _, tt := range tests {
+		warnings := checkWarnings(t, comparedEnums+tt.input)
+		if len(warnings) != 1 {
+			t.Errorf("Expected 1 warning for %q, got %d", tt.input, len(warnings))
+			continue
+		}
+		if warnings[0].Message != tt.expected {
+			t.Errorf("Expected warning %q, got %q", tt.expected, warnings[0].Message)
+		}
+	}
+}
+
+func TestComparisonWithOverlap(t *testing.T) {
+	inputs := []string{
+		"pending = color == Color.Green",
+		// Enums compare with values of the type their members hold
+		"pending = status == 404",
+		"pending = color == count",
+		"pending = size == \"s\"",
+		"pending = size == label",
+		"pending = count == 1",
+		"pending = label ~= \"blue\"",
+		"pending = color ~= nil",
+		"local maybe: string? = nil\npending = maybe == label",
+		"local either: string | number = 1\npending = either == count",
+		"local value: any = 1\npending = color == value",
+	}
+
+	for _, input := range inputs {
+		if warnings := checkWarnings(t, comparedEnums+input); len(warnings) > 0 {
+			t.Errorf("Expected no warnings for %q, got: %s", input, warnings[0].Message)
+		}
+	}
+}
+
+func TestSelfComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"pending = label == label", "Comparing 'label' to itself is always true"},
+		{"pending = color ~= color", "Comparing 'color' to itself is always false"},
+		{"pending = count == count", "Comparing 'count' to itself is always true, except for NaN"},
+		{"local point = { x = 1 }\npending = point.x == point.x", "Comparing 'point.x' to itself is always true, except for NaN"},
+	}
+
+	for _, tt := range tests {
+		warnings := checkWarnings(t, comparedEnums+tt.input)
+		if len(warnings) != 1 {
+			t.Errorf("Expected 1 warning for %q, got %d", tt.input, len(warnings))
+			continue
+		}
+		if warnings[0].Message != tt.expected {
+			t.Errorf("Expected warning %q, got %q", tt.expected, warnings[0].Message)
+		}
+	}
+}
+
+func TestLiteralOutsideUnion(t *testing.T) {
+	input := comparedEnums + `
+local state: "open" | "closed" = "open"
+pending = state == "unknown"
+`
+	warnings := checkWarnings(t, input)
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	expected := "This comparison appears unintentional; types '\"open\" | \"closed\"' and '\"unknown\"' have no overlap."
+	if warnings[0].Message != expected {
+		t.Errorf("Expected warning %q, got %q", expected, warnings[0].Message)
+	}
+}
+
+func TestComparisonsWithoutSelfComparisonWarning(t *testing.T) {
+	inputs := []string{
+		// The usual test for NaN
+		"pending = count ~= count",
+		"pending = count == 1",
+		"local other: number = 2\npending = count == other",
+		"local state: \"open\" | \"closed\" = \"open\"\npending = state == \"closed\"",
+	}
+
+	for _, input := range inputs {
+		if warnings := checkWarnings(t, comparedEnums+input); len(warnings) > 0 {
+			t.Errorf("Expected no warnings for %q, got: %s", input, warnings[0].Message)
+		}
+	}
+}
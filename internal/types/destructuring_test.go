@@ -0,0 +1,64 @@
+package types
+
+import "testing"
+
+const serviceOptions = `
+interface Options
+	name: string
+	timeout?: number
+	retries?: number
+end
+
+local options: Options = { name = "api" }
+`
+
+func TestDestructuringWithDefaults(t *testing.T) {
+	input := serviceOptions + `
+local { name, timeout = 30, retries = 3 } = options
+local n: string = name
+local seconds: number = timeout
+local attempts: number = retries
+`
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`local { timeout = "slow" } = options`,
+			"Cannot use type '\"slow\"' as the default of 'timeout', which has type 'number'",
+		},
+		{
+			`local { port } = options`,
+			"Type 'Options' has no property 'port' to destructure",
+		},
+		{
+			// Without a default the field may still be nil
+			`local { timeout } = options
+local seconds: number = timeout`,
+			"Cannot assign type 'number | nil' to variable of type 'number'",
+		},
+		{
+			`local maybe: Options? = nil
+local { name } = maybe`,
+			"Cannot destructure 'name' from type 'Options?', which may be nil; check it against nil first",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, serviceOptions+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package types
+
+import "testing"
+
+const computeFunction = `
+function compute(): number
+	return 41
+end
+`
+
+func TestDoExpressionTypeFromReturn(t *testing.T) {
+	input := computeFunction + `
+local x = do local t = compute(); return t + 1 end
+local n: number = x
+`
+
+	for _, err := range checkWithOptions(t, input, Options{}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestDoExpressionTypeFromFinalExpression(t *testing.T) {
+	input := computeFunction + `
+local label: string = do
+	local t = compute()
+	"value"
+end
+local wrong: boolean = do
+	compute()
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d: %v", len(errors), errors)
+	}
+	expected := "Cannot assign type 'number' to variable of type 'boolean'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestDoExpressionScope(t *testing.T) {
+	input := computeFunction + `
+function run(): string
+	local x = do local t = compute(); return t + 1 end
+	local y = t
+	return "done"
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d: %v", len(errors), errors)
+	}
+	// The block's return leaves only the block, not run
+	expected := "Undefined variable 't'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected %q, got %q", expected, errors[0].Message)
+	}
+}
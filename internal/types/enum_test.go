@@ -189,3 +189,226 @@ setPriority(Priority.High)
 		}
 	}
 }
+
+func TestConstEnum(t *testing.T) {
+	input := `
+const enum Color
+    Red
+    Green = 5
+    Blue = "blue"
+end
+
+function paint(c: Color): Color
+    return c
+end
+
+local c: Color = paint(Color.Red)
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+
+	if !checker.enums["Color"].IsConst {
+		t.Error("Expected Color to be marked as a const enum")
+	}
+}
+
+func TestConstEnumRequiresConstantValues(t *testing.T) {
+	input := `
+const enum Labels
+    Low = "low"
+    High = "hi" .. "gh"
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 1 error: High is not a compile-time constant
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Const enum member 'Labels.High' must have a constant value"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+const trafficLight = `
+enum Light
+	Red
+	Yellow
+	Green
+end
+
+local light: Light = Light.Red
+local wait: boolean = false
+`
+
+func TestEnumChainMissingMember(t *testing.T) {
+	input := trafficLight + `
+if light == Light.Red then
+	wait = true
+elseif Light.Yellow == light then
+	wait = true
+end
+`
+	warnings := checkWarnings(t, input)
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	if warnings[0].Message != "Enum member 'Green' not handled" {
+		t.Errorf("Expected a warning for Green, got: %s", warnings[0].Message)
+	}
+	if warnings[0].Line != 11 {
+		t.Errorf("Expected the warning on the if at line 11, got line %d", warnings[0].Line)
+	}
+}
+
+func TestEnumChainsWithoutWarnings(t *testing.T) {
+	inputs := []string{
+		// Every member is handled
+		`
+if light == Light.Red then
+	wait = true
+elseif light == Light.Yellow then
+	wait = true
+elseif light == Light.Green then
+	wait = false
+end
+`,
+		// An else handles the rest
+		`
+if light == Light.Red then
+	wait = true
+elseif light == Light.Yellow then
+	wait = true
+else
+	wait = false
+end
+`,
+		// A lone if tests one value rather than matching the enum
+		`
+if light == Light.Red then
+	wait = true
+end
+`,
+		// Not every condition compares the enum
+		`
+if light == Light.Red then
+	wait = true
+elseif wait then
+	wait = false
+end
+`,
+	}
+
+	for _, input := range inputs {
+		if warnings := checkWarnings(t, trafficLight+input); len(warnings) > 0 {
+			t.Errorf("Expected no warnings for:%s\ngot: %s", input, warnings[0].Message)
+		}
+	}
+}
+
+func TestEnumValueTypes(t *testing.T) {
+	inputs := []string{
+		`
+enum Priority
+	Low = 1
+	Medium = 5
+	High = -10
+end
+`,
+		`
+enum Direction
+	Up = "up"
+	Down = "down"
+end
+`,
+	}
+	for _, input := range inputs {
+		if warnings := checkWarnings(t, input); len(warnings) > 0 {
+			t.Errorf("Unexpected warning: %s", warnings[0].Message)
+		}
+	}
+
+	checker := NewChecker()
+	l := lexer.New(inputs[1])
+	checker.Check(parser.New(l).Parse())
+	if valueType := checker.enums["Direction"].ValueType; !valueType.Equals(String) {
+		t.Errorf("Expected Direction values to be strings, got %s", valueType)
+	}
+}
+
+func TestEnumMemberMustBeNumberOrString(t *testing.T) {
+	input := `
+enum Flags
+	On = true
+	Off = 0
+end
+`
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Enum member 'Flags.On' must have a number or string value, got 'boolean'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestEnumMixingNumbersAndStrings(t *testing.T) {
+	input := `
+enum Code
+	Ok = 200
+	Missing = "404"
+end
+`
+	warnings := checkWarnings(t, input)
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	if warnings[0].Message != "Enum 'Code' mixes number and string values" {
+		t.Errorf("Expected a mixed values warning, got: %s", warnings[0].Message)
+	}
+}
+
+func TestEnumMemberAfterStringNeedsValue(t *testing.T) {
+	input := `
+enum Color
+	Red = "red"
+	Green
+end
+`
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Enum member 'Color.Green' must have a value, since it follows a member with a string value"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
@@ -0,0 +1,456 @@
+package types
+
+import (
+	"fmt"
+	"lunar/internal/ast"
+	"lunar/internal/lexer"
+	"lunar/internal/parser"
+	"testing"
+)
+
+// moduleResolver resolves module paths against in-memory sources
+func moduleResolver(t *testing.T, modules map[string]string) ModuleResolver {
+	return func(from, path string) (string, []ast.Statement, error) {
+		source, ok := modules[path]
+		if !ok {
+			return "", nil, fmt.Errorf("module not found")
+		}
+
+		p := parser.New(lexer.New(source))
+		statements := p.Parse()
+		if len(p.Errors()) > 0 {
+			t.Fatalf("Parser errors in %s: %v", path, p.Errors())
+		}
+		return path, statements, nil
+	}
+}
+
+func checkModule(t *testing.T, input string, modules map[string]string) *Checker {
+	t.Helper()
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewCheckerWithOptions(Options{
+		File:          "main.lunar",
+		ResolveModule: moduleResolver(t, modules),
+	})
+	checker.Check(statements)
+	return checker
+}
+
+const utilModule = `
+export function add(a: number, b: number): number
+	return a + b
+end
+
+export const NAME: string = "util"
+`
+
+func TestReExportAll(t *testing.T) {
+	checker := checkModule(t, `export * from "./util"`, map[string]string{"./util": utilModule})
+
+	if len(checker.errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(checker.errors))
+		for _, err := range checker.errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+
+	exports := checker.Exports()
+	if len(exports) != 2 {
+		t.Fatalf("Expected 2 exports, got %d", len(exports))
+	}
+	if typ, ok := exports["add"]; !ok || typ.String() != "(number, number) -> number" {
+		t.Errorf("Expected 'add' exported as (number, number) -> number, got %v", typ)
+	}
+	if typ, ok := exports["NAME"]; !ok || !typ.Equals(String) {
+		t.Errorf("Expected 'NAME' exported as string, got %v", typ)
+	}
+}
+
+func TestReExportNameCollision(t *testing.T) {
+	input := `
+export function add(a: number, b: number): number
+	return a + b
+end
+
+export * from "./util"
+`
+	checker := checkModule(t, input, map[string]string{"./util": utilModule})
+
+	// Should have 1 error: 'add' is exported twice
+	if len(checker.errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(checker.errors))
+	}
+	expected := "Module './util' re-exports 'add', which is already exported"
+	if checker.errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, checker.errors[0].Message)
+	}
+}
+
+func TestReExportUnknownModule(t *testing.T) {
+	checker := checkModule(t, `export * from "./missing"`, map[string]string{})
+
+	if len(checker.errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(checker.errors))
+	}
+}
+
+func TestDefaultExportAnonymousFunction(t *testing.T) {
+	input := `
+export default function(a: number): number
+	return a * 2
+end
+`
+	checker := checkModule(t, input, map[string]string{})
+
+	if len(checker.errors) > 0 {
+		t.Fatalf("Expected no type errors, got %d: %s", len(checker.errors), checker.errors[0].Message)
+	}
+	exports := checker.Exports()
+	if len(exports) != 1 {
+		t.Fatalf("Expected 1 export, got %d", len(exports))
+	}
+	if typ, ok := exports["default"]; !ok || typ.String() != "(number) -> number" {
+		t.Errorf("Expected 'default' exported as (number) -> number, got %v", typ)
+	}
+}
+
+func TestDefaultExportAnonymousClass(t *testing.T) {
+	input := `
+export default class
+	public name: string
+	constructor(name: string)
+		self.name = name
+	end
+	public greet(): string
+		return "hi " .. self.name
+	end
+end
+`
+	checker := checkModule(t, input, map[string]string{})
+
+	if len(checker.errors) > 0 {
+		t.Fatalf("Expected no type errors, got %d: %s", len(checker.errors), checker.errors[0].Message)
+	}
+	classType, ok := checker.Exports()["default"].(*ClassType)
+	if !ok {
+		t.Fatalf("Expected 'default' exported as a class, got %v", checker.Exports()["default"])
+	}
+	if _, ok := classType.GetMethod("greet"); !ok {
+		t.Error("Expected the default class to have a greet method")
+	}
+}
+
+func TestDefaultExportBodyIsChecked(t *testing.T) {
+	input := `
+export default function(a: number): string
+	return a
+end
+`
+	checker := checkModule(t, input, map[string]string{})
+
+	if len(checker.errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(checker.errors))
+	}
+}
+
+func TestDuplicateDefaultExport(t *testing.T) {
+	input := `
+export default function() end
+export default class end
+`
+	checker := checkModule(t, input, map[string]string{})
+
+	if len(checker.errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(checker.errors))
+	}
+	if checker.errors[0].Message != "Duplicate export 'default'" {
+		t.Errorf("Expected a duplicate export error, got %q", checker.errors[0].Message)
+	}
+}
+
+const shapesModule = `
+export interface Point
+	x: number
+	y: number
+end
+
+export type Name = string
+
+export const ORIGIN_X: number = 0
+`
+
+func TestTypeOnlyImport(t *testing.T) {
+	input := `
+import type { Point, Name } from "./shapes"
+
+function describe(p: Point, label: Name): string
+	return label .. p.x
+end
+`
+	checker := checkModule(t, input, map[string]string{"./shapes": shapesModule})
+
+	if len(checker.errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(checker.errors))
+		for _, err := range checker.errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestTypeOnlyImportChecksAnnotations(t *testing.T) {
+	input := `
+import type { Point } from "./shapes"
+
+const p: Point = { x = 1 }
+`
+	checker := checkModule(t, input, map[string]string{"./shapes": shapesModule})
+
+	if len(checker.errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(checker.errors))
+	}
+	expected := "Cannot assign type '<table literal>' to variable of type 'Point'"
+	if checker.errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, checker.errors[0].Message)
+	}
+}
+
+func TestTypeOnlyImportUnknownType(t *testing.T) {
+	input := `import type { Point, ORIGIN_X, Size } from "./shapes"`
+	checker := checkModule(t, input, map[string]string{"./shapes": shapesModule})
+
+	// Values and missing names aren't exported types
+	expected := []string{
+		"Module './shapes' has no exported type 'ORIGIN_X'",
+		"Module './shapes' has no exported type 'Size'",
+	}
+	if len(checker.errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d", len(expected), len(checker.errors))
+	}
+	for i, msg := range expected {
+		if checker.errors[i].Message != msg {
+			t.Errorf("Expected error %q, got %q", msg, checker.errors[i].Message)
+		}
+	}
+}
+
+func TestTypeOnlyImportBindsNoValue(t *testing.T) {
+	input := `
+import type { Point } from "./shapes"
+
+local q = Point
+`
+	checker := checkModule(t, input, map[string]string{"./shapes": shapesModule})
+
+	if len(checker.errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(checker.errors))
+	}
+	expected := "Undefined variable 'Point'"
+	if checker.errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, checker.errors[0].Message)
+	}
+}
+
+func TestImportBindsExportedTypes(t *testing.T) {
+	input := `
+import { add, NAME, Point } from "./util"
+
+local total: number = add(1, 2)
+local label: string = NAME
+local origin: Point = { x = 0 }
+`
+	modules := map[string]string{"./util": utilModule + `
+export interface Point
+	x: number
+end
+`}
+	checker := checkModule(t, input, modules)
+
+	if len(checker.errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(checker.errors))
+		for _, err := range checker.errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestImportedFunctionIsChecked(t *testing.T) {
+	input := `
+import { add } from "./util"
+
+local total: string = add(1, "2")
+`
+	checker := checkModule(t, input, map[string]string{"./util": utilModule})
+
+	expected := []string{
+		"Argument 2: cannot pass type '\"2\"' to parameter of type 'number'",
+		"Cannot assign type 'number' to variable of type 'string'",
+	}
+	if len(checker.errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d", len(expected), len(checker.errors))
+	}
+	for i, msg := range expected {
+		if checker.errors[i].Message != msg {
+			t.Errorf("Expected error %q, got %q", msg, checker.errors[i].Message)
+		}
+	}
+}
+
+func TestImportUnexportedName(t *testing.T) {
+	input := `import { add, subtract } from "./util"`
+	checker := checkModule(t, input, map[string]string{"./util": utilModule + `
+function subtract(a: number, b: number): number
+	return a - b
+end
+`})
+
+	if len(checker.errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(checker.errors))
+	}
+	expected := "Module './util' has no exported member 'subtract'"
+	if checker.errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, checker.errors[0].Message)
+	}
+}
+
+func TestImportedInterfaceBindsNoValue(t *testing.T) {
+	input := `
+import { Point } from "./shapes"
+
+local q = Point
+`
+	checker := checkModule(t, input, map[string]string{"./shapes": shapesModule})
+
+	if len(checker.errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(checker.errors))
+	}
+	expected := "Undefined variable 'Point'"
+	if checker.errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, checker.errors[0].Message)
+	}
+}
+
+// checkCycle checks the module at ./a, which the given modules may import
+// back
+func checkCycle(t *testing.T, input string, modules map[string]string) *Checker {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	statements := p.Parse()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	modules["./a"] = input
+	checker := NewCheckerWithOptions(Options{
+		File:          "./a",
+		ResolveModule: moduleResolver(t, modules),
+	})
+	checker.Check(statements)
+	return checker
+}
+
+func TestCircularImport(t *testing.T) {
+	input := `
+import { b } from "./b"
+
+export function a(): number
+	return 1
+end
+`
+	checker := checkCycle(t, input, map[string]string{"./b": `
+import { a } from "./a"
+
+export function b(): number
+	return a()
+end
+`})
+
+	for _, err := range checker.errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+	if len(checker.warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(checker.warnings))
+	}
+	expected := "Circular import detected: a -> b -> a"
+	if checker.warnings[0].Message != expected {
+		t.Errorf("Expected warning %q, got %q", expected, checker.warnings[0].Message)
+	}
+	if checker.warnings[0].Line != 2 {
+		t.Errorf("Expected the warning on the import, line 2, got line %d", checker.warnings[0].Line)
+	}
+}
+
+func TestCircularImportThroughThreeModules(t *testing.T) {
+	checker := checkCycle(t, `import { b } from "./b"`, map[string]string{
+		"./b": `import { c } from "./c"`,
+		"./c": `import { a } from "./a"`,
+	})
+
+	if len(checker.warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(checker.warnings))
+	}
+	expected := "Circular import detected: a -> b -> c -> a"
+	if checker.warnings[0].Message != expected {
+		t.Errorf("Expected warning %q, got %q", expected, checker.warnings[0].Message)
+	}
+}
+
+func TestCircularImportThroughImportTypeIsNotReported(t *testing.T) {
+	modules := []map[string]string{
+		{
+			"./b": `import { c } from "./c"`,
+			"./c": `import type { a } from "./a"`,
+		},
+		{
+			"./b": `import type { c } from "./c"`,
+			"./c": `import { a } from "./a"`,
+		},
+	}
+
+	for _, deps := range modules {
+		checker := checkCycle(t, `import { b } from "./b"`, deps)
+		for _, warning := range checker.warnings {
+			t.Errorf("Unexpected warning: %s", warning.Message)
+		}
+	}
+}
+
+func TestCircularImportSeesRegisteredTypes(t *testing.T) {
+	// b is checked while a is only partly checked, yet sees a's Point class
+	input := `
+import { origin } from "./b"
+
+export class Point
+	x: number
+	y: number
+end
+
+function move(p: Point)
+	local moved: Point = origin(p)
+	local n: number = origin(p)
+end
+`
+	checker := checkCycle(t, input, map[string]string{"./b": `
+import { Point } from "./a"
+
+export function origin(p: Point): Point
+	return p
+end
+`})
+
+	if len(checker.errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(checker.errors))
+	}
+	expected := "Cannot assign type 'Point' to variable of type 'number'"
+	if checker.errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, checker.errors[0].Message)
+	}
+}
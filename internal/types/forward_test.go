@@ -0,0 +1,285 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func expectNoTypeErrors(t *testing.T, errors []*TypeError) {
+	t.Helper()
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestFunctionUsesTypeDeclaredLater(t *testing.T) {
+	input := `
+function area(s: Shape): number
+	return s.width * s.height
+end
+
+function originX(p: Point): number
+	return p.x
+end
+
+interface Shape
+	width: number
+	height: number
+end
+
+class Point
+	x: number
+	y: number
+	constructor(x: number, y: number)
+		self.x = x
+		self.y = y
+	end
+end
+`
+
+	expectNoTypeErrors(t, checkWithOptions(t, input, Options{}))
+}
+
+func TestClassImplementsInterfaceDeclaredLater(t *testing.T) {
+	input := `
+class Box implements Sized
+	size: number
+	next: Box
+	constructor(size: number)
+		self.size = size
+	end
+	public getSize(): number
+		return self.size
+	end
+end
+
+interface Sized
+	size: number
+	getSize(): number
+end
+`
+
+	expectNoTypeErrors(t, checkWithOptions(t, input, Options{}))
+}
+
+func TestClassMissingMemberOfInterfaceDeclaredLater(t *testing.T) {
+	input := `
+class Box implements Sized
+	constructor()
+	end
+end
+
+interface Sized
+	size: number
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) == 0 {
+		t.Fatal("Expected an error for the missing interface property")
+	}
+	if !strings.Contains(errors[0].Message, "size") {
+		t.Errorf("Expected error about 'size', got: %s", errors[0].Message)
+	}
+}
+
+func TestClassPropertyReferencesClassDeclaredLater(t *testing.T) {
+	input := `
+class List
+	head: Node
+	constructor(head: Node)
+		self.head = head
+	end
+end
+
+class Node
+	value: number
+	constructor(value: number)
+		self.value = value
+	end
+end
+
+function first(list: List): number
+	return list.head.value
+end
+`
+
+	expectNoTypeErrors(t, checkWithOptions(t, input, Options{}))
+}
+
+func TestTypeAliasReferencesAliasDeclaredLater(t *testing.T) {
+	input := `
+local a: Alias = 1
+type Alias = Num
+type Num = number
+`
+
+	expectNoTypeErrors(t, checkWithOptions(t, input, Options{}))
+
+	errors := checkWithOptions(t, `
+local a: Alias = "text"
+type Alias = Num
+type Num = number
+`, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+}
+
+func TestNestedTypeDeclarationUsedBeforeIt(t *testing.T) {
+	input := `
+do
+	local p: Point = { x = 1 }
+	interface Point
+		x: number
+	end
+end
+
+function f(): number
+	local get = function(c: Counter): number
+		return c.count
+	end
+	class Counter
+		count: number
+		constructor()
+			self.count = 0
+		end
+	end
+	return 0
+end
+
+local g = function(): number
+	local shape: Shape = { sides = 3 }
+	type Shape = Sides
+	type Sides = { sides: number }
+	return shape.sides
+end
+`
+
+	expectNoTypeErrors(t, checkWithOptions(t, input, Options{}))
+}
+
+func TestNestedTypeDeclarationsAreScopedToTheirBlock(t *testing.T) {
+	// Each function's Foo is its own class, and neither is visible outside
+	// the function declaring it
+	input := `
+function a(f: any): number
+	class Foo
+		x: number
+		constructor()
+			self.x = 1
+		end
+	end
+	local g: Foo = f
+	return g.x
+end
+
+function b(f: any): number
+	class Foo
+		y: number
+		constructor()
+			self.y = 1
+		end
+	end
+	local g: Foo = f
+	return g.x
+end
+
+local h: Foo = nil
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	expected := []string{
+		"Type 'Foo' has no property or method 'x'",
+		"Unknown type 'Foo'",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d: %v", len(expected), len(errors), errors)
+	}
+	for i, message := range expected {
+		if errors[i].Message != message {
+			t.Errorf("Expected error %q, got %q", message, errors[i].Message)
+		}
+	}
+}
+
+func TestCircularTypeAlias(t *testing.T) {
+	input := `
+type A = B
+type B = A
+local x: A = 1
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if !strings.Contains(errors[0].Message, "circularly references itself") {
+		t.Errorf("Expected circular alias error, got: %s", errors[0].Message)
+	}
+}
+
+func TestMutuallyRecursiveFunctions(t *testing.T) {
+	input := `
+function isEven(n: number): boolean
+	if n == 0 then
+		return true
+	end
+	return isOdd(n - 1)
+end
+
+function isOdd(n: number): boolean
+	if n == 0 then
+		return false
+	end
+	return isEven(n - 1)
+end
+
+local even: boolean = isEven(10)
+`
+
+	expectNoTypeErrors(t, checkWithOptions(t, input, Options{}))
+}
+
+func TestFunctionDeclaredLaterIsTypeChecked(t *testing.T) {
+	input := `
+function first(): string
+	return second(1)
+end
+
+function second(n: number): number
+	return n
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if errors[0].Message != "Cannot return type 'number' from function with return type 'string'" {
+		t.Errorf("Expected return type error, got: %s", errors[0].Message)
+	}
+}
+
+func TestTopLevelCallBeforeFunctionDeclaration(t *testing.T) {
+	// The function is only assigned when its declaration runs
+	input := `
+local n = later()
+
+function later(): number
+	return 1
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if errors[0].Message != "Undefined variable 'later'" {
+		t.Errorf("Expected undefined variable error, got: %s", errors[0].Message)
+	}
+}
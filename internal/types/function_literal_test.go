@@ -0,0 +1,73 @@
+package types
+
+import "testing"
+
+func TestFunctionLiteralArgument(t *testing.T) {
+	input := `
+function map(items: any, fn: (x: number) => number): any
+	return items
+end
+
+local doubled = map({}, function(x) return x * 2 end)
+local halved = map({}, function(x: number): number
+	return x / 2
+end)
+
+local add = function(a: number, b: number): number
+	return a + b
+end
+local sum: number = add(1, 2)
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestFunctionLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`function map(items: any, fn: (x: number) => number): any
+	return items
+end
+map({}, function(x: string): number return 1 end)`,
+			"Argument 2: cannot pass type '(string) -> number' to parameter of type '(number) -> number'",
+		},
+		{
+			`local f = function(x: number): string
+	return x
+end`,
+			"Cannot return type 'number' from function with return type 'string'",
+		},
+		{
+			`local f = function(a: number, b: number): number
+	return a + b
+end
+f(1)`,
+			"Function expects 2 arguments, got 1",
+		},
+		{
+			`while true do
+	local f = function(): void
+		break
+	end
+end`,
+			"break statement not within a loop",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("%q: expected 1 type error, got %d: %v", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
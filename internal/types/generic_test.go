@@ -203,3 +203,531 @@ local x: Pair<string> = "test"
 		}
 	}
 }
+
+func TestGenericTypeAliasArrayBody(t *testing.T) {
+	input := `
+type List<T> = T[]
+
+function takesNumbers(xs: number[]): number
+	return xs[1]
+end
+
+function takesStrings(xs: string[]): number
+	return 0
+end
+
+function useList(xs: List<number>): number
+	local n: number = xs[1]
+	local s: number = takesStrings(xs)
+	return takesNumbers(xs)
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 1 error: List<number> is number[], not string[]
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d:", len(errors))
+	}
+	expected := "Argument 1: cannot pass type 'number[]' to parameter of type 'string[]'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestGenericTypeAliasObjectShapeBody(t *testing.T) {
+	input := `
+type Box<T> = { value: T }
+
+local b: Box<string> = { value = "hello" }
+local s: string = b.value
+local n: number = b.value
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 1 error: Box<string>.value is a string, not a number
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d:", len(errors))
+	}
+	expected := "Cannot assign type 'string' to variable of type 'number'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestGenericTypeAliasStructuralBodies(t *testing.T) {
+	input := `
+type Dict<V> = table<string, V>
+type Pair<A, B> = (A, B)
+type Mapper<T> = (x: T) => T
+
+function double(x: number): number
+	return x * 2
+end
+
+function lookup(d: Dict<boolean>): boolean
+	return d["key"]
+end
+
+function swap(p: Pair<string, number>): (string, number)
+	return p
+end
+
+local m: Mapper<number> = double
+local bad: Mapper<string> = double
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 1 error: double is (number) -> number, not (string) -> string
+	if len(errors) != 1 {
+		t.Errorf("Expected 1 type error, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestGenericTypeAliasDefaults(t *testing.T) {
+	input := `
+type Box<T = number> = { value: T }
+type Pair<A, B = A> = A | B
+
+local a: Box<> = { value = 1 }
+local b: Box<string> = { value = "x" }
+local c: Pair<string> = "first"
+local d: Pair<string, number> = 2
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestGenericTypeAliasDefaultIsApplied(t *testing.T) {
+	input := `
+type Pair<A, B = A> = { first: A, second: B }
+
+local c: Pair<string> = { first = "a", second = 2 }
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 1 error: B defaults to string, so number isn't allowed
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Cannot assign type '<table literal>' to variable of type '{ first: string, second: string }'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestGenericTypeAliasMissingRequiredArg(t *testing.T) {
+	input := `
+type Pair<A, B = A> = A | B
+
+local x: Pair<> = "test"
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Generic type 'Pair' expects 1 to 2 type arguments, got 0"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestGenericClassParameterDefault(t *testing.T) {
+	input := `
+class Container<T = any>
+	public value: T
+
+	constructor(value: T)
+		self.value = value
+	end
+end
+
+function unwrap(box: Container<>, other: Container<string>): any
+	return box.value
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestGenericClassParameterDefaultErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`class Box<T extends number = string>
+	public value: T
+end`,
+			"Default type 'string' does not satisfy the constraint 'number' of type parameter 'T'",
+		},
+		{
+			`class Pair<A, B = A>
+	public first: A
+end
+local p: Pair<> = nil`,
+			"Generic type 'Pair' expects 1 to 2 type arguments, got 0",
+		},
+		{
+			`class Pair<A, B = A>
+	public first: A
+end
+local p: Pair<string, string, string> = nil`,
+			"Generic type 'Pair' expects 1 to 2 type arguments, got 3",
+		},
+		{
+			`class Limit<T extends number = number>
+	public value: T
+end
+local l: Limit<string> = nil`,
+			"Type 'string' does not satisfy the constraint 'number' of type parameter 'T'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) == 0 || errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q first for:\n%s\ngot %v", tt.expected, tt.input, errors)
+		}
+	}
+}
+
+func TestGenericFunctionParameterDefault(t *testing.T) {
+	errors := checkWithOptions(t, `
+function empty<T = string>(): T[]
+	return {}
+end
+
+local names: string[] = empty()
+local counts: number[] = empty()
+`, Options{})
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Cannot assign type 'string[]' to variable of type 'number[]'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestSelfExpandingGenericTypeAlias(t *testing.T) {
+	input := `
+type Grow<T> = Grow<T[]>
+
+local x: Grow<number> = nil
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Generic type instantiation too deep (possible infinite expansion)"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestGenericTypeAliasNestingItselfInItsArgument(t *testing.T) {
+	// Each level nests the argument once more; without caching the work
+	// doubles at every level
+	errors := checkWithOptions(t, `
+type Box<T> = { value: Box<Box<T>> }
+
+local b: Box<number> = nil
+`, Options{})
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Generic type instantiation too deep (possible infinite expansion)"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestRecursiveGenericTypeAlias(t *testing.T) {
+	errors := checkWithOptions(t, `
+type Tree<T> = { value: T, left: Tree<T>? }
+
+local leaf: Tree<number> = { value = 1 }
+local tree: Tree<number> = { value = 2, left = leaf }
+local wrong: Tree<number> = { value = 3, left = { value = "x" } }
+`, Options{})
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d: %v", len(errors), errors)
+	}
+	expected := "Cannot assign type '<table literal>' to variable of type '{ value: number, left: Tree<number>? }'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestDeeplyNestedGenericTypeAlias(t *testing.T) {
+	input := `
+type Nullable<T> = T | nil
+
+local x: Nullable<Nullable<Nullable<Nullable<string>>>> = "deep"
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+const genericFunctions = `
+function identity<T>(x: T): T
+	return x
+end
+
+function same<T>(a: T, b: T): boolean
+	return a == b
+end
+
+function first<T>(items: T[]): T?
+	return items[1]
+end
+
+function apply<T, U>(value: T, f: (item: T) => U): U
+	return f(value)
+end
+`
+
+func TestGenericFunctionCallInference(t *testing.T) {
+	input := genericFunctions + `
+local s: string = identity("hi")
+local n: number = identity(42)
+local equal: boolean = same("a", "b")
+function firstName(names: string[]): string?
+	return first(names)
+end
+local doubled: number = apply(21, function(n: number): number return n * 2 end)
+`
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestGenericFunctionCallErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`local n: number = identity("hi")`,
+			"Cannot assign type 'string' to variable of type 'number'",
+		},
+		{
+			`local equal = same("a", 1)`,
+			"Argument 2: cannot pass type '1' to parameter of type 'string'",
+		},
+		{
+			`function firstName(numbers: number[]): string?
+	return first(numbers)
+end`,
+			"Cannot return type 'number?' from function with return type 'string?'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, genericFunctions+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			for _, err := range errors {
+				t.Errorf("  %s", err.Message)
+			}
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
+
+const constrainedGenerics = `
+interface Comparable
+	compare(other: Comparable): number
+end
+
+class Version implements Comparable
+	public major: number
+
+	constructor(major: number)
+		self.major = major
+	end
+
+	public compare(other: Comparable): number
+		return 0
+	end
+end
+
+function largest<T extends Comparable>(a: T, b: T): T
+	if a:compare(b) >= 0 then
+		return a
+	end
+	return b
+end
+
+type Labeled<T extends string | number> = { label: T }
+`
+
+func TestGenericConstraints(t *testing.T) {
+	input := constrainedGenerics + `
+function newest(a: Version, b: Version): Version
+	return largest(a, b)
+end
+local label: Labeled<string> = { label = "moon" }
+local count: Labeled<number> = { label = 3 }
+`
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestGenericConstraintViolations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`local biggest = largest("a", "b")`,
+			"Type 'string' does not satisfy the constraint 'Comparable' of type parameter 'T'",
+		},
+		{
+			`local flag: Labeled<boolean> = { label = true }`,
+			"Type 'boolean' does not satisfy the constraint 'string | number' of type parameter 'T'",
+		},
+		{
+			`function describe<T extends Comparable>(value: T): string
+	return value.name
+end`,
+			"Type 'Comparable' has no property or method 'name'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, constrainedGenerics+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			for _, err := range errors {
+				t.Errorf("  %s", err.Message)
+			}
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
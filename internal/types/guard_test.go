@@ -0,0 +1,116 @@
+package types
+
+import (
+	"lunar/internal/lexer"
+	"lunar/internal/parser"
+	"testing"
+)
+
+const animalInterfaces = `
+interface Animal
+	name: string
+end
+
+interface Dog extends Animal
+	bark(): void
+end
+
+function isDog(animal: Animal): animal is Dog
+	return animal.name == "dog"
+end
+`
+
+func TestTypeGuardNarrowsInConsequence(t *testing.T) {
+	input := animalInterfaces + `
+function speak(animal: Animal): void
+	if isDog(animal) then
+		animal:bark()
+	end
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestTypeGuardDoesNotNarrowOutsideConsequence(t *testing.T) {
+	input := animalInterfaces + `
+function speak(animal: Animal): void
+	if isDog(animal) then
+		local name: string = animal.name
+	else
+		animal:bark()
+	end
+	animal:bark()
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 2 errors: bark isn't on Animal in the else branch or
+	// after the if
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 type errors, got %d", len(errors))
+	}
+	for _, err := range errors {
+		expected := "Type 'Animal' has no method 'bark'"
+		if err.Message != expected {
+			t.Errorf("Expected error %q, got %q", expected, err.Message)
+		}
+	}
+}
+
+func TestTypeGuardUnknownParameter(t *testing.T) {
+	input := `
+interface Dog
+	bark(): void
+end
+
+function isDog(animal: any): pet is Dog
+	return true
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Type predicate refers to unknown parameter 'pet'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
@@ -0,0 +1,91 @@
+package types
+
+import "testing"
+
+func TestNoImplicitAnyUnannotatedParameter(t *testing.T) {
+	input := `
+function greet(name): string
+	return "hello"
+end
+`
+
+	errors := checkWithOptions(t, input, Options{NoImplicitAny: true})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d: %v", len(errors), errors)
+	}
+	expected := "Parameter 'name' implicitly has type 'any'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+	if errors[0].Line != 2 {
+		t.Errorf("Expected the error at line 2, got line %d", errors[0].Line)
+	}
+
+	// Without the option the parameter is accepted
+	if errors := checkWithOptions(t, input, Options{}); len(errors) > 0 {
+		t.Errorf("Expected no type errors without the option, got: %s", errors[0].Message)
+	}
+}
+
+func TestNoImplicitAnyExplicitAnyParameter(t *testing.T) {
+	input := `
+function greet(name: any, ...: any): string
+	local copy = name
+	return "hello"
+end
+`
+
+	for _, err := range checkWithOptions(t, input, Options{NoImplicitAny: true}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestNoImplicitAnyUnannotatedParameters(t *testing.T) {
+	input := `
+class Counter
+	public add(amount): void
+	end
+end
+
+local double = function(x): number
+	return 2
+end
+
+function log(...): void
+end
+`
+
+	errors := checkWithOptions(t, input, Options{NoImplicitAny: true})
+	expected := []string{
+		"Parameter 'amount' implicitly has type 'any'",
+		"Parameter 'x' implicitly has type 'any'",
+		"Parameter '...' implicitly has type 'any'",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d: %v", len(expected), len(errors), errors)
+	}
+	for i, msg := range expected {
+		if errors[i].Message != msg {
+			t.Errorf("Error %d: expected %q, got %q", i, msg, errors[i].Message)
+		}
+	}
+}
+
+func TestNoImplicitAnyInferredVariable(t *testing.T) {
+	input := `
+local callback = function(): number
+	return 1
+end
+local result = (function() return 1 end)()
+local count = callback()
+`
+
+	errors := checkWithOptions(t, input, Options{NoImplicitAny: true})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d: %v", len(errors), errors)
+	}
+	expected := "Variable 'result' implicitly has type 'any'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
@@ -0,0 +1,100 @@
+package types
+
+import "testing"
+
+const petClasses = `
+interface Named
+	name: string
+end
+
+class Animal implements Named
+	public name: string
+	constructor(name: string)
+		self.name = name
+	end
+	public speak(): string
+		return self.name
+	end
+end
+
+class Dog extends Animal
+	public fetch(): string
+		return self.name .. " fetches"
+	end
+end
+`
+
+func TestSubclassInheritsMembers(t *testing.T) {
+	input := petClasses + `
+function describe(dog: Dog): string
+	return dog.name .. dog:speak() .. dog:fetch()
+end
+`
+
+	for _, err := range checkWithOptions(t, input, Options{}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestSubclassIsAssignableToParent(t *testing.T) {
+	input := petClasses + `
+function adopt(dog: Dog): void
+	local animal: Animal = dog
+	local named: Named = dog
+end
+`
+
+	for _, err := range checkWithOptions(t, input, Options{}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestParentIsNotAssignableToSubclass(t *testing.T) {
+	input := petClasses + `
+function train(animal: Animal): void
+	local dog: Dog = animal
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d: %v", len(errors), errors)
+	}
+	expected := "Cannot assign type 'Animal' to variable of type 'Dog'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestSuperCallThroughParentWithoutConstructor(t *testing.T) {
+	input := petClasses + `
+class Puppy extends Dog
+	constructor(name: string)
+		super(name)
+	end
+end
+`
+
+	for _, err := range checkWithOptions(t, input, Options{}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestClassCannotExtendItself(t *testing.T) {
+	input := `
+class A extends B
+end
+
+class B extends A
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d: %v", len(errors), errors)
+	}
+	expected := "Class 'B' cannot extend 'A': it would inherit from itself"
+	if errors[0].Message != expected {
+		t.Errorf("Expected %q, got %q", expected, errors[0].Message)
+	}
+}
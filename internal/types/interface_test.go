@@ -0,0 +1,253 @@
+package types
+
+import "testing"
+
+const shapeClass = `
+class Shape
+	name: string
+	sides: number
+	private id: number
+	protected color: string
+
+	public area(): number
+		return 0
+	end
+
+	private describe(): string
+		return self.name
+	end
+end
+`
+
+func TestInterfaceExtendingClassRequiresPublicMembers(t *testing.T) {
+	input := shapeClass + `
+interface Named extends Shape
+end
+
+local named: Named = { name = "square" }
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error, got %d: %v", len(errors), errors)
+	}
+	expected := "Cannot assign type '<table literal>' to variable of type 'Named'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestInterfaceExtendingClassUsesPublicMembers(t *testing.T) {
+	input := shapeClass + `
+interface Named extends Shape
+	label: string
+end
+
+function describe(named: Named): string
+	local sides: number = named.sides
+	local area: number = named:area()
+	return named.name .. named.label
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestInterfaceExtendingClassExcludesPrivateMembers(t *testing.T) {
+	input := shapeClass + `
+interface Named extends Shape
+end
+
+function describe(named: Named): void
+	local id: number = named.id
+	local color: string = named.color
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	expected := []string{
+		"Type 'Named' has no property or method 'id'",
+		"Type 'Named' has no property or method 'color'",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expected), len(errors), errors)
+	}
+	for i, msg := range expected {
+		if errors[i].Message != msg {
+			t.Errorf("Error %d: expected %q, got %q", i, msg, errors[i].Message)
+		}
+	}
+}
+
+func TestInterfaceExtendingClassAcceptsPublicShape(t *testing.T) {
+	input := `
+class Point
+	x: number
+	y: number
+	private id: number
+end
+
+interface Labelled extends Point
+	label: string
+end
+
+local point: Labelled = { x = 1, y = 2, label = "origin" }
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestInterfaceExtendingClassDeclaredLater(t *testing.T) {
+	input := `
+interface Labelled extends Point
+end
+
+local point: Labelled = { x = 1 }
+
+class Point
+	x: number
+	y: number
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error, got %d: %v", len(errors), errors)
+	}
+}
+
+const measuredShapes = `
+interface Shape
+	area: (shape: Shape) => number
+	describe(prefix: string): string
+end
+
+class Square implements Shape
+	public side: number
+	public describe: (shape: Shape, prefix: string) => string
+
+	constructor(side: number)
+		self.side = side
+		self.describe = function(shape: Shape, prefix: string): string
+			return prefix
+		end
+	end
+
+	public area(): number
+		return self.side * self.side
+	end
+end
+`
+
+func TestMethodsAndFunctionPropertiesInterchange(t *testing.T) {
+	input := measuredShapes + `
+interface Sized
+	area(): number
+	describe: (sized: Sized, prefix: string) => string
+end
+
+function measure(shape: Shape): number
+	local sized: Sized = shape
+	return shape.area(shape) + sized:area()
+end
+
+local unit: Shape = {
+	area = function(shape: Shape): number
+		return 1
+	end,
+	describe = function(shape: Shape, prefix: string): string
+		return prefix
+	end,
+}
+`
+	for _, err := range checkWithOptions(t, input, Options{}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestMethodAndFunctionPropertyMismatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			// Called through '.', the method would need the receiver
+			`interface Counter
+	next: () => number
+end
+
+class Ticker implements Counter
+	public next(): number
+		return 1
+	end
+end`,
+			"Method 'next' in class 'Ticker' has type '(Counter) -> number' but interface 'Counter' requires '() -> number'",
+		},
+		{
+			`interface Named
+	label(): string
+end
+
+class Tag implements Named
+	public label: (named: Named, upper: boolean) => string
+
+	constructor()
+		self.label = function(named: Named, upper: boolean): string
+			return "tag"
+		end
+	end
+end`,
+			"Property 'label' in class 'Tag' has type '(Named, boolean) -> string' but interface 'Named' requires '(Named) -> string'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			for _, err := range errors {
+				t.Errorf("  %s", err.Message)
+			}
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestRecursiveInterfacesCompareStructurally(t *testing.T) {
+	input := `
+interface Node
+	next: Node?
+end
+
+interface Link
+	next: Link?
+end
+
+interface Counted
+	next: Counted?
+	count: number
+end
+
+function relink(node: Node): void
+	local link: Link = node
+	local counted: Counted = node
+end
+`
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Cannot assign type 'Node' to variable of type 'Counted'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
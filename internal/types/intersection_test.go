@@ -0,0 +1,82 @@
+package types
+
+import "testing"
+
+const namedAndAged = `
+interface Named
+	name: string
+end
+
+interface Aged
+	age: number
+end
+`
+
+func TestIntersectionAcceptsTableLiteral(t *testing.T) {
+	input := namedAndAged + `
+local person: Named & Aged = {name = "Ada", age = 36}
+local name: string = person.name
+local age: number = person.age
+local named: Named = person
+local aged: Aged = person
+`
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestIntersectionWithObjectShape(t *testing.T) {
+	input := namedAndAged + `
+function greet(person: Named & { title: string }): string
+	return person.title .. " " .. person.name
+end
+
+local greeting = greet({name = "Ada", title = "Countess"})
+`
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestIntersectionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`local person: Named & Aged = {name = "Ada"}`,
+			"Cannot assign type '<table literal>' to variable of type 'Named & Aged'",
+		},
+		{
+			`local person: Named & Aged = {name = "Ada", age = "old"}`,
+			"Cannot assign type '<table literal>' to variable of type 'Named & Aged'",
+		},
+		{
+			`local person: Named & Aged = {name = "Ada", age = 36, email = "ada@example.com"}`,
+			"Object literal specifies unknown property 'email'.",
+		},
+		{
+			`local named: Named = {name = "Ada"}
+local person: Named & Aged = named`,
+			"Cannot assign type 'Named' to variable of type 'Named & Aged'",
+		},
+		{
+			`local person: Named & Aged = {name = "Ada", age = 36}
+local email = person.email`,
+			"Type 'Named & Aged' has no property or method 'email'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, namedAndAged+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"lunar/internal/lexer"
+	"lunar/internal/parser"
+	"testing"
+)
+
+func TestBreakInsideLoop(t *testing.T) {
+	input := `
+local i: number = 0
+while i < 10 do
+	i = i + 1
+	if i > 5 then
+		break
+	end
+end
+
+for j = 1, 10 do
+	break
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestBreakOutsideLoop(t *testing.T) {
+	input := `
+break
+
+while true do
+	function stop(): void
+		break
+	end
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 2 errors: the top-level break, and the break in a function
+	// nested in a loop, which doesn't belong to that loop
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 type errors, got %d", len(errors))
+	}
+	for _, err := range errors {
+		expected := "break statement not within a loop"
+		if err.Message != expected {
+			t.Errorf("Expected error %q, got %q", expected, err.Message)
+		}
+	}
+}
+
+func TestRepeatUntil(t *testing.T) {
+	// The until-condition can see locals declared in the body, and break
+	// leaves the loop
+	input := `
+local i: number = 0
+repeat
+	local next: number = i + 1
+	i = next
+	if i > 100 then
+		break
+	end
+until next >= 10
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestRepeatUntilErrors(t *testing.T) {
+	input := `
+repeat
+	local count: number = 1
+until count
+
+local after: number = count
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// The condition isn't boolean, and the body's locals end with the loop
+	expected := []string{
+		"Repeat condition must be boolean, got 'number'",
+		"Undefined variable 'count'",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d: %v", len(expected), len(errors), errors)
+	}
+	for i, err := range errors {
+		if err.Message != expected[i] {
+			t.Errorf("Expected error %q, got %q", expected[i], err.Message)
+		}
+	}
+}
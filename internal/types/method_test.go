@@ -0,0 +1,149 @@
+package types
+
+import (
+	"lunar/internal/lexer"
+	"lunar/internal/parser"
+	"testing"
+)
+
+const counterClass = `
+class Counter
+	private count: number
+
+	constructor(start: number)
+		self.count = start
+	end
+
+	public add(n: number): number
+		self.count = self.count + n
+		return self.count
+	end
+end
+`
+
+func TestBoundAndUnboundMethodReferences(t *testing.T) {
+	input := counterClass + `
+function use(c: Counter): number
+	local bound: (n: number) => number = c:add
+	local unbound: (receiver: Counter, n: number) => number = c.add
+	local a: number = bound(1)
+	local b: number = unbound(c, 2)
+	return c:add(3) + c.add(c, 4)
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestMethodReferenceArity(t *testing.T) {
+	input := counterClass + `
+function use(c: Counter): void
+	local unbound = c.add
+	local bound = c:add
+	unbound(1)
+	bound(c, 1)
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 2 errors: the unbound form needs the receiver, the bound
+	// form must not be given one
+	expected := []string{
+		"Function expects 2 arguments, got 1",
+		"Function expects 1 arguments, got 2",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d", len(expected), len(errors))
+	}
+	for i, msg := range expected {
+		if errors[i].Message != msg {
+			t.Errorf("Expected error %q, got %q", msg, errors[i].Message)
+		}
+	}
+}
+
+func TestMethodReferenceUnknownMethod(t *testing.T) {
+	input := counterClass + `
+function use(c: Counter): void
+	c:reset()
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Type 'Counter' has no method 'reset'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestSelfOutsideClass(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"top level", "self.x = 1"},
+		{"function", `
+function reset(): void
+	local x = self
+end
+`},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("%s: expected 1 type error, got %d", tt.name, len(errors))
+			continue
+		}
+		expected := "'self' can only be used inside a class method or constructor"
+		if errors[0].Message != expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, expected, errors[0].Message)
+		}
+	}
+}
+
+func TestSelfInsideClass(t *testing.T) {
+	errors := checkWithOptions(t, counterClass, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
@@ -0,0 +1,175 @@
+package types
+
+import "testing"
+
+const optionalUser = `
+interface User
+	name: string
+end
+
+local user: User? = nil
+`
+
+func TestOptionalPropertyAccessRequiresNilCheck(t *testing.T) {
+	errors := checkWithOptions(t, optionalUser+`
+local name = user.name
+`, Options{})
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Cannot access 'name' on type 'User?', which may be nil; check it against nil first"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestNilCheckNarrowsInConsequence(t *testing.T) {
+	inputs := []string{
+		`
+if user ~= nil then
+	local name: string = user.name
+end
+`,
+		`
+if nil != user then
+	local name: string = user.name
+end
+`,
+		`
+if user == nil then
+	print("nobody")
+else
+	local name: string = user.name
+end
+`,
+		`
+if user == nil then
+	print("nobody")
+elseif user.name == "root" then
+	local name: string = user.name
+end
+`,
+		`
+local isRoot = user ~= nil and user.name == "root"
+local isGuest = user == nil or user.name == "guest"
+`,
+	}
+
+	for _, input := range inputs {
+		errors := checkWithOptions(t, "declare function print(message: any): void end\n"+optionalUser+input, Options{})
+		if len(errors) > 0 {
+			t.Errorf("Expected no type errors for:%s\ngot %d:", input, len(errors))
+			for _, err := range errors {
+				t.Errorf("  %s", err.Message)
+			}
+		}
+	}
+}
+
+func TestNilCheckDoesNotNarrowOutsideBranch(t *testing.T) {
+	errors := checkWithOptions(t, optionalUser+`
+if user ~= nil then
+	local name: string = user.name
+else
+	local other = user.name
+end
+local after = user.name
+local wrong = user == nil and user.name == "root"
+`, Options{})
+
+	if len(errors) != 3 {
+		t.Fatalf("Expected 3 type errors, got %d", len(errors))
+	}
+	for i, line := range []int{11, 13, 14} {
+		if errors[i].Line != line {
+			t.Errorf("Expected error %d on line %d, got line %d: %s", i, line, errors[i].Line, errors[i].Message)
+		}
+	}
+}
+
+func TestNarrowedVariableKeepsDeclaredTypeForAssignment(t *testing.T) {
+	errors := checkWithOptions(t, optionalUser+`
+if user ~= nil then
+	user = nil
+	user = 5
+end
+`, Options{})
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Cannot assign type '5' to type 'User?'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestAssignmentUndoesNarrowing(t *testing.T) {
+	errors := checkWithOptions(t, optionalUser+`
+function name(u: User?): string
+	if nil ~= u then
+		u = nil
+		return u.name
+	end
+	return ""
+end
+
+local count: number = 0
+if user ~= nil then
+	if true then
+		user, count = nil, 1
+	end
+	local after = user.name
+end
+`, Options{})
+
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 type errors, got %d", len(errors))
+	}
+	for i, line := range []int{11, 21} {
+		if errors[i].Line != line {
+			t.Errorf("Expected error %d on line %d, got line %d: %s", i, line, errors[i].Line, errors[i].Message)
+		}
+	}
+}
+
+func TestNarrowedVariableAssignedFromItself(t *testing.T) {
+	errors := checkWithOptions(t, `
+interface Node
+	next: Node?
+	name: string
+end
+
+function second(node: Node?): string?
+	if node ~= nil then
+		node = node.next
+		return nil
+	end
+	return nil
+end
+`, Options{})
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestNilCheckNarrowsUnionWithNil(t *testing.T) {
+	errors := checkWithOptions(t, `
+local value: string | number | nil = nil
+if value ~= nil then
+	local known: string | number = value
+end
+`, Options{})
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package types
+
+import "testing"
+
+const neverFunctions = `
+declare function error(message: string): never end
+
+function fail(message: string): never
+	error(message)
+end
+
+function isString(value: any): value is string
+	return true
+end
+
+function isNumber(value: any): value is number
+	return true
+end
+`
+
+func TestNeverAssignableToEverything(t *testing.T) {
+	input := neverFunctions + `
+local n: number = fail("no number")
+local s: string = fail("no string")
+local b: boolean? = fail("no boolean")
+local nothing: never = fail("nothing")
+local anything: any = nothing
+`
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestNothingAssignableToNever(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`local n: never = 1`,
+			"Cannot assign type '1' to variable of type 'never'",
+		},
+		{
+			`local n: never = nil`,
+			"Cannot assign type 'nil' to variable of type 'never'",
+		},
+		{
+			`function broken(): never
+	return "value"
+end`,
+			"Cannot return type '\"value\"' from function with return type 'never'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, neverFunctions+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestNeverAfterExhaustiveGuards(t *testing.T) {
+	input := neverFunctions + `
+function describe(value: string | number): string
+	if isString(value) then
+		return value
+	elseif isNumber(value) then
+		local n: number = value
+		return "number"
+	else
+		local unreachable: never = value
+		return unreachable
+	end
+end
+`
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestRemainingTypeAfterGuard(t *testing.T) {
+	input := neverFunctions + `
+function describe(value: string | number | boolean): string
+	if isString(value) then
+		return value
+	else
+		local unreachable: never = value
+	end
+	return "other"
+end
+`
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Cannot assign type 'number | boolean' to variable of type 'never'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
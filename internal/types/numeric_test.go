@@ -0,0 +1,281 @@
+package types
+
+import (
+	"lunar/internal/ast"
+	"lunar/internal/lexer"
+	"lunar/internal/parser"
+	"strings"
+	"testing"
+)
+
+func checkWithTarget(t *testing.T, input, target string) []*TypeError {
+	t.Helper()
+	return checkWithOptions(t, input, Options{Target: target})
+}
+
+func checkWithOptions(t *testing.T, input string, opts Options) []*TypeError {
+	t.Helper()
+	return NewCheckerWithOptions(opts).Check(parseSource(t, input))
+}
+
+// parseSource parses input, failing the test on any parser error
+func parseSource(t *testing.T, input string) []ast.Statement {
+	t.Helper()
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+	return statements
+}
+
+func TestDivisionYieldsFloat(t *testing.T) {
+	input := `
+local a: int = 7
+local b: int = 2
+local q: float = a / b
+local n: number = a / b
+`
+
+	errors := checkWithTarget(t, input, "5.3")
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestDivisionNotAssignableToInt(t *testing.T) {
+	input := `
+local a: int = 7
+local b: int = 2
+local q: int = a / b
+`
+
+	errors := checkWithTarget(t, input, "5.3")
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+}
+
+func TestFloorDivisionYieldsInt(t *testing.T) {
+	input := `
+local a: int = 7
+local b: int = 2
+local q: int = a // b
+local n: number = a // b
+local f: float = 7.5 // 2
+`
+
+	errors := checkWithTarget(t, input, "5.3")
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestFloorDivisionOperands(t *testing.T) {
+	input := `
+local a: number = 7
+local q: number = a // 2
+local s: number = "7" // 2
+`
+
+	errors := checkWithTarget(t, input, "")
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if errors[0].Line != 4 {
+		t.Errorf("Expected the error on the string operand at line 4, got line %d", errors[0].Line)
+	}
+}
+
+func TestIntArithmeticPreservesInt(t *testing.T) {
+	input := `
+local a: int = 7
+local b: float = 2.5
+local sum: int = a + 1
+local mixed: float = a * b
+local plain: number = 3
+local widened: number = plain + a
+`
+
+	errors := checkWithTarget(t, input, "5.4")
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestUnaryMinusPreservesNumberKind(t *testing.T) {
+	input := `
+local b: int = 2
+local c: int = -b
+local f: float = 2.5
+local g: float = -f
+local n: number = 3
+local m: number = -n
+`
+
+	for _, err := range checkWithTarget(t, input, "5.3") {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+
+	errors := checkWithTarget(t, "local f: float = 2.5\nlocal i: int = -f\n", "5.3")
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if errors[0].Message != "Cannot assign type 'float' to variable of type 'int'" {
+		t.Errorf("Expected float to int error, got: %s", errors[0].Message)
+	}
+}
+
+func TestNonIntegralLiteralNotAssignableToInt(t *testing.T) {
+	input := `
+local a: int = 1.5
+`
+
+	errors := checkWithTarget(t, input, "5.3")
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+}
+
+func TestFloatWrittenLiteralIsFloat(t *testing.T) {
+	input := `
+local a: float = 1.0
+local b: float = 1e3
+local c: float = 1.0 // 1
+local d: float = 1.0 + 1
+local e: float = -2.0
+local f: int = 0x10
+local g: int = 1 + 1
+`
+
+	for _, err := range checkWithTarget(t, input, "5.3") {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+
+	for _, source := range []string{
+		"local z: int = 1.0",
+		"local k: int = 1e3",
+		"local q: int = 1.0 // 1",
+		"local s: int = 1.0 + 1",
+	} {
+		errors := checkWithTarget(t, source, "5.3")
+		if len(errors) != 1 {
+			t.Errorf("%s: expected 1 type error, got %d", source, len(errors))
+		}
+	}
+}
+
+func TestNumberSubtypesRequireTarget(t *testing.T) {
+	input := `
+local a: int = 1
+local b: float = 1.5
+`
+
+	for _, target := range []string{"", "5.1", "5.2"} {
+		errors := checkWithTarget(t, input, target)
+		if len(errors) != 2 {
+			t.Errorf("target %q: expected 2 unknown type errors, got %d", target, len(errors))
+		}
+	}
+}
+
+func TestStringArithmeticRejectedByDefault(t *testing.T) {
+	input := `local n: number = "10" + 5`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Operator '+' cannot be applied to type '\"10\"'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestStringArithmeticWithLuaCoercion(t *testing.T) {
+	input := `
+local s: string = "2"
+local n: number = "10" + 5
+local m: number = s * s
+`
+
+	errors := checkWithOptions(t, input, Options{LuaCoercion: true})
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+
+	// Coercion yields a plain number even when int and float are available
+	errors = checkWithOptions(t, `local i: int = "10" + 5`, Options{Target: "5.3", LuaCoercion: true})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+}
+
+func TestBitwiseOperators(t *testing.T) {
+	input := `
+local flags: number = 0xFF
+local masked: number = flags & 0x0F | 0x10
+local toggled: number = flags ~ 1
+local inverted: number = ~flags
+local shifted: number = 1 << 4 >> 2
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestBitwiseOperatorsRequireNumbers(t *testing.T) {
+	input := `
+local s: string = "a"
+local x = s & 1
+local y = true << 2
+local z = ~s
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 3 {
+		t.Fatalf("Expected 3 type errors, got %d", len(errors))
+	}
+	for _, err := range errors {
+		if !strings.Contains(err.Message, "cannot be applied to type") {
+			t.Errorf("Unexpected error: %s", err.Message)
+		}
+	}
+}
+
+func TestBitwiseOperatorsYieldInt(t *testing.T) {
+	errors := checkWithTarget(t, `local i: int = 2.5 // 1 & 3`, "5.3")
+	if len(errors) > 0 {
+		t.Errorf("Expected a bitwise result to be an int, got: %s", errors[0].Message)
+	}
+}
+
+func TestBitwiseOperatorsRequireLua53(t *testing.T) {
+	errors := checkWithTarget(t, `local x: number = 1 | 2`, "5.2")
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if !strings.Contains(errors[0].Message, "requires Lua 5.3") {
+		t.Errorf("Expected a target error, got: %s", errors[0].Message)
+	}
+}
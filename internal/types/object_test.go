@@ -106,7 +106,8 @@ type Point {
 }
 end
 
-local p: Point = { x = 10, y = 20, z = 30 }
+local coords = { x = 10, y = 20, z = 30 }
+local p: Point = coords
 `
 
 	l := lexer.New(input)
@@ -120,7 +121,8 @@ local p: Point = { x = 10, y = 20, z = 30 }
 	checker := NewChecker()
 	errors := checker.Check(statements)
 
-	// Extra properties are allowed (structural subtyping)
+	// Extra properties are allowed (structural subtyping) when the value
+	// isn't a literal written for the type
 	if len(errors) > 0 {
 		t.Errorf("Expected no type errors (extra properties allowed), got %d:", len(errors))
 		for _, err := range errors {
@@ -129,6 +131,43 @@ local p: Point = { x = 10, y = 20, z = 30 }
 	}
 }
 
+func TestObjectLiteralWithExcessProperty(t *testing.T) {
+	input := `
+type Point {
+	x: number
+	y: number
+}
+end
+
+function move(point: Point): void
+end
+
+local p: Point = { x = 10, y = 20, z = 30 }
+p = { x = 1, y = 2, w = 3 }
+move({ x = 1, y = 2, dx = 5 })
+local q: Point? = { x = 1, y = 2, zz = 0 }
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	expected := []string{
+		"Object literal specifies unknown property 'z'.",
+		"Object literal specifies unknown property 'w'.",
+		"Object literal specifies unknown property 'dx'.",
+		"Object literal specifies unknown property 'zz'.",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d: %v", len(expected), len(errors), errors)
+	}
+	for i, msg := range expected {
+		if errors[i].Message != msg {
+			t.Errorf("Error %d: expected %q, got %q", i, msg, errors[i].Message)
+		}
+	}
+	if errors[0].Line != 11 || errors[0].Column != 36 {
+		t.Errorf("Expected the first error on 'z' at 11:36, got %d:%d", errors[0].Line, errors[0].Column)
+	}
+}
+
 func TestNestedObjectTypes(t *testing.T) {
 	input := `
 type Address {
@@ -202,3 +241,469 @@ local d: number = distance(p1, p2)
 		}
 	}
 }
+
+func TestInlineShapeReturnType(t *testing.T) {
+	input := `
+function makeUser(id: number): { id: number, name: string }
+	return { id = id, name = "user" }
+end
+
+local user = makeUser(1)
+local name: string = user.name
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestInlineShapeReturnMissingField(t *testing.T) {
+	input := `
+function makeUser(id: number): { id: number, name: string }
+	return { id = id }
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 1 error: 'name' is missing from the returned table
+	if len(errors) != 1 {
+		t.Errorf("Expected 1 type error, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestReadonlyOptionalProperty(t *testing.T) {
+	input := `
+interface User
+	readonly id: number
+	readonly name?: string
+end
+
+local anonymous: User = { id = 1 }
+local named: User = { id = 2, name = "lunar" }
+local label: string | nil = named.name
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestReadonlyOptionalPropertyAssignment(t *testing.T) {
+	input := `
+interface User
+	readonly id: number
+	readonly name?: string
+	nickname?: string
+end
+
+local user: User = { id = 1 }
+user.nickname = "moon"
+user.name = "lunar"
+user.id = 2
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 2 errors: name and id are read-only
+	expected := []string{
+		"Cannot assign to 'name' because it is a read-only property",
+		"Cannot assign to 'id' because it is a read-only property",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d", len(expected), len(errors))
+	}
+	for i, msg := range expected {
+		if errors[i].Message != msg {
+			t.Errorf("Expected error %q, got %q", msg, errors[i].Message)
+		}
+	}
+}
+
+func TestReadonlyClassPropertyInConstructor(t *testing.T) {
+	input := `
+class Account
+	private readonly id: number
+
+	constructor(id: number)
+		self.id = id
+	end
+
+	public reset(): void
+		self.id = 0
+	end
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 1 error: id can only be set in the constructor
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+}
+
+func TestMissingInterfaceMembersReportedInOrder(t *testing.T) {
+	input := `
+interface Shape
+	perimeter(): number
+	area(): number
+	name: string
+	corners: number
+end
+
+class Blob implements Shape
+	constructor()
+	end
+end
+`
+
+	expected := []string{
+		"Class 'Blob' does not implement method 'area' from interface 'Shape'",
+		"Class 'Blob' does not implement method 'perimeter' from interface 'Shape'",
+		"Class 'Blob' does not implement property 'corners' from interface 'Shape'",
+		"Class 'Blob' does not implement property 'name' from interface 'Shape'",
+	}
+
+	// Map iteration order varies between runs, so check repeatedly
+	for run := 0; run < 20; run++ {
+		l := lexer.New(input)
+		p := parser.New(l)
+		statements := p.Parse()
+
+		if len(p.Errors()) > 0 {
+			t.Fatalf("Parser errors: %v", p.Errors())
+		}
+
+		checker := NewChecker()
+		errors := checker.Check(statements)
+
+		if len(errors) != len(expected) {
+			t.Fatalf("Expected %d type errors, got %d", len(expected), len(errors))
+		}
+		for i, msg := range expected {
+			if errors[i].Message != msg {
+				t.Fatalf("run %d: expected error %d to be %q, got %q", run, i, msg, errors[i].Message)
+			}
+		}
+	}
+}
+
+const numberListInterface = `
+interface NumberList
+	[index: number]: number
+	length: number
+end
+`
+
+func TestNumericIndexSignature(t *testing.T) {
+	input := numberListInterface + `
+function first(list: NumberList): number
+	local count: number = list.length
+	local value: number = list[1]
+	return value + count
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) > 0 {
+		t.Errorf("Expected no type errors, got %d:", len(errors))
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+	}
+}
+
+func TestNumericIndexSignatureValueType(t *testing.T) {
+	input := numberListInterface + `
+function first(list: NumberList): void
+	local value: string = list[1]
+	local count: string = list.length
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 2 errors: the indexed element and length are both numbers
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 type errors, got %d", len(errors))
+	}
+	for _, err := range errors {
+		expected := "Cannot assign type 'number' to variable of type 'string'"
+		if err.Message != expected {
+			t.Errorf("Expected error %q, got %q", expected, err.Message)
+		}
+	}
+}
+
+func TestIndexSignatureRequiresNumberOrStringKey(t *testing.T) {
+	input := `
+interface Dict
+	[key: boolean]: number
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Index signature key must be 'number' or 'string', got 'boolean'"
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestConstructorParameterProperties(t *testing.T) {
+	input := `
+class Account
+	constructor(private balance: number, public readonly owner: string)
+	end
+
+	public deposit(amount: number): number
+		self.balance = self.balance + amount
+		return self.balance
+	end
+end
+
+function describe(account: Account): void
+	local owner: string = account.owner
+	local balance: string = account.balance
+	account.owner = "someone else"
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewChecker()
+	errors := checker.Check(statements)
+
+	// Should have 3 errors: balance is private and a number, and owner is
+	// read-only
+	expected := []string{
+		"'balance' is private and only accessible within class 'Account'",
+		"Cannot assign type 'number' to variable of type 'string'",
+		"Cannot assign to 'owner' because it is a read-only property",
+	}
+	if len(errors) != len(expected) {
+		for _, err := range errors {
+			t.Errorf("  %s", err.Message)
+		}
+		t.Fatalf("Expected %d type errors, got %d", len(expected), len(errors))
+	}
+	for i, msg := range expected {
+		if errors[i].Message != msg {
+			t.Errorf("Expected error %q, got %q", msg, errors[i].Message)
+		}
+	}
+}
+
+const dictInterface = `
+interface Dict
+	[key: string]: number
+end
+`
+
+func TestStringIndexSignature(t *testing.T) {
+	input := dictInterface + `
+type Scores
+	[name: string]: number
+	total: number
+end
+
+function read(d: Dict, scores: Scores, key: string): number
+	local x: number = d["x"]
+	local y: number = d[key]
+	local z: number = d.z
+	d["w"] = 4
+	local ada: number = scores["ada"]
+	local total: number = scores.total
+	return x + y + z + ada + total
+end
+
+local counts: Dict = { apples = 3, pears = 5 }
+local inline: { [word: string]: number } = counts
+`
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestDuplicateIndexSignatures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`interface Twice
+	[a: number]: number
+	[b: number]: string
+end`,
+			"Duplicate 'number' index signature",
+		},
+		{
+			`type Key = string
+interface Twice
+	[a: Key]: number
+	[b: string]: number
+end`,
+			"Duplicate 'string' index signature",
+		},
+		{
+			`type Twice
+	[a: number]: number
+	[b: number]: number
+end`,
+			"Duplicate 'number' index signature",
+		},
+		{
+			`local twice: { [a: string]: number, [b: string]: string } = {}`,
+			"Duplicate 'string' index signature",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) == 0 || errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q first for:\n%s\ngot %v", tt.expected, tt.input, errors)
+		}
+	}
+}
+
+func TestStringIndexSignatureErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`function read(d: Dict): string
+	return d["x"]
+end`,
+			"Cannot return type 'number' from function with return type 'string'",
+		},
+		{
+			`function write(d: Dict): void
+	d["x"] = "many"
+end`,
+			"Cannot assign type '\"many\"' to type 'number'",
+		},
+		{
+			`local counts: Dict = { apples = "three" }`,
+			"Cannot assign type '<table literal>' to variable of type 'Dict'",
+		},
+		{
+			`interface Twice
+	[a: string]: number
+	[b: string]: string
+end`,
+			"Duplicate 'string' index signature",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, dictInterface+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			for _, err := range errors {
+				t.Errorf("  %s", err.Message)
+			}
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package types
+
+import "testing"
+
+func TestRangeTypeInRange(t *testing.T) {
+	input := `
+local percent: number<0, 100> = 50
+local low: number<0, 100> = 0
+local high: number<0, 100> = 100
+local ratio: number<0, 1> = 0.25
+local offset: number<-1, 1> = -1
+local narrow: number<10, 20> = 15
+local wide: number<0, 100> = narrow
+local maybe: number<0, 100>? = nil
+local either: number<0, 10> | string = 5
+
+function setVolume(level: number<0, 11>): void
+end
+
+setVolume(11)
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestRangeTypeOutOfRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`local percent: number<0, 100> = 150`,
+			"Cannot assign type '150' to variable of type 'number<0, 100>'",
+		},
+		{
+			`local percent: number<0, 100> = -1`,
+			"Cannot assign type '-1' to variable of type 'number<0, 100>'",
+		},
+		{
+			`local n: number = 5
+local percent: number<0, 100> = n`,
+			"Cannot assign type 'number' to variable of type 'number<0, 100>'",
+		},
+		{
+			`local wide: number<0, 100> = 50
+local narrow: number<10, 20> = wide`,
+			"Cannot assign type 'number<0, 100>' to variable of type 'number<10, 20>'",
+		},
+		{
+			`local bad: number<10, 0>`,
+			"Range type 'number<10, 0>' has a minimum greater than its maximum",
+		},
+		{
+			`local bad: number<string, 1>`,
+			"Range type 'number<string, 1>' expects a minimum and a maximum number",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) == 0 {
+			t.Errorf("%q: expected error %q, got none", tt.input, tt.expected)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestRangeTypeAssignableToNumber(t *testing.T) {
+	input := `
+local percent: number<0, 100> = 42
+local n: number = percent
+local doubled: number = percent * 2
+local maybe: number | nil = percent
+
+function show(value: number): void
+end
+
+show(percent)
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestIntRangeType(t *testing.T) {
+	input := `
+local roll: int<1, 6> = 4
+local n: int = roll
+local f: float = roll
+local half: int<1, 6> = 2.5
+`
+
+	errors := checkWithTarget(t, input, "5.3")
+	expected := "Cannot assign type '2.5' to variable of type 'int<1, 6>'"
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d: %v", len(errors), errors)
+	}
+	if errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, errors[0].Message)
+	}
+}
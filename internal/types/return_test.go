@@ -0,0 +1,257 @@
+package types
+
+import "testing"
+
+func TestMultipleReturnValues(t *testing.T) {
+	input := `
+function divmod(a: number, b: number): (number, number)
+	return a // b, a % b
+end
+
+function lookup(key: string): (boolean, string)
+	if key == "" then
+		return false, "empty key"
+	end
+	return true, key
+end
+
+function forward(key: string): (boolean, string)
+	return lookup(key)
+end
+
+function loose(): any
+	return 1, "two"
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestMultipleReturnValueErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`function f(): (number, string)
+	return 1, "a", true
+end`,
+			"Cannot return 3 values from function with return type '(number, string)'",
+		},
+		{
+			`function f(): (number, string, boolean)
+	return 1, "a"
+end`,
+			"Cannot return 2 values from function with return type '(number, string, boolean)'",
+		},
+		{
+			`function f(): number
+	return 1, 2
+end`,
+			"Cannot return 2 values from function with return type 'number'",
+		},
+		{
+			`function f(): (number, string)
+	return "a", 1
+end`,
+			"Return value 1: cannot return type '\"a\"' as type 'number'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) == 0 {
+			t.Errorf("%q: expected error %q, got none", tt.input, tt.expected)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestInferredReturnTypes(t *testing.T) {
+	input := `
+function five()
+	return 5
+end
+
+function greeting(name: string)
+	return "hello " .. name
+end
+
+function sign(n: number)
+	if n < 0 then
+		return "negative"
+	end
+	return n
+end
+
+function pair()
+	return 1, "one"
+end
+
+function nothing()
+	local x = 1
+end
+
+function maybe(x: boolean)
+	if x then
+		return 1
+	end
+end
+
+function pick(x: boolean)
+	if x then
+		return 1
+	else
+		return 2
+	end
+end
+
+function forever()
+	while true do
+		return 1
+	end
+end
+
+local n: number = five()
+local s: string = greeting("world")
+local either: string | number = sign(1)
+local first: number = pair()
+local v: void = nothing()
+local m: number? = maybe(true)
+local p: number = pick(true)
+local f: number = forever()
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestInferredReturnTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`function five()
+	return 5
+end
+local s: string = five()`,
+			"Cannot assign type 'number' to variable of type 'string'",
+		},
+		{
+			`function sign(n: number)
+	if n < 0 then
+		return "negative"
+	end
+	return n
+end
+local n: number = sign(1)`,
+			"Cannot assign type 'string | number' to variable of type 'number'",
+		},
+		{
+			`function nothing()
+end
+local n: number = nothing()`,
+			"Cannot assign type 'void' to variable of type 'number'",
+		},
+		{
+			`function f(x)
+	if x then
+		return 1
+	end
+end
+local n: number = f(true)`,
+			"Cannot assign type 'number | nil' to variable of type 'number'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestInferredUnionReturnType(t *testing.T) {
+	input := `
+function parse(text: string)
+	if text == "" then
+		return 0
+	elseif text == "one" then
+		return 1
+	end
+	return text
+end
+
+function describe(value: number | string): string
+	return "value"
+end
+
+function stop(done: boolean)
+	if done then
+		return
+	end
+	local x = 1
+end
+
+local parsed: number | string = parse("one")
+local described: string = describe(parse("two"))
+local v: void = stop(true)
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestInferredUnionReturnTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`function parse(text: string)
+	if text == "" then
+		return 0
+	end
+	return text
+end
+local s: string = parse("one")`,
+			"Cannot assign type 'number | string' to variable of type 'string'",
+		},
+		{
+			`function find(text: string)
+	if text == "" then
+		return
+	end
+	return text
+end`,
+			"Missing return value; the other return statements of 'find' return 'string'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
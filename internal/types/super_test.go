@@ -0,0 +1,80 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func animalClasses(visibility string) string {
+	return `
+class Animal
+	public name: string
+	` + visibility + `constructor(name: string)
+		self.name = name
+	end
+	public speak(): string
+		return self.name
+	end
+end
+
+class Dog extends Animal
+	constructor(name: string)
+		super(name)
+	end
+	public speak(): string
+		return super.speak() .. "!"
+	end
+end
+`
+}
+
+func TestSuperCallToAccessibleConstructor(t *testing.T) {
+	for _, visibility := range []string{"", "public ", "protected "} {
+		errors := checkWithOptions(t, animalClasses(visibility), Options{})
+		for _, err := range errors {
+			t.Errorf("%q constructor: unexpected type error: %s", visibility, err.Message)
+		}
+	}
+}
+
+func TestSuperCallToPrivateConstructor(t *testing.T) {
+	errors := checkWithOptions(t, animalClasses("private "), Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "Cannot call private constructor of 'Animal' via super."
+	if errors[0].Message != expected {
+		t.Errorf("Expected %q, got %q", expected, errors[0].Message)
+	}
+}
+
+func TestSuperCallArguments(t *testing.T) {
+	input := strings.Replace(animalClasses(""), "super(name)", "super(42)", 1)
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	if !strings.Contains(errors[0].Message, "cannot pass type '42' to parameter of type 'string'") {
+		t.Errorf("Expected argument error, got: %s", errors[0].Message)
+	}
+}
+
+func TestSuperOutsideSubclass(t *testing.T) {
+	input := `
+class Animal
+	constructor(name: string)
+		super(name)
+	end
+end
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 type error, got %d", len(errors))
+	}
+	expected := "'super' can only be used inside a subclass"
+	if errors[0].Message != expected {
+		t.Errorf("Expected %q, got %q", expected, errors[0].Message)
+	}
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"lunar/internal/ast"
+	"lunar/internal/lexer"
+)
+
+// Symbol is a declared name along with its type and where it was declared
+type Symbol struct {
+	Name  string
+	Type  Type
+	Token lexer.Token // the declaring identifier
+	Scope *Scope
+}
+
+// Scope is a lexical scope in the symbol table. Each function body, block
+// and loop opens a new scope nested in the one around it
+type Scope struct {
+	Parent   *Scope
+	Children []*Scope
+	Symbols  []*Symbol // in declaration order
+}
+
+// Lookup finds the symbol a name refers to from within this scope, searching
+// the enclosing scopes when it isn't declared here
+func (s *Scope) Lookup(name string) (*Symbol, bool) {
+	for scope := s; scope != nil; scope = scope.Parent {
+		// A later declaration in the same scope shadows an earlier one
+		for i := len(scope.Symbols) - 1; i >= 0; i-- {
+			if scope.Symbols[i].Name == name {
+				return scope.Symbols[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Symbols returns the root scope of the symbol table built by Check, or nil
+// unless Options.CollectSymbols is set
+func (c *Checker) Symbols() *Scope {
+	return c.symbols
+}
+
+// recordSymbol adds a declaration to the symbol table, in the scope of the
+// current environment
+func (c *Checker) recordSymbol(name *ast.Identifier, typ Type) {
+	if c.symbols == nil {
+		return
+	}
+
+	scope := c.scopeFor(c.env)
+	scope.Symbols = append(scope.Symbols, &Symbol{
+		Name:  name.Value,
+		Type:  typ,
+		Token: name.Token,
+		Scope: scope,
+	})
+}
+
+// scopeFor returns the symbol table scope of an environment, creating it and
+// any missing enclosing scopes on first use
+func (c *Checker) scopeFor(env *Environment) *Scope {
+	if env.outer == nil {
+		return c.symbols
+	}
+	if scope, ok := c.scopes[env]; ok {
+		return scope
+	}
+
+	parent := c.scopeFor(env.outer)
+	scope := &Scope{Parent: parent}
+	parent.Children = append(parent.Children, scope)
+	c.scopes[env] = scope
+	return scope
+}
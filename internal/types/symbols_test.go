@@ -0,0 +1,127 @@
+package types
+
+import (
+	"lunar/internal/lexer"
+	"lunar/internal/parser"
+	"testing"
+)
+
+// findSymbol searches a scope and everything nested in it for a declaration
+func findSymbol(scope *Scope, name string) *Symbol {
+	for _, symbol := range scope.Symbols {
+		if symbol.Name == name {
+			return symbol
+		}
+	}
+	for _, child := range scope.Children {
+		if symbol := findSymbol(child, name); symbol != nil {
+			return symbol
+		}
+	}
+	return nil
+}
+
+func TestSymbolTable(t *testing.T) {
+	input := `
+local count: number = 1
+
+function add(a: number, b: number): number
+	local sum = a + b
+	return sum
+end
+
+class Point
+	x: number
+
+	constructor(x: number)
+		self.x = x
+	end
+end
+
+for i = 1, 3 do
+	local label: string = "item"
+end
+`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	statements := p.Parse()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	checker := NewCheckerWithOptions(Options{CollectSymbols: true})
+	errors := checker.Check(statements)
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+
+	root := checker.Symbols()
+	if root == nil {
+		t.Fatal("Expected a symbol table")
+	}
+
+	tests := []struct {
+		name      string
+		topLevel  bool
+		typ       string
+		line      int
+		column    int
+		enclosing []string // names visible from the symbol's scope
+	}{
+		{"count", true, "number", 2, 7, nil},
+		{"add", true, "(number, number) -> number", 4, 10, nil},
+		{"Point", true, "Point", 9, 7, nil},
+		{"a", false, "number", 4, 14, []string{"b", "add", "count"}},
+		{"sum", false, "number", 5, 8, []string{"a", "b", "add"}},
+		{"x", false, "number", 12, 14, []string{"Point"}},
+		{"i", false, "number", 17, 5, []string{"count"}},
+		{"label", false, "string", 18, 8, []string{"i", "count"}},
+	}
+
+	for _, tt := range tests {
+		symbol := findSymbol(root, tt.name)
+		if symbol == nil {
+			t.Errorf("%s: not found in the symbol table", tt.name)
+			continue
+		}
+		if (symbol.Scope == root) != tt.topLevel {
+			t.Errorf("%s: expected top level %v", tt.name, tt.topLevel)
+		}
+		if symbol.Type.String() != tt.typ {
+			t.Errorf("%s: expected type %s, got %s", tt.name, tt.typ, symbol.Type.String())
+		}
+		if symbol.Token.Line != tt.line || symbol.Token.Column != tt.column {
+			t.Errorf("%s: expected position %d:%d, got %d:%d",
+				tt.name, tt.line, tt.column, symbol.Token.Line, symbol.Token.Column)
+		}
+		for _, name := range tt.enclosing {
+			if _, ok := symbol.Scope.Lookup(name); !ok {
+				t.Errorf("%s: expected %s to be visible from its scope", tt.name, name)
+			}
+		}
+	}
+
+	// Names declared in a nested scope aren't visible from the top level
+	for _, name := range []string{"a", "sum", "i", "label"} {
+		if _, ok := root.Lookup(name); ok {
+			t.Errorf("Expected %s not to be visible at the top level", name)
+		}
+	}
+
+	// The loop body is its own scope inside the loop's scope
+	label := findSymbol(root, "label")
+	if i, _ := label.Scope.Lookup("i"); i == nil || i.Scope != label.Scope.Parent {
+		t.Errorf("Expected the loop variable in the scope enclosing the loop body")
+	}
+}
+
+func TestSymbolTableDisabledByDefault(t *testing.T) {
+	checker := NewChecker()
+	checker.Check(nil)
+
+	if checker.Symbols() != nil {
+		t.Error("Expected no symbol table unless CollectSymbols is set")
+	}
+}
@@ -0,0 +1,81 @@
+package types
+
+import (
+	"fmt"
+	"lunar/internal/ast"
+	"strings"
+)
+
+// tracef writes a line to Options.Trace, indented by the nesting of the
+// step being traced. Steps traced on every resolution go through the typed
+// helpers below, which return before building any arguments when tracing
+// is off
+func (c *Checker) tracef(format string, args ...interface{}) {
+	if c.options.Trace == nil {
+		return
+	}
+	fmt.Fprintf(c.options.Trace, "%s%s\n", strings.Repeat("  ", c.traceDepth), fmt.Sprintf(format, args...))
+}
+
+// traceResolution resolves a type expression, tracing it, the steps
+// nested in resolving it, and the type it resolves to
+func (c *Checker) traceResolution(expr ast.Expression) Type {
+	c.tracef("resolve '%s'", expr.String())
+	c.traceDepth++
+	typ := c.resolveType(expr)
+	c.traceDepth--
+	c.tracef("'%s' resolved to '%s'", expr.String(), typ.String())
+	return typ
+}
+
+// traceLookup traces where a type name was found, or that it wasn't when
+// found is empty
+func (c *Checker) traceLookup(name *ast.Identifier, found string) {
+	if c.options.Trace == nil {
+		return
+	}
+	if found == "" {
+		c.tracef("lookup of '%s' at line %d, column %d failed; using any",
+			name.Value, name.Token.Line, name.Token.Column)
+		return
+	}
+	c.tracef("found '%s' %s", name.Value, found)
+}
+
+// traceSubstitution traces the type arguments a generic body is resolved
+// with
+func (c *Checker) traceSubstitution(typeParams []string, typeArgs []Type) {
+	if c.options.Trace == nil {
+		return
+	}
+	c.tracef("substitute %s", formatTypeArguments(typeParams, typeArgs))
+}
+
+// traceInference traces the type arguments inferred for a generic call.
+// A parameter no argument binds takes its constraint, or any
+func (c *Checker) traceInference(node *ast.CallExpression, typeParams []string, typeArgs []Type, bindings map[string]Type) {
+	if c.options.Trace == nil {
+		return
+	}
+	var unbound []string
+	for _, param := range typeParams {
+		if _, ok := bindings[param]; !ok {
+			unbound = append(unbound, param)
+		}
+	}
+	line := fmt.Sprintf("infer %s for call to '%s'", formatTypeArguments(typeParams, typeArgs), node.Function.String())
+	if len(unbound) > 0 {
+		line += fmt.Sprintf(" (%s not bound by an argument)", strings.Join(unbound, ", "))
+	}
+	c.tracef("%s", line)
+}
+
+// formatTypeArguments formats type parameters with the types bound to them,
+// as T = string, U = number
+func formatTypeArguments(typeParams []string, typeArgs []Type) string {
+	pairs := make([]string, len(typeParams))
+	for i, param := range typeParams {
+		pairs[i] = fmt.Sprintf("%s = %s", param, typeArgs[i].String())
+	}
+	return strings.Join(pairs, ", ")
+}
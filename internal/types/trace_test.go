@@ -0,0 +1,73 @@
+package types
+
+import (
+	"bytes"
+	"lunar/internal/lexer"
+	"lunar/internal/parser"
+	"strings"
+	"testing"
+)
+
+// traceCheck checks input with tracing on and returns the trace
+func traceCheck(t *testing.T, input string, opts Options) string {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	statements := p.Parse()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("Parser errors: %v", p.Errors())
+	}
+
+	var trace bytes.Buffer
+	opts.Trace = &trace
+	NewCheckerWithOptions(opts).Check(statements)
+	return trace.String()
+}
+
+func TestTraceMisspelledType(t *testing.T) {
+	trace := traceCheck(t, `
+type Nullable<T> = T | nil
+
+local name: Nullable<Strng> = nil
+`, Options{})
+
+	for _, line := range []string{
+		"resolve 'Nullable<Strng>'\n",
+		"  resolve 'Strng'\n",
+		"    lookup of 'Strng' at line 4, column 22 failed; using any\n",
+		"  'Strng' resolved to 'any'\n",
+		"  substitute T = any\n",
+		"'Nullable<Strng>' resolved to 'any | nil'\n",
+	} {
+		if !strings.Contains(trace, line) {
+			t.Errorf("Expected the trace to contain %q, got:\n%s", line, trace)
+		}
+	}
+}
+
+func TestTraceGenericCallAndImports(t *testing.T) {
+	input := `
+import { add, missing } from "./util"
+
+function identity<T>(value: T): T
+	return value
+end
+
+local n = identity(add(1, 2))
+`
+	trace := traceCheck(t, input, Options{
+		File:          "main.lunar",
+		ResolveModule: moduleResolver(t, map[string]string{"./util": utilModule}),
+	})
+
+	for _, line := range []string{
+		"module './util' is 'util', checking it\n",
+		"import 'add' from './util' is '(number, number) -> number'\n",
+		"import 'missing' from './util' isn't exported; using any\n",
+		"infer T = number for call to 'identity'\n",
+	} {
+		if !strings.Contains(trace, line) {
+			t.Errorf("Expected the trace to contain %q, got:\n%s", line, trace)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package types
+
+import "testing"
+
+func TestTupleNotAssignableToArray(t *testing.T) {
+	// Tuples are multiple return values rather than tables, so they never
+	// stand in for an array
+	tests := []struct {
+		from Type
+		to   Type
+	}{
+		{&TupleType{Elements: []Type{Number, Number}}, &ArrayType{ElementType: Number}},
+		{&TupleType{Elements: []Type{Int, Float}}, &ArrayType{ElementType: Number}},
+		{&ArrayType{ElementType: Number}, &TupleType{Elements: []Type{Number, Number}}},
+	}
+
+	for _, tt := range tests {
+		if tt.from.IsAssignableTo(tt.to) {
+			t.Errorf("expected %s not to be assignable to %s", tt.from, tt.to)
+		}
+	}
+}
+
+const pairFunctions = `
+function pair(): (number, number)
+	return 1, 2
+end
+
+function mixed(): (number, string)
+	return 1, "two"
+end
+
+function sum(values: number[]): number
+	return 0
+end
+`
+
+func TestTupleArrayMismatches(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`local total = sum(pair())`,
+			"Argument 1: cannot pass type '(number, number)' to parameter of type 'number[]'",
+		},
+		{
+			`local total = sum(mixed())`,
+			"Argument 1: cannot pass type '(number, string)' to parameter of type 'number[]'",
+		},
+		{
+			`local point = pair()
+local total = sum(point)`,
+			"Argument 1: cannot pass type 'number' to parameter of type 'number[]'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, pairFunctions+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestMultipleReturnsInSingleValueContexts(t *testing.T) {
+	// As in Lua, a lone target or an argument before the last only gets the
+	// first value of a call returning several
+	input := pairFunctions + `
+function describe(n: number, label: string): string
+	return label
+end
+
+local first: number = pair()
+local inferred = mixed()
+local count: number = inferred
+first = mixed()
+local label = describe(mixed(), "first")
+`
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestMultipleReturnsInSingleValueContextErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`local name: string = mixed()`,
+			"Cannot assign type 'number' to variable of type 'string'",
+		},
+		{
+			`local name: string = ""
+name = pair()`,
+			"Cannot assign type 'number' to type 'string'",
+		},
+		{
+			`function join(a: string, b: string): string
+	return a
+end
+local joined = join(mixed(), "b")`,
+			"Argument 1: cannot pass type 'number' to parameter of type 'string'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, pairFunctions+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"fmt"
 	"lunar/internal/ast"
+	"math"
 	"strings"
 )
 
@@ -27,6 +28,9 @@ func (t *NumberType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -49,6 +53,9 @@ func (t *StringType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -71,6 +78,9 @@ func (t *BooleanType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -123,6 +133,21 @@ func (t *VoidType) IsAssignableTo(other Type) bool {
 	return isAny
 }
 
+// NeverType is the type of a value that can't exist: the result of a
+// function that never returns, or a variable whose every possible type has
+// been ruled out. It is assignable to every type, and only never and any are
+// assignable to it
+type NeverType struct{}
+
+func (t *NeverType) String() string { return "never" }
+func (t *NeverType) Equals(other Type) bool {
+	_, ok := other.(*NeverType)
+	return ok
+}
+func (t *NeverType) IsAssignableTo(other Type) bool {
+	return true // there is no value to be incompatible
+}
+
 // StringLiteralType represents a specific string value as a type
 type StringLiteralType struct {
 	Value string
@@ -140,6 +165,9 @@ func (t *StringLiteralType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -165,7 +193,14 @@ func (t *StringLiteralType) IsAssignableTo(other Type) bool {
 
 // NumberLiteralType represents a specific number value as a type
 type NumberLiteralType struct {
-	Value float64
+	Value   float64
+	IsFloat bool // written as a float, like 1.0, so a float even when whole
+}
+
+// IsInteger reports whether the literal is an integer, written without a
+// decimal point or exponent
+func (t *NumberLiteralType) IsInteger() bool {
+	return !t.IsFloat && t.Value == math.Trunc(t.Value)
 }
 
 func (t *NumberLiteralType) String() string { return fmt.Sprintf("%g", t.Value) }
@@ -180,6 +215,9 @@ func (t *NumberLiteralType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -187,24 +225,158 @@ func (t *NumberLiteralType) IsAssignableTo(other Type) bool {
 	if _, isNumber := other.(*NumberType); isNumber {
 		return true
 	}
+	// Any number literal fits a float; only integral ones fit an int
+	if _, isFloat := other.(*FloatType); isFloat {
+		return true
+	}
+	if _, isInt := other.(*IntType); isInt {
+		return t.IsInteger()
+	}
+	if rangeType, isRange := other.(*RangeType); isRange {
+		if _, isInt := rangeType.Base.(*IntType); isInt && t.IsFloat {
+			return false
+		}
+		return rangeType.Contains(t.Value)
+	}
 	// Check if other is a union type that contains this literal OR the base number type
 	if unionType, isUnion := other.(*UnionType); isUnion {
 		// First check if the literal itself is in the union
 		if unionType.Contains(t) {
 			return true
 		}
-		// Then check if the base number type is in the union
+		// Then check if the base number type or a range holding it is in the union
 		for _, ut := range unionType.Types {
 			if _, isNumber := ut.(*NumberType); isNumber {
 				return true
 			}
+			if rangeType, isRange := ut.(*RangeType); isRange && rangeType.Contains(t.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RangeType represents a number bounded to [Min, Max], written number<0, 100>.
+// Its base is number or int
+type RangeType struct {
+	Base Type
+	Min  float64
+	Max  float64
+}
+
+func (t *RangeType) String() string {
+	return fmt.Sprintf("%s<%g, %g>", t.Base.String(), t.Min, t.Max)
+}
+func (t *RangeType) Equals(other Type) bool {
+	otherRange, ok := other.(*RangeType)
+	if !ok {
+		return false
+	}
+	return t.Base.Equals(otherRange.Base) && t.Min == otherRange.Min && t.Max == otherRange.Max
+}
+func (t *RangeType) IsAssignableTo(other Type) bool {
+	if t.Equals(other) {
+		return true
+	}
+	if assignableToOptional(t, other) {
+		return true
+	}
+	// A range fits any range that covers it
+	if otherRange, ok := other.(*RangeType); ok {
+		return t.Base.IsAssignableTo(otherRange.Base) && t.Min >= otherRange.Min && t.Max <= otherRange.Max
+	}
+	if unionType, isUnion := other.(*UnionType); isUnion {
+		for _, ut := range unionType.Types {
+			if t.IsAssignableTo(ut) {
+				return true
+			}
+		}
+		return false
+	}
+	// Otherwise a range is used like its base type
+	return t.Base.IsAssignableTo(other)
+}
+
+// Contains reports whether value lies within the range, and is a whole
+// number when the range is of ints
+func (t *RangeType) Contains(value float64) bool {
+	if value < t.Min || value > t.Max {
+		return false
+	}
+	if _, isInt := t.Base.(*IntType); isInt {
+		return value == math.Trunc(value)
+	}
+	return true
+}
+
+// IntType represents the integer subtype of number (Lua 5.3+)
+type IntType struct{}
+
+func (t *IntType) String() string { return "int" }
+func (t *IntType) Equals(other Type) bool {
+	_, ok := other.(*IntType)
+	return ok
+}
+func (t *IntType) IsAssignableTo(other Type) bool {
+	if t.Equals(other) {
+		return true
+	}
+	if assignableToOptional(t, other) {
+		return true
+	}
+	switch other.(type) {
+	case *AnyType, *NumberType, *FloatType:
+		// Lua converts integers to floats implicitly, so int widens to both
+		return true
+	}
+	// Check if other is a union type that contains int or a wider numeric type
+	if unionType, isUnion := other.(*UnionType); isUnion {
+		for _, ut := range unionType.Types {
+			if t.IsAssignableTo(ut) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FloatType represents the floating-point subtype of number (Lua 5.3+)
+type FloatType struct{}
+
+func (t *FloatType) String() string { return "float" }
+func (t *FloatType) Equals(other Type) bool {
+	_, ok := other.(*FloatType)
+	return ok
+}
+func (t *FloatType) IsAssignableTo(other Type) bool {
+	if t.Equals(other) {
+		return true
+	}
+	if assignableToOptional(t, other) {
+		return true
+	}
+	switch other.(type) {
+	case *AnyType, *NumberType:
+		return true
+	}
+	// Check if other is a union type that contains float or number
+	if unionType, isUnion := other.(*UnionType); isUnion {
+		for _, ut := range unionType.Types {
+			if t.IsAssignableTo(ut) {
+				return true
+			}
 		}
 	}
 	return false
 }
 
 // AnyType represents the any type (accepts all types)
-type AnyType struct{}
+type AnyType struct {
+	// Implicit is set when the any comes from a missing annotation rather
+	// than an explicit one
+	Implicit bool
+}
 
 func (t *AnyType) String() string { return "any" }
 func (t *AnyType) Equals(other Type) bool {
@@ -220,32 +392,63 @@ func (t *AnyType) IsAssignableTo(other Type) bool {
 // ArrayType represents an array type with element type
 type ArrayType struct {
 	ElementType Type
+	Readonly    bool
 }
 
 func (t *ArrayType) String() string {
-	return fmt.Sprintf("%s[]", t.ElementType.String())
+	element := t.ElementType.String()
+	// Function, union and intersection element types need parentheses to
+	// keep the [] from binding to their last part
+	switch t.ElementType.(type) {
+	case *FunctionType, *UnionType, *IntersectionType:
+		element = "(" + element + ")"
+	}
+	if t.Readonly {
+		return fmt.Sprintf("readonly %s[]", element)
+	}
+	return fmt.Sprintf("%s[]", element)
 }
 func (t *ArrayType) Equals(other Type) bool {
 	otherArray, ok := other.(*ArrayType)
 	if !ok {
 		return false
 	}
-	return t.ElementType.Equals(otherArray.ElementType)
+	return t.Readonly == otherArray.Readonly && t.ElementType.Equals(otherArray.ElementType)
 }
 func (t *ArrayType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
-	// Array is covariant in its element type
 	if otherArray, ok := other.(*ArrayType); ok {
-		return t.ElementType.IsAssignableTo(otherArray.ElementType)
+		// A read-only array can't be used where its elements may be assigned
+		if t.Readonly && !otherArray.Readonly {
+			return false
+		}
+		// Nothing can be stored through a read-only array, so it is
+		// covariant in its element type. A mutable array is invariant:
+		// a Dog[] used as an Animal[] could have a Cat stored in it
+		if otherArray.Readonly {
+			return t.ElementType.IsAssignableTo(otherArray.ElementType)
+		}
+		return sameElementType(t.ElementType, otherArray.ElementType)
 	}
 	return false
 }
 
+// sameElementType reports whether two element types are interchangeable in
+// a mutable array. any opts out of checking in either direction
+func sameElementType(a, b Type) bool {
+	_, aIsAny := a.(*AnyType)
+	_, bIsAny := b.(*AnyType)
+	return aIsAny || bIsAny || a.Equals(b)
+}
+
 // TableType represents a table type with key and value types
 type TableType struct {
 	KeyType   Type
@@ -266,6 +469,9 @@ func (t *TableType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -281,6 +487,32 @@ func (t *TableType) IsAssignableTo(other Type) bool {
 type FunctionType struct {
 	Parameters []Type
 	ReturnType Type
+	Guard      *TypeGuard // set for user-defined type guards, nil otherwise
+
+	// Variadic is the type of each extra argument of a variadic function,
+	// nil when the function takes a fixed number of arguments
+	Variadic Type
+
+	// Generic is set for functions declared with type parameters. Their
+	// Parameters and ReturnType hold any in place of each type parameter
+	Generic *GenericSignature
+}
+
+// GenericSignature keeps the declared signature of a generic function, so
+// each call can resolve it again with the type arguments it infers
+type GenericSignature struct {
+	TypeParams  []string
+	Constraints []ast.Expression // constraint per type parameter, nil entries for none
+	Defaults    []ast.Expression // default type per type parameter, nil entries for none
+	Parameters  []ast.Expression // the declared parameter types, nil where unannotated
+	ReturnType  ast.Expression   // nil when the return type is inferred
+}
+
+// TypeGuard records that a boolean function narrows one of its arguments:
+// when it returns true, the argument has the guarded type
+type TypeGuard struct {
+	ParamIndex int
+	Type       Type
 }
 
 func (t *FunctionType) String() string {
@@ -288,6 +520,9 @@ func (t *FunctionType) String() string {
 	for i, p := range t.Parameters {
 		params[i] = p.String()
 	}
+	if t.Variadic != nil {
+		params = append(params, "..."+t.Variadic.String())
+	}
 	return fmt.Sprintf("(%s) -> %s", strings.Join(params, ", "), t.ReturnType.String())
 }
 func (t *FunctionType) Equals(other Type) bool {
@@ -303,12 +538,21 @@ func (t *FunctionType) Equals(other Type) bool {
 			return false
 		}
 	}
+	if (t.Variadic == nil) != (otherFunc.Variadic == nil) {
+		return false
+	}
+	if t.Variadic != nil && !t.Variadic.Equals(otherFunc.Variadic) {
+		return false
+	}
 	return t.ReturnType.Equals(otherFunc.ReturnType)
 }
 func (t *FunctionType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -323,6 +567,11 @@ func (t *FunctionType) IsAssignableTo(other Type) bool {
 				return false
 			}
 		}
+		// Extra arguments the other function may be called with must be
+		// accepted too
+		if otherFunc.Variadic != nil && (t.Variadic == nil || !otherFunc.Variadic.IsAssignableTo(t.Variadic)) {
+			return false
+		}
 		// Covariance: this return type must be assignable to other's return type
 		return t.ReturnType.IsAssignableTo(otherFunc.ReturnType)
 	}
@@ -392,6 +641,179 @@ func (t *UnionType) Contains(typ Type) bool {
 	return false
 }
 
+// IntersectionType is a type that is all of its members at once (A & B).
+// A value of it has the properties and methods of every member
+type IntersectionType struct {
+	Types []Type
+}
+
+func (t *IntersectionType) String() string {
+	typeStrs := make([]string, 0, len(t.Types))
+	for _, typ := range t.Types {
+		if typ != nil {
+			typeStrs = append(typeStrs, typ.String())
+		}
+	}
+	return strings.Join(typeStrs, " & ")
+}
+func (t *IntersectionType) Equals(other Type) bool {
+	otherIntersection, ok := other.(*IntersectionType)
+	if !ok {
+		return false
+	}
+	if len(t.Types) != len(otherIntersection.Types) {
+		return false
+	}
+	// Check if all types match (order-independent)
+	for _, typ := range t.Types {
+		found := false
+		for _, otherTyp := range otherIntersection.Types {
+			if typ.Equals(otherTyp) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+func (t *IntersectionType) IsAssignableTo(other Type) bool {
+	if t.Equals(other) {
+		return true
+	}
+	if assignableToOptional(t, other) {
+		return true
+	}
+	if _, isAny := other.(*AnyType); isAny {
+		return true
+	}
+	if assignableToIntersection(t, other) {
+		return true
+	}
+	// An intersection is assignable wherever one of its members is
+	for _, typ := range t.Types {
+		if typ.IsAssignableTo(other) {
+			return true
+		}
+	}
+	// Otherwise the members together may have everything an interface needs
+	if otherInterface, ok := other.(*InterfaceType); ok {
+		return hasMembersOf(t, otherInterface)
+	}
+	return false
+}
+
+// GetProperty returns the type of a property of any member
+func (t *IntersectionType) GetProperty(name string) (Type, bool) {
+	for _, typ := range t.Types {
+		if member, ok := typ.(memberLookup); ok {
+			if prop, ok := member.GetProperty(name); ok {
+				return prop, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// GetMethod returns the type of a method of any member
+func (t *IntersectionType) GetMethod(name string) (*FunctionType, bool) {
+	for _, typ := range t.Types {
+		if member, ok := typ.(memberLookup); ok {
+			if method, ok := member.GetMethod(name); ok {
+				return method, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// assignableToIntersection reports whether other is an intersection type
+// whose every member accepts t
+func assignableToIntersection(t, other Type) bool {
+	intersection, ok := other.(*IntersectionType)
+	if !ok || len(intersection.Types) == 0 {
+		return false
+	}
+	for _, typ := range intersection.Types {
+		if !t.IsAssignableTo(typ) {
+			return false
+		}
+	}
+	return true
+}
+
+// memberLookup is implemented by the types that have named properties and
+// methods: classes, interfaces and intersections
+type memberLookup interface {
+	GetProperty(name string) (Type, bool)
+	GetMethod(name string) (*FunctionType, bool)
+}
+
+// hasMembersOf reports whether t has every required property and method of
+// an interface, with compatible types
+func hasMembersOf(t memberLookup, iface *InterfaceType) bool {
+	for propName, propType := range iface.allProperties() {
+		myPropType, hasProperty := t.GetProperty(propName)
+		if !hasProperty {
+			// A method stands in for a function property that takes the
+			// receiver first, which is how the method is called through '.'
+			if myMethodType, hasMethod := t.GetMethod(propName); hasMethod {
+				if !unboundMethod(iface, myMethodType).IsAssignableTo(propType) {
+					return false
+				}
+				continue
+			}
+			if IsOptionalProperty(propType) {
+				continue // An absent key reads as nil in Lua
+			}
+			return false // Missing required property
+		}
+		if !myPropType.IsAssignableTo(propType) {
+			return false // Property type mismatch
+		}
+	}
+
+	for methodName, methodType := range iface.allMethods() {
+		myMethodType, hasMethod := t.GetMethod(methodName)
+		if !hasMethod {
+			// Likewise a function property taking the receiver first
+			// stands in for a method
+			if myPropType, hasProperty := t.GetProperty(methodName); hasProperty {
+				if !myPropType.IsAssignableTo(unboundMethod(iface, methodType)) {
+					return false
+				}
+				continue
+			}
+			return false // Missing required method
+		}
+		if !myMethodType.IsAssignableTo(methodType) {
+			return false // Method type mismatch
+		}
+	}
+	return true
+}
+
+// fitsStringIndex reports whether every property of t, and the values of its
+// own string index signature, can be stored under the string index
+// signature of iface. It holds when iface has no string index signature
+func fitsStringIndex(t, iface *InterfaceType) bool {
+	valueType, ok := iface.GetStringIndex()
+	if !ok {
+		return true
+	}
+	if own, ok := t.GetStringIndex(); ok && !own.IsAssignableTo(valueType) {
+		return false
+	}
+	for _, propType := range t.allProperties() {
+		if !propType.IsAssignableTo(valueType) {
+			return false
+		}
+	}
+	return true
+}
+
 // OptionalType represents an optional type (T | nil)
 type OptionalType struct {
 	BaseType Type
@@ -418,15 +840,45 @@ func (t *OptionalType) IsAssignableTo(other Type) bool {
 	if otherOpt, ok := other.(*OptionalType); ok {
 		return t.BaseType.IsAssignableTo(otherOpt.BaseType)
 	}
+	// T? is T | nil, so it fits a union that accepts both
+	if _, isUnion := other.(*UnionType); isUnion {
+		return t.BaseType.IsAssignableTo(other) && Nil.IsAssignableTo(other)
+	}
 	// Optional is NOT assignable to non-optional (must unwrap first)
 	return false
 }
 
+// assignableToOptional reports whether other is an optional type whose base
+// type accepts t. An optional type accepts its base type as well as nil
+func assignableToOptional(t, other Type) bool {
+	opt, ok := other.(*OptionalType)
+	return ok && t.IsAssignableTo(opt.BaseType)
+}
+
 // GenericTypeAlias represents a generic type alias like type Nullable<T> = T | nil
 type GenericTypeAlias struct {
-	Name       string
-	TypeParams []string       // e.g., ["T", "U"]
-	Body       ast.Expression // the type expression with type parameters
+	Name        string
+	TypeParams  []string         // e.g., ["T", "U"]
+	Constraints []ast.Expression // constraint per parameter, nil entries for none
+	Defaults    []ast.Expression // default type per parameter, nil entries for none
+	Body        ast.Expression   // the type expression with type parameters
+}
+
+// RequiredTypeParams returns how many leading type parameters have no
+// default and must always be given
+func (t *GenericTypeAlias) RequiredTypeParams() int {
+	return requiredTypeParams(t.TypeParams, t.Defaults)
+}
+
+// requiredTypeParams returns how many leading type parameters have no
+// default in defaults
+func requiredTypeParams(typeParams []string, defaults []ast.Expression) int {
+	for i := range typeParams {
+		if i < len(defaults) && defaults[i] != nil {
+			return i
+		}
+	}
+	return len(typeParams)
 }
 
 func (t *GenericTypeAlias) String() string {
@@ -493,6 +945,9 @@ func (t *TupleType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -518,6 +973,25 @@ type ClassType struct {
 	Properties map[string]Type
 	Methods    map[string]*FunctionType
 	Implements []*InterfaceType
+	Readonly   map[string]bool // names of read-only properties
+	Hidden     map[string]bool // names of private and protected members
+	Private    map[string]bool // names of private members
+
+	// Parent is the class this one extends, or nil
+	Parent *ClassType
+
+	// Constructor is the signature of the constructor, nil when the class
+	// declares none. ConstructorVisibility is "public", "private" or
+	// "protected"; empty means public
+	Constructor           *FunctionType
+	ConstructorVisibility string
+
+	// TypeParams, Constraints and Defaults describe the type parameters of
+	// a generic class. Within the class each stands for its constraint, or
+	// any, so type arguments are checked against them but not substituted
+	TypeParams  []string
+	Constraints []ast.Expression // constraint per parameter, nil entries for none
+	Defaults    []ast.Expression // default type per parameter, nil entries for none
 }
 
 func (t *ClassType) String() string {
@@ -534,6 +1008,9 @@ func (t *ClassType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -545,19 +1022,71 @@ func (t *ClassType) IsAssignableTo(other Type) bool {
 			}
 		}
 	}
+	if assignableToIntersection(t, other) {
+		return true
+	}
+	// A subclass is assignable wherever its parent is
+	if t.Parent != nil {
+		return t.Parent.IsAssignableTo(other)
+	}
 	return false
 }
 
-// GetProperty returns the type of a property
+// GetProperty returns the type of a property, searching the parent classes
+// when the class doesn't declare it
 func (t *ClassType) GetProperty(name string) (Type, bool) {
-	typ, ok := t.Properties[name]
-	return typ, ok
+	if typ, ok := t.Properties[name]; ok {
+		return typ, true
+	}
+	if t.Parent != nil {
+		return t.Parent.GetProperty(name)
+	}
+	return nil, false
 }
 
-// GetMethod returns the type of a method
+// IsReadonly reports whether a property is read-only
+func (t *ClassType) IsReadonly(name string) bool {
+	if _, ok := t.Properties[name]; ok || t.Parent == nil {
+		return t.Readonly[name]
+	}
+	return t.Parent.IsReadonly(name)
+}
+
+// GetMethod returns the type of a method, searching the parent classes when
+// the class doesn't declare it
 func (t *ClassType) GetMethod(name string) (*FunctionType, bool) {
-	typ, ok := t.Methods[name]
-	return typ, ok
+	if typ, ok := t.Methods[name]; ok {
+		return typ, true
+	}
+	if t.Parent != nil {
+		return t.Parent.GetMethod(name)
+	}
+	return nil, false
+}
+
+// declaringClass returns the class, this one or an ancestor, that declares
+// the named member
+func (t *ClassType) declaringClass(name string) (*ClassType, bool) {
+	for class := t; class != nil; class = class.Parent {
+		if _, ok := class.Properties[name]; ok {
+			return class, true
+		}
+		if _, ok := class.Methods[name]; ok {
+			return class, true
+		}
+	}
+	return nil, false
+}
+
+// IsSubclassOf reports whether the class extends other, directly or through
+// its ancestors
+func (t *ClassType) IsSubclassOf(other *ClassType) bool {
+	for parent := t.Parent; parent != nil; parent = parent.Parent {
+		if parent.Equals(other) {
+			return true
+		}
+	}
+	return false
 }
 
 // InterfaceType represents an interface type
@@ -566,6 +1095,19 @@ type InterfaceType struct {
 	Methods    map[string]*FunctionType
 	Properties map[string]Type
 	Extends    []*InterfaceType
+	Readonly   map[string]bool // names of read-only properties
+
+	// Classes are the classes this interface extends. Their public properties
+	// and methods are requirements of the interface too
+	Classes []*ClassType
+
+	// NumberIndex is the value type of a numeric index signature
+	// ([index: number]: T), or nil when the interface has none
+	NumberIndex Type
+
+	// StringIndex is the value type of a string index signature
+	// ([key: string]: T), or nil when the interface has none
+	StringIndex Type
 }
 
 func (t *InterfaceType) String() string {
@@ -582,6 +1124,9 @@ func (t *InterfaceType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -593,35 +1138,26 @@ func (t *InterfaceType) IsAssignableTo(other Type) bool {
 			}
 		}
 
-		// Structural compatibility: check if this interface has all required properties
-		// This allows table literals to be assigned to interface types
-		for propName, propType := range otherInterface.Properties {
-			myPropType, hasProperty := t.Properties[propName]
-			if !hasProperty {
-				return false // Missing required property
-			}
-			if !myPropType.IsAssignableTo(propType) {
-				return false // Property type mismatch
-			}
-		}
-
-		// Check methods (if any required)
-		for methodName, methodType := range otherInterface.Methods {
-			myMethodType, hasMethod := t.Methods[methodName]
-			if !hasMethod {
-				return false // Missing required method
-			}
-			if !myMethodType.IsAssignableTo(methodType) {
-				return false // Method type mismatch
-			}
+		// Structural compatibility: check if this interface has all required
+		// properties and methods. This allows table literals to be assigned
+		// to interface types
+		pair := [2]*InterfaceType{t, otherInterface}
+		if comparingInterfaces[pair] {
+			return true
 		}
-
-		// If we have all required properties and methods, we're compatible
-		return true
+		comparingInterfaces[pair] = true
+		defer delete(comparingInterfaces, pair)
+		return hasMembersOf(t, otherInterface) && fitsStringIndex(t, otherInterface)
 	}
-	return false
+	return assignableToIntersection(t, other)
 }
 
+// comparingInterfaces holds the pairs of interfaces being compared
+// structurally. Interfaces that refer to themselves, such as a node with a
+// next node, lead back to the same pair, which is assumed to fit rather than
+// compared forever. Type checking runs on a single goroutine
+var comparingInterfaces = map[[2]*InterfaceType]bool{}
+
 // GetMethod returns the type of a method
 func (t *InterfaceType) GetMethod(name string) (*FunctionType, bool) {
 	// Check own methods
@@ -634,6 +1170,12 @@ func (t *InterfaceType) GetMethod(name string) (*FunctionType, bool) {
 			return method, true
 		}
 	}
+	// Check the public methods of extended classes
+	for _, class := range t.Classes {
+		if method, ok := class.Methods[name]; ok && !class.Hidden[name] {
+			return method, true
+		}
+	}
 	return nil, false
 }
 
@@ -649,13 +1191,119 @@ func (t *InterfaceType) GetProperty(name string) (Type, bool) {
 			return prop, true
 		}
 	}
+	// Check the public properties of extended classes
+	for _, class := range t.Classes {
+		if prop, ok := class.Properties[name]; ok && !class.Hidden[name] {
+			return prop, true
+		}
+	}
 	return nil, false
 }
 
+// allProperties returns every property of the interface, including those it
+// inherits from extended interfaces and classes
+func (t *InterfaceType) allProperties() map[string]Type {
+	props := make(map[string]Type)
+	for _, class := range t.Classes {
+		for name, prop := range class.Properties {
+			if !class.Hidden[name] {
+				props[name] = prop
+			}
+		}
+	}
+	for _, ext := range t.Extends {
+		for name, prop := range ext.allProperties() {
+			props[name] = prop
+		}
+	}
+	for name, prop := range t.Properties {
+		props[name] = prop
+	}
+	return props
+}
+
+// allMethods returns every method of the interface, including those it
+// inherits from extended interfaces and classes
+func (t *InterfaceType) allMethods() map[string]*FunctionType {
+	methods := make(map[string]*FunctionType)
+	for _, class := range t.Classes {
+		for name, method := range class.Methods {
+			if !class.Hidden[name] {
+				methods[name] = method
+			}
+		}
+	}
+	for _, ext := range t.Extends {
+		for name, method := range ext.allMethods() {
+			methods[name] = method
+		}
+	}
+	for name, method := range t.Methods {
+		methods[name] = method
+	}
+	return methods
+}
+
+// GetNumberIndex returns the value type of the numeric index signature
+func (t *InterfaceType) GetNumberIndex() (Type, bool) {
+	if t.NumberIndex != nil {
+		return t.NumberIndex, true
+	}
+	for _, ext := range t.Extends {
+		if typ, ok := ext.GetNumberIndex(); ok {
+			return typ, true
+		}
+	}
+	return nil, false
+}
+
+// GetStringIndex returns the value type of the string index signature
+func (t *InterfaceType) GetStringIndex() (Type, bool) {
+	if t.StringIndex != nil {
+		return t.StringIndex, true
+	}
+	for _, ext := range t.Extends {
+		if typ, ok := ext.GetStringIndex(); ok {
+			return typ, true
+		}
+	}
+	return nil, false
+}
+
+// IsReadonly reports whether a property is read-only
+func (t *InterfaceType) IsReadonly(name string) bool {
+	if t.Readonly[name] {
+		return true
+	}
+	for _, ext := range t.Extends {
+		if ext.IsReadonly(name) {
+			return true
+		}
+	}
+	for _, class := range t.Classes {
+		if class.Readonly[name] && !class.Hidden[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOptionalProperty reports whether a property of this type may be left out
+// of a table. Lua can't distinguish a missing key from one holding nil, so any
+// property that accepts nil is optional
+func IsOptionalProperty(t Type) bool {
+	return Nil.IsAssignableTo(t)
+}
+
 // EnumType represents an enum type
 type EnumType struct {
 	Name    string
 	Members map[string]Type
+	IsConst bool // const enums are inlined at use sites and have no runtime table
+
+	// ValueType is the type of the members' runtime values: number, string,
+	// or their union when the enum mixes both. Nil when unknown
+	ValueType Type
 }
 
 func (t *EnumType) String() string {
@@ -672,6 +1320,9 @@ func (t *EnumType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -713,6 +1364,9 @@ func (t *GenericType) IsAssignableTo(other Type) bool {
 	if t.Equals(other) {
 		return true
 	}
+	if assignableToOptional(t, other) {
+		return true
+	}
 	if _, isAny := other.(*AnyType); isAny {
 		return true
 	}
@@ -727,8 +1381,11 @@ func (t *GenericType) IsAssignableTo(other Type) bool {
 
 // IsNumericType checks if a type is numeric
 func IsNumericType(t Type) bool {
-	_, ok := t.(*NumberType)
-	return ok
+	switch t.(type) {
+	case *NumberType, *NumberLiteralType, *IntType, *FloatType, *RangeType:
+		return true
+	}
+	return false
 }
 
 // IsStringType checks if a type is a string
@@ -762,5 +1419,17 @@ var (
 	Boolean = &BooleanType{}
 	Nil     = &NilType{}
 	Void    = &VoidType{}
+	Never   = &NeverType{}
 	Any     = &AnyType{}
+	Int     = &IntType{}
+	Float   = &FloatType{}
+
+	// ImplicitAny is the any inferred where a type annotation is missing
+	ImplicitAny = &AnyType{Implicit: true}
 )
+
+// IsImplicitAny reports whether a type is an any that no annotation asked for
+func IsImplicitAny(t Type) bool {
+	anyType, ok := t.(*AnyType)
+	return ok && anyType.Implicit
+}
@@ -213,3 +213,102 @@ end
 		}
 	}
 }
+
+func TestOptionalTypeAssignment(t *testing.T) {
+	input := `
+local empty: string? = nil
+local name: string? = "lunar"
+local maybe: string | nil = name
+local again: string? = maybe
+
+function greet(who: string?): string?
+	return who
+end
+
+local greeting: string? = greet("hi")
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestOptionalTypeError(t *testing.T) {
+	tests := []string{
+		`local data: string? = 42`,
+		`local name: string? = "a"
+local s: string = name`,
+	}
+
+	for _, input := range tests {
+		errors := checkWithOptions(t, input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("%q: expected 1 type error, got %d", input, len(errors))
+		}
+	}
+}
+
+func TestOptionalTypeIsNotAny(t *testing.T) {
+	// An unresolved annotation would degrade to any and accept anything
+	input := `
+local data: string? = 42
+
+function greet(who: string?): void
+end
+
+greet(true)
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	expected := []string{
+		"Cannot assign type '42' to variable of type 'string?'",
+		"Argument 1: cannot pass type 'boolean' to parameter of type 'string?'",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d: %v", len(expected), len(errors), errors)
+	}
+	for i, message := range expected {
+		if errors[i].Message != message {
+			t.Errorf("Error %d: expected %q, got %q", i, message, errors[i].Message)
+		}
+	}
+}
+
+func TestOptionalDeclarationWithoutInitializer(t *testing.T) {
+	input := `
+local name: string?
+name = "hi"
+name = nil
+local count: number? = nil
+count = 3
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestOptionalDeclarationAssignmentErrors(t *testing.T) {
+	input := `
+local name: string?
+name = 5
+local count: number? = nil
+local total: number = count
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	expected := []string{
+		"Cannot assign type '5' to type 'string?'",
+		"Cannot assign type 'number?' to variable of type 'number'",
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d", len(expected), len(errors))
+	}
+	for i, message := range expected {
+		if errors[i].Message != message {
+			t.Errorf("Error %d: expected %q, got %q", i, message, errors[i].Message)
+		}
+	}
+}
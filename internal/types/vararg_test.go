@@ -0,0 +1,67 @@
+package types
+
+import "testing"
+
+func TestVariadicFunction(t *testing.T) {
+	input := `
+function sum(first: number, ...: number): number
+	local next: number = ...
+	return first + next
+end
+
+function log(...)
+	local values = {...}
+end
+
+local a: number = sum(1)
+local b: number = sum(1, 2, 3)
+log()
+log("a", 1, true)
+
+local args = ...
+`
+
+	errors := checkWithOptions(t, input, Options{})
+	for _, err := range errors {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestVariadicFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`function sum(first: number, ...: number): number
+	return first
+end
+sum()`,
+			"Function expects at least 1 arguments, got 0",
+		},
+		{
+			`function sum(first: number, ...: number): number
+	return first
+end
+sum(1, 2, "3")`,
+			"Argument 3: cannot pass type '\"3\"' to parameter of type 'number'",
+		},
+		{
+			`function fixed(a: number): void
+	local rest = ...
+end`,
+			"Cannot use '...' outside a variadic function",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("%q: expected 1 type error, got %d: %v", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
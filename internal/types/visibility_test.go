@@ -0,0 +1,125 @@
+package types
+
+import "testing"
+
+const counterClasses = `
+class Counter
+	private count: number
+	protected step: number
+
+	constructor()
+		self.count = 0
+		self.step = 1
+	end
+
+	public each(fn: (n: number) => void): void
+		fn(self.count)
+	end
+
+	private reset(): void
+		self.count = 0
+	end
+end
+
+class StepCounter extends Counter
+	public double(): void
+		local grow = function(): void
+			self.step = self.step * 2
+		end
+		grow()
+	end
+end
+`
+
+func TestMemberAccessFromClosuresInMethods(t *testing.T) {
+	input := `
+class Tally
+	private total: number
+	protected label: string
+
+	constructor()
+		self.total = 0
+		self.label = "tally"
+	end
+
+	public each(fn: (n: number) => void): void
+		fn(self.total)
+	end
+
+	private reset(): void
+		self.total = 0
+	end
+
+	public add(values: number[]): void
+		self:each(function(n: number): void
+			self.total = self.total + n
+		end)
+		function clear(): void
+			self:reset()
+			self.label = "cleared"
+		end
+		clear()
+	end
+
+	public merge(other: Tally): void
+		local take = function(): number
+			return other.total
+		end
+		self.total = self.total + take()
+	end
+end
+`
+	for _, err := range checkWithOptions(t, input+counterClasses, Options{}) {
+		t.Errorf("Unexpected type error: %s", err.Message)
+	}
+}
+
+func TestMemberAccessOutsideClass(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`function peek(counter: Counter): () => number
+	return function(): number
+		return counter.count
+	end
+end`,
+			"'count' is private and only accessible within class 'Counter'",
+		},
+		{
+			`function restart(counter: Counter): void
+	counter:reset()
+end`,
+			"'reset' is private and only accessible within class 'Counter'",
+		},
+		{
+			`function speed(counter: Counter): number
+	return counter.step
+end`,
+			"'step' is protected and only accessible within class 'Counter' and its subclasses",
+		},
+		{
+			`class Cheater extends Counter
+	public peek(): number
+		return self.count
+	end
+end`,
+			"'count' is private and only accessible within class 'Counter'",
+		},
+	}
+
+	for _, tt := range tests {
+		errors := checkWithOptions(t, counterClasses+tt.input, Options{})
+		if len(errors) != 1 {
+			t.Errorf("Expected 1 type error for:\n%s\ngot %d", tt.input, len(errors))
+			for _, err := range errors {
+				t.Errorf("  %s", err.Message)
+			}
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("Expected error %q, got %q", tt.expected, errors[0].Message)
+		}
+	}
+}